@@ -1,12 +1,12 @@
 package main
 
 import (
-	"context"
-	"log"
 	gohttp "net/http"
 
 	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
 	"github.com/lynx-go/lynx/contrib/zap"
+	"github.com/lynx-go/lynx/debug"
 	"github.com/lynx-go/lynx/server/http"
 	"github.com/spf13/pflag"
 )
@@ -14,16 +14,14 @@ import (
 func main() {
 	runner := lynx.NewRunner(func(app lynx.App) error {
 		app.SetLogger(zap.MustNewLogger(app))
-		boot, cleanup, err := wireBootstrap(app, app.Logger())
-		if err != nil {
-			log.Fatal(err)
+		c := boot.NewContainer()
+		if err := c.Install(debug.Module); err != nil {
+			return err
 		}
-		app.OnStop(func(ctx context.Context) error {
-			cleanup()
-			return nil
-		})
-		boot.Bind(app)
-		return nil
+		if err := c.Provide(Providers...); err != nil {
+			return err
+		}
+		return c.Bind(app)
 	},
 		lynx.WithSetFlagsFunc(func(f *pflag.FlagSet) {
 			f.String("addr", ":8080", "http listen address")
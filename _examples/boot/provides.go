@@ -3,23 +3,19 @@ package main
 import (
 	"context"
 
-	"github.com/google/wire"
 	"github.com/lynx-go/lynx"
 	"github.com/lynx-go/lynx/boot"
-	"github.com/lynx-go/lynx/server/http"
 )
 
-//go:generate wire
-
-var ProviderSet = wire.NewSet(
-	boot.New,
+// Providers 是交给 boot.Container 的构造函数：依赖按参数类型解析，
+// lynx.App 由 Bind 自动提供；*http.Server 实现了 lynx.Service，
+// 构造后自动注册，无需再汇总为 []lynx.Service。
+var Providers = []any{
 	NewHttpServer,
 	NewConfig,
-	NewServices,
-	NewServiceFactories,
 	NewOnStarts,
 	NewOnStops,
-)
+}
 
 func NewConfig(app lynx.App) (*AppConfig, error) {
 	c := new(AppConfig)
@@ -29,14 +25,6 @@ func NewConfig(app lynx.App) (*AppConfig, error) {
 	return c, nil
 }
 
-func NewServices(hs *http.Server) []lynx.Service {
-	return []lynx.Service{hs}
-}
-
-func NewServiceFactories() []lynx.ServiceFactory {
-	return []lynx.ServiceFactory{}
-}
-
 func NewOnStarts(app lynx.App) boot.OnStartHooks {
 	return boot.OnStartHooks{
 		func(ctx context.Context) error {
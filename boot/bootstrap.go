@@ -1,5 +1,6 @@
-// Package boot 提供基于 Wire 依赖注入的应用引导，把依赖图中的
-// 服务与 hooks 批量注册进应用。
+// Package boot 提供应用引导，把依赖图中的服务与 hooks 批量注册进应用：
+// 既可配合 Wire 代码生成使用 Bootstrap，也可使用基于反射的 Container
+// （无需生成代码）。
 package boot
 
 import (
@@ -3,6 +3,7 @@ package boot_test
 import (
 	"context"
 	"log/slog"
+	"os"
 	"testing"
 
 	"github.com/lynx-go/lynx"
@@ -16,10 +17,13 @@ type fakeLynx struct {
 	onStops   []lynx.HookFunc
 	services  []lynx.Service
 	factories []lynx.ServiceFactory
+	// cfg 非 nil 时作为 Config() 的返回值。
+	cfg lynx.Config
 }
 
-func (f *fakeLynx) OnStart(fns ...lynx.HookFunc) { f.onStarts = append(f.onStarts, fns...) }
-func (f *fakeLynx) OnStop(fns ...lynx.HookFunc)  { f.onStops = append(f.onStops, fns...) }
+func (f *fakeLynx) OnStart(fns ...lynx.HookFunc)           { f.onStarts = append(f.onStarts, fns...) }
+func (f *fakeLynx) OnStop(fns ...lynx.HookFunc)            { f.onStops = append(f.onStops, fns...) }
+func (f *fakeLynx) OnSignal(os.Signal, ...lynx.SignalFunc) {}
 func (f *fakeLynx) Register(cs ...lynx.Service) {
 	f.services = append(f.services, cs...)
 }
@@ -27,8 +31,13 @@ func (f *fakeLynx) RegisterFactories(fs ...lynx.ServiceFactory) {
 	f.factories = append(f.factories, fs...)
 }
 
-func (f *fakeLynx) Close()                             {}
-func (f *fakeLynx) Config() lynx.Config                { return lynx.NewViperConfig(viper.New()) }
+func (f *fakeLynx) Close() {}
+func (f *fakeLynx) Config() lynx.Config {
+	if f.cfg != nil {
+		return f.cfg
+	}
+	return lynx.NewViperConfig(viper.New())
+}
 func (f *fakeLynx) Context() context.Context           { return context.Background() }
 func (f *fakeLynx) Command(cmd lynx.CommandFunc) error { return nil }
 func (f *fakeLynx) Run() error                         { return nil }
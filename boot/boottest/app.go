@@ -0,0 +1,52 @@
+// Package boottest 提供测试 boot.Module 与 boot.Container 用的 lynx.App
+// 替身：Bind 注册的服务、工厂与钩子记录在字段中供断言。
+package boottest
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/lynx-go/lynx"
+	"github.com/spf13/viper"
+)
+
+// App 是记录注册调用的 lynx.App 实现，不运行任何服务：Register 不调用
+// 服务的 Init，Run 与 Command 直接返回。
+type App struct {
+	// Services、Factories、StartHooks、StopHooks 按调用顺序记录注册内容。
+	Services   []lynx.Service
+	Factories  []lynx.ServiceFactory
+	StartHooks []lynx.HookFunc
+	StopHooks  []lynx.HookFunc
+
+	cfg    lynx.Config
+	logger *slog.Logger
+}
+
+var _ lynx.App = (*App)(nil)
+
+// NewApp 创建以 cfg 为配置的 App，cfg 为 nil 时使用空配置；logger 默认
+// 为 slog.Default()，可经 SetLogger 替换（不修改全局默认 logger）。
+func NewApp(cfg lynx.Config) *App {
+	if cfg == nil {
+		cfg = lynx.NewViperConfig(viper.New())
+	}
+	return &App{cfg: cfg, logger: slog.Default()}
+}
+
+func (a *App) Context() context.Context               { return context.Background() }
+func (a *App) Config() lynx.Config                    { return a.cfg }
+func (a *App) Logger(...any) *slog.Logger             { return a.logger }
+func (a *App) SetLogger(l *slog.Logger)               { a.logger = l }
+func (a *App) HealthCheckers() []lynx.Checker         { return nil }
+func (a *App) Close()                                 {}
+func (a *App) Command(lynx.CommandFunc) error         { return nil }
+func (a *App) Run() error                             { return nil }
+func (a *App) OnSignal(os.Signal, ...lynx.SignalFunc) {}
+func (a *App) OnStart(fns ...lynx.HookFunc)           { a.StartHooks = append(a.StartHooks, fns...) }
+func (a *App) OnStop(fns ...lynx.HookFunc)            { a.StopHooks = append(a.StopHooks, fns...) }
+func (a *App) Register(svcs ...lynx.Service)          { a.Services = append(a.Services, svcs...) }
+func (a *App) RegisterFactories(fs ...lynx.ServiceFactory) {
+	a.Factories = append(a.Factories, fs...)
+}
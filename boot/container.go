@@ -0,0 +1,601 @@
+package boot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/lynx-go/lynx"
+)
+
+// 容器错误，均以 %w 包装附带上下文（依赖路径、构造函数名）后返回。
+var (
+	// ErrMissingDependency 表示依赖类型没有对应的 provider。
+	ErrMissingDependency = errors.New("boot: missing dependency")
+	// ErrDependencyCycle 表示依赖图存在环。
+	ErrDependencyCycle = errors.New("boot: dependency cycle")
+	// ErrDuplicateProvider 表示同一非分组类型注册了多个 provider。
+	ErrDuplicateProvider = errors.New("boot: duplicate provider")
+	// ErrInvalidConstructor 表示构造函数签名不受支持。
+	ErrInvalidConstructor = errors.New("boot: invalid constructor")
+	// ErrContainerClosed 表示容器已释放，不能再构造或注册。
+	ErrContainerClosed = errors.New("boot: container closed")
+	// ErrInvalidGroup 表示分组类型不是切片或 map，或 map 分组出现重复键。
+	ErrInvalidGroup = errors.New("boot: invalid group")
+	// ErrConstructorPanic 表示构造函数 panic，panic 值附在错误信息中。
+	ErrConstructorPanic = errors.New("boot: constructor panicked")
+)
+
+var (
+	typeError      = reflect.TypeFor[error]()
+	typeCleanup    = reflect.TypeFor[func()]()
+	typeCtxCleanup = reflect.TypeFor[func(context.Context) error]()
+	typeServices   = reflect.TypeFor[[]lynx.Service]()
+	typeFactories  = reflect.TypeFor[[]lynx.ServiceFactory]()
+	typeStartHooks = reflect.TypeFor[OnStartHooks]()
+	typeStopHooks  = reflect.TypeFor[OnStopHooks]()
+	typeService    = reflect.TypeFor[lynx.Service]()
+	typeApp        = reflect.TypeFor[lynx.App]()
+	typeAppContext = reflect.TypeFor[lynx.AppContext]()
+	typeConfig     = reflect.TypeFor[lynx.Config]()
+	typeLogger     = reflect.TypeFor[*slog.Logger]()
+	typeContext    = reflect.TypeFor[context.Context]()
+)
+
+// groupTypes 是内置分组类型：可由多个 provider 提供，依赖方得到按
+// Provide 顺序拼接的切片；没有 provider 时得到 nil 切片而非缺失错误。
+// 其他分组类型经 Container.Group 或 Module.Groups 声明。
+var groupTypes = map[reflect.Type]bool{
+	typeServices:   true,
+	typeFactories:  true,
+	typeStartHooks: true,
+	typeStopHooks:  true,
+}
+
+// Container 是基于反射的依赖注入容器，作为 wire 代码生成之外的选择：
+// 不需要生成代码，新增 provider 只需追加到 Provide。
+//
+// 构造函数形如 func(deps...) T，可选追加清理函数（func() 或
+// func(context.Context) error，与 wire 的 cleanup 约定一致）与末位 error。
+// 依赖按参数的精确类型匹配（不做接口到实现的推断，需要接口时让构造函数
+// 返回接口类型）。[]lynx.Service、[]lynx.ServiceFactory、OnStartHooks、
+// OnStopHooks 是分组类型，可由多个 provider 提供并拼接；其他切片或 map
+// 类型可经 Group 声明为分组。
+//
+// Bind 构造全部 provider 并注册进应用：分组中的服务、工厂与钩子，以及
+// 任何实现了 lynx.Service 的构造结果（自动 Register，按构造顺序去重）。
+// 清理函数按构造逆序执行，时机见 Bind。Container 可并发使用：构造函数
+// 在容器锁外调用，其内可再次 Invoke 或 Provide；同一 provider 由多个
+// goroutine 同时依赖时只构造一次，其余等待构造结束。
+type Container struct {
+	mu        sync.Mutex
+	providers []*provider
+	byType    map[reflect.Type]*provider
+	groups    map[reflect.Type][]*provider
+	// groupSet 是本容器的分组类型：内置分组加上经 Group 声明的类型。
+	groupSet map[reflect.Type]bool
+	modules  []Module
+	// built 按构造完成顺序记录 provider：依赖先于依赖方。
+	built  []*provider
+	closed bool
+}
+
+// provider 是一个构造函数（或 Supply 的现成值）。
+type provider struct {
+	name    string
+	fn      reflect.Value
+	in      []reflect.Type
+	out     reflect.Type
+	cleanup int // 清理函数的返回值下标，-1 表示没有
+	hasErr  bool
+
+	value reflect.Value
+	built bool
+	// building 在构造期间非 nil，构造结束（无论成败）时关闭。
+	building chan struct{}
+	dispose  func(context.Context) error
+}
+
+// NewContainer 创建空容器。
+func NewContainer() *Container {
+	c := &Container{
+		byType:   map[reflect.Type]*provider{},
+		groups:   map[reflect.Type][]*provider{},
+		groupSet: map[reflect.Type]bool{},
+	}
+	for t := range groupTypes {
+		c.groupSet[t] = true
+	}
+	return c
+}
+
+// Group 把类型声明为分组，类型以其零值表示（如 []pubsub.Handler(nil)）：
+// 切片分组按 Provide 顺序拼接，map 分组合并（重复键在解析时返回
+// ErrInvalidGroup）。声明可重复，已按普通类型注册的 provider 一并转入分组。
+func (c *Container) Group(types ...any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, v := range types {
+		if err := c.groupLocked(reflect.TypeOf(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Container) groupLocked(t reflect.Type) error {
+	if t == nil || (t.Kind() != reflect.Slice && t.Kind() != reflect.Map) {
+		return fmt.Errorf("%w: %v is not a slice or map type", ErrInvalidGroup, t)
+	}
+	if c.groupSet[t] {
+		return nil
+	}
+	c.groupSet[t] = true
+	if p, ok := c.byType[t]; ok {
+		delete(c.byType, t)
+		c.groups[t] = append(c.groups[t], p)
+	}
+	return nil
+}
+
+// Provide 注册构造函数。构造在首次被依赖（Invoke）或 Bind 时发生，
+// 每个 provider 至多构造一次。签名不受支持或非分组类型重复时返回错误。
+func (c *Container) Provide(constructors ...any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrContainerClosed
+	}
+	for _, ctor := range constructors {
+		p, err := newProvider(ctor)
+		if err != nil {
+			return err
+		}
+		if err := c.addLocked(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Supply 注册现成的值，类型取值的动态类型（接口类型的值请用返回接口的
+// 构造函数提供）。
+func (c *Container) Supply(values ...any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrContainerClosed
+	}
+	for _, v := range values {
+		if v == nil {
+			return fmt.Errorf("%w: cannot supply untyped nil", ErrInvalidConstructor)
+		}
+		rv := reflect.ValueOf(v)
+		p := &provider{name: "supplied " + rv.Type().String(), out: rv.Type(), cleanup: -1, value: rv, built: true}
+		if err := c.addLocked(p); err != nil {
+			return err
+		}
+		c.built = append(c.built, p)
+	}
+	return nil
+}
+
+func (c *Container) addLocked(p *provider) error {
+	if c.groupSet[p.out] {
+		c.groups[p.out] = append(c.groups[p.out], p)
+	} else {
+		if prev, ok := c.byType[p.out]; ok {
+			return fmt.Errorf("%w: %s is provided by both %s and %s", ErrDuplicateProvider, p.out, prev.name, p.name)
+		}
+		c.byType[p.out] = p
+	}
+	c.providers = append(c.providers, p)
+	return nil
+}
+
+// newProvider 校验构造函数签名并记录参数与返回值布局。
+func newProvider(ctor any) (*provider, error) {
+	fn := reflect.ValueOf(ctor)
+	if ctor == nil || fn.Kind() != reflect.Func {
+		return nil, fmt.Errorf("%w: %T is not a function", ErrInvalidConstructor, ctor)
+	}
+	p := &provider{name: funcName(fn), fn: fn, cleanup: -1}
+	ft := fn.Type()
+	if ft.IsVariadic() {
+		return nil, fmt.Errorf("%w: %s is variadic", ErrInvalidConstructor, p.name)
+	}
+	if ft.NumOut() == 0 || ft.Out(0) == typeError {
+		return nil, fmt.Errorf("%w: %s must return a value as its first result", ErrInvalidConstructor, p.name)
+	}
+	p.out = ft.Out(0)
+	for i := 1; i < ft.NumOut(); i++ {
+		switch out := ft.Out(i); {
+		case out == typeError && i == ft.NumOut()-1:
+			p.hasErr = true
+		case (out == typeCleanup || out == typeCtxCleanup) && p.cleanup < 0 && i == 1:
+			p.cleanup = i
+		default:
+			return nil, fmt.Errorf("%w: %s: unsupported result %d (%s); want T, optional cleanup func, optional trailing error",
+				ErrInvalidConstructor, p.name, i, out)
+		}
+	}
+	for i := 0; i < ft.NumIn(); i++ {
+		p.in = append(p.in, ft.In(i))
+	}
+	return p, nil
+}
+
+// Invoke 解析 fn 的参数并调用之；fn 的末位返回值为 error 时将其返回。
+// 只构造 fn 依赖所需的 provider。
+func (c *Container) Invoke(fn any) error {
+	fv := reflect.ValueOf(fn)
+	if fn == nil || fv.Kind() != reflect.Func {
+		return fmt.Errorf("%w: Invoke argument %T is not a function", ErrInvalidConstructor, fn)
+	}
+	ft := fv.Type()
+	if ft.IsVariadic() {
+		return fmt.Errorf("%w: Invoke argument %s is variadic", ErrInvalidConstructor, funcName(fv))
+	}
+	if c.isClosed() {
+		return ErrContainerClosed
+	}
+	caller := &provider{name: funcName(fv)}
+	args := make([]reflect.Value, ft.NumIn())
+	for i := range args {
+		v, err := c.resolve(ft.In(i), []*provider{caller})
+		if err != nil {
+			return err
+		}
+		args[i] = v
+	}
+
+	// 在锁外调用：fn 内可再次 Invoke/Provide。
+	outs := fv.Call(args)
+	if n := len(outs); n > 0 && ft.Out(n-1) == typeError {
+		if err, _ := outs[n-1].Interface().(error); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Container) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// resolve 返回类型 t 的值；path 是当前依赖路径（供错误信息与环检测）。
+// 只在查找 provider 时持锁。
+func (c *Container) resolve(t reflect.Type, path []*provider) (reflect.Value, error) {
+	c.mu.Lock()
+	isGroup := c.groupSet[t]
+	members := slices.Clone(c.groups[t])
+	p, ok := c.byType[t]
+	c.mu.Unlock()
+	if isGroup {
+		return c.resolveGroup(t, members, path)
+	}
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("%w: no provider for %s (required by %s)", ErrMissingDependency, t, pathString(path))
+	}
+	return c.build(p, path)
+}
+
+// resolveGroup 拼接（切片）或合并（map）分组 t 的成员 provider 结果。
+func (c *Container) resolveGroup(t reflect.Type, members []*provider, path []*provider) (reflect.Value, error) {
+	out := reflect.Zero(t)
+	for _, p := range members {
+		v, err := c.build(p, path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if t.Kind() == reflect.Slice {
+			out = reflect.AppendSlice(out, v)
+			continue
+		}
+		if v.Len() == 0 {
+			continue
+		}
+		if out.IsNil() {
+			out = reflect.MakeMap(t)
+		}
+		for it := v.MapRange(); it.Next(); {
+			if out.MapIndex(it.Key()).IsValid() {
+				return reflect.Value{}, fmt.Errorf("%w: duplicate key %v in %s (provided by %s)", ErrInvalidGroup, it.Key(), t, p.name)
+			}
+			out.SetMapIndex(it.Key(), it.Value())
+		}
+	}
+	return out, nil
+}
+
+// build 构造 p（若尚未构造）。锁下只检查并标记构造状态，参数解析与
+// 构造函数调用都在锁外进行；另一 goroutine 正在构造 p 时等待其结束后
+// 重新检查（对方失败则由本次重试）。环由依赖路径检测。
+func (c *Container) build(p *provider, path []*provider) (reflect.Value, error) {
+	if slices.Contains(path, p) {
+		return reflect.Value{}, fmt.Errorf("%w: %s", ErrDependencyCycle, cycleString(path, p))
+	}
+	c.mu.Lock()
+	for p.building != nil {
+		wait := p.building
+		c.mu.Unlock()
+		<-wait
+		c.mu.Lock()
+	}
+	if p.built {
+		defer c.mu.Unlock()
+		return p.value, nil
+	}
+	if c.closed {
+		c.mu.Unlock()
+		return reflect.Value{}, ErrContainerClosed
+	}
+	done := make(chan struct{})
+	p.building = done
+	c.mu.Unlock()
+
+	v, dispose, err := c.construct(p, append(slices.Clip(path), p))
+
+	c.mu.Lock()
+	p.building = nil
+	close(done)
+	closed := c.closed
+	if err == nil && !closed {
+		p.value, p.dispose, p.built = v, dispose, true
+		c.built = append(c.built, p)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if closed {
+		// 构造期间容器已释放，Close 看不到这个结果，就地清理。
+		if dispose != nil {
+			_ = dispose(context.Background())
+		}
+		return reflect.Value{}, ErrContainerClosed
+	}
+	return v, nil
+}
+
+// construct 解析 p 的参数并调用构造函数，返回结果与清理函数；构造函数
+// panic 时返回 ErrConstructorPanic。
+func (c *Container) construct(p *provider, path []*provider) (v reflect.Value, dispose func(context.Context) error, err error) {
+	args := make([]reflect.Value, len(p.in))
+	for i, in := range p.in {
+		if args[i], err = c.resolve(in, path); err != nil {
+			return reflect.Value{}, nil, err
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %s: %v", ErrConstructorPanic, p.name, r)
+		}
+	}()
+	outs := p.fn.Call(args)
+	if p.hasErr {
+		if err, _ := outs[len(outs)-1].Interface().(error); err != nil {
+			return reflect.Value{}, nil, fmt.Errorf("boot: %s: %w", p.name, err)
+		}
+	}
+	if p.cleanup > 0 {
+		switch fn := outs[p.cleanup].Interface().(type) {
+		case func():
+			if fn != nil {
+				dispose = func(context.Context) error { fn(); return nil }
+			}
+		case func(context.Context) error:
+			dispose = fn
+		}
+	}
+	return outs[0], dispose, nil
+}
+
+// Bind 构造全部 provider 并注册进应用：OnStartHooks/OnStopHooks 分组注册为
+// 钩子，[]lynx.ServiceFactory 分组注册为工厂，[]lynx.Service 分组成员与
+// 实现 lynx.Service 的构造结果按构造顺序去重后 Register。经 Install 安装
+// 的模块在此按应用配置启用并先于其他 provider 构造。
+//
+// 未提供时自动提供 lynx.App、lynx.AppContext、lynx.Config、context.Context
+// 与 *slog.Logger（取自 app；logger 在首次被依赖时取，反映模块构造期间
+// SetLogger 的替换）。任一构造失败时按逆序执行已构造部分的清理
+// 函数并返回错误。
+//
+// 清理函数由一个最后注册的内部服务（"boot-container"）在其 Stop 中按构造
+// 逆序执行：关停时服务按注册顺序停止，清理因此发生在 Bind 注册的服务
+// 全部停止之后，而不是早于服务停止的 OnStop 阶段。
+func (c *Container) Bind(app lynx.App) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrContainerClosed
+	}
+	defaults := []struct {
+		t reflect.Type
+		v any
+	}{
+		{typeApp, app},
+		{typeAppContext, app},
+		{typeConfig, app.Config()},
+		{typeContext, app.Context()},
+		{typeLogger, app.Logger()},
+	}
+	for _, d := range defaults {
+		if _, ok := c.byType[d.t]; ok || d.v == nil {
+			continue
+		}
+		if d.t == typeLogger {
+			// logger 在首次被依赖时才取：模块（如 zap.Module）可在构造时经
+			// app.SetLogger 替换应用 logger，之后构造的依赖方得到新 logger。
+			p, _ := newProvider(func() *slog.Logger { return app.Logger() })
+			p.name = "lynx " + d.t.String()
+			_ = c.addLocked(p)
+			continue
+		}
+		v := reflect.New(d.t).Elem()
+		v.Set(reflect.ValueOf(d.v))
+		p := &provider{name: "lynx " + d.t.String(), out: d.t, cleanup: -1, value: v, built: true}
+		_ = c.addLocked(p)
+		c.built = append(c.built, p)
+	}
+	modProviders, disabled, err := c.installModulesLocked(app.Config())
+	all := append(modProviders, c.providers...)
+	c.mu.Unlock()
+	if err != nil {
+		return errors.Join(err, c.Close(app.Context()))
+	}
+	// 构造在锁外进行，见 build。
+	for _, p := range all {
+		if _, err := c.build(p, nil); err != nil {
+			return errors.Join(err, c.Close(app.Context()))
+		}
+	}
+
+	var (
+		services  []lynx.Service
+		seen      = map[any]bool{}
+		factories []lynx.ServiceFactory
+		starts    OnStartHooks
+		stops     OnStopHooks
+	)
+	addService := func(s lynx.Service) {
+		if s == nil {
+			return
+		}
+		if reflect.TypeOf(s).Comparable() {
+			if seen[s] {
+				return
+			}
+			seen[s] = true
+		}
+		services = append(services, s)
+	}
+	c.mu.Lock()
+	for _, p := range c.built {
+		if !p.value.IsValid() {
+			continue
+		}
+		switch p.out {
+		case typeServices:
+			for _, s := range p.value.Interface().([]lynx.Service) {
+				addService(s)
+			}
+			continue
+		case typeFactories:
+			factories = append(factories, p.value.Interface().([]lynx.ServiceFactory)...)
+			continue
+		case typeStartHooks:
+			starts = append(starts, p.value.Interface().(OnStartHooks)...)
+			continue
+		case typeStopHooks:
+			stops = append(stops, p.value.Interface().(OnStopHooks)...)
+			continue
+		case typeApp, typeAppContext:
+			continue
+		}
+		if p.out.Implements(typeService) && !isNilValue(p.value) {
+			addService(p.value.Interface().(lynx.Service))
+		}
+	}
+	c.mu.Unlock()
+
+	for _, name := range disabled {
+		app.Logger().Info("module disabled by config", "module", name)
+	}
+	app.OnStart(starts...)
+	app.OnStop(stops...)
+	app.Register(services...)
+	app.RegisterFactories(factories...)
+	app.Register(&disposer{c: c})
+	return nil
+}
+
+// Close 按构造逆序执行全部清理函数并释放容器，聚合返回清理错误；
+// 重复调用返回 nil。通常由 Bind 注册的内部服务调用，脱离应用使用容器时
+// 由调用方负责。
+func (c *Container) Close(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	built := c.built
+	c.mu.Unlock()
+
+	var errs []error
+	for i := len(built) - 1; i >= 0; i-- {
+		if d := built[i].dispose; d != nil {
+			if err := d(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("boot: cleanup of %s: %w", built[i].name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// disposer 是 Bind 注册的内部服务：Start 阻塞至服务 ctx 取消，Stop 释放
+// 容器（见 Bind 关于清理时机的说明）。
+type disposer struct {
+	c *Container
+}
+
+func (d *disposer) Name() string                   { return "boot-container" }
+func (d *disposer) Init(lynx.AppContext) error     { return nil }
+func (d *disposer) Stop(ctx context.Context) error { return d.c.Close(ctx) }
+func (d *disposer) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
+
+func funcName(fn reflect.Value) string {
+	if f := runtime.FuncForPC(fn.Pointer()); f != nil {
+		return f.Name()
+	}
+	return fn.Type().String()
+}
+
+// pathString 把依赖路径格式化为 "A -> B"（从最外层依赖方开始）。
+func pathString(path []*provider) string {
+	if len(path) == 0 {
+		return "Bind"
+	}
+	names := make([]string, len(path))
+	for i, p := range path {
+		names[i] = p.name
+	}
+	return strings.Join(names, " -> ")
+}
+
+// cycleString 把从 p 首次出现到再次到达 p 的路径格式化为 "A -> B -> A"。
+func cycleString(path []*provider, p *provider) string {
+	start := 0
+	for i, q := range path {
+		if q == p {
+			start = i
+			break
+		}
+	}
+	names := make([]string, 0, len(path)-start+1)
+	for _, q := range path[start:] {
+		names = append(names, fmt.Sprintf("%s (%s)", q.name, q.out))
+	}
+	names = append(names, fmt.Sprintf("%s (%s)", p.name, p.out))
+	return strings.Join(names, " -> ")
+}
@@ -0,0 +1,321 @@
+package boot_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
+)
+
+type repo struct{ dsn string }
+
+type handler struct{ repo *repo }
+
+// testService 是最小的 lynx.Service 实现。
+type testService struct {
+	name string
+}
+
+func (s *testService) Name() string                   { return s.name }
+func (s *testService) Init(lynx.AppContext) error     { return nil }
+func (s *testService) Start(context.Context) error    { return nil }
+func (s *testService) Stop(ctx context.Context) error { return nil }
+
+func TestContainerInvoke(t *testing.T) {
+	c := boot.NewContainer()
+	calls := 0
+	err := c.Provide(
+		func() string { return "dsn://test" },
+		func(dsn string) (*repo, error) { calls++; return &repo{dsn: dsn}, nil },
+		func(r *repo) *handler { return &handler{repo: r} },
+	)
+	if err != nil {
+		t.Fatalf("Provide() error = %v", err)
+	}
+	var got *handler
+	if err := c.Invoke(func(h *handler, r *repo) { got = h }); err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if got == nil || got.repo.dsn != "dsn://test" {
+		t.Fatalf("Invoke() got %+v", got)
+	}
+	if err := c.Invoke(func(*repo) {}); err != nil || calls != 1 {
+		t.Fatalf("second Invoke() error = %v, constructor calls = %d, want 1", err, calls)
+	}
+
+	wantErr := errors.New("boom")
+	if err := c.Invoke(func() error { return wantErr }); !errors.Is(err, wantErr) {
+		t.Errorf("Invoke() error = %v, want the function's error", err)
+	}
+}
+
+func TestContainerConstructorError(t *testing.T) {
+	c := boot.NewContainer()
+	wantErr := errors.New("dial failed")
+	_ = c.Provide(func() (*repo, error) { return nil, wantErr })
+	if err := c.Invoke(func(*repo) {}); !errors.Is(err, wantErr) {
+		t.Fatalf("Invoke() error = %v, want %v", err, wantErr)
+	}
+}
+
+func TestContainerMissingDependency(t *testing.T) {
+	c := boot.NewContainer()
+	_ = c.Provide(func(r *repo) *handler { return &handler{repo: r} })
+	err := c.Invoke(func(*handler) {})
+	if !errors.Is(err, boot.ErrMissingDependency) {
+		t.Fatalf("Invoke() error = %v, want ErrMissingDependency", err)
+	}
+	// 错误信息给出缺失的类型与依赖路径。
+	for _, want := range []string{"*boot_test.repo", "TestContainerMissingDependency", " -> "} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestContainerCycle(t *testing.T) {
+	c := boot.NewContainer()
+	_ = c.Provide(
+		func(*handler) *repo { return &repo{} },
+		func(r *repo) *handler { return &handler{repo: r} },
+	)
+	err := c.Invoke(func(*handler) {})
+	if !errors.Is(err, boot.ErrDependencyCycle) {
+		t.Fatalf("Invoke() error = %v, want ErrDependencyCycle", err)
+	}
+	if got := strings.Count(err.Error(), "(*boot_test.handler)"); got != 2 {
+		t.Errorf("cycle %q should start and end with *handler, got %d occurrences", err, got)
+	}
+}
+
+func TestContainerInvalidProviders(t *testing.T) {
+	c := boot.NewContainer()
+	for _, ctor := range []any{
+		"not a function",
+		func() {},
+		func() error { return nil },
+		func(...int) string { return "" },
+		func() (string, int) { return "", 0 },
+	} {
+		if err := c.Provide(ctor); !errors.Is(err, boot.ErrInvalidConstructor) {
+			t.Errorf("Provide(%T) error = %v, want ErrInvalidConstructor", ctor, err)
+		}
+	}
+	if err := c.Provide(func() string { return "a" }); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Provide(func() string { return "b" }); !errors.Is(err, boot.ErrDuplicateProvider) {
+		t.Errorf("duplicate Provide() error = %v, want ErrDuplicateProvider", err)
+	}
+}
+
+func TestContainerBind(t *testing.T) {
+	c := boot.NewContainer()
+	a, b, auto := &testService{name: "a"}, &testService{name: "b"}, &testService{name: "auto"}
+	err := c.Provide(
+		func() []lynx.Service { return []lynx.Service{a} },
+		func() []lynx.Service { return []lynx.Service{b, a} },
+		func() *testService { return auto },
+		func() boot.OnStartHooks { return boot.OnStartHooks{func(context.Context) error { return nil }} },
+		func() boot.OnStopHooks { return boot.OnStopHooks{func(context.Context) error { return nil }} },
+		// 依赖 lynx.App 与 lynx.Config 由 Bind 自动提供。
+		func(app lynx.App, cfg lynx.Config) *handler { return &handler{} },
+	)
+	if err != nil {
+		t.Fatalf("Provide() error = %v", err)
+	}
+	app := &fakeLynx{}
+	if err := c.Bind(app); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+
+	var names []string
+	for _, s := range app.services {
+		names = append(names, s.Name())
+	}
+	// 分组成员去重，自动注册构造结果，内部释放服务最后注册。
+	if got := strings.Join(names, ","); got != "a,b,auto,boot-container" {
+		t.Errorf("registered services = %s, want a,b,auto,boot-container", got)
+	}
+	if len(app.onStarts) != 1 || len(app.onStops) != 1 {
+		t.Errorf("hooks = %d start / %d stop, want 1 / 1", len(app.onStarts), len(app.onStops))
+	}
+}
+
+func TestContainerDisposal(t *testing.T) {
+	c := boot.NewContainer()
+	var order []string
+	_ = c.Provide(
+		func() (*repo, func()) {
+			return &repo{}, func() { order = append(order, "repo") }
+		},
+		func(r *repo) (*handler, func(context.Context) error, error) {
+			return &handler{repo: r}, func(context.Context) error {
+				order = append(order, "handler")
+				return errors.New("flush failed")
+			}, nil
+		},
+	)
+	app := &fakeLynx{}
+	if err := c.Bind(app); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if len(order) != 0 {
+		t.Fatalf("cleanups ran before shutdown: %v", order)
+	}
+	disposer := app.services[len(app.services)-1]
+	err := disposer.Stop(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "flush failed") {
+		t.Errorf("Stop() error = %v, want aggregated cleanup error", err)
+	}
+	if got := strings.Join(order, ","); got != "handler,repo" {
+		t.Errorf("cleanup order = %s, want handler,repo (reverse construction)", got)
+	}
+	if err := disposer.Stop(context.Background()); err != nil || len(order) != 2 {
+		t.Errorf("second Stop() = %v, cleanups = %v; want idempotent", err, order)
+	}
+	if err := c.Invoke(func(*repo) {}); !errors.Is(err, boot.ErrContainerClosed) {
+		t.Errorf("Invoke() after Close = %v, want ErrContainerClosed", err)
+	}
+}
+
+// stoppingService 在 Start 后立即返回（触发关停），Stop 时记录事件。
+type stoppingService struct {
+	testService
+	events chan<- string
+}
+
+func (s *stoppingService) Stop(context.Context) error {
+	s.events <- "stop " + s.name
+	return nil
+}
+
+// TestContainerDisposesAfterServicesStop 验证在真实应用中清理函数在
+// Bind 注册的服务停止之后执行。
+func TestContainerDisposesAfterServicesStop(t *testing.T) {
+	events := make(chan string, 4)
+	runner := lynx.NewRunner(func(app lynx.App) error {
+		c := boot.NewContainer()
+		err := c.Provide(
+			func() (*repo, func()) { return &repo{}, func() { events <- "cleanup repo" } },
+			func(*repo) *stoppingService {
+				return &stoppingService{testService: testService{name: "svc"}, events: events}
+			},
+		)
+		if err != nil {
+			return err
+		}
+		return c.Bind(app)
+	})
+	if err := runner.RunE(); err != nil {
+		t.Fatalf("RunE() error = %v", err)
+	}
+	close(events)
+	var got []string
+	for e := range events {
+		got = append(got, e)
+	}
+	if strings.Join(got, ",") != "stop svc,cleanup repo" {
+		t.Errorf("events = %v, want [stop svc cleanup repo]", got)
+	}
+}
+
+func TestContainerBindFailureDisposes(t *testing.T) {
+	c := boot.NewContainer()
+	var cleaned bool
+	_ = c.Provide(
+		func() (*repo, func()) { return &repo{}, func() { cleaned = true } },
+		func(*repo) (*handler, error) { return nil, errors.New("init failed") },
+	)
+	app := &fakeLynx{}
+	if err := c.Bind(app); err == nil || !strings.Contains(err.Error(), "init failed") {
+		t.Fatalf("Bind() error = %v, want constructor error", err)
+	}
+	if !cleaned {
+		t.Error("already-built providers should be cleaned up when Bind fails")
+	}
+	if len(app.services) != 0 {
+		t.Errorf("Bind() registered %d services on failure, want 0", len(app.services))
+	}
+}
+
+// TestContainerConstructorPanic：构造函数 panic 转为错误，容器锁随之释放，
+// 之后 Invoke 与 Close 照常返回。
+func TestContainerConstructorPanic(t *testing.T) {
+	c := boot.NewContainer()
+	var cleaned bool
+	_ = c.Provide(
+		func() (*repo, func()) { return &repo{}, func() { cleaned = true } },
+		func(*repo) *handler { panic("nil config") },
+	)
+	err := c.Invoke(func(*handler) {})
+	if !errors.Is(err, boot.ErrConstructorPanic) || !strings.Contains(err.Error(), "nil config") {
+		t.Fatalf("Invoke() error = %v, want ErrConstructorPanic", err)
+	}
+	if err := c.Invoke(func(*repo) {}); err != nil {
+		t.Fatalf("Invoke() after panic error = %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- c.Close(context.Background()) }()
+	select {
+	case err := <-done:
+		if err != nil || !cleaned {
+			t.Fatalf("Close() error = %v, cleaned = %v", err, cleaned)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close() deadlocked after a constructor panic")
+	}
+}
+
+// TestContainerReentrantConstructor：构造函数内可再次 Provide 与 Invoke。
+func TestContainerReentrantConstructor(t *testing.T) {
+	c := boot.NewContainer()
+	_ = c.Provide(func() string { return "dsn://test" })
+	_ = c.Provide(func() (*handler, error) {
+		if err := c.Provide(func(dsn string) *repo { return &repo{dsn: dsn} }); err != nil {
+			return nil, err
+		}
+		h := &handler{}
+		return h, c.Invoke(func(r *repo) { h.repo = r })
+	})
+	done := make(chan error, 1)
+	var got *handler
+	go func() { done <- c.Invoke(func(h *handler) { got = h }) }()
+	select {
+	case err := <-done:
+		if err != nil || got == nil || got.repo == nil || got.repo.dsn != "dsn://test" {
+			t.Fatalf("Invoke() error = %v, got %+v", err, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Invoke() deadlocked on a re-entrant constructor")
+	}
+}
+
+// TestContainerConcurrentInvoke：并发依赖同一 provider 时只构造一次。
+func TestContainerConcurrentInvoke(t *testing.T) {
+	c := boot.NewContainer()
+	var calls atomic.Int32
+	_ = c.Provide(func() *repo {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return &repo{}
+	})
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			if err := c.Invoke(func(*repo) {}); err != nil {
+				t.Errorf("Invoke() error = %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("constructor calls = %d, want 1", n)
+	}
+}
@@ -0,0 +1,232 @@
+package boot
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lynx-go/lynx"
+)
+
+// ErrDuplicateModule 表示同名模块被重复安装。
+var ErrDuplicateModule = errors.New("boot: duplicate module")
+
+// Module 把一个功能（kafka、pubsub、telemetry、debug 等）需要的构造函数、
+// 配置缺省值与配置结构打包，经 Container.Install 一次装配。服务与钩子
+// 同样经 Providers 提供：实现了 lynx.Service 的构造结果自动注册，返回
+// OnStartHooks/OnStopHooks 的构造函数提供钩子。
+//
+// 模块可由配置键 modules.<Name>.enabled 开关（如 modules.debug.enabled:
+// false），未配置时启用（DisabledByDefault 时关闭）。
+type Module struct {
+	// Name 是模块名，用于开关配置键与错误信息，容器内唯一。
+	Name string
+	// Providers 是模块的构造函数，约定同 Container.Provide。
+	Providers []any
+	// Groups 是模块声明的额外分组类型（以零值表示），约定同 Container.Group，
+	// 用于跨模块汇集（如 kafka 与 pubsub 模块都提供 pubsub.Transports）。
+	Groups []any
+	// Defaults 是配置缺省值，键为完整配置路径；只在键未经配置文件、环境
+	// 变量或 flag 设置时生效（需要配置实现 lynx.ConfigDefaulter）。
+	Defaults map[string]any
+	// ConfigKey 是模块配置段的路径（如 "kafka"）。
+	ConfigKey string
+	// Config 是配置段对应的结构体（零值或其指针），字段的 mapstructure
+	// 标签即配置键，供 Schema 输出配置说明。
+	Config any
+	// DisabledByDefault 为 true 时模块缺省关闭，需配置
+	// modules.<Name>.enabled: true 开启。
+	DisabledByDefault bool
+}
+
+// EnabledKey 返回模块开关的配置键 modules.<Name>.enabled。
+func (m Module) EnabledKey() string {
+	return "modules." + m.Name + ".enabled"
+}
+
+// Enabled 按配置判断模块是否启用：未配置开关时取 !DisabledByDefault。
+func (m Module) Enabled(cfg lynx.Config) bool {
+	if cfg != nil && cfg.IsSet(m.EnabledKey()) {
+		return cfg.GetBool(m.EnabledKey())
+	}
+	return !m.DisabledByDefault
+}
+
+// ConfigField 描述模块配置中的一个键。
+type ConfigField struct {
+	// Key 是完整配置路径；map 的任意键记为 "*"，切片元素记为 "[]"。
+	Key string `json:"key"`
+	// Type 是值类型（string、bool、int、duration、[]string 等）。
+	Type string `json:"type"`
+	// Default 是 Defaults 中该键的缺省值，没有时为 nil。
+	Default any `json:"default,omitempty"`
+}
+
+// Schema 返回模块的配置说明：开关键在前，其余为 Config 结构体的叶子字段
+// （按定义顺序），以及 Defaults 中未在结构体里出现的键（按键排序）。
+func (m Module) Schema() []ConfigField {
+	fields := []ConfigField{{Key: m.EnabledKey(), Type: "bool", Default: !m.DisabledByDefault}}
+	seen := map[string]bool{}
+	if m.Config != nil {
+		walkConfig(m.ConfigKey, reflect.TypeOf(m.Config), func(key, typ string) {
+			seen[key] = true
+			fields = append(fields, ConfigField{Key: key, Type: typ, Default: m.Defaults[key]})
+		})
+	}
+	var extra []string
+	for k := range m.Defaults {
+		if !seen[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	for _, k := range extra {
+		fields = append(fields, ConfigField{Key: k, Type: fmt.Sprintf("%T", m.Defaults[k]), Default: m.Defaults[k]})
+	}
+	return fields
+}
+
+var typeDuration = reflect.TypeFor[time.Duration]()
+
+// walkConfig 按 mapstructure 标签遍历配置结构体的叶子字段。
+func walkConfig(prefix string, t reflect.Type, leaf func(key, typ string)) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	join := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "." + name
+	}
+	switch {
+	case t == typeDuration:
+		leaf(prefix, "duration")
+	case t.Kind() == reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+			if name == "-" {
+				continue
+			}
+			// ",remain" 与 ",squash" 字段的键位于当前层级。
+			if opts == "remain" || opts == "squash" {
+				walkConfig(prefix, f.Type, leaf)
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			walkConfig(join(name), f.Type, leaf)
+		}
+	case t.Kind() == reflect.Map && isStructLike(t.Elem()):
+		walkConfig(join("*"), t.Elem(), leaf)
+	case t.Kind() == reflect.Slice && isStructLike(t.Elem()):
+		walkConfig(prefix+"[]", t.Elem(), leaf)
+	default:
+		leaf(prefix, typeName(t))
+	}
+}
+
+func isStructLike(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != typeDuration
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice:
+		return "[]" + typeName(t.Elem())
+	case reflect.Map:
+		return "map[" + typeName(t.Key()) + "]" + typeName(t.Elem())
+	case reflect.Interface:
+		return "any"
+	case reflect.Pointer:
+		return typeName(t.Elem())
+	}
+	if t == typeDuration {
+		return "duration"
+	}
+	return t.Kind().String()
+}
+
+// Install 安装模块：分组立即声明，启用判断、配置缺省值与构造函数注册
+// 在 Bind 时按应用配置进行，因此模块提供的类型只在 Bind 之后可被
+// Invoke。启用模块的构造函数先于 Provide 注册的构造函数构造（telemetry
+// 等基础设施因而先注册、先 Init）。模块名重复时返回 ErrDuplicateModule。
+func (c *Container) Install(modules ...Module) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrContainerClosed
+	}
+	for _, m := range modules {
+		if m.Name == "" {
+			return fmt.Errorf("%w: module name is required", ErrInvalidConstructor)
+		}
+		for _, prev := range c.modules {
+			if prev.Name == m.Name {
+				return fmt.Errorf("%w: %s", ErrDuplicateModule, m.Name)
+			}
+		}
+		for _, g := range m.Groups {
+			if err := c.groupLocked(reflect.TypeOf(g)); err != nil {
+				return fmt.Errorf("module %s: %w", m.Name, err)
+			}
+		}
+		c.modules = append(c.modules, m)
+	}
+	return nil
+}
+
+// installModulesLocked 在 Bind 时应用已启用模块的配置缺省值并注册其
+// 构造函数，返回按安装顺序排列的模块 provider。
+func (c *Container) installModulesLocked(cfg lynx.Config) ([]*provider, []string, error) {
+	var (
+		providers []*provider
+		disabled  []string
+	)
+	for _, m := range c.modules {
+		if !m.Enabled(cfg) {
+			disabled = append(disabled, m.Name)
+			continue
+		}
+		if len(m.Defaults) > 0 {
+			d, ok := cfg.(lynx.ConfigDefaulter)
+			if !ok {
+				return nil, nil, fmt.Errorf("boot: module %s has config defaults but %T does not implement lynx.ConfigDefaulter", m.Name, cfg)
+			}
+			for k, v := range m.Defaults {
+				d.SetDefault(k, v)
+			}
+		}
+		for _, ctor := range m.Providers {
+			p, err := newProvider(ctor)
+			if err != nil {
+				return nil, nil, fmt.Errorf("module %s: %w", m.Name, err)
+			}
+			if err := c.addLocked(p); err != nil {
+				return nil, nil, fmt.Errorf("module %s: %w", m.Name, err)
+			}
+			providers = append(providers, p)
+		}
+	}
+	return providers, disabled, nil
+}
+
+// Schema 汇总多个模块的配置说明，顺序同参数。
+func Schema(modules ...Module) []ConfigField {
+	var out []ConfigField
+	for _, m := range modules {
+		out = append(out, m.Schema()...)
+	}
+	return out
+}
@@ -0,0 +1,177 @@
+package boot_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
+	"github.com/spf13/viper"
+)
+
+func yamlConfig(t *testing.T, src string) lynx.Config {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(src)); err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	return lynx.NewViperConfig(v)
+}
+
+type workerConfig struct {
+	Addr    string        `mapstructure:"addr"`
+	Timeout time.Duration `mapstructure:"timeout"`
+	Queues  map[string]struct {
+		Brokers []string `mapstructure:"brokers"`
+	} `mapstructure:",remain"`
+}
+
+// workerModule 提供一个读取 worker 段的服务。
+func workerModule() boot.Module {
+	return boot.Module{
+		Name: "worker",
+		Providers: []any{
+			func(cfg lynx.Config) *testService {
+				return &testService{name: "worker@" + cfg.GetString("worker.addr")}
+			},
+		},
+		Defaults:  map[string]any{"worker.addr": ":9000", "worker.retries": 3},
+		ConfigKey: "worker",
+		Config:    workerConfig{},
+	}
+}
+
+func registeredNames(app *fakeLynx) string {
+	var names []string
+	for _, s := range app.services {
+		names = append(names, s.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func TestModuleEnabledWithDefaults(t *testing.T) {
+	c := boot.NewContainer()
+	if err := c.Install(workerModule()); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	app := &fakeLynx{cfg: yamlConfig(t, "name: demo\n")}
+	if err := c.Bind(app); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if got := registeredNames(app); got != "worker@:9000,boot-container" {
+		t.Errorf("registered = %s, want worker with default addr", got)
+	}
+}
+
+func TestModuleConfigOverridesDefaults(t *testing.T) {
+	c := boot.NewContainer()
+	_ = c.Install(workerModule())
+	app := &fakeLynx{cfg: yamlConfig(t, "worker:\n  addr: \":7000\"\n")}
+	if err := c.Bind(app); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if got := registeredNames(app); got != "worker@:7000,boot-container" {
+		t.Errorf("registered = %s, want worker with configured addr", got)
+	}
+}
+
+func TestModuleDisabledByConfig(t *testing.T) {
+	c := boot.NewContainer()
+	_ = c.Install(workerModule(), boot.Module{
+		Name:              "extra",
+		DisabledByDefault: true,
+		Providers:         []any{func() *handler { return &handler{} }},
+	})
+	app := &fakeLynx{cfg: yamlConfig(t, "modules:\n  worker:\n    enabled: false\n")}
+	if err := c.Bind(app); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if got := registeredNames(app); got != "boot-container" {
+		t.Errorf("registered = %s, want only the container disposer", got)
+	}
+	if err := c.Invoke(func(*handler) {}); !errors.Is(err, boot.ErrMissingDependency) {
+		t.Errorf("Invoke() = %v, DisabledByDefault module must not provide", err)
+	}
+}
+
+func TestModuleBuiltBeforeProviders(t *testing.T) {
+	c := boot.NewContainer()
+	_ = c.Provide(func() []lynx.Service { return []lynx.Service{&testService{name: "app"}} })
+	_ = c.Install(workerModule())
+	app := &fakeLynx{cfg: yamlConfig(t, "")}
+	if err := c.Bind(app); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if got := registeredNames(app); got != "worker@:9000,app,boot-container" {
+		t.Errorf("registered = %s, want module services first", got)
+	}
+}
+
+func TestModuleDuplicate(t *testing.T) {
+	c := boot.NewContainer()
+	if err := c.Install(workerModule(), workerModule()); !errors.Is(err, boot.ErrDuplicateModule) {
+		t.Fatalf("Install() error = %v, want ErrDuplicateModule", err)
+	}
+}
+
+type endpoints map[string]string
+
+func TestModuleMapGroup(t *testing.T) {
+	c := boot.NewContainer()
+	_ = c.Install(
+		boot.Module{Name: "a", Groups: []any{endpoints(nil)}, Providers: []any{
+			func() endpoints { return endpoints{"a": "1"} },
+		}},
+		boot.Module{Name: "b", Groups: []any{endpoints(nil)}, Providers: []any{
+			func() endpoints { return endpoints{"b": "2"} },
+			func(e endpoints) *handler { return &handler{repo: &repo{dsn: e["a"] + e["b"]}} },
+		}},
+	)
+	app := &fakeLynx{cfg: yamlConfig(t, "")}
+	if err := c.Bind(app); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	var got string
+	if err := c.Invoke(func(h *handler) { got = h.repo.dsn }); err != nil || got != "12" {
+		t.Fatalf("Invoke() = %v, merged = %q, want 12", err, got)
+	}
+
+	c = boot.NewContainer()
+	_ = c.Group(endpoints(nil))
+	_ = c.Provide(func() endpoints { return endpoints{"a": "1"} })
+	_ = c.Supply(endpoints{"a": "2"})
+	if err := c.Invoke(func(endpoints) {}); !errors.Is(err, boot.ErrInvalidGroup) {
+		t.Errorf("Invoke() = %v, want ErrInvalidGroup for a duplicate key", err)
+	}
+	if err := c.Group("not a slice"); !errors.Is(err, boot.ErrInvalidGroup) {
+		t.Errorf("Group(string) = %v, want ErrInvalidGroup", err)
+	}
+}
+
+func TestModuleSchema(t *testing.T) {
+	got := map[string]boot.ConfigField{}
+	var keys []string
+	for _, f := range workerModule().Schema() {
+		got[f.Key] = f
+		keys = append(keys, f.Key)
+	}
+	want := []string{"modules.worker.enabled", "worker.addr", "worker.timeout", "worker.*.brokers", "worker.retries"}
+	if strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Fatalf("schema keys = %v, want %v", keys, want)
+	}
+	if f := got["worker.addr"]; f.Type != "string" || f.Default != ":9000" {
+		t.Errorf("worker.addr = %+v", f)
+	}
+	if f := got["worker.timeout"]; f.Type != "duration" {
+		t.Errorf("worker.timeout = %+v", f)
+	}
+	if f := got["worker.*.brokers"]; f.Type != "[]string" {
+		t.Errorf("worker.*.brokers = %+v", f)
+	}
+	if f := got["modules.worker.enabled"]; f.Default != true {
+		t.Errorf("enabled default = %+v", f)
+	}
+}
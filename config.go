@@ -47,6 +47,13 @@ type ConfigSource interface {
 	BindEnv(path string, env ...string) error
 }
 
+// ConfigDefaulter 是 Config 的可选扩展：设置只在 path 未经配置文件、
+// 环境变量或 flag 设置时生效的缺省值。默认实现支持该接口，boot.Module
+// 的配置缺省值依赖它。
+type ConfigDefaulter interface {
+	SetDefault(path string, value any)
+}
+
 // NewViperConfig 将 *viper.Viper 包装为 ConfigSource（同时也是 Config），
 // 是框架的默认实现。其他配置库可自行实现 Config / ConfigSource 接入。
 func NewViperConfig(v *viper.Viper) ConfigSource {
@@ -58,6 +65,8 @@ type viperConfig struct {
 	v *viper.Viper
 }
 
+var _ ConfigDefaulter = (*viperConfig)(nil)
+
 func (c *viperConfig) Get(key string) any {
 	return c.v.Get(key)
 }
@@ -98,6 +107,10 @@ func (c *viperConfig) Set(key string, value any) {
 	c.v.Set(key, value)
 }
 
+func (c *viperConfig) SetDefault(key string, value any) {
+	c.v.SetDefault(key, value)
+}
+
 func (c *viperConfig) SetFile(path string) {
 	c.v.SetConfigFile(path)
 }
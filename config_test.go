@@ -62,3 +62,20 @@ func TestViperConfigUnmarshalKeyMissingPath(t *testing.T) {
 		t.Fatalf("expected empty map, got %+v", got)
 	}
 }
+
+func TestViperConfigSetDefault(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader("debug:\n  addr: \":7070\"\n")); err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	c := NewViperConfig(v).(ConfigDefaulter)
+	c.SetDefault("debug.addr", "127.0.0.1:6060")
+	c.SetDefault("debug.pprof", true)
+	if got := v.GetString("debug.addr"); got != ":7070" {
+		t.Errorf("debug.addr = %q, default must not override the config file", got)
+	}
+	if !v.GetBool("debug.pprof") {
+		t.Error("debug.pprof default not applied")
+	}
+}
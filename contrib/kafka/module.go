@@ -0,0 +1,25 @@
+package kafka
+
+import (
+	"github.com/lynx-go/lynx/boot"
+	"github.com/lynx-go/lynx/contrib/pubsub"
+)
+
+// transports 把已启用的 Transport 以标识 "kafka" 汇入 pubsub.Transports 分组。
+func transports(t *Transport) pubsub.Transports {
+	if t == nil {
+		return nil
+	}
+	return pubsub.Transports{"kafka": t}
+}
+
+// Module 是 Kafka Transport 的 boot 模块：按 "kafka" 段创建 Transport
+// （段缺失或为空时不创建、不注册），并以标识 "kafka" 提供给
+// pubsub.Module 的 Broker。可用 modules.kafka.enabled: false 关闭。
+var Module = boot.Module{
+	Name:      "kafka",
+	Providers: []any{NewFromConfig, transports},
+	Groups:    []any{pubsub.Transports(nil)},
+	ConfigKey: "kafka",
+	Config:    Options{},
+}
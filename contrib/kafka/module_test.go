@@ -0,0 +1,78 @@
+package kafka
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lynx-go/lynx/boot"
+	"github.com/lynx-go/lynx/boot/boottest"
+	"github.com/lynx-go/lynx/contrib/pubsub"
+)
+
+func bindModules(t *testing.T, yaml string) (*boot.Container, *boottest.App) {
+	t.Helper()
+	c := boot.NewContainer()
+	if err := c.Install(Module, pubsub.Module); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	app := boottest.NewApp(fromConfigTestConfig(t, yaml))
+	if err := c.Bind(app); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	return c, app
+}
+
+func serviceNames(app *boottest.App) string {
+	var names []string
+	for _, s := range app.Services {
+		names = append(names, s.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func TestModuleFeedsPubsubTransports(t *testing.T) {
+	c, app := bindModules(t, `
+kafka:
+  hello:
+    brokers: ["127.0.0.1:19092"]
+    topics: [topic_hello]
+`)
+	if got := serviceNames(app); got != "kafka-transport,pubsub-memory,pubsub-broker,pubsub-router,boot-container" {
+		t.Errorf("registered = %s", got)
+	}
+	err := c.Invoke(func(ts pubsub.Transports) {
+		if _, ok := ts["kafka"].(*Transport); !ok || len(ts) != 2 {
+			t.Errorf("Transports = %v, want kafka and memory", ts)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+}
+
+func TestModuleWithoutKafkaSection(t *testing.T) {
+	c, app := bindModules(t, "addr: \":9090\"\n")
+	if got := serviceNames(app); strings.Contains(got, "kafka-transport") {
+		t.Errorf("registered = %s, kafka transport must not be registered without config", got)
+	}
+	_ = c.Invoke(func(ts pubsub.Transports) {
+		if len(ts) != 1 {
+			t.Errorf("Transports = %v, want only memory", ts)
+		}
+	})
+}
+
+func TestModuleDisabled(t *testing.T) {
+	_, app := bindModules(t, `
+modules:
+  kafka:
+    enabled: false
+kafka:
+  hello:
+    brokers: ["127.0.0.1:19092"]
+    topics: [topic_hello]
+`)
+	if got := serviceNames(app); strings.Contains(got, "kafka-transport") {
+		t.Errorf("registered = %s, disabled module must not register", got)
+	}
+}
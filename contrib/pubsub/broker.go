@@ -116,16 +116,22 @@ type Options struct {
 	//（如 request_id/user_id，全链路关联）。nil 时使用默认
 	// {request_id, user_id}；非 nil 空切片表示完全关闭。
 	PropagateAttrs []string
+	// IgnoreMaintenance 为 true 时消费不随应用维护状态（lynx.Maintenance）
+	// 暂停；缺省 false：维护期间 handler 在处理消息前阻塞，消息不确认，
+	// 退出维护后继续处理。
+	IgnoreMaintenance bool
 }
 
 // NewBroker 创建消息代理门面。
 func NewBroker(opts Options) Broker {
-	return &broker{
+	b := &broker{
 		options:  opts,
 		routes:   map[string]routeEntry{},
 		explicit: map[string]routeEntry{},
 		logger:   slog.Default(),
 	}
+	b.stopCtx, b.stopCancel = context.WithCancel(context.Background())
+	return b
 }
 
 // HandlerFunc 是事件处理函数，返回错误时按订阅选项决定重试或确认。
@@ -236,6 +242,13 @@ type broker struct {
 	// 文案。Stop-before-Start（失败清理路径）不置位，不破坏后续正常
 	// Start/Stop 流程（回归 TestBrokerStopBeforeStart）。
 	stopped bool
+
+	// maintenance 是 Init 时取自应用 Context 的维护状态（可为 nil）。
+	maintenance *lynx.Maintenance
+	// stopCtx 在真实运行后的 Stop 时取消：唤醒维护期间暂停的 handler，
+	// 避免 router.Close 等待其超时。
+	stopCtx    context.Context
+	stopCancel context.CancelFunc
 }
 
 // routeEntry 是路由表的一项：逻辑 topic → (Transport, transport 侧主题名)。
@@ -279,6 +292,9 @@ func (b *broker) CheckHealth() error {
 func (b *broker) Init(ctx lynx.AppContext) error {
 	if ctx != nil {
 		b.logger = ctx.Logger("service", "pubsub")
+		if !b.options.IgnoreMaintenance {
+			b.maintenance = lynx.MaintenanceFromContext(ctx.Context())
+		}
 	}
 	// Debug 关闭（缺省）时把 watermill 核心日志过滤为 info+：订阅接线、
 	// 中间件装载等内部 debug 日志不输出，避免与业务 debug 日志混刷屏。
@@ -413,6 +429,7 @@ func (b *broker) Stop(ctx context.Context) error {
 	// 是失败清理路径，必须容忍且不改变后续 Start/Stop 语义。
 	if b.started {
 		b.stopped = true
+		b.stopCancel()
 	}
 	b.mu.Unlock()
 	if b.router != nil {
@@ -561,12 +578,38 @@ func (b *broker) wrapHandler(topic string, h HandlerFunc, o SubscribeOptions) me
 		// AutoAck 语义：先确认再执行，最多执行一次。handler 出错仅记日志
 		// （handler 内已记录），返回 nil 以免触发重试中间件。
 		return func(msg *message.Message) error {
+			if err := b.waitMaintenance(topic, msg); err != nil {
+				return err
+			}
 			msg.Ack()
 			_ = handler(msg)
 			return nil
 		}
 	}
-	return handler
+	return func(msg *message.Message) error {
+		if err := b.waitMaintenance(topic, msg); err != nil {
+			return err
+		}
+		return handler(msg)
+	}
+}
+
+// waitMaintenance 在应用维护期间阻塞消费（消息尚未确认），退出维护后
+// 返回 nil 继续处理；消息 ctx 结束或 broker 停止时返回错误，消息按
+// handler 失败处理（不确认，由 Transport 重投）。
+func (b *broker) waitMaintenance(topic string, msg *message.Message) error {
+	if !b.maintenance.Enabled() {
+		return nil
+	}
+	b.logger.InfoContext(msg.Context(), "consumer paused for maintenance", "topic", topic, "x-message-id", msg.UUID)
+	ctx, cancel := context.WithCancel(msg.Context())
+	defer cancel()
+	defer context.AfterFunc(b.stopCtx, cancel)()
+	if err := b.maintenance.Wait(ctx); err != nil {
+		return fmt.Errorf("consumer paused for maintenance: %w", err)
+	}
+	b.logger.InfoContext(msg.Context(), "consumer resumed after maintenance", "topic", topic)
+	return nil
 }
 
 // retryMiddleware 返回 topic 的重试中间件：事件级 Retry > Options.Retry >
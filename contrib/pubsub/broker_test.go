@@ -1098,3 +1098,80 @@ func TestBrokerLogAttrsFlowIntoLogs(t *testing.T) {
 		t.Errorf("handler log missing request_id, got: %s", buf.String())
 	}
 }
+
+// startMaintenanceBroker 启动携带维护状态的 broker，返回 broker 与 Start 的结果 chan。
+func startMaintenanceBroker(t *testing.T, m *lynx.Maintenance, h HandlerFunc) (Broker, context.CancelFunc, chan error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	app := newFakeApp()
+	app.ctx = lynx.ContextWithMaintenance(app.ctx, m)
+	b := NewBroker(Options{DefaultTransport: NewMemoryTransport()})
+	if err := b.Init(app); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if err := b.Subscribe(ctx, "maint.event", "maint-handler", h); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- b.Start(ctx) }()
+	if !pollUntil(5*time.Second, 10*time.Millisecond, func() bool { return b.CheckHealth() == nil }) {
+		t.Fatal("broker did not start")
+	}
+	return b, cancel, done
+}
+
+// TestBrokerPausesDuringMaintenance 验证维护期间消费暂停，退出维护后继续处理。
+func TestBrokerPausesDuringMaintenance(t *testing.T) {
+	m := lynx.NewMaintenance()
+	m.Set(true)
+	received := make(chan struct{}, 1)
+	b, cancel, done := startMaintenanceBroker(t, m, func(context.Context, *Message) error {
+		received <- struct{}{}
+		return nil
+	})
+	defer func() {
+		cancel()
+		_ = b.Stop(context.Background())
+		<-done
+	}()
+
+	if err := b.Publish(context.Background(), "maint.event", MustJSONMessage("x")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	select {
+	case <-received:
+		t.Fatal("message handled during maintenance")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	m.Set(false)
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not handled after maintenance ended")
+	}
+}
+
+// TestBrokerStopWhilePaused 验证维护期间暂停的 handler 不会阻塞 Stop。
+func TestBrokerStopWhilePaused(t *testing.T) {
+	m := lynx.NewMaintenance()
+	m.Set(true)
+	b, cancel, done := startMaintenanceBroker(t, m, func(context.Context, *Message) error { return nil })
+	if err := b.Publish(context.Background(), "maint.event", MustJSONMessage("x")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	stopped := make(chan struct{})
+	go func() {
+		cancel()
+		_ = b.Stop(context.Background())
+		<-done
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop blocked by a handler paused for maintenance")
+	}
+}
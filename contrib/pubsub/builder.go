@@ -9,7 +9,8 @@ import (
 
 // pubsubConfig 是 "pubsub" 段的配置结构（NewFromConfig 使用）。
 type pubsubConfig struct {
-	// Debug 控制 watermill 核心（router）debug 日志的输出，缺省 false。
+	// Debug 控制 watermill 核心（router）debug 日志的输出，缺省 false；
+	// 未配置且显式指定了运行环境时按其取值（dev 为 true，其余 false）。
 	Debug bool `mapstructure:"debug"`
 	// LogMessage 是全局收发日志配置（事件未单独配置时生效）。
 	LogMessage *logMessageConfig `mapstructure:"log_message"`
@@ -17,6 +18,8 @@ type pubsubConfig struct {
 	Retry *retryConfig `mapstructure:"retry"`
 	// Events 按逻辑 topic（业务事件名）配置路由与事件级选项。
 	Events map[string]eventConfig `mapstructure:"events"`
+	// IgnoreMaintenance 为 true 时消费不随应用维护状态暂停。
+	IgnoreMaintenance bool `mapstructure:"ignore_maintenance"`
 }
 
 // eventConfig 是一个逻辑 topic 的配置：route 显式路由，其余字段是事件级
@@ -78,13 +81,15 @@ func (r *retryConfig) toOptions() *RetryOptions {
 //     时逐条应用 RouteKey，引用未提供的 transport 标识时报错；route 缺省的
 //     topic 走自动路由/默认回退；
 //   - 段级 debug 控制 watermill 核心（router）debug 日志输出（缺省 false，
-//     过滤为 info+）；
+//     过滤为 info+）；未配置但显式指定了运行环境（配置键 env，含 --env 与
+//     LYNX_ENV）时按其取值：dev 为 true，test/prod 为 false；
 //   - 段级 log_message（publish/subscribe）作为全局收发日志默认值，事件级
 //     events.<name>.log_message 整体覆盖（未开启的一侧关闭）；
 //   - events 的事件级选项（auto_ack/continue_on_error/group/instances/retry）
 //     作为 Broker 的 Options.Events：Subscribe 合并为默认订阅选项，retry
 //     覆盖全局重试；
 //   - "pubsub" 段 retry 配置全局 handler 重试默认值（事件未单独配置时生效）；
+//   - 段级 ignore_maintenance 为 true 时消费不随应用维护状态暂停；
 //   - 传入 transports 的非 nil 值参与自动路由；
 //   - 标识 "memory" 的 transport（提供且非 nil 时）兼作默认回退——未路由
 //     的 topic 走它；不提供则无默认回退，未路由 topic 发布报错；
@@ -99,10 +104,16 @@ func NewFromConfig(cfg lynx.Config, transports map[string]Transport) (Broker, er
 		return nil, err
 	}
 
+	debug := cfgPubsub.Debug
+	if !cfg.IsSet("pubsub.debug") && cfg.IsSet("env") {
+		debug = lynx.EnvFromConfig(cfg).IsDev()
+	}
 	opts := Options{
-		Debug:      cfgPubsub.Debug,
+		Debug:      debug,
 		LogMessage: cfgPubsub.LogMessage.toOptions(),
 		Retry:      cfgPubsub.Retry.toOptions(),
+
+		IgnoreMaintenance: cfgPubsub.IgnoreMaintenance,
 	}
 	for name, t := range transports {
 		if t == nil {
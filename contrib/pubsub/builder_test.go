@@ -159,6 +159,21 @@ pubsub:
 	}
 }
 
+// TestNewFromConfigDebugEnv 验证未配置 pubsub.debug 时，显式指定的运行
+// 环境决定缺省值：dev 为 true，prod 为 false。
+func TestNewFromConfigDebugEnv(t *testing.T) {
+	for env, want := range map[string]bool{"dev": true, "prod": false} {
+		b, err := NewFromConfig(builderTestConfig(t, "env: "+env+"\n"),
+			map[string]Transport{"kafka": newFakeTransport()})
+		if err != nil {
+			t.Fatalf("NewFromConfig: %v", err)
+		}
+		if got := b.(*broker).options.Debug; got != want {
+			t.Errorf("env %s: Debug = %v, want %v", env, got, want)
+		}
+	}
+}
+
 // TestNewFromConfigGlobalLogMessage 验证 pubsub.log_message 全局默认配置
 // 加载到 Options.LogMessage，事件未单独配置时生效。
 func TestNewFromConfigGlobalLogMessage(t *testing.T) {
@@ -0,0 +1,32 @@
+package pubsub
+
+import (
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
+)
+
+// Transports 是按标识（如 "kafka"、"memory"）汇集的 Transport，作为
+// boot 容器的 map 分组：各模块提供的条目合并后交给 NewFromConfig。
+type Transports map[string]Transport
+
+// memoryTransports 以标识 "memory" 提供进程内 Transport（默认回退）。
+func memoryTransports(t *MemoryTransport) Transports {
+	return Transports{"memory": t}
+}
+
+// newModuleBroker 以合并后的 Transports 从配置装配 Broker。
+func newModuleBroker(cfg lynx.Config, ts Transports) (Broker, error) {
+	return NewFromConfig(cfg, ts)
+}
+
+// Module 是 pubsub 的 boot 模块：提供 MemoryTransport（默认回退）、按
+// "pubsub" 段装配的 Broker 与订阅 []Handler 分组的 Router，并全部注册。
+// 其他 Transport 经 Transports 分组接入（如 kafka.Module）；处理器由应用
+// 提供返回 []pubsub.Handler 的构造函数。可用 modules.pubsub.enabled: false 关闭。
+var Module = boot.Module{
+	Name:      "pubsub",
+	Providers: []any{NewMemoryTransport, memoryTransports, newModuleBroker, NewRouter},
+	Groups:    []any{Transports(nil), []Handler(nil)},
+	ConfigKey: "pubsub",
+	Config:    pubsubConfig{},
+}
@@ -0,0 +1,54 @@
+package pubsub
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lynx-go/lynx/boot"
+	"github.com/lynx-go/lynx/boot/boottest"
+)
+
+func TestModule(t *testing.T) {
+	c := boot.NewContainer()
+	if err := c.Install(Module); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	_ = c.Provide(func() []Handler {
+		return []Handler{NewHandler("hello", "h", func(context.Context, *Message) error { return nil })}
+	})
+	app := boottest.NewApp(nil)
+	if err := c.Bind(app); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	var names []string
+	for _, s := range app.Services {
+		names = append(names, s.Name())
+	}
+	if got := strings.Join(names, ","); got != "pubsub-memory,pubsub-broker,pubsub-router,boot-container" {
+		t.Errorf("registered = %s", got)
+	}
+	err := c.Invoke(func(ts Transports, r *Router) {
+		if _, ok := ts["memory"]; !ok || len(ts) != 1 {
+			t.Errorf("Transports = %v, want only memory", ts)
+		}
+		if len(r.handlers) != 1 {
+			t.Errorf("router handlers = %d, want 1", len(r.handlers))
+		}
+	})
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+}
+
+func TestModuleSchema(t *testing.T) {
+	keys := map[string]bool{}
+	for _, f := range Module.Schema() {
+		keys[f.Key] = true
+	}
+	for _, want := range []string{"modules.pubsub.enabled", "pubsub.debug", "pubsub.events.*.route.transport", "pubsub.retry.backoff"} {
+		if !keys[want] {
+			t.Errorf("schema missing %s", want)
+		}
+	}
+}
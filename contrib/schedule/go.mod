@@ -7,6 +7,7 @@ replace github.com/lynx-go/lynx => ../../
 require (
 	github.com/lynx-go/lynx v1.0.0
 	github.com/robfig/cron/v3 v3.0.1
+	github.com/spf13/viper v1.21.0
 )
 
 require (
@@ -21,7 +22,6 @@ require (
 	github.com/spf13/afero v1.15.0 // indirect
 	github.com/spf13/cast v1.10.0 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
-	github.com/spf13/viper v1.21.0 // indirect
 	github.com/subosito/gotenv v1.6.0 // indirect
 	go.yaml.in/yaml/v3 v3.0.5 // indirect
 	golang.org/x/sys v0.47.0 // indirect
@@ -0,0 +1,59 @@
+package schedule
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
+)
+
+// Config 是 "schedule" 配置段（NewFromConfig 与 Module 使用）。
+type Config struct {
+	// Location 是任务调度的时区（IANA 名称，如 "Asia/Shanghai"），缺省
+	// time.Local（同 WithLocation）。
+	Location string `mapstructure:"location"`
+	// Debug 输出 cron 的调试日志（同 WithDebugEnabled）。
+	Debug bool `mapstructure:"debug"`
+}
+
+// NewFromConfig 从配置 "schedule" 段创建调度器，opts 追加在配置派生的
+// 选项之后（可覆盖之）。时区非法或 cron 表达式非法时返回错误。
+func NewFromConfig(cfg lynx.Config, tasks []Task, opts ...Option) (*Scheduler, error) {
+	var c Config
+	if err := cfg.UnmarshalKey("schedule", &c); err != nil {
+		return nil, err
+	}
+	var base []Option
+	if c.Location != "" {
+		loc, err := time.LoadLocation(c.Location)
+		if err != nil {
+			return nil, fmt.Errorf("schedule: location: %w", err)
+		}
+		base = append(base, WithLocation(loc))
+	}
+	if c.Debug {
+		base = append(base, WithDebugEnabled())
+	}
+	return NewScheduler(tasks, append(base, opts...)...)
+}
+
+// newModuleScheduler 以 []Task 分组创建调度器；没有任务时不创建、不注册。
+func newModuleScheduler(cfg lynx.Config, tasks []Task, logger *slog.Logger) (*Scheduler, error) {
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	return NewFromConfig(cfg, tasks, WithLogger(logger))
+}
+
+// Module 是定时调度器的 boot 模块：汇集 []Task 分组（应用提供返回
+// []schedule.Task 的构造函数），按 "schedule" 段创建并注册调度器；没有
+// 任务时不注册。可用 modules.schedule.enabled: false 关闭。
+var Module = boot.Module{
+	Name:      "schedule",
+	Providers: []any{newModuleScheduler},
+	Groups:    []any{[]Task(nil)},
+	ConfigKey: "schedule",
+	Config:    Config{},
+}
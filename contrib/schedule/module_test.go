@@ -0,0 +1,62 @@
+package schedule
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
+	"github.com/lynx-go/lynx/boot/boottest"
+	"github.com/spf13/viper"
+)
+
+func yamlConfig(t *testing.T, doc string) lynx.Config {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(doc)); err != nil {
+		t.Fatal(err)
+	}
+	return lynx.NewViperConfig(v)
+}
+
+func TestNewFromConfig(t *testing.T) {
+	s, err := NewFromConfig(yamlConfig(t, "schedule:\n  location: Asia/Shanghai\n  debug: true\n"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.options.Location == nil || s.options.Location.String() != "Asia/Shanghai" || !s.options.DebugEnabled {
+		t.Errorf("options = %+v, want location and debug from config", s.options)
+	}
+	if _, err := NewFromConfig(yamlConfig(t, "schedule:\n  location: Nowhere/City\n"), nil); err == nil {
+		t.Error("NewFromConfig with an unknown location error = nil")
+	}
+}
+
+func TestModule(t *testing.T) {
+	bind := func(tasks []Task) *boottest.App {
+		t.Helper()
+		c := boot.NewContainer()
+		if err := c.Install(Module); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Provide(func() []Task { return tasks }); err != nil {
+			t.Fatal(err)
+		}
+		app := boottest.NewApp(yamlConfig(t, "name: demo\n"))
+		app.SetLogger(discardLogger())
+		if err := c.Bind(app); err != nil {
+			t.Fatalf("Bind() error = %v", err)
+		}
+		return app
+	}
+	task := &testTask{name: "tick", cron: "@every 1h", handler: func(context.Context) error { return nil }}
+	app := bind([]Task{task})
+	if s, ok := app.Services[0].(*Scheduler); !ok || len(s.tasks) != 1 {
+		t.Errorf("registered %v, want a scheduler with one task", app.Services)
+	}
+	if app := bind(nil); len(app.Services) != 1 { // 仅 boot-container
+		t.Errorf("registered %d services without tasks, want no scheduler", len(app.Services)-1)
+	}
+}
@@ -0,0 +1,44 @@
+package telemetry
+
+import (
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
+)
+
+// Config 是 "telemetry" 配置段（NewFromConfig 与 Module 使用）。
+type Config struct {
+	// StdoutTrace 以 stdout exporter 输出 span，供开发调试（同 WithStdoutTrace）。
+	StdoutTrace bool `mapstructure:"stdout_trace"`
+}
+
+// NewFromConfig 从配置 "telemetry" 段创建 telemetry 服务，opts 追加在
+// 配置派生的选项之后（可覆盖之，如设置 exporter）。
+func NewFromConfig(cfg lynx.Config, opts ...Option) (lynx.Service, error) {
+	var c Config
+	if err := cfg.UnmarshalKey("telemetry", &c); err != nil {
+		return nil, err
+	}
+	if c.StdoutTrace {
+		opts = append([]Option{WithStdoutTrace()}, opts...)
+	}
+	return New(opts...), nil
+}
+
+// newModuleService 以具体类型返回服务，避免在容器中占用 lynx.Service 类型。
+func newModuleService(cfg lynx.Config) (*otelService, error) {
+	svc, err := NewFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return svc.(*otelService), nil
+}
+
+// Module 是 telemetry 服务的 boot 模块：按 "telemetry" 段创建并注册
+// 服务（模块先于应用构造函数注册，全局 provider 因而先于其他服务设置），
+// 可用 modules.telemetry.enabled: false 关闭。
+var Module = boot.Module{
+	Name:      "telemetry",
+	Providers: []any{newModuleService},
+	ConfigKey: "telemetry",
+	Config:    Config{},
+}
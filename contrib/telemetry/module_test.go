@@ -0,0 +1,36 @@
+package telemetry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lynx-go/lynx"
+	"github.com/spf13/viper"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader("telemetry:\n  stdout_trace: true\n")); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := NewFromConfig(lynx.NewViperConfig(v))
+	if err != nil {
+		t.Fatalf("NewFromConfig() error = %v", err)
+	}
+	if !svc.(*otelService).options.stdoutTrace {
+		t.Error("stdout_trace: true should enable the stdout exporter")
+	}
+
+	svc, err = newModuleService(lynx.NewViperConfig(viper.New()))
+	if err != nil || svc.(*otelService).options.stdoutTrace {
+		t.Errorf("newModuleService() = %v, %v; want default options", svc, err)
+	}
+}
+
+func TestModuleSchema(t *testing.T) {
+	fields := Module.Schema()
+	if len(fields) != 2 || fields[1].Key != "telemetry.stdout_trace" || fields[1].Type != "bool" {
+		t.Errorf("Schema() = %+v", fields)
+	}
+}
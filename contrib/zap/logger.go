@@ -32,11 +32,15 @@ func NewLogger(ctx lynx.AppContext) (*slog.Logger, error) {
 
 // buildLogger 创建 zap 实例与包装后的 slog 实例，并注入服务标识字段。
 func buildLogger(ctx lynx.AppContext) (*zap.Logger, *slog.Logger, error) {
-	logLevel := lynx.LogLevelFromConfig(ctx.Config())
+	cfg := ctx.Config()
+	logLevel := lynx.LogLevelFromConfig(cfg)
 	if logLevel == "" {
 		logLevel = "info"
 	}
-	zapLogger, err := NewZapLogger(logLevel)
+	// 日志格式随运行环境：prod 缺省 JSON，dev/test 缺省 console 文本，
+	// 配置键 logging.format 显式覆盖（与框架缺省 logger 一致）。
+	format := lynx.LogFormatFromConfig(cfg, lynx.EnvFromConfig(cfg))
+	zapLogger, err := newZapLogger(logLevel, format)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -55,6 +59,12 @@ func buildLogger(ctx lynx.AppContext) (*zap.Logger, *slog.Logger, error) {
 // outputs 指定输出路径（zap 的 OutputPaths），为空时默认输出到 stdout；
 // 需要写文件时传入文件路径（替代旧 NewZapLoggerToFile）。
 func NewZapLogger(logLevel string, outputs ...string) (*zap.Logger, error) {
+	return newZapLogger(logLevel, lynx.LogFormatJSON, outputs...)
+}
+
+// newZapLogger 创建 zap 实例：format 为 lynx.LogFormatText 时使用 console
+// 编码（开发可读），其余使用生产配置的 JSON 编码。
+func newZapLogger(logLevel, format string, outputs ...string) (*zap.Logger, error) {
 	atomicLevel := zap.NewAtomicLevel()
 
 	zapLevel := zap.DebugLevel
@@ -66,6 +76,10 @@ func NewZapLogger(logLevel string, outputs ...string) (*zap.Logger, error) {
 	zapConfig := zap.NewProductionConfig()
 	zapConfig.Level = atomicLevel
 	zapConfig.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	if format == lynx.LogFormatText {
+		zapConfig.Encoding = "console"
+		zapConfig.EncoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
 	if len(outputs) == 0 {
 		outputs = []string{"stdout"}
 	}
@@ -0,0 +1,39 @@
+package zap
+
+import (
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
+)
+
+// Config 是 "logging" 配置段（Module 使用），取值同框架缺省 logger。
+type Config struct {
+	// Level 是日志级别（debug/info/warn/error），缺省 info。
+	Level string `mapstructure:"level"`
+	// Format 是日志格式（text/json），未配置时按运行环境取缺省值。
+	Format string `mapstructure:"format"`
+}
+
+// newModuleLogger 创建 zap logger 并设为应用 logger。
+func newModuleLogger(app lynx.App) (*SyncableLogger, error) {
+	l, err := NewSyncableLogger(app)
+	if err != nil {
+		return nil, err
+	}
+	app.SetLogger(l.Logger)
+	return l, nil
+}
+
+// syncHooks 在应用关闭前刷新缓冲的日志。
+func syncHooks(l *SyncableLogger) boot.OnStopHooks {
+	return boot.OnStopHooks{SyncOnStop(l)}
+}
+
+// Module 是 zap logger 的 boot 模块：按 "logging" 段创建 logger 并经
+// app.SetLogger 设为应用 logger，关闭时刷新缓冲。应先于依赖 *slog.Logger
+// 的模块安装，可用 modules.zap.enabled: false 关闭（保留框架缺省 logger）。
+var Module = boot.Module{
+	Name:      "zap",
+	Providers: []any{newModuleLogger, syncHooks},
+	ConfigKey: "logging",
+	Config:    Config{},
+}
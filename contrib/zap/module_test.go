@@ -0,0 +1,51 @@
+package zap
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
+	"github.com/lynx-go/lynx/boot/boottest"
+	"github.com/spf13/viper"
+)
+
+// TestModule 验证模块把 zap logger 设为应用 logger，之后构造的依赖方
+// 注入的 *slog.Logger 即为该 logger，并注册关闭时的刷新钩子。
+func TestModule(t *testing.T) {
+	c := boot.NewContainer()
+	if err := c.Install(Module); err != nil {
+		t.Fatal(err)
+	}
+	var injected *slog.Logger
+	if err := c.Provide(func(l *slog.Logger) lynx.HookFunc { injected = l; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	app := boottest.NewApp(nil)
+	if err := c.Bind(app); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if app.Logger() == slog.Default() {
+		t.Fatal("app logger not replaced")
+	}
+	if injected != app.Logger() {
+		t.Error("injected *slog.Logger is not the zap logger")
+	}
+	if len(app.StopHooks) != 1 {
+		t.Fatalf("OnStop hooks = %d, want 1", len(app.StopHooks))
+	}
+	if err := app.StopHooks[0](context.Background()); err != nil {
+		t.Errorf("sync hook error = %v", err)
+	}
+}
+
+func TestModuleInvalidLevel(t *testing.T) {
+	c := boot.NewContainer()
+	_ = c.Install(Module)
+	v := viper.New()
+	v.Set("logging.level", "bogus")
+	if err := c.Bind(boottest.NewApp(lynx.NewViperConfig(v))); err == nil {
+		t.Error("Bind() with an invalid level error = nil")
+	}
+}
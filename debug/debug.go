@@ -1,4 +1,5 @@
-// Package debug 提供运维诊断服务：pprof 端点（仅建议监听本机回环地址）。
+// Package debug 提供运维诊断服务：pprof 端点与维护模式开关
+// （仅建议监听本机回环地址）。
 // Service 实现 lynx.Service：Init/Start/Stop 全生命周期契约，
 // Stop 容忍先于 Start 调用，Start 阻塞在传入 ctx。
 //
@@ -10,12 +11,14 @@ package debug
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
 	"net/http/pprof"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -37,9 +40,18 @@ const (
 type Options struct {
 	Addr   string
 	Logger *slog.Logger
+	// Pprof 控制是否挂载 /debug/pprof/* 端点；未经 WithPprof 显式设置时
+	// 按运行环境取缺省值：prod 关闭（仅保留 /healthz），其余开启。
+	Pprof bool
+	// Maintenance 是 /maintenance 端点读写的维护状态；nil 时 Init 取应用
+	// Context 携带的状态，仍为 nil 时不挂载该端点。
+	Maintenance *lynx.Maintenance
 	// loggerSet 标记 Logger 是否由 WithLogger 显式设置：显式设置时
 	// Init 不再用 ctx.Logger 覆盖。
 	loggerSet bool
+	// pprofSet 标记 Pprof 是否由 WithPprof 显式设置：显式设置时
+	// Init 不再按运行环境调整。
+	pprofSet bool
 }
 
 // Option 用于配置 debug 服务 Options 的选项函数。
@@ -63,11 +75,29 @@ func WithLogger(l *slog.Logger) Option {
 	}
 }
 
+// WithPprof 显式开启或关闭 pprof 端点，覆盖按运行环境的缺省值
+// （prod 关闭，其余开启）。
+func WithPprof(enabled bool) Option {
+	return func(o *Options) {
+		o.Pprof = enabled
+		o.pprofSet = true
+	}
+}
+
+// WithMaintenance 设置 /maintenance 端点读写的维护状态，缺省取应用
+// Context 携带的状态（脱离框架单用时需显式设置）。
+func WithMaintenance(m *lynx.Maintenance) Option {
+	return func(o *Options) {
+		o.Maintenance = m
+	}
+}
+
 // NewService 创建 debug 运维诊断服务，挂载 pprof 端点。
 func NewService(opts ...Option) *Service {
 	options := Options{
 		Addr:   DefaultAddr,
 		Logger: slog.Default(),
+		Pprof:  true,
 	}
 	for _, opt := range opts {
 		opt(&options)
@@ -101,8 +131,10 @@ func (s *Service) Name() string {
 	return "debug"
 }
 
-// Init 记录日志实例：未显式 WithLogger 时取 ctx.Logger（带服务标签）。
-// ctx 为 nil（脱离框架单用）时保持 NewService 的默认 logger。
+// Init 记录日志实例：未显式 WithLogger 时取 ctx.Logger（带服务标签）；
+// 未显式 WithPprof 时按运行环境决定是否挂载 pprof（prod 关闭）；
+// 未显式 WithMaintenance 时取应用 Context 携带的维护状态。
+// ctx 为 nil（脱离框架单用）时保持 NewService 的配置。
 func (s *Service) Init(ctx lynx.AppContext) error {
 	if ctx == nil {
 		return nil
@@ -110,6 +142,12 @@ func (s *Service) Init(ctx lynx.AppContext) error {
 	if !s.o.loggerSet {
 		s.logger = ctx.Logger("service", "debug")
 	}
+	if !s.o.pprofSet && lynx.EnvFromContext(ctx.Context()).IsProd() {
+		s.o.Pprof = false
+	}
+	if s.o.Maintenance == nil {
+		s.o.Maintenance = lynx.MaintenanceFromContext(ctx.Context())
+	}
 	return nil
 }
 
@@ -144,7 +182,11 @@ func (s *Service) Start(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	srv := &http.Server{Handler: newMux()}
+	mux := newMux(s.o.Pprof)
+	if s.o.Maintenance != nil {
+		mux.Handle("/maintenance", s.handleMaintenance())
+	}
+	srv := &http.Server{Handler: mux}
 	s.mu.Lock()
 	s.httpServer = srv
 	s.listener = ln
@@ -229,10 +271,43 @@ func (s *Service) Stop(ctx context.Context) error {
 	}
 }
 
+// handleMaintenance 读写维护状态：GET 返回 {"maintenance":bool}；
+// POST/PUT 以查询参数 enabled（true/false）设置后返回同样结构。
+func (s *Service) handleMaintenance() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m := s.o.Maintenance
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPost, http.MethodPut:
+			enabled, err := strconv.ParseBool(r.URL.Query().Get("enabled"))
+			if err != nil {
+				http.Error(w, "query parameter enabled must be true or false", http.StatusBadRequest)
+				return
+			}
+			if m.Set(enabled) {
+				s.logger.InfoContext(r.Context(), "maintenance set via debug server", "maintenance", enabled)
+			}
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST, PUT")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]bool{"maintenance": m.Enabled()})
+	})
+}
+
 // newMux 构建自建 mux：显式挂载 pprof handlers，不依赖 net/http/pprof
-// 注册到 DefaultServeMux 的全局副作用。
-func newMux() *http.ServeMux {
+// 注册到 DefaultServeMux 的全局副作用。withPprof 为 false 时只挂载 /healthz。
+func newMux(withPprof bool) *http.ServeMux {
 	mux := http.NewServeMux()
+	// /healthz 便于探活：进程存活即 200。
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	if !withPprof {
+		return mux
+	}
 	mux.HandleFunc("/debug/pprof/", pprof.Index)
 	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
 	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
@@ -243,10 +318,6 @@ func newMux() *http.ServeMux {
 	} {
 		mux.Handle("/debug/pprof/"+name, pprof.Handler(name))
 	}
-	// /healthz 便于探活：进程存活即 200。
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusOK)
-	})
 	return mux
 }
 
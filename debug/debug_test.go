@@ -16,10 +16,16 @@ import (
 // fakeAppContext 是 lynx.AppContext 的最小测试替身。
 type fakeAppContext struct {
 	logger *slog.Logger
+	env    lynx.Env
 }
 
-func (f *fakeAppContext) Context() context.Context { return context.Background() }
-func (f *fakeAppContext) Config() lynx.Config      { return nil }
+func (f *fakeAppContext) Context() context.Context {
+	if f.env != "" {
+		return lynx.ContextWithEnv(context.Background(), f.env)
+	}
+	return context.Background()
+}
+func (f *fakeAppContext) Config() lynx.Config { return nil }
 func (f *fakeAppContext) Logger(...any) *slog.Logger {
 	return f.logger
 }
@@ -193,6 +199,40 @@ func TestPprofEndpoints(t *testing.T) {
 	}
 }
 
+// TestPprofDisabledInProd：prod 下未显式 WithPprof 时不挂载 pprof 端点，
+// /healthz 保持可用；显式 WithPprof(true) 优先于运行环境。
+func TestPprofDisabledInProd(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		want int
+	}{
+		{name: "prod default", want: http.StatusNotFound},
+		{name: "prod explicit", opts: []Option{WithPprof(true)}, want: http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewService(append([]Option{WithAddr("127.0.0.1:0")}, tt.opts...)...)
+			if err := s.Init(&fakeAppContext{logger: discardLogger(), env: lynx.EnvProd}); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan error, 1)
+			go func() { done <- s.Start(ctx) }()
+			addr := waitServing(t, s)
+
+			if status, _ := getBody(t, "http://"+addr+"/debug/pprof/"); status != tt.want {
+				t.Errorf("GET /debug/pprof/ status = %d, want %d", status, tt.want)
+			}
+			if err := s.Stop(context.Background()); err != nil {
+				t.Fatalf("Stop() error = %v", err)
+			}
+			cancel()
+			<-done
+		})
+	}
+}
+
 func TestCheckHealth(t *testing.T) {
 	s := NewService()
 	if err := s.CheckHealth(); err == nil {
@@ -328,3 +368,68 @@ func TestServeErrorNoPanic(t *testing.T) {
 		t.Errorf("Start() error = %v, want nil", err)
 	}
 }
+
+// TestMaintenanceEndpoint 验证 /maintenance 端点读写应用维护状态。
+func TestMaintenanceEndpoint(t *testing.T) {
+	m := lynx.NewMaintenance()
+	s := NewService(WithAddr("127.0.0.1:0"), WithMaintenance(m))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- s.Start(ctx) }()
+	addr := waitServing(t, s)
+	defer func() {
+		_ = s.Stop(context.Background())
+		cancel()
+		<-done
+	}()
+
+	if status, body := getBody(t, "http://"+addr+"/maintenance"); status != http.StatusOK || !strings.Contains(body, `"maintenance":false`) {
+		t.Fatalf("GET /maintenance = %d %s, want 200 with false", status, body)
+	}
+	resp, err := http.Post("http://"+addr+"/maintenance?enabled=true", "", nil)
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !m.Enabled() {
+		t.Fatalf("POST enabled=true: status %d, enabled %v; want 200 and maintenance on", resp.StatusCode, m.Enabled())
+	}
+	resp, err = http.Post("http://"+addr+"/maintenance?enabled=maybe", "", nil)
+	if err != nil {
+		t.Fatalf("POST error = %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || !m.Enabled() {
+		t.Errorf("POST enabled=maybe: status %d, want 400 and state unchanged", resp.StatusCode)
+	}
+}
+
+// TestMaintenanceEndpointFromAppContext 验证未显式设置时取应用 Context 的维护状态。
+func TestMaintenanceEndpointFromAppContext(t *testing.T) {
+	s := NewService()
+	if err := s.Init(&fakeAppContext{logger: slog.Default()}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if s.o.Maintenance != nil {
+		t.Fatal("Maintenance should stay nil when the app context carries none")
+	}
+	m := lynx.NewMaintenance()
+	s = NewService()
+	ctx := &maintenanceAppContext{fakeAppContext: fakeAppContext{logger: slog.Default()}, m: m}
+	if err := s.Init(ctx); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if s.o.Maintenance != m {
+		t.Error("Init should take the maintenance state from the app context")
+	}
+}
+
+type maintenanceAppContext struct {
+	fakeAppContext
+	m *lynx.Maintenance
+}
+
+func (c *maintenanceAppContext) Context() context.Context {
+	return lynx.ContextWithMaintenance(context.Background(), c.m)
+}
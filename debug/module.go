@@ -0,0 +1,41 @@
+package debug
+
+import (
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
+)
+
+// Config 是 "debug" 配置段（NewFromConfig 与 Module 使用）。
+type Config struct {
+	// Addr 是监听地址，缺省 DefaultAddr。
+	Addr string `mapstructure:"addr"`
+	// Pprof 显式开启或关闭 pprof 端点；未配置时按运行环境取缺省值。
+	Pprof *bool `mapstructure:"pprof"`
+}
+
+// NewFromConfig 从配置 "debug" 段创建 debug 服务；logger 与维护状态
+// 由 Init 取自应用。
+func NewFromConfig(cfg lynx.Config) (*Service, error) {
+	var c Config
+	if err := cfg.UnmarshalKey("debug", &c); err != nil {
+		return nil, err
+	}
+	var opts []Option
+	if c.Addr != "" {
+		opts = append(opts, WithAddr(c.Addr))
+	}
+	if c.Pprof != nil {
+		opts = append(opts, WithPprof(*c.Pprof))
+	}
+	return NewService(opts...), nil
+}
+
+// Module 是 debug 服务的 boot 模块：按 "debug" 段创建并注册服务，
+// 可用 modules.debug.enabled: false 关闭。
+var Module = boot.Module{
+	Name:      "debug",
+	Providers: []any{NewFromConfig},
+	Defaults:  map[string]any{"debug.addr": DefaultAddr},
+	ConfigKey: "debug",
+	Config:    Config{},
+}
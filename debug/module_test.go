@@ -0,0 +1,39 @@
+package debug
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lynx-go/lynx"
+	"github.com/spf13/viper"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader("debug:\n  addr: \"127.0.0.1:0\"\n  pprof: false\n")); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := NewFromConfig(lynx.NewViperConfig(v))
+	if err != nil {
+		t.Fatalf("NewFromConfig() error = %v", err)
+	}
+	if svc.o.Addr != "127.0.0.1:0" || svc.o.Pprof || !svc.o.pprofSet {
+		t.Errorf("options = %+v, want configured addr and explicit pprof off", svc.o)
+	}
+
+	svc, err = NewFromConfig(lynx.NewViperConfig(viper.New()))
+	if err != nil || svc.o.Addr != DefaultAddr || svc.o.pprofSet {
+		t.Errorf("empty config: options = %+v (err %v), want defaults", svc.o, err)
+	}
+}
+
+func TestModuleSchema(t *testing.T) {
+	var keys []string
+	for _, f := range Module.Schema() {
+		keys = append(keys, f.Key)
+	}
+	if got := strings.Join(keys, ","); got != "modules.debug.enabled,debug.addr,debug.pprof" {
+		t.Errorf("schema keys = %s", got)
+	}
+}
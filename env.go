@@ -0,0 +1,107 @@
+package lynx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Env 是应用的运行环境，框架缺省值（日志格式、gRPC 反射、pprof 暴露、
+// 超时等）按其取值调整；每项缺省值都可由对应的显式选项/配置覆盖。
+type Env string
+
+// 内置运行环境。
+const (
+	// EnvDev 是开发环境：文本日志、gRPC 反射与 pprof 开启。
+	EnvDev Env = "dev"
+	// EnvTest 是测试环境：缺省值与 dev 一致，供测试/预发布区分使用。
+	EnvTest Env = "test"
+	// EnvProd 是生产环境：JSON 日志、gRPC 反射与 pprof 关闭、更严格的超时。
+	EnvProd Env = "prod"
+)
+
+const (
+	// DefaultEnv 是未指定运行环境时的缺省值。未指定时各项框架缺省值
+	// 与引入运行环境之前相同（文本日志、gRPC 反射与 pprof 开启、60 秒
+	// 读写超时、pubsub.debug 关闭）；显式指定 dev 时 pubsub.debug 随之开启。
+	DefaultEnv = EnvDev
+	// EnvVar 是运行环境的环境变量名，优先级：--env flag > LYNX_ENV >
+	// 配置键 env > Options.Env（WithEnv）> DefaultEnv。
+	EnvVar = "LYNX_ENV"
+)
+
+// IsDev 报告是否为开发环境。
+func (e Env) IsDev() bool { return e == EnvDev }
+
+// IsTest 报告是否为测试环境。
+func (e Env) IsTest() bool { return e == EnvTest }
+
+// IsProd 报告是否为生产环境。
+func (e Env) IsProd() bool { return e == EnvProd }
+
+// String 返回运行环境的字符串形式。
+func (e Env) String() string { return string(e) }
+
+// ParseEnv 解析运行环境字符串（大小写不敏感），接受 dev/development、
+// test/testing、prod/production；空字符串返回 DefaultEnv。
+func ParseEnv(s string) (Env, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return DefaultEnv, nil
+	case "dev", "development":
+		return EnvDev, nil
+	case "test", "testing":
+		return EnvTest, nil
+	case "prod", "production":
+		return EnvProd, nil
+	}
+	return DefaultEnv, fmt.Errorf("unrecognized env %q (want dev, test or prod)", s)
+}
+
+// EnvFromConfig 从配置中解析运行环境（配置键 env，框架已把 --env flag 与
+// LYNX_ENV 环境变量绑定到该键）。未设置或取值非法时返回 DefaultEnv——
+// 非法取值由应用初始化阶段报错，此处不重复报告。
+func EnvFromConfig(c Config) Env {
+	env, err := ParseEnv(c.GetString("env"))
+	if err != nil {
+		return DefaultEnv
+	}
+	return env
+}
+
+type envCtx struct{}
+
+var keyEnv = envCtx{}
+
+// ContextWithEnv 返回携带运行环境的 ctx。应用 Context 由框架在初始化时
+// 注入运行环境，本函数主要供测试与脱离框架单用的服务构造 AppContext。
+func ContextWithEnv(ctx context.Context, env Env) context.Context {
+	return context.WithValue(ctx, keyEnv, env)
+}
+
+// EnvFromContext 返回 ctx 携带的运行环境；未携带时返回 DefaultEnv，与
+// EnvFromConfig 一致。
+func EnvFromContext(ctx context.Context) Env {
+	if e, ok := ctx.Value(keyEnv).(Env); ok && e != "" {
+		return e
+	}
+	return DefaultEnv
+}
+
+// 日志格式：框架缺省 logger（见 applyLogging）与 contrib/zap 共用。
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LogFormatFromConfig 返回日志格式：配置键 logging.format（text/json）
+// 显式设置时以其为准，否则按运行环境取缺省值——prod 为 json，其余为 text。
+func LogFormatFromConfig(c Config, env Env) string {
+	if f := strings.ToLower(strings.TrimSpace(c.GetString("logging.format"))); f != "" {
+		return f
+	}
+	if env.IsProd() {
+		return LogFormatJSON
+	}
+	return LogFormatText
+}
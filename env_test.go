@@ -0,0 +1,138 @@
+package lynx
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestParseEnv(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Env
+		wantErr bool
+	}{
+		{in: "", want: DefaultEnv},
+		{in: "dev", want: EnvDev},
+		{in: "Development", want: EnvDev},
+		{in: "test", want: EnvTest},
+		{in: " testing ", want: EnvTest},
+		{in: "PROD", want: EnvProd},
+		{in: "production", want: EnvProd},
+		{in: "staging", want: DefaultEnv, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseEnv(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseEnv(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseEnv(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEnvFromContext(t *testing.T) {
+	if got := EnvFromContext(context.Background()); got != DefaultEnv {
+		t.Errorf("EnvFromContext(empty) = %q, want %q", got, DefaultEnv)
+	}
+	if got := EnvFromContext(context.WithValue(context.Background(), keyEnv, "prod")); got != DefaultEnv {
+		t.Errorf("EnvFromContext(wrong type) = %q, want %q", got, DefaultEnv)
+	}
+	if got := EnvFromContext(ContextWithEnv(context.Background(), EnvTest)); got != EnvTest {
+		t.Errorf("EnvFromContext() = %q, want %q", got, EnvTest)
+	}
+}
+
+func TestLogFormatFromConfig(t *testing.T) {
+	v := viper.New()
+	c := NewViperConfig(v)
+	if got := LogFormatFromConfig(c, EnvDev); got != LogFormatText {
+		t.Errorf("dev default = %q, want %q", got, LogFormatText)
+	}
+	if got := LogFormatFromConfig(c, EnvProd); got != LogFormatJSON {
+		t.Errorf("prod default = %q, want %q", got, LogFormatJSON)
+	}
+	v.Set("logging.format", "TEXT")
+	if got := LogFormatFromConfig(c, EnvProd); got != LogFormatText {
+		t.Errorf("explicit format = %q, want %q", got, LogFormatText)
+	}
+}
+
+// TestEnvResolution 验证运行环境的来源优先级：--env flag > LYNX_ENV >
+// Options.Env > DefaultEnv，且解析结果注入应用 Context。
+func TestEnvResolution(t *testing.T) {
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+	defer slog.SetDefault(slog.Default())
+
+	tests := []struct {
+		name   string
+		args   []string
+		envVar string
+		opts   []Option
+		want   Env
+	}{
+		{name: "default", args: []string{"lynx"}, want: DefaultEnv},
+		{name: "options", args: []string{"lynx"}, opts: []Option{WithEnv(EnvTest)}, want: EnvTest},
+		{name: "env var over options", args: []string{"lynx"}, envVar: "prod", opts: []Option{WithEnv(EnvTest)}, want: EnvProd},
+		{name: "flag over env var", args: []string{"lynx", "--env", "test"}, envVar: "prod", want: EnvTest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Args = tt.args
+			t.Setenv(EnvVar, tt.envVar)
+			app, err := newLynx(NewOptions(tt.opts...))
+			if err != nil {
+				t.Fatalf("newLynx() error = %v", err)
+			}
+			if got := EnvFromContext(app.Context()); got != tt.want {
+				t.Errorf("EnvFromContext() = %q, want %q", got, tt.want)
+			}
+			if got := EnvFromConfig(app.Config()); got != tt.want {
+				t.Errorf("EnvFromConfig() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestInvalidEnvIsFatal 验证非法运行环境在初始化阶段报错，而非静默回退。
+func TestInvalidEnvIsFatal(t *testing.T) {
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+	os.Args = []string{"lynx"}
+	t.Setenv(EnvVar, "staging")
+
+	if _, err := newLynx(NewOptions()); err == nil {
+		t.Fatal("newLynx() error = nil, want error for unrecognized env")
+	}
+}
+
+// TestProdDefaultsToJSONLogs 验证 prod 下框架缺省 logger 为 JSON 格式，
+// dev 下未配置级别与格式时保持 slog.Default()。
+func TestProdDefaultsToJSONLogs(t *testing.T) {
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+	os.Args = []string{"lynx"}
+	defer slog.SetDefault(slog.Default())
+
+	app, err := newLynx(NewOptions(WithEnv(EnvProd)))
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	if _, ok := app.(*lynx).logger.Handler().(*slog.JSONHandler); !ok {
+		t.Errorf("prod logger handler = %T, want *slog.JSONHandler", app.(*lynx).logger.Handler())
+	}
+
+	def := slog.Default()
+	dev, err := newLynx(NewOptions(WithEnv(EnvDev)))
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	if dev.(*lynx).logger != def {
+		t.Error("dev logger should stay slog.Default() when level and format are unset")
+	}
+}
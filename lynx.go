@@ -36,6 +36,9 @@ type App interface {
 	OnStart(fns ...HookFunc)
 	// OnStop 注册应用停止阶段执行的钩子函数
 	OnStop(fns ...HookFunc)
+	// OnSignal 为非退出信号注册处理函数，在内置动作（Options.SignalActions）
+	// 之后按注册顺序执行；必须先于 Run 调用。
+	OnSignal(sig os.Signal, fns ...SignalFunc)
 	// Register 注册需要由应用托管生命周期的服务实例。
 	// 服务的 Init 在注册时同步执行；注册阶段产生的错误不会立即返回，
 	// 首个错误会被记录，并在 Run() 时统一返回。
@@ -115,12 +118,26 @@ type lynx struct {
 	// Run 侧无需再与注册侧并发争用 run.G 的 actors。
 	running atomic.Bool
 
-	onStarts []HookFunc
-	onStops  []HookFunc
+	onStarts  []HookFunc
+	onStops   []HookFunc
+	onSignals map[os.Signal][]SignalFunc
+	// level 是框架托管 logger 的级别（managedLogger 为 true 时生效），
+	// baseLevel 是配置给出的基础级别，二者供 toggle-debug 与 reload 动作
+	// 运行期调整；levelMu 串行化信号动作间的读改写。
+	levelMu   sync.Mutex
+	level     slog.LevelVar
+	baseLevel slog.Level
+	// managedLogger 标记 app.logger 由 applyLogLevel 构建；customLogger
+	// 标记用户经 SetLogger 替换了 logger（框架不再调整其级别）。
+	managedLogger atomic.Bool
+	customLogger  atomic.Bool
 	// drain 是框架内部的排水检查器（见 drain.go）：DrainTimeout > 0 时由
 	// newLynx 注册进 healthCheckers，关停时置位让 readiness 立即失败。
 	// 手构的 lynx 实例（如测试辅助）可能为 nil，shutdown 路径需判空。
 	drain *drainChecker
+	// maintenance 是应用级维护状态（见 maintenance.go），经应用 Context
+	// 下发给各服务；仅在维护期间出现在 HealthCheckers() 快照中。
+	maintenance *Maintenance
 	// initErr 记录注册阶段产生的首个错误，由 Run() 统一返回。
 	initErr error
 	// shutdownErrors 聚合服务 Stop 返回的错误与超时错误，由 Run() 统一上抛。
@@ -201,14 +218,20 @@ var errRunStarted = errors.New("lynx: registration after Run() has started")
 func (app *lynx) SetLogger(logger *slog.Logger) {
 	slog.SetDefault(logger)
 	app.logger = logger
+	app.customLogger.Store(true)
 }
 
 // HealthCheckers 返回当前已注册的健康检查器快照。
 func (app *lynx) HealthCheckers() []Checker {
 	app.mu.Lock()
 	defer app.mu.Unlock()
-	out := make([]Checker, len(app.healthCheckers))
+	out := make([]Checker, len(app.healthCheckers), len(app.healthCheckers)+1)
 	copy(out, app.healthCheckers)
+	// 维护状态只在维护期间加入快照：非维护时快照内容与 v1.0 一致，
+	// 维护期间 readiness 聚合随之失败。
+	if app.maintenance.Enabled() {
+		out = append(out, app.maintenance)
+	}
 	return out
 }
 
@@ -256,27 +279,53 @@ func (app *lynx) init() error {
 		version = app.o.Version
 	}
 	app.ctx = context.WithValue(app.ctx, keyVersion, version)
+	env, err := ParseEnv(app.c.GetString("env"))
+	if err != nil {
+		return err
+	}
+	app.ctx = ContextWithEnv(app.ctx, env)
+	app.ctx = ContextWithMaintenance(app.ctx, app.maintenance)
+	app.applyMaintenanceConfig()
 
 	app.applyLogLevel()
 	return nil
 }
 
-// applyLogLevel 读取配置中的日志级别并应用到应用默认 logger 上。
+// applyLogLevel 读取配置中的日志级别与格式并应用到应用默认 logger 上。
 // 仅当用户未通过 SetLogger 覆盖时生效——init 在构建回调之前运行，
 // 构建回调里的 SetLogger 会再次替换 app.logger。
+// 格式按 LogFormatFromConfig 解析：prod 缺省 JSON，其余缺省文本；
+// dev/test 下未配置级别与格式时保持 slog.Default()（与 v1.x 一致）。
 func (app *lynx) applyLogLevel() {
-	levelStr := LogLevelFromConfig(app.Config())
-	if levelStr == "" {
+	cfg := app.Config()
+	env := EnvFromContext(app.ctx)
+	levelStr := LogLevelFromConfig(cfg)
+	if levelStr == "" && !cfg.IsSet("logging.format") && !env.IsProd() {
 		return
 	}
-	level, err := ParseLogLevel(levelStr)
-	if err != nil {
-		app.logger.Warn("invalid log level, using default", "level", levelStr)
-		return
+	level := slog.LevelInfo
+	if levelStr != "" {
+		var err error
+		if level, err = ParseLogLevel(levelStr); err != nil {
+			app.logger.Warn("invalid log level, using default", "level", levelStr)
+			return
+		}
 	}
-	var levelVar slog.LevelVar
-	levelVar.Set(level)
-	app.logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: &levelVar}))
+	app.baseLevel = level
+	app.level.Set(level)
+	handlerOpts := &slog.HandlerOptions{Level: &app.level}
+	var handler slog.Handler
+	switch format := LogFormatFromConfig(cfg, env); format {
+	case LogFormatJSON:
+		handler = slog.NewJSONHandler(os.Stderr, handlerOpts)
+	case LogFormatText:
+		handler = slog.NewTextHandler(os.Stderr, handlerOpts)
+	default:
+		app.logger.Warn("invalid log format, using text", "format", format)
+		handler = slog.NewTextHandler(os.Stderr, handlerOpts)
+	}
+	app.logger = slog.New(handler)
+	app.managedLogger.Store(true)
 	// 与应用日志保持单通道：--log-level 对框架与应用日志一致生效。
 	slog.SetDefault(app.logger)
 }
@@ -308,7 +357,7 @@ func ParseLogLevel(s string) (slog.Level, error) {
 	return slog.LevelInfo, fmt.Errorf("unrecognized log level %q", s)
 }
 
-// DefaultSetFlagsFunc 注册默认的命令行 flags：配置文件路径、类型、目录与日志级别。
+// DefaultSetFlagsFunc 注册默认的命令行 flags：配置文件路径、类型、目录、日志级别与运行环境。
 func DefaultSetFlagsFunc(f *pflag.FlagSet) {
 	f.StringP("config", "c", "", "config file path")
 	f.String("config-type", "yaml", "config file type, default yaml")
@@ -319,6 +368,8 @@ func DefaultSetFlagsFunc(f *pflag.FlagSet) {
 	// logging.level/log_level 永远不生效（回归：config.yaml 设 log_level
 	// 无效）。空默认时未传 flag 即回退配置文件键，缺省仍为 info。
 	f.String("log-level", "", "log level, default info")
+	// 默认值同样为空：未传 flag 时回退 LYNX_ENV 与配置键 env。
+	f.String("env", "", "runtime environment: dev, test or prod, default dev")
 }
 
 // DefaultBindConfigFunc 将默认 flags 中的配置文件路径、目录与类型绑定到应用配置源。
@@ -354,6 +405,15 @@ func (app *lynx) initConfigure() error {
 		}
 	}
 
+	// 运行环境的来源绑定不依赖 flags 开关：LYNX_ENV 恒生效，Options.Env
+	// 作为优先级最低的缺省值（见 EnvVar）。
+	if err := app.c.BindEnv("env", EnvVar); err != nil {
+		return fmt.Errorf("failed to bind env: %w", err)
+	}
+	if app.o.Env != "" {
+		app.c.SetDefault("env", string(app.o.Env))
+	}
+
 	if app.o.BindConfigFunc != nil {
 		if err := app.o.BindConfigFunc(app.f, NewViperConfig(app.c)); err != nil {
 			return err
@@ -513,6 +573,15 @@ func (app *lynx) Run() error {
 	if alreadyRunning {
 		return errors.New("lynx: Run must not be called more than once")
 	}
+	// PreRun 先于一切启动动作：服务可在此完成一次性任务（如 --openapi）
+	// 并以 ErrSkipRun 结束，不触发任何钩子、信号处理与服务 Start。
+	if err := app.runPreRun(); err != nil {
+		app.stopServices(app.ctx)
+		if errors.Is(err, ErrSkipRun) {
+			return nil
+		}
+		return err
+	}
 	app.Logger().Info("starting")
 
 	// 退出信号提前注册：OnStart hook 阻塞期间收到的信号进入缓冲 chan，
@@ -521,13 +590,22 @@ func (app *lynx) Run() error {
 	exitCh := make(chan os.Signal, 1)
 	signal.Notify(exitCh, app.o.ExitSignals...)
 	defer signal.Stop(exitCh)
+	// 动作信号（SIGHUP 等，见 Options.SignalActions 与 OnSignal）同样提前
+	// 注册缓冲，hooks 结束后才开始处理。
+	actionCh := app.notifySignals()
 
 	// 顺序执行 OnStart hooks，全部成功后服务才开始启动。
 	if err := app.runOnStartHooks(); err != nil {
+		if actionCh != nil {
+			signal.Stop(actionCh)
+		}
 		// 未进入 run.Group：已 Init 的服务需手动逆序清理，释放资源。
 		app.stopServices(app.ctx)
 		return err
 	}
+	if actionCh != nil {
+		go app.serveSignals(actionCh)
+	}
 
 	// 关闭 actor：收到退出信号或应用上下文被取消时，先在 actor 内执行
 	// OnStop hooks，返回后 run.Group 才按注册顺序停止服务——保证清理逻辑
@@ -544,9 +622,11 @@ func (app *lynx) Run() error {
 		// DrainTimeout 与 ShutdownTimeout 是两段独立预算：总关停时长上界 =
 		// DrainTimeout + ShutdownTimeout + 各服务 StopTimeout 叠加的既有上界。
 		// 所有关停入口（信号/中断/Close）都经过本函数，排水窗口统一生效。
+		// 实现 Drainer 的服务同时收到通知（如请 SSE/WebSocket 客户端重连）。
 		if app.drain != nil {
 			app.drain.SetDraining(true)
 		}
+		app.drainServices()
 		if app.o.DrainTimeout > 0 {
 			app.Logger().Info("draining: readiness marked unhealthy, waiting for drain window",
 				"drain_timeout", app.o.DrainTimeout.String())
@@ -590,6 +670,34 @@ func (app *lynx) Run() error {
 	return errors.Join(runErr, shutdownErr)
 }
 
+// drainServices 通知实现 Drainer 的服务进入排水阶段，与 DrainTimeout
+// 无关：未启用排水窗口时通知紧接着 Stop，长连接仍能先收到重连提示。
+func (app *lynx) drainServices() {
+	app.mu.Lock()
+	services := append([]Service(nil), app.services...)
+	app.mu.Unlock()
+	for _, svc := range services {
+		if d, ok := svc.(Drainer); ok {
+			d.Drain(app.ctx)
+		}
+	}
+}
+
+// runPreRun 按注册顺序调用实现 PreRunner 的服务，遇到首个错误即返回。
+func (app *lynx) runPreRun() error {
+	app.mu.Lock()
+	services := append([]Service(nil), app.services...)
+	app.mu.Unlock()
+	for _, svc := range services {
+		if p, ok := svc.(PreRunner); ok {
+			if err := p.PreRun(app.ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (app *lynx) runOnStartHooks() error {
 	app.mu.Lock()
 	hooks := append([]HookFunc(nil), app.onStarts...)
@@ -665,6 +773,7 @@ func newLynx(o *Options) (App, error) {
 	// DrainTimeout=0 时 healthCheckers 保持 nil，HealthCheckers() 快照
 	// 内容与 v1.0 逐字节一致（回归红线）。
 	app.drain = &drainChecker{}
+	app.maintenance = NewMaintenance()
 	if o.DrainTimeout > 0 {
 		app.healthCheckers = []Checker{app.drain}
 	}
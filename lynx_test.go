@@ -985,6 +985,43 @@ func TestDrainTimeoutZeroSkipsDrainWindow(t *testing.T) {
 	}
 }
 
+// drainerProbe 记录 Drain 是否先于 Stop 被调用。
+type drainerProbe struct {
+	drainProbe
+	drained       atomic.Bool
+	drainedOnStop atomic.Bool
+}
+
+func (c *drainerProbe) Drain(context.Context) { c.drained.Store(true) }
+func (c *drainerProbe) Stop(ctx context.Context) error {
+	c.drainedOnStop.Store(c.drained.Load())
+	return c.drainProbe.Stop(ctx)
+}
+
+func TestDrainerNotifiedBeforeStop(t *testing.T) {
+	app, err := newLynx(NewOptions(WithDrainTimeout(50 * time.Millisecond)))
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	probe := &drainerProbe{drainProbe: drainProbe{name: "probe"}}
+	app.Register(probe)
+	runErr := make(chan error, 1)
+	go func() { runErr <- app.Run() }()
+	waitFor(t, 2*time.Second, func() bool { return probe.started.Load() }, "probe to start")
+
+	app.Close()
+	waitFor(t, 40*time.Millisecond, probe.drained.Load, "Drain at the start of the drain window")
+	if probe.stopped.Load() {
+		t.Fatal("service stopped during drain window")
+	}
+	if err := <-runErr; err != nil {
+		t.Fatalf("Run() error = %v, want nil", err)
+	}
+	if !probe.drainedOnStop.Load() {
+		t.Fatal("Stop called before Drain")
+	}
+}
+
 func TestCloseCancelsContext(t *testing.T) {
 	app, err := newLynx(NewOptions())
 	if err != nil {
@@ -1345,3 +1382,50 @@ func newLynxWithConfig(c ConfigSource) (App, error) {
 	}
 	return app, nil
 }
+
+// preRunService 在 PreRun 中返回 err。
+type preRunService struct {
+	blockingService
+	err error
+}
+
+func (c *preRunService) PreRun(context.Context) error {
+	c.record("prerun:" + c.name)
+	return c.err
+}
+
+// TestPreRunSkipRun：PreRun 返回 ErrSkipRun 时 Run 不执行 OnStart 钩子、
+// 不启动服务，Stop 已注册服务后返回 nil；其他错误原样返回。
+func TestPreRunSkipRun(t *testing.T) {
+	wantErr := errors.New("prerun failed")
+	for _, tc := range []struct {
+		err, want error
+	}{
+		{ErrSkipRun, nil},
+		{wantErr, wantErr},
+	} {
+		app, err := newLynx(NewOptions())
+		if err != nil {
+			t.Fatalf("newLynx() error = %v", err)
+		}
+		rec := &eventRecorder{}
+		app.OnStart(func(context.Context) error { rec.record("on-start"); return nil })
+		app.Register(
+			&blockingService{name: "svc", record: rec.record},
+			&preRunService{blockingService: blockingService{name: "dump", record: rec.record}, err: tc.err},
+		)
+		done := make(chan error, 1)
+		go func() { done <- app.Run() }()
+		select {
+		case err := <-done:
+			if !errors.Is(err, tc.want) || (tc.want == nil && err != nil) {
+				t.Fatalf("Run() error = %v, want %v", err, tc.want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Run() did not return after PreRun")
+		}
+		if got := strings.Join(rec.snapshot(), ","); got != "prerun:dump,stop:dump,stop:svc" {
+			t.Errorf("events = %s, want PreRun then reverse Stop without hooks or Start", got)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package lynx
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrMaintenance 是维护模式下健康检查返回的错误。
+var ErrMaintenance = errors.New("maintenance")
+
+// Maintenance 是应用级维护状态，可并发使用：维护期间实例保持运行，但
+// readiness 失败、server/http 与 server/grpc 拒绝非白名单请求、pubsub
+// 消费暂停。应用 Context 由框架注入（MaintenanceFromContext），可经
+// debug 服务、信号动作（SignalActionToggleMaintenance）或配置键
+// maintenance.enabled（启动与 reload 时读取）切换。
+// 零值可用；nil *Maintenance 的方法均可安全调用，表示"从不维护"。
+type Maintenance struct {
+	mu sync.Mutex
+	// enabled 在 mu 下写入，读取无需加锁（请求路径上的 Enabled 热点）。
+	enabled atomic.Bool
+	// changed 在每次状态变化时关闭并替换，供 Wait 等待；惰性创建。
+	changed chan struct{}
+}
+
+// NewMaintenance 创建未处于维护状态的 Maintenance。
+func NewMaintenance() *Maintenance {
+	return &Maintenance{}
+}
+
+// broadcastLocked 唤醒所有 Wait 调用方，调用方需持有 m.mu。
+func (m *Maintenance) broadcastLocked() {
+	if m.changed != nil {
+		close(m.changed)
+		m.changed = nil
+	}
+}
+
+// Enabled 报告当前是否处于维护状态。
+func (m *Maintenance) Enabled() bool {
+	if m == nil {
+		return false
+	}
+	return m.enabled.Load()
+}
+
+// Set 设置维护状态，返回状态是否发生变化。
+func (m *Maintenance) Set(enabled bool) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.enabled.Load() == enabled {
+		return false
+	}
+	m.enabled.Store(enabled)
+	m.broadcastLocked()
+	return true
+}
+
+// Toggle 切换维护状态并返回切换后的状态。
+func (m *Maintenance) Toggle() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	enabled := !m.enabled.Load()
+	m.enabled.Store(enabled)
+	m.broadcastLocked()
+	return enabled
+}
+
+// Wait 阻塞至退出维护状态或 ctx 结束；未处于维护状态时立即返回 nil。
+func (m *Maintenance) Wait(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	for {
+		m.mu.Lock()
+		if !m.enabled.Load() {
+			m.mu.Unlock()
+			return nil
+		}
+		if m.changed == nil {
+			m.changed = make(chan struct{})
+		}
+		changed := m.changed
+		m.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// CheckHealth 实现 Checker：维护期间返回 ErrMaintenance。
+func (m *Maintenance) CheckHealth() error {
+	if m.Enabled() {
+		return ErrMaintenance
+	}
+	return nil
+}
+
+var _ Checker = (*Maintenance)(nil)
+
+type maintenanceCtx struct{}
+
+var keyMaintenance = maintenanceCtx{}
+
+// ContextWithMaintenance 返回携带维护状态的 ctx。应用 Context 由框架在
+// 初始化时注入，本函数主要供测试与脱离框架单用的服务构造 AppContext。
+func ContextWithMaintenance(ctx context.Context, m *Maintenance) context.Context {
+	return context.WithValue(ctx, keyMaintenance, m)
+}
+
+// MaintenanceFromContext 返回 ctx 携带的维护状态，未设置时返回 nil
+// （nil 的方法可安全调用，表示从不维护）。
+func MaintenanceFromContext(ctx context.Context) *Maintenance {
+	if m, ok := ctx.Value(keyMaintenance).(*Maintenance); ok {
+		return m
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package lynx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceSetToggle(t *testing.T) {
+	var m Maintenance
+	if m.Enabled() || m.CheckHealth() != nil {
+		t.Fatal("zero Maintenance must not be in maintenance")
+	}
+	if !m.Set(true) || m.Set(true) {
+		t.Error("Set(true) should report a change only once")
+	}
+	if !errors.Is(m.CheckHealth(), ErrMaintenance) {
+		t.Errorf("CheckHealth() = %v, want ErrMaintenance", m.CheckHealth())
+	}
+	if m.Toggle() || m.Enabled() {
+		t.Error("Toggle() should leave maintenance")
+	}
+
+	var nilM *Maintenance
+	if nilM.Enabled() || nilM.Set(true) || nilM.Toggle() || nilM.Wait(context.Background()) != nil {
+		t.Error("nil Maintenance must behave as never in maintenance")
+	}
+}
+
+func TestMaintenanceWait(t *testing.T) {
+	m := NewMaintenance()
+	if err := m.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() outside maintenance = %v, want nil", err)
+	}
+
+	m.Set(true)
+	done := make(chan error, 1)
+	go func() { done <- m.Wait(context.Background()) }()
+	select {
+	case <-done:
+		t.Fatal("Wait() returned during maintenance")
+	case <-time.After(50 * time.Millisecond):
+	}
+	m.Set(false)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Wait() = %v, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Wait() did not return after maintenance ended")
+	}
+
+	m.Set(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := m.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() = %v, want DeadlineExceeded", err)
+	}
+}
+
+// TestMaintenanceInHealthCheckers 验证维护状态注入应用 Context，且只在
+// 维护期间出现在 HealthCheckers() 快照中。
+func TestMaintenanceInHealthCheckers(t *testing.T) {
+	app, err := newLynx(NewOptions())
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	m := MaintenanceFromContext(app.Context())
+	if m == nil {
+		t.Fatal("MaintenanceFromContext(app.Context()) = nil")
+	}
+	if got := len(app.HealthCheckers()); got != 0 {
+		t.Fatalf("health checkers = %d, want 0 outside maintenance", got)
+	}
+	m.Set(true)
+	checkers := app.HealthCheckers()
+	if len(checkers) != 1 || !errors.Is(checkers[0].CheckHealth(), ErrMaintenance) {
+		t.Fatalf("health checkers = %v, want the maintenance checker", checkers)
+	}
+}
+
+func TestMaintenanceFromConfig(t *testing.T) {
+	origArgs := os.Args
+	defer func() { os.Args = origArgs }()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("maintenance:\n  enabled: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Args = []string{"lynx", "-c", path}
+
+	a, err := newLynx(NewOptions())
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	m := MaintenanceFromContext(a.Context())
+	if !m.Enabled() {
+		t.Fatal("maintenance.enabled: true should enable maintenance at startup")
+	}
+
+	if err := os.WriteFile(path, []byte("maintenance:\n  enabled: false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.(*lynx).reloadConfig(); err != nil {
+		t.Fatalf("reloadConfig() error = %v", err)
+	}
+	if m.Enabled() {
+		t.Error("reload with maintenance.enabled: false should leave maintenance")
+	}
+}
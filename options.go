@@ -33,6 +33,8 @@ var (
 	ErrStopTimeoutTooLarge = errors.New("stop timeout must be at most 5 minutes")
 	// ErrDrainTimeoutInvalid 表示 DrainTimeout 为负值（排水窗口不允许负值）。
 	ErrDrainTimeoutInvalid = errors.New("drain timeout must not be negative")
+	// ErrEnvInvalid 表示 Env 不是可识别的运行环境（见 ParseEnv）。
+	ErrEnvInvalid = errors.New("env must be one of dev, test or prod")
 )
 
 // Options 是 App 应用的核心配置项。
@@ -54,6 +56,16 @@ type Options struct {
 	// DrainTimeout + ShutdownTimeout + 各服务 StopTimeout 叠加的既有上界。
 	// 取值任意 ≥0，无下限约束（1ms 等小值合法）。
 	DrainTimeout time.Duration `json:"drain_timeout"`
+	// Env 是代码层面的运行环境缺省值：优先级最低，--env flag、LYNX_ENV
+	// 环境变量与配置键 env 均可覆盖；全部缺省时为 DefaultEnv（dev）。
+	Env Env `json:"env"`
+	// SignalActions 是非退出信号的内置动作表（见 SignalAction）。缺省为
+	// SIGHUP 重载配置、SIGUSR1 转储、SIGUSR2 切换 debug（仅 unix）；
+	// EnsureDefaults 只补齐未出现的缺省条目，SignalActionNone 关闭单个条目。
+	// 退出信号优先：与 ExitSignals 重叠的条目不生效。
+	SignalActions map[os.Signal]SignalAction `json:"-"`
+	// DumpDir 是转储（SignalActionDump）的输出目录：为空时写入日志。
+	DumpDir string `json:"dump_dir"`
 	// disableConfigFlags 标记用户显式关闭默认 flags（WithDisableConfigFlags）。
 	// EnsureDefaults 在 NewOptions 与 newLynx 间可能被多次调用，需要该
 	// 标记保持关闭语义不被默认值覆盖。
@@ -90,6 +102,16 @@ func (o *Options) Validate() error {
 	if o.DrainTimeout < 0 {
 		return ErrDrainTimeoutInvalid
 	}
+	if o.Env != "" {
+		if _, err := ParseEnv(string(o.Env)); err != nil {
+			return ErrEnvInvalid
+		}
+	}
+	for _, action := range o.SignalActions {
+		if !action.valid() {
+			return ErrSignalActionInvalid
+		}
+	}
 	return nil
 }
 
@@ -119,6 +141,16 @@ func (o *Options) EnsureDefaults() {
 		}
 	}
 
+	// 补齐缺省动作：已出现的信号（含显式 SignalActionNone）保持不变。
+	if o.SignalActions == nil {
+		o.SignalActions = map[os.Signal]SignalAction{}
+	}
+	for sig, action := range defaultSignalActions() {
+		if _, ok := o.SignalActions[sig]; !ok {
+			o.SignalActions[sig] = action
+		}
+	}
+
 	// 默认启用框架内置的命令行 flags：不传任何 flags 相关 Option 时
 	// 也能解析 -c/--log-level 等（修复"静默失效"陷阱）。显式传入自定义
 	// 函数时保留自定义实现；WithDisableConfigFlags 显式关闭。
@@ -213,6 +245,32 @@ func WithDrainTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithEnv 设置代码层面的运行环境缺省值（dev/test/prod），可被 --env
+// flag、LYNX_ENV 环境变量与配置键 env 覆盖。
+func WithEnv(env Env) Option {
+	return func(o *Options) {
+		o.Env = env
+	}
+}
+
+// WithSignalAction 为 sig 设置内置动作；action 为 SignalActionNone 时关闭
+// 该信号的缺省动作。
+func WithSignalAction(sig os.Signal, action SignalAction) Option {
+	return func(o *Options) {
+		if o.SignalActions == nil {
+			o.SignalActions = map[os.Signal]SignalAction{}
+		}
+		o.SignalActions[sig] = action
+	}
+}
+
+// WithDumpDir 设置转储文件的输出目录，缺省（空）时转储写入日志。
+func WithDumpDir(dir string) Option {
+	return func(o *Options) {
+		o.DumpDir = dir
+	}
+}
+
 // NewOptions 创建带默认值的 Options，并按顺序应用给定的选项。
 func NewOptions(opts ...Option) *Options {
 	o := &Options{}
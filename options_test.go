@@ -72,6 +72,16 @@ func TestOptionsValidate(t *testing.T) {
 			options: Options{DrainTimeout: -time.Millisecond},
 			wantErr: ErrDrainTimeoutInvalid,
 		},
+		{
+			name:    "valid env",
+			options: Options{Env: EnvProd},
+			wantErr: nil,
+		},
+		{
+			name:    "invalid env",
+			options: Options{Env: "staging"},
+			wantErr: ErrEnvInvalid,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lynx-go/lynx/logging"
+)
+
+// FieldAPIKeyID 是 API key ID 的日志属性 key。日志只记录 ID，从不记录
+// 密钥本身。
+const FieldAPIKeyID = "api_key_id"
+
+// DefaultAPIKeyFileReload 是 FileAPIKeys 检查文件变化的缺省间隔。
+const DefaultAPIKeyFileReload = 10 * time.Second
+
+// APIKey 是一个 API key 对应的调用方身份。
+type APIKey struct {
+	// ID 标识该 key（如 "billing-worker-2026"），写入日志属性 api_key_id。
+	ID string `mapstructure:"id" json:"id"`
+	// Subject 是调用方身份，非空时写入 logging.FieldUserID。
+	Subject string `mapstructure:"subject" json:"subject,omitempty"`
+	// Scopes 与 Roles 以 Claims 形式提供，RequireScopes/RequireRoles 对
+	// API key 同样生效。
+	Scopes []string `mapstructure:"scopes" json:"scopes,omitempty"`
+	Roles  []string `mapstructure:"roles" json:"roles,omitempty"`
+}
+
+// APIKeyStore 按密钥查找 API key。实现须并发安全。
+type APIKeyStore interface {
+	// Lookup 返回 key 对应的身份；key 未知时返回 false 与 nil 错误，错误
+	// 只表示查找本身失败（如存储不可用）。
+	Lookup(ctx context.Context, key string) (APIKey, bool, error)
+}
+
+// APIKeyEntry 是配置或文件中的一条 API key。Key 为明文，SHA256 为明文的
+// SHA-256 十六进制摘要，二者选一；推荐 SHA256，配置中不出现明文密钥。
+type APIKeyEntry struct {
+	APIKey `mapstructure:",squash"`
+	Key    string `mapstructure:"key" json:"key,omitempty"`
+	SHA256 string `mapstructure:"sha256" json:"sha256,omitempty"`
+}
+
+// StaticAPIKeys 是固定的 API key 集合，按密钥的 SHA-256 摘要索引。
+type StaticAPIKeys struct {
+	byHash map[[sha256.Size]byte]APIKey
+}
+
+// NewStaticAPIKeys 由 entries 创建 StaticAPIKeys；ID 缺失或重复、Key 与
+// SHA256 未恰好设置一个或摘要格式错误时返回错误。
+func NewStaticAPIKeys(entries []APIKeyEntry) (*StaticAPIKeys, error) {
+	s := &StaticAPIKeys{byHash: make(map[[sha256.Size]byte]APIKey, len(entries))}
+	ids := map[string]bool{}
+	for i, e := range entries {
+		if e.ID == "" {
+			return nil, fmt.Errorf("auth: API key %d has no id", i)
+		}
+		if ids[e.ID] {
+			return nil, fmt.Errorf("auth: duplicate API key id %q", e.ID)
+		}
+		ids[e.ID] = true
+		var sum [sha256.Size]byte
+		switch {
+		case e.Key != "" && e.SHA256 == "":
+			sum = sha256.Sum256([]byte(e.Key))
+		case e.Key == "" && e.SHA256 != "":
+			b, err := hex.DecodeString(e.SHA256)
+			if err != nil || len(b) != sha256.Size {
+				return nil, fmt.Errorf("auth: API key %q has an invalid sha256", e.ID)
+			}
+			copy(sum[:], b)
+		default:
+			return nil, fmt.Errorf("auth: API key %q needs exactly one of key and sha256", e.ID)
+		}
+		s.byHash[sum] = e.APIKey
+	}
+	return s, nil
+}
+
+// Lookup 实现 APIKeyStore。比较的是摘要，耗时与密钥内容无关。
+func (s *StaticAPIKeys) Lookup(_ context.Context, key string) (APIKey, bool, error) {
+	k, ok := s.byHash[sha256.Sum256([]byte(key))]
+	return k, ok, nil
+}
+
+// FileAPIKeys 从 JSON 文件（APIKeyEntry 数组）加载 API key，文件修改后
+// 自动重新加载，轮换 key 无需重启。重新加载失败时继续使用已加载的 key
+// 并记录警告。
+type FileAPIKeys struct {
+	path   string
+	reload time.Duration
+
+	mu        sync.Mutex
+	keys      *StaticAPIKeys
+	modTime   time.Time
+	size      int64
+	lastCheck time.Time
+}
+
+// NewFileAPIKeys 加载 path 并返回 FileAPIKeys，reload 是检查文件变化的
+// 间隔（≤ 0 时取 DefaultAPIKeyFileReload）。文件无法读取或内容非法时返回
+// 错误。
+func NewFileAPIKeys(path string, reload time.Duration) (*FileAPIKeys, error) {
+	if reload <= 0 {
+		reload = DefaultAPIKeyFileReload
+	}
+	f := &FileAPIKeys{path: path, reload: reload}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+	f.lastCheck = time.Now()
+	return f, nil
+}
+
+// Lookup 实现 APIKeyStore。
+func (f *FileAPIKeys) Lookup(ctx context.Context, key string) (APIKey, bool, error) {
+	f.mu.Lock()
+	if now := time.Now(); now.Sub(f.lastCheck) >= f.reload {
+		f.lastCheck = now
+		if err := f.load(); err != nil {
+			slog.WarnContext(ctx, "auth: reload API keys failed, using previous keys", "path", f.path, "error", err)
+		}
+	}
+	keys := f.keys
+	f.mu.Unlock()
+	return keys.Lookup(ctx, key)
+}
+
+// load 在文件变化（或首次）时重新解析。
+func (f *FileAPIKeys) load() error {
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("auth: API key file: %w", err)
+	}
+	if f.keys != nil && fi.ModTime().Equal(f.modTime) && fi.Size() == f.size {
+		return nil
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("auth: API key file: %w", err)
+	}
+	var entries []APIKeyEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("auth: parse API key file %s: %w", f.path, err)
+	}
+	keys, err := NewStaticAPIKeys(entries)
+	if err != nil {
+		return err
+	}
+	f.keys, f.modTime, f.size = keys, fi.ModTime(), fi.Size()
+	return nil
+}
+
+type apiKeyCtx struct{}
+
+// WithAPIKey 返回已以 k 认证的 Context：k 的 Subject、Scopes、Roles 以
+// Claims 形式提供（FromContext），并写入日志属性 api_key_id 与 user_id
+// （Subject 非空时）。server/http 的 APIKeyAuth 与 gRPC 的 APIKey 拦截器
+// 使用它。
+func WithAPIKey(ctx context.Context, k APIKey) context.Context {
+	ctx = context.WithValue(ctx, apiKeyCtx{}, k)
+	ctx = NewContext(ctx, &Claims{Subject: k.Subject, Scopes: k.Scopes, Roles: k.Roles, raw: []byte("{}")})
+	attrs := []slog.Attr{slog.String(FieldAPIKeyID, k.ID)}
+	if k.Subject != "" {
+		attrs = append(attrs, slog.String(logging.FieldUserID, k.Subject))
+	}
+	return logging.WithAttrs(ctx, attrs...)
+}
+
+// APIKeyFrom 返回请求认证所用的 API key 身份；请求未经 API key 认证时
+// 返回 false。
+func APIKeyFrom(ctx context.Context) (APIKey, bool) {
+	k, ok := ctx.Value(apiKeyCtx{}).(APIKey)
+	return k, ok
+}
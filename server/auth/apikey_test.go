@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lynx-go/lynx/logging"
+)
+
+func TestStaticAPIKeys(t *testing.T) {
+	sum := sha256.Sum256([]byte("secret-2"))
+	keys, err := NewStaticAPIKeys([]APIKeyEntry{
+		{APIKey: APIKey{ID: "k1", Subject: "svc-a", Scopes: []string{"orders:read"}}, Key: "secret-1"},
+		{APIKey: APIKey{ID: "k2"}, SHA256: hex.EncodeToString(sum[:])},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for key, want := range map[string]string{"secret-1": "k1", "secret-2": "k2", "other": ""} {
+		k, ok, err := keys.Lookup(ctx, key)
+		if err != nil || ok != (want != "") || k.ID != want {
+			t.Errorf("Lookup(%q) = %+v, %v, %v", key, k, ok, err)
+		}
+	}
+
+	for name, entries := range map[string][]APIKeyEntry{
+		"no id":      {{Key: "x"}},
+		"duplicate":  {{APIKey: APIKey{ID: "a"}, Key: "x"}, {APIKey: APIKey{ID: "a"}, Key: "y"}},
+		"both":       {{APIKey: APIKey{ID: "a"}, Key: "x", SHA256: hex.EncodeToString(sum[:])}},
+		"neither":    {{APIKey: APIKey{ID: "a"}}},
+		"bad digest": {{APIKey: APIKey{ID: "a"}, SHA256: "abcd"}},
+	} {
+		if _, err := NewStaticAPIKeys(entries); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestFileAPIKeysReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	write := func(doc string) {
+		if err := os.WriteFile(path, []byte(doc), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`[{"id":"old","key":"k-old"}]`)
+	keys, err := NewFileAPIKeys(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, ok, _ := keys.Lookup(ctx, "k-old"); !ok {
+		t.Fatal("initial key missing")
+	}
+
+	// 轮换：新文件生效后旧 key 失效。
+	write(`[{"id":"new","key":"k-new","subject":"svc"}]`)
+	keys.lastCheck = time.Time{}
+	if k, ok, _ := keys.Lookup(ctx, "k-new"); !ok || k.Subject != "svc" {
+		t.Fatalf("rotated key = %+v, %v", k, ok)
+	}
+	if _, ok, _ := keys.Lookup(ctx, "k-old"); ok {
+		t.Fatal("old key still valid")
+	}
+
+	// 内容非法时保留已加载的 key。
+	write(`[{"id":"broken"`)
+	keys.lastCheck = time.Time{}
+	if _, ok, _ := keys.Lookup(ctx, "k-new"); !ok {
+		t.Fatal("keys dropped after failed reload")
+	}
+
+	if _, err := NewFileAPIKeys(filepath.Join(t.TempDir(), "missing.json"), 0); err == nil {
+		t.Fatal("missing file accepted")
+	}
+}
+
+func TestWithAPIKey(t *testing.T) {
+	ctx := WithAPIKey(context.Background(), APIKey{ID: "k1", Subject: "svc-a", Roles: []string{"ops"}})
+	if k, ok := APIKeyFrom(ctx); !ok || k.ID != "k1" {
+		t.Fatalf("APIKeyFrom = %+v, %v", k, ok)
+	}
+	claims, ok := FromContext(ctx)
+	if !ok || claims.Subject != "svc-a" || !claims.HasRole("ops") {
+		t.Fatalf("claims = %+v, %v", claims, ok)
+	}
+	attrs := map[string]string{}
+	for _, a := range logging.AttrsFrom(ctx) {
+		attrs[a.Key] = a.Value.String()
+	}
+	if attrs[FieldAPIKeyID] != "k1" || attrs[logging.FieldUserID] != "svc-a" {
+		t.Fatalf("attrs = %v", attrs)
+	}
+	if _, ok := APIKeyFrom(context.Background()); ok {
+		t.Fatal("APIKeyFrom on empty context")
+	}
+}
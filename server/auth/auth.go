@@ -0,0 +1,71 @@
+// Package auth 提供请求认证的公共部分：JWT 校验（HS256/RS256/ES256/
+// EdDSA）、验签密钥来源（静态密钥、PEM 与可轮换的 JWKS）、经 Context
+// 传递的认证声明，以及 mTLS 对端身份与声明式授权策略（Peer、
+// PeerPolicy）。server/http 的 JWTAuth、PeerAuth 中间件基于本包实现。
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Claims 是已校验令牌的声明。注册声明（RFC 7519 §4.1）解析为字段，
+// 其余声明经 Decode 或 ClaimsAs 解码到调用方的类型。
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	ID        string
+	// Scopes 来自 "scope"（空格分隔，RFC 8693）或 "scp"（字符串或数组）。
+	Scopes []string
+	// Roles 来自 VerifierOptions.RolesClaim 指定的声明（缺省 "roles"）。
+	Roles []string
+
+	raw json.RawMessage
+}
+
+// HasScope 报告声明是否包含 scope。
+func (c *Claims) HasScope(scope string) bool { return slices.Contains(c.Scopes, scope) }
+
+// HasRole 报告声明是否包含 role。
+func (c *Claims) HasRole(role string) bool { return slices.Contains(c.Roles, role) }
+
+// Decode 把令牌载荷（全部声明）按 JSON 解码到 v，用于读取自定义声明。
+func (c *Claims) Decode(v any) error { return json.Unmarshal(c.raw, v) }
+
+type claimsCtx struct{}
+
+// NewContext 返回携带 claims 的 Context。
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsCtx{}, claims)
+}
+
+// FromContext 返回 ctx 中的声明；请求未经认证时返回 nil 与 false。
+func FromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(claimsCtx{}).(*Claims)
+	return c, ok && c != nil
+}
+
+// ClaimsAs 把 ctx 中的令牌载荷解码为 T（通常是带 json 标签的自定义声明
+// 结构体）；请求未经认证或解码失败时返回 false。
+//
+//	type TenantClaims struct {
+//		Tenant string `json:"tenant"`
+//	}
+//	tc, ok := auth.ClaimsAs[TenantClaims](r.Context())
+func ClaimsAs[T any](ctx context.Context) (T, bool) {
+	var v T
+	c, ok := FromContext(ctx)
+	if !ok {
+		return v, false
+	}
+	if err := c.Decode(&v); err != nil {
+		return v, false
+	}
+	return v, true
+}
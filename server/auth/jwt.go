@@ -0,0 +1,388 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 支持的 JWS 算法（JWT 头部 alg）。
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// DefaultClockSkew 是校验 exp/nbf 时容忍的缺省时钟偏差。
+const DefaultClockSkew = time.Minute
+
+// 令牌校验失败的原因，可用 errors.Is 识别。
+var (
+	ErrTokenMalformed    = errors.New("auth: malformed token")
+	ErrTokenUnverifiable = errors.New("auth: no key to verify token")
+	ErrTokenSignature    = errors.New("auth: invalid token signature")
+	ErrTokenExpired      = errors.New("auth: token expired")
+	ErrTokenNotYetValid  = errors.New("auth: token not yet valid")
+	ErrTokenIssuer       = errors.New("auth: invalid token issuer")
+	ErrTokenAudience     = errors.New("auth: invalid token audience")
+)
+
+// VerifierOptions 配置 Verifier，字段带 mapstructure 标签，可直接作为
+// 配置段（见 server/http 的 Config）。密钥来源 Secret、PublicKey、
+// PublicKeyFile、JWKSURL、JWKSFile 与 KeySet 至少配置一个，可同时配置
+// 多个（如轮换期间新旧密钥并存）。
+type VerifierOptions struct {
+	// Issuer 非空时要求 iss 与之相同。
+	Issuer string `mapstructure:"issuer"`
+	// Audience 非空时要求 aud 至少包含其中之一。
+	Audience []string `mapstructure:"audience"`
+	// Algorithms 限定接受的算法，缺省接受全部支持的算法（仍须与密钥类型
+	// 匹配）。
+	Algorithms []string `mapstructure:"algorithms"`
+	// ClockSkew 是 exp/nbf 的时钟偏差容忍：0 取 DefaultClockSkew，负数表示
+	// 不容忍。
+	ClockSkew time.Duration `mapstructure:"clock_skew"`
+	// Secret 是 HS256 的共享密钥，至少 32 字节。
+	Secret string `mapstructure:"secret"`
+	// PublicKey 是 PEM 格式的公钥或证书（可含多个）。
+	PublicKey string `mapstructure:"public_key"`
+	// PublicKeyFile 是 PEM 公钥文件，启动时读取。
+	PublicKeyFile string `mapstructure:"public_key_file"`
+	// JWKSURL 是 JWKS 端点，按 JWKSRefresh 缓存刷新（见 JWKS）。
+	JWKSURL string `mapstructure:"jwks_url"`
+	// JWKSFile 是本地 JWKS 文件，按 JWKSRefresh 重新读取。
+	JWKSFile string `mapstructure:"jwks_file"`
+	// JWKSRefresh 是 JWKS 刷新间隔，缺省 DefaultJWKSRefresh。
+	JWKSRefresh time.Duration `mapstructure:"jwks_refresh"`
+	// RolesClaim 是角色声明名，缺省 "roles"；支持以 "." 分隔的嵌套路径
+	// （如 Keycloak 的 "realm_access.roles"）。
+	RolesClaim string `mapstructure:"roles_claim"`
+	// KeySet 是自定义密钥来源。
+	KeySet KeySet `mapstructure:"-"`
+	// HTTPClient 用于拉取 JWKSURL。
+	HTTPClient *http.Client `mapstructure:"-"`
+}
+
+// Verifier 校验 JWS 紧凑序列化的 JWT，并发安全。
+type Verifier struct {
+	o    VerifierOptions
+	keys KeySet
+	algs []string
+	skew time.Duration
+	now  func() time.Time
+}
+
+// NewVerifier 按 o 创建 Verifier；没有密钥来源、密钥无法解析、HS256
+// 密钥过短或算法不受支持时返回错误。
+func NewVerifier(o VerifierOptions) (*Verifier, error) {
+	v := &Verifier{o: o, skew: o.ClockSkew, now: time.Now}
+	if v.skew == 0 {
+		v.skew = DefaultClockSkew
+	} else if v.skew < 0 {
+		v.skew = 0
+	}
+	if v.o.RolesClaim == "" {
+		v.o.RolesClaim = "roles"
+	}
+	for _, alg := range o.Algorithms {
+		switch alg {
+		case AlgHS256, AlgRS256, AlgES256, AlgEdDSA:
+			v.algs = append(v.algs, alg)
+		default:
+			return nil, fmt.Errorf("auth: unsupported algorithm %q", alg)
+		}
+	}
+	var (
+		sets   multiKeySet
+		static StaticKeys
+	)
+	if o.Secret != "" {
+		if len(o.Secret) < 32 {
+			return nil, errors.New("auth: HS256 secret must be at least 32 bytes")
+		}
+		static = append(static, Key{Algorithm: AlgHS256, Key: []byte(o.Secret)})
+	}
+	pemData := []byte(o.PublicKey)
+	if o.PublicKeyFile != "" {
+		data, err := os.ReadFile(o.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("auth: read public key: %w", err)
+		}
+		pemData = append(append(pemData, '\n'), data...)
+	}
+	if len(bytes.TrimSpace(pemData)) > 0 {
+		keys, err := ParsePEMKeys(pemData)
+		if err != nil {
+			return nil, err
+		}
+		static = append(static, keys...)
+	}
+	if len(static) > 0 {
+		sets = append(sets, static)
+	}
+	for _, src := range []JWKSOptions{{URL: o.JWKSURL}, {File: o.JWKSFile}} {
+		if src.URL == "" && src.File == "" {
+			continue
+		}
+		src.Refresh, src.Client = o.JWKSRefresh, o.HTTPClient
+		jwks, err := NewJWKS(src)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, jwks)
+	}
+	if o.KeySet != nil {
+		sets = append(sets, o.KeySet)
+	}
+	switch len(sets) {
+	case 0:
+		return nil, errors.New("auth: verifier requires at least one key source")
+	case 1:
+		v.keys = sets[0]
+	default:
+		v.keys = sets
+	}
+	return v, nil
+}
+
+// Verify 校验令牌的签名与 iss/aud/exp/nbf（exp 必须存在），成功时返回
+// 其声明。失败时返回的错误包装 ErrToken* 之一。
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected 3 segments", ErrTokenMalformed)
+	}
+	var header struct {
+		Alg  string          `json:"alg"`
+		Kid  string          `json:"kid"`
+		Crit json.RawMessage `json:"crit"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrTokenMalformed, err)
+	}
+	if header.Crit != nil {
+		return nil, fmt.Errorf("%w: unsupported crit header", ErrTokenMalformed)
+	}
+	if !v.algorithmAllowed(header.Alg) {
+		return nil, fmt.Errorf("%w: algorithm %q not allowed", ErrTokenUnverifiable, header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrTokenMalformed, err)
+	}
+	keys, err := v.keys.Keys(ctx, header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenUnverifiable, err)
+	}
+	signed := []byte(token[:len(parts[0])+1+len(parts[1])])
+	tried := false
+	verified := false
+	for _, k := range keys {
+		if k.Algorithm != "" && k.Algorithm != header.Alg {
+			continue
+		}
+		ok, usable := verifySignature(header.Alg, k.Key, signed, sig)
+		tried = tried || usable
+		if ok {
+			verified = true
+			break
+		}
+	}
+	if !tried {
+		return nil, fmt.Errorf("%w: no %s key for kid %q", ErrTokenUnverifiable, header.Alg, header.Kid)
+	}
+	if !verified {
+		return nil, ErrTokenSignature
+	}
+	claims, err := v.parseClaims(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) algorithmAllowed(alg string) bool {
+	switch alg {
+	case AlgHS256, AlgRS256, AlgES256, AlgEdDSA:
+		return len(v.algs) == 0 || slices.Contains(v.algs, alg)
+	}
+	return false
+}
+
+// verifySignature 以 key 校验签名；usable 表示 key 的类型适用于 alg
+// （算法与密钥类型必须匹配，防止以公钥作为 HMAC 密钥的算法混淆攻击）。
+func verifySignature(alg string, key any, signed, sig []byte) (ok, usable bool) {
+	digest := sha256.Sum256(signed)
+	switch alg {
+	case AlgHS256:
+		secret, isSecret := key.([]byte)
+		if !isSecret {
+			return false, false
+		}
+		m := hmac.New(sha256.New, secret)
+		m.Write(signed)
+		return hmac.Equal(m.Sum(nil), sig), true
+	case AlgRS256:
+		pub, isRSA := key.(*rsa.PublicKey)
+		if !isRSA {
+			return false, false
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil, true
+	case AlgES256:
+		pub, isEC := key.(*ecdsa.PublicKey)
+		if !isEC || pub.Curve.Params().BitSize != 256 {
+			return false, false
+		}
+		if len(sig) != 64 {
+			return false, true
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, digest[:], r, s), true
+	case AlgEdDSA:
+		pub, isEd := key.(ed25519.PublicKey)
+		if !isEd {
+			return false, false
+		}
+		return ed25519.Verify(pub, signed, sig), true
+	}
+	return false, false
+}
+
+func (v *Verifier) parseClaims(segment string) (*Claims, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrTokenMalformed, err)
+	}
+	var reg struct {
+		Iss   string     `json:"iss"`
+		Sub   string     `json:"sub"`
+		Aud   stringList `json:"aud"`
+		Exp   *float64   `json:"exp"`
+		Nbf   *float64   `json:"nbf"`
+		Iat   *float64   `json:"iat"`
+		Jti   string     `json:"jti"`
+		Scope string     `json:"scope"`
+		Scp   stringList `json:"scp"`
+	}
+	if err := json.Unmarshal(raw, &reg); err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrTokenMalformed, err)
+	}
+	c := &Claims{
+		Issuer:    reg.Iss,
+		Subject:   reg.Sub,
+		Audience:  reg.Aud,
+		ID:        reg.Jti,
+		ExpiresAt: numericDate(reg.Exp),
+		NotBefore: numericDate(reg.Nbf),
+		IssuedAt:  numericDate(reg.Iat),
+		Scopes:    append(strings.Fields(reg.Scope), reg.Scp...),
+		raw:       raw,
+	}
+	roles, err := rolesClaim(raw, v.o.RolesClaim)
+	if err != nil {
+		return nil, err
+	}
+	c.Roles = roles
+
+	now := v.now()
+	if reg.Exp == nil {
+		return nil, fmt.Errorf("%w: missing exp claim", ErrTokenMalformed)
+	}
+	if !now.Before(c.ExpiresAt.Add(v.skew)) {
+		return nil, ErrTokenExpired
+	}
+	if reg.Nbf != nil && now.Add(v.skew).Before(c.NotBefore) {
+		return nil, ErrTokenNotYetValid
+	}
+	if v.o.Issuer != "" && c.Issuer != v.o.Issuer {
+		return nil, ErrTokenIssuer
+	}
+	if len(v.o.Audience) > 0 && !slices.ContainsFunc(c.Audience, func(a string) bool {
+		return slices.Contains(v.o.Audience, a)
+	}) {
+		return nil, ErrTokenAudience
+	}
+	return c, nil
+}
+
+// rolesClaim 按以 "." 分隔的路径读取角色声明（字符串或字符串数组）。
+func rolesClaim(raw []byte, path string) ([]string, error) {
+	var cur any
+	if err := json.Unmarshal(raw, &cur); err != nil {
+		return nil, fmt.Errorf("%w: payload: %v", ErrTokenMalformed, err)
+	}
+	for _, name := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		cur = m[name]
+	}
+	switch v := cur.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out, nil
+	}
+	return nil, nil
+}
+
+func decodeSegment(s string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func numericDate(f *float64) time.Time {
+	if f == nil {
+		return time.Time{}
+	}
+	sec, frac := math.Modf(*f)
+	return time.Unix(int64(sec), int64(frac*1e9))
+}
+
+// stringList 解码为字符串或字符串数组的声明（aud、scp）。
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(data, &ss); err != nil {
+		return err
+	}
+	*l = ss
+	return nil
+}
@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testSecret   = strings.Repeat("k", 32)
+	testRSA, _   = rsa.GenerateKey(rand.Reader, 2048)
+	testEC, _    = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, testEd, _ = ed25519.GenerateKey(rand.Reader)
+)
+
+// sign 生成测试令牌；key 为 []byte、*rsa.PrivateKey、*ecdsa.PrivateKey 或
+// ed25519.PrivateKey。
+func sign(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	header := map[string]any{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	enc := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := enc(header) + "." + enc(claims)
+	digest := sha256.Sum256([]byte(signed))
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		m := hmac.New(sha256.New, k)
+		m.Write([]byte(signed))
+		sig = m.Sum(nil)
+	case *rsa.PrivateKey:
+		sig, _ = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case ed25519.PrivateKey:
+		sig = ed25519.Sign(k, []byte(signed))
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func validClaims() map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss": "https://issuer.example.com",
+		"sub": "u-42",
+		"aud": "orders",
+		"exp": now.Add(time.Hour).Unix(),
+		"nbf": now.Add(-time.Minute).Unix(),
+		"iat": now.Unix(),
+	}
+}
+
+func pemPublicKey(t *testing.T, pub any) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestVerifyAlgorithms(t *testing.T) {
+	v, err := NewVerifier(VerifierOptions{
+		Issuer:   "https://issuer.example.com",
+		Audience: []string{"orders", "billing"},
+		Secret:   testSecret,
+		PublicKey: pemPublicKey(t, &testRSA.PublicKey) + pemPublicKey(t, &testEC.PublicKey) +
+			pemPublicKey(t, testEd.Public()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for alg, key := range map[string]any{
+		AlgHS256: []byte(testSecret),
+		AlgRS256: testRSA,
+		AlgES256: testEC,
+		AlgEdDSA: testEd,
+	} {
+		t.Run(alg, func(t *testing.T) {
+			c, err := v.Verify(context.Background(), sign(t, alg, "", key, validClaims()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Subject != "u-42" || c.Audience[0] != "orders" || c.ExpiresAt.IsZero() {
+				t.Fatalf("claims = %+v", c)
+			}
+		})
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	v, err := NewVerifier(VerifierOptions{
+		Issuer:    "https://issuer.example.com",
+		Audience:  []string{"orders"},
+		ClockSkew: 30 * time.Second,
+		PublicKey: pemPublicKey(t, &testRSA.PublicKey),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	with := func(k string, val any) map[string]any {
+		c := validClaims()
+		if val == nil {
+			delete(c, k)
+		} else {
+			c[k] = val
+		}
+		return c
+	}
+	now := time.Now()
+	otherRSA, _ := rsa.GenerateKey(rand.Reader, 2048)
+	valid := sign(t, AlgRS256, "", testRSA, validClaims())
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"garbage", "not-a-token", ErrTokenMalformed},
+		{"alg none", base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+			strings.Split(valid, ".")[1] + ".", ErrTokenUnverifiable},
+		{"wrong key", sign(t, AlgRS256, "", otherRSA, validClaims()), ErrTokenSignature},
+		{"tampered payload", func() string {
+			p := strings.Split(valid, ".")
+			p[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin","exp":9999999999}`))
+			return strings.Join(p, ".")
+		}(), ErrTokenSignature},
+		// 以 RSA 公钥作为 HMAC 密钥的算法混淆攻击。
+		{"alg confusion", sign(t, AlgHS256, "", []byte(pemPublicKey(t, &testRSA.PublicKey)), validClaims()), ErrTokenUnverifiable},
+		{"expired", sign(t, AlgRS256, "", testRSA, with("exp", now.Add(-time.Minute).Unix())), ErrTokenExpired},
+		{"missing exp", sign(t, AlgRS256, "", testRSA, with("exp", nil)), ErrTokenMalformed},
+		{"not yet valid", sign(t, AlgRS256, "", testRSA, with("nbf", now.Add(time.Minute).Unix())), ErrTokenNotYetValid},
+		{"issuer", sign(t, AlgRS256, "", testRSA, with("iss", "https://evil.example.com")), ErrTokenIssuer},
+		{"audience", sign(t, AlgRS256, "", testRSA, with("aud", []string{"billing"})), ErrTokenAudience},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Verify(context.Background(), tt.token); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// 时钟偏差之内的 exp/nbf 仍然接受。
+	skewed := sign(t, AlgRS256, "", testRSA, with("exp", now.Add(-10*time.Second).Unix()))
+	if _, err := v.Verify(context.Background(), skewed); err != nil {
+		t.Fatalf("within skew: %v", err)
+	}
+}
+
+func TestVerifyAlgorithmsOption(t *testing.T) {
+	v, err := NewVerifier(VerifierOptions{Algorithms: []string{AlgRS256}, Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(context.Background(), sign(t, AlgHS256, "", []byte(testSecret), validClaims())); !errors.Is(err, ErrTokenUnverifiable) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestClaimsScopesRolesAndDecode(t *testing.T) {
+	v, err := NewVerifier(VerifierOptions{Secret: testSecret, RolesClaim: "realm_access.roles"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := validClaims()
+	claims["scope"] = "orders:read orders:write"
+	claims["scp"] = []string{"profile"}
+	claims["realm_access"] = map[string]any{"roles": []string{"admin", "ops"}}
+	claims["tenant"] = "acme"
+	c, err := v.Verify(context.Background(), sign(t, AlgHS256, "", []byte(testSecret), claims))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.HasScope("orders:write") || !c.HasScope("profile") || c.HasScope("orders") {
+		t.Fatalf("scopes = %v", c.Scopes)
+	}
+	if !c.HasRole("ops") || c.HasRole("user") {
+		t.Fatalf("roles = %v", c.Roles)
+	}
+
+	type tenantClaims struct {
+		Tenant string `json:"tenant"`
+		Sub    string `json:"sub"`
+	}
+	ctx := NewContext(context.Background(), c)
+	tc, ok := ClaimsAs[tenantClaims](ctx)
+	if !ok || tc.Tenant != "acme" || tc.Sub != "u-42" {
+		t.Fatalf("ClaimsAs = %+v, %v", tc, ok)
+	}
+	if _, ok := ClaimsAs[tenantClaims](context.Background()); ok {
+		t.Fatal("ClaimsAs succeeded without claims")
+	}
+}
+
+func TestNewVerifierErrors(t *testing.T) {
+	for _, o := range []VerifierOptions{
+		{},
+		{Secret: "short"},
+		{Secret: testSecret, Algorithms: []string{"HS512"}},
+		{PublicKey: "not pem"},
+		{PublicKeyFile: "/nonexistent.pem"},
+	} {
+		if _, err := NewVerifier(o); err == nil {
+			t.Errorf("NewVerifier(%+v) succeeded", o)
+		}
+	}
+}
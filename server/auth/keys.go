@@ -0,0 +1,357 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultJWKSRefresh 是 JWKS 的缺省刷新间隔。
+const DefaultJWKSRefresh = time.Hour
+
+// jwksMinRefetch 是两次加载 JWKS 的最短间隔，避免携带随机 kid 的请求
+// 或端点故障放大为对 JWKS 端点的请求。
+const jwksMinRefetch = 30 * time.Second
+
+// Key 是一个验签密钥。
+type Key struct {
+	// ID 对应 JWT 头部的 kid，为空时匹配任意 kid。
+	ID string
+	// Algorithm 限定该密钥只用于此算法，为空时按密钥类型推断。
+	Algorithm string
+	// Key 是 []byte（HS256）、*rsa.PublicKey（RS256）、*ecdsa.PublicKey
+	// （ES256，P-256）或 ed25519.PublicKey（EdDSA）。
+	Key any
+}
+
+// KeySet 提供验签密钥。实现须并发安全。
+type KeySet interface {
+	// Keys 返回可能与 kid 匹配的候选密钥；kid 为空时返回全部密钥。
+	Keys(ctx context.Context, kid string) ([]Key, error)
+}
+
+// StaticKeys 是固定的密钥集合。
+type StaticKeys []Key
+
+// Keys 实现 KeySet：返回 ID 为空或与 kid 相同的密钥。
+func (s StaticKeys) Keys(_ context.Context, kid string) ([]Key, error) {
+	return matchKeys(s, kid), nil
+}
+
+func matchKeys(keys []Key, kid string) []Key {
+	var out []Key
+	for _, k := range keys {
+		if kid == "" || k.ID == "" || k.ID == kid {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// ParsePEMKeys 解析 PEM 数据中的全部公钥：PKIX 公钥（"PUBLIC KEY"）、
+// PKCS#1 RSA 公钥（"RSA PUBLIC KEY"）或证书（"CERTIFICATE"，取其公钥）。
+func ParsePEMKeys(data []byte) ([]Key, error) {
+	var keys []Key
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		var (
+			pub any
+			err error
+		)
+		switch block.Type {
+		case "PUBLIC KEY":
+			pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+				pub = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("auth: parse PEM %s: %w", block.Type, err)
+		}
+		keys = append(keys, Key{Key: pub})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("auth: no public key found in PEM data")
+	}
+	return keys, nil
+}
+
+// ParseJWKS 解析 JWK Set 文档（RFC 7517）。支持 RSA、EC（P-256）、OKP
+// （Ed25519）与 oct 密钥；"use" 不是 "sig" 的密钥与不支持的类型被跳过。
+func ParseJWKS(data []byte) ([]Key, error) {
+	var doc struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("auth: parse JWKS: %w", err)
+	}
+	var keys []Key
+	for i, raw := range doc.Keys {
+		k, ok, err := parseJWK(raw)
+		if err != nil {
+			return nil, fmt.Errorf("auth: parse JWKS key %d: %w", i, err)
+		}
+		if ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func parseJWK(raw json.RawMessage) (Key, bool, error) {
+	var jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+		Use string `json:"use"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+		K   string `json:"k"`
+	}
+	if err := json.Unmarshal(raw, &jwk); err != nil {
+		return Key{}, false, err
+	}
+	if jwk.Use != "" && jwk.Use != "sig" {
+		return Key{}, false, nil
+	}
+	key := Key{ID: jwk.Kid, Algorithm: jwk.Alg}
+	b64 := base64.RawURLEncoding.DecodeString
+	switch jwk.Kty {
+	case "RSA":
+		n, err := b64(jwk.N)
+		if err != nil {
+			return Key{}, false, err
+		}
+		e, err := b64(jwk.E)
+		if err != nil {
+			return Key{}, false, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return Key{}, false, errors.New("invalid RSA key")
+		}
+		key.Key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return Key{}, false, nil
+		}
+		x, err := b64(jwk.X)
+		if err != nil {
+			return Key{}, false, err
+		}
+		y, err := b64(jwk.Y)
+		if err != nil {
+			return Key{}, false, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return Key{}, false, errors.New("invalid P-256 coordinates")
+		}
+		pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return Key{}, false, err
+		}
+		key.Key = pub
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return Key{}, false, nil
+		}
+		x, err := b64(jwk.X)
+		if err != nil {
+			return Key{}, false, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return Key{}, false, errors.New("invalid Ed25519 key")
+		}
+		key.Key = ed25519.PublicKey(x)
+	case "oct":
+		k, err := b64(jwk.K)
+		if err != nil {
+			return Key{}, false, err
+		}
+		key.Key = k
+	default:
+		return Key{}, false, nil
+	}
+	return key, true, nil
+}
+
+// JWKSOptions 配置 JWKS。URL 与 File 二选一。
+type JWKSOptions struct {
+	// URL 是 JWKS 端点（如 "https://issuer/.well-known/jwks.json"）。
+	URL string
+	// File 是本地 JWKS 文件，刷新时重新读取，便于由密钥管理工具轮换。
+	File string
+	// Refresh 是刷新间隔，缺省 DefaultJWKSRefresh。
+	Refresh time.Duration
+	// Client 是拉取 URL 的 HTTP 客户端，缺省超时 10 秒。
+	Client *http.Client
+}
+
+// JWKS 是缓存并按间隔刷新的 JWK Set，实现 KeySet。密钥轮换时签发方先
+// 发布新密钥再启用：缓存过期时重新加载，遇到未知 kid 时也会提前重新
+// 加载。两次加载尝试的间隔不短于 30 秒（刷新间隔更短时取刷新间隔），
+// 加载失败时同样计入，避免端点故障时每个请求都重新拉取。加载在锁外
+// 进行，同一时刻只有一个：已有匹配密钥的调用直接使用缓存，没有匹配
+// 密钥的调用等待本次加载结果。加载失败时继续使用已缓存的密钥并记录
+// 警告，从未加载成功时返回最近一次的错误。
+type JWKS struct {
+	o JWKSOptions
+
+	mu          sync.Mutex
+	keys        []Key
+	loaded      time.Time
+	lastAttempt time.Time
+	lastErr     error
+	// loading 非 nil 时有加载进行中，加载结束后关闭。
+	loading chan struct{}
+}
+
+// NewJWKS 创建 JWKS；首次加载在第一次 Keys 调用时进行。
+func NewJWKS(o JWKSOptions) (*JWKS, error) {
+	if (o.URL == "") == (o.File == "") {
+		return nil, errors.New("auth: JWKS requires exactly one of URL and File")
+	}
+	if o.Refresh <= 0 {
+		o.Refresh = DefaultJWKSRefresh
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &JWKS{o: o}, nil
+}
+
+// Keys 实现 KeySet。
+func (j *JWKS) Keys(ctx context.Context, kid string) ([]Key, error) {
+	j.mu.Lock()
+	now := time.Now()
+	matched := matchKeys(j.keys, kid)
+	stale := j.keys == nil || now.Sub(j.loaded) >= j.o.Refresh
+	unknown := kid != "" && len(matched) == 0
+	if (stale || unknown) && j.loading == nil && now.Sub(j.lastAttempt) >= min(j.o.Refresh, jwksMinRefetch) {
+		j.lastAttempt = now
+		done := make(chan struct{})
+		j.loading = done
+		j.mu.Unlock()
+		j.refresh(ctx, done)
+		j.mu.Lock()
+	} else if done := j.loading; done != nil && len(matched) == 0 {
+		j.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		j.mu.Lock()
+	}
+	defer j.mu.Unlock()
+	if j.keys == nil {
+		return nil, j.lastErr
+	}
+	return matchKeys(j.keys, kid), nil
+}
+
+// refresh 在锁外加载密钥，更新缓存后关闭 done。
+func (j *JWKS) refresh(ctx context.Context, done chan struct{}) {
+	keys, err := j.load(ctx)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	defer close(done)
+	j.loading, j.lastErr = nil, err
+	switch {
+	case err == nil:
+		j.keys, j.loaded = append([]Key{}, keys...), time.Now()
+	case j.keys != nil:
+		slog.WarnContext(ctx, "auth: JWKS refresh failed, using cached keys", "source", j.source(), "error", err)
+	}
+}
+
+func (j *JWKS) source() string {
+	if j.o.URL != "" {
+		return j.o.URL
+	}
+	return j.o.File
+}
+
+func (j *JWKS) load(ctx context.Context) ([]Key, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if j.o.File != "" {
+		data, err = os.ReadFile(j.o.File)
+	} else {
+		data, err = j.fetch(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("auth: load JWKS %s: %w", j.source(), err)
+	}
+	return ParseJWKS(data)
+}
+
+func (j *JWKS) fetch(ctx context.Context) ([]byte, error) {
+	// 与触发刷新的请求解耦：请求取消不应使共享缓存的刷新失败。
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodGet, j.o.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := j.o.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// multiKeySet 合并多个密钥来源。
+type multiKeySet []KeySet
+
+func (m multiKeySet) Keys(ctx context.Context, kid string) ([]Key, error) {
+	var (
+		out  []Key
+		errs []error
+	)
+	for _, ks := range m {
+		keys, err := ks.Keys(ctx, kid)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		out = append(out, keys...)
+	}
+	if len(out) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return out, nil
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func jwk(t *testing.T, kid string, pub any) map[string]any {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return map[string]any{"kty": "RSA", "kid": kid, "use": "sig", "alg": AlgRS256,
+			"n": b64(k.N.Bytes()), "e": b64(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		raw, err := k.Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return map[string]any{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(raw[1:33]), "y": b64(raw[33:])}
+	case ed25519.PublicKey:
+		return map[string]any{"kty": "OKP", "kid": kid, "crv": "Ed25519", "x": b64(k)}
+	}
+	t.Fatalf("unsupported key %T", pub)
+	return nil
+}
+
+func jwksDoc(t *testing.T, keys ...map[string]any) []byte {
+	t.Helper()
+	b, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestParseJWKS(t *testing.T) {
+	keys, err := ParseJWKS(jwksDoc(t,
+		jwk(t, "rsa", &testRSA.PublicKey),
+		jwk(t, "ec", &testEC.PublicKey),
+		jwk(t, "ed", testEd.Public()),
+		map[string]any{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		map[string]any{"kty": "EC", "kid": "p384", "crv": "P-384"},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 || keys[0].ID != "rsa" || keys[0].Algorithm != AlgRS256 {
+		t.Fatalf("keys = %+v", keys)
+	}
+	if _, err := ParseJWKS([]byte(`{"keys":[{"kty":"EC","crv":"P-256","x":"AA","y":"AA"}]}`)); err == nil {
+		t.Fatal("invalid EC point accepted")
+	}
+}
+
+func TestJWKSRotation(t *testing.T) {
+	newRSA, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var (
+		doc     atomic.Value
+		fetches atomic.Int32
+		failing atomic.Bool
+	)
+	doc.Store(jwksDoc(t, jwk(t, "k1", &testRSA.PublicKey)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(doc.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	v, err := NewVerifier(VerifierOptions{JWKSURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for range 3 {
+		if _, err := v.Verify(ctx, sign(t, AlgRS256, "k1", testRSA, validClaims())); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("fetches = %d, want 1 (cached)", n)
+	}
+
+	// 签发方发布新密钥 k2：未知 kid 触发重新拉取（距上次拉取超过最短间隔）。
+	jwks := v.keys.(*JWKS)
+	jwks.mu.Lock()
+	jwks.lastAttempt = time.Now().Add(-jwksMinRefetch)
+	jwks.mu.Unlock()
+	doc.Store(jwksDoc(t, jwk(t, "k1", &testRSA.PublicKey), jwk(t, "k2", &newRSA.PublicKey)))
+	if _, err := v.Verify(ctx, sign(t, AlgRS256, "k2", newRSA, validClaims())); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+	// 未知 kid 的重新拉取受最短间隔限制。
+	if _, err := v.Verify(ctx, sign(t, AlgRS256, "k3", newRSA, validClaims())); !errors.Is(err, ErrTokenUnverifiable) {
+		t.Fatalf("unknown kid err = %v", err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("fetches = %d, want 2", n)
+	}
+
+	// 刷新失败时继续使用缓存的密钥。
+	failing.Store(true)
+	jwks.mu.Lock()
+	jwks.loaded = time.Now().Add(-2 * DefaultJWKSRefresh)
+	jwks.mu.Unlock()
+	if _, err := v.Verify(ctx, sign(t, AlgRS256, "k2", newRSA, validClaims())); err != nil {
+		t.Fatalf("cached keys after failed refresh: %v", err)
+	}
+}
+
+// TestJWKSRefreshStorm：加载失败后在最短间隔内不再拉取；并发的首次加载
+// 只拉取一次，其余调用等待其结果。
+func TestJWKSRefreshStorm(t *testing.T) {
+	var (
+		fetches atomic.Int32
+		failing atomic.Bool
+	)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		<-release
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(jwksDoc(t, jwk(t, "k1", &testRSA.PublicKey)))
+	}))
+	defer srv.Close()
+	jwks, err := NewJWKS(JWKSOptions{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			if keys, err := jwks.Keys(ctx, "k1"); err != nil || len(keys) != 1 {
+				t.Errorf("Keys = %v, %v", keys, err)
+			}
+		})
+	}
+	for fetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("concurrent first load fetches = %d, want 1", n)
+	}
+
+	// 缓存过期且刷新失败：继续使用缓存，最短间隔内不再拉取。
+	failing.Store(true)
+	jwks.mu.Lock()
+	jwks.loaded = time.Now().Add(-2 * DefaultJWKSRefresh)
+	jwks.lastAttempt = time.Time{}
+	jwks.mu.Unlock()
+	for range 5 {
+		if keys, err := jwks.Keys(ctx, "k1"); err != nil || len(keys) != 1 {
+			t.Fatalf("cached Keys = %v, %v", keys, err)
+		}
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("failed refresh fetches = %d, want 2", n)
+	}
+}
+
+func TestJWKSFileAndInitialFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	v, err := NewVerifier(VerifierOptions{JWKSFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := sign(t, AlgEdDSA, "ed", testEd, validClaims())
+	if _, err := v.Verify(context.Background(), token); !errors.Is(err, ErrTokenUnverifiable) {
+		t.Fatalf("missing file err = %v", err)
+	}
+	if err := os.WriteFile(path, jwksDoc(t, jwk(t, "ed", testEd.Public())), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v.keys.(*JWKS).lastAttempt = time.Time{}
+	if _, err := v.Verify(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewJWKS(JWKSOptions{}); err == nil {
+		t.Fatal("NewJWKS without source succeeded")
+	}
+}
@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/lynx-go/lynx/logging"
+)
+
+// FieldPeerID 是 mTLS 对端身份的日志属性 key。
+const FieldPeerID = "peer_id"
+
+// Peer 是经 mTLS 校验的对端身份，取自客户端证书（已通过 CA 校验的链的
+// 叶子证书）。
+type Peer struct {
+	// SPIFFEID 是 URI SAN 中的 SPIFFE ID（"spiffe://trust-domain/path"）。
+	SPIFFEID string
+	// DNSNames 是 DNS SAN。
+	DNSNames []string
+	// CommonName 是证书主题的 CN。
+	CommonName string
+	// Certificate 是客户端证书。
+	Certificate *x509.Certificate
+}
+
+// ID 返回对端的主标识：依次取 SPIFFE ID、第一个 DNS SAN 与 CN。
+func (p Peer) ID() string {
+	switch {
+	case p.SPIFFEID != "":
+		return p.SPIFFEID
+	case len(p.DNSNames) > 0:
+		return p.DNSNames[0]
+	}
+	return p.CommonName
+}
+
+// names 返回用于匹配 PeerRule.Allow 的全部标识。CN 不受 CA 约束，
+// 只在证书没有任何 SAN 时作为兼容旧证书的兜底。
+func (p Peer) names() []string {
+	names := make([]string, 0, len(p.DNSNames)+2)
+	if p.SPIFFEID != "" {
+		names = append(names, p.SPIFFEID)
+	}
+	names = append(names, p.DNSNames...)
+	if p.CommonName != "" && !p.hasSANs() {
+		names = append(names, p.CommonName)
+	}
+	return names
+}
+
+// hasSANs 报告对端证书是否带有 SAN（证书缺失时按 SPIFFEID 与 DNSNames
+// 判断）。
+func (p Peer) hasSANs() bool {
+	if c := p.Certificate; c != nil {
+		return len(c.DNSNames)+len(c.URIs)+len(c.IPAddresses)+len(c.EmailAddresses) > 0
+	}
+	return p.SPIFFEID != "" || len(p.DNSNames) > 0
+}
+
+// PeerFromCertificate 从证书提取对端身份。
+func PeerFromCertificate(cert *x509.Certificate) Peer {
+	p := Peer{DNSNames: cert.DNSNames, CommonName: cert.Subject.CommonName, Certificate: cert}
+	for _, u := range cert.URIs {
+		if u.Scheme == "spiffe" {
+			p.SPIFFEID = u.String()
+			break
+		}
+	}
+	return p
+}
+
+// PeerFromTLS 从 TLS 连接状态提取对端身份：只取已通过校验的证书链
+// （VerifiedChains），未出示证书或未校验（tls.RequestClientCert 等）时
+// 返回 false。
+func PeerFromTLS(cs *tls.ConnectionState) (Peer, bool) {
+	if cs == nil || len(cs.VerifiedChains) == 0 || len(cs.VerifiedChains[0]) == 0 {
+		return Peer{}, false
+	}
+	return PeerFromCertificate(cs.VerifiedChains[0][0]), true
+}
+
+type peerCtx struct{}
+
+// WithPeer 返回携带对端身份的 Context，并写入日志属性 peer_id。
+// server/http 的 PeerAuth 与 gRPC 的 PeerAuth 拦截器使用它。
+func WithPeer(ctx context.Context, p Peer) context.Context {
+	ctx = context.WithValue(ctx, peerCtx{}, p)
+	return logging.WithAttrs(ctx, slog.String(FieldPeerID, p.ID()))
+}
+
+// PeerFrom 返回请求的 mTLS 对端身份；连接未出示经校验的客户端证书时
+// 返回 false。
+func PeerFrom(ctx context.Context) (Peer, bool) {
+	p, ok := ctx.Value(peerCtx{}).(Peer)
+	return p, ok
+}
+
+var (
+	// ErrPeerRequired 表示匹配了规则的请求未出示经校验的客户端证书。
+	ErrPeerRequired = errors.New("auth: client certificate required")
+	// ErrPeerNotAllowed 表示对端身份不在匹配规则的允许列表中。
+	ErrPeerNotAllowed = errors.New("auth: peer not allowed")
+)
+
+// PeerRule 是一条对端授权规则：请求目标匹配 Match 时，对端须匹配
+// Allow 之一。
+//
+// 模式中的 "*" 匹配任意字符序列（包括 "/"），其余字符精确匹配，如
+// Match "/payments.*" 匹配 gRPC 服务 payments 下的全部方法，Allow
+// "spiffe://prod/ns/billing/*" 匹配 billing 命名空间下的全部工作负载。
+// 以 "spiffe://" 开头的 Allow 模式只匹配 SPIFFE ID（URI SAN）；其余模式
+// 对 SPIFFE ID、每个 DNS SAN 逐一匹配，证书没有任何 SAN 时再匹配 CN。
+// Allow 为空时拒绝全部请求。
+type PeerRule struct {
+	// Match 是 HTTP 请求路径或 gRPC 完整方法名（"/pkg.Svc/Method"）模式。
+	Match string `mapstructure:"match"`
+	// Allow 是允许的对端身份模式。
+	Allow []string `mapstructure:"allow"`
+}
+
+// PeerPolicy 是声明式的对端授权策略，可作为配置段解码：
+//
+//	peer_auth:
+//	  rules:
+//	    - match: /payments.*
+//	      allow: ["spiffe://prod/ns/billing/*"]
+//	    - match: /admin/*
+//	      allow: ["ops.internal.example.com"]
+//
+// 规则按顺序匹配，第一条匹配请求目标的规则生效。
+type PeerPolicy struct {
+	Rules []PeerRule `mapstructure:"rules"`
+	// DenyUnmatched 为 true 时拒绝未匹配任何规则的请求；缺省放行（仍
+	// 提取对端身份）。
+	DenyUnmatched bool `mapstructure:"deny_unmatched"`
+}
+
+// Authorize 判断对端 peer（ok 为 false 表示未出示经校验的证书）能否
+// 访问 target：未出示证书时返回 ErrPeerRequired，身份不在允许列表中时
+// 返回 ErrPeerNotAllowed。
+func (p PeerPolicy) Authorize(target string, peer Peer, ok bool) error {
+	for _, r := range p.Rules {
+		if !matchGlob(r.Match, target) {
+			continue
+		}
+		if !ok {
+			return ErrPeerRequired
+		}
+		if !PeerAllowed(r.Allow, peer) {
+			return ErrPeerNotAllowed
+		}
+		return nil
+	}
+	if !p.DenyUnmatched {
+		return nil
+	}
+	if !ok {
+		return ErrPeerRequired
+	}
+	return ErrPeerNotAllowed
+}
+
+// Validate 检查规则：Match 不能为空。
+func (p PeerPolicy) Validate() error {
+	for i, r := range p.Rules {
+		if r.Match == "" {
+			return fmt.Errorf("auth: peer policy rule %d: match is required", i)
+		}
+	}
+	return nil
+}
+
+// PeerAllowed 报告 peer 是否匹配 patterns 之一（模式语法见 PeerRule）。
+func PeerAllowed(patterns []string, peer Peer) bool {
+	names := peer.names()
+	for _, pat := range patterns {
+		if strings.HasPrefix(pat, "spiffe://") {
+			if peer.SPIFFEID != "" && matchGlob(pat, peer.SPIFFEID) {
+				return true
+			}
+			continue
+		}
+		for _, name := range names {
+			if matchGlob(pat, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchGlob 报告 s 是否匹配 pattern："*" 匹配任意字符序列。
+func matchGlob(pattern, s string) bool {
+	// 按 "*" 切分：首段须为前缀、末段须为后缀，中间各段依次出现。
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
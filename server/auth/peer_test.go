@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/lynx-go/lynx/logging"
+)
+
+func testPeerCert(spiffe string, dns []string, cn string) *x509.Certificate {
+	c := &x509.Certificate{Subject: pkix.Name{CommonName: cn}, DNSNames: dns}
+	if spiffe != "" {
+		u, _ := url.Parse(spiffe)
+		c.URIs = []*url.URL{{Scheme: "https", Host: "example.com"}, u}
+	}
+	return c
+}
+
+func TestPeerFromCertificate(t *testing.T) {
+	tests := []struct {
+		cert   *x509.Certificate
+		spiffe string
+		id     string
+	}{
+		{testPeerCert("spiffe://prod/ns/billing/sa/api", []string{"billing.internal"}, "billing"), "spiffe://prod/ns/billing/sa/api", "spiffe://prod/ns/billing/sa/api"},
+		{testPeerCert("", []string{"orders.internal", "orders"}, "orders"), "", "orders.internal"},
+		{testPeerCert("", nil, "legacy-client"), "", "legacy-client"},
+	}
+	for _, tt := range tests {
+		p := PeerFromCertificate(tt.cert)
+		if p.SPIFFEID != tt.spiffe || p.ID() != tt.id || p.Certificate != tt.cert {
+			t.Errorf("PeerFromCertificate() = %+v, ID() = %q, want SPIFFEID %q, ID %q", p, p.ID(), tt.spiffe, tt.id)
+		}
+	}
+
+	if _, ok := PeerFromTLS(nil); ok {
+		t.Error("PeerFromTLS(nil) ok = true")
+	}
+	unverified := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{testPeerCert("", nil, "x")}}
+	if _, ok := PeerFromTLS(unverified); ok {
+		t.Error("PeerFromTLS() accepted an unverified certificate")
+	}
+	verified := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{testPeerCert("", nil, "x")}}}
+	if p, ok := PeerFromTLS(verified); !ok || p.ID() != "x" {
+		t.Errorf("PeerFromTLS() = %+v, %v", p, ok)
+	}
+}
+
+func TestWithPeer(t *testing.T) {
+	if _, ok := PeerFrom(context.Background()); ok {
+		t.Fatal("PeerFrom(empty) ok = true")
+	}
+	ctx := WithPeer(context.Background(), PeerFromCertificate(testPeerCert("spiffe://prod/ns/a/sa/b", nil, "")))
+	if p, ok := PeerFrom(ctx); !ok || p.SPIFFEID != "spiffe://prod/ns/a/sa/b" {
+		t.Fatalf("PeerFrom() = %+v, %v", p, ok)
+	}
+	var found bool
+	for _, a := range logging.AttrsFrom(ctx) {
+		found = found || (a.Key == FieldPeerID && a.Value.String() == "spiffe://prod/ns/a/sa/b")
+	}
+	if !found {
+		t.Errorf("log attrs = %v, want %s", logging.AttrsFrom(ctx), FieldPeerID)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"/payments.*", "/payments.Payments/Charge", true},
+		{"/payments.*", "/orders.Orders/Get", false},
+		{"spiffe://prod/ns/billing/*", "spiffe://prod/ns/billing/sa/api", true},
+		{"spiffe://prod/ns/billing/*", "spiffe://prod/ns/billing", false},
+		{"*.internal", "orders.internal", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "acb", false},
+		{"ab*ba", "aba", false},
+		{"*", "", true},
+		{"exact", "exact", true},
+		{"exact", "exact2", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestPeerPolicyAuthorize(t *testing.T) {
+	billing := PeerFromCertificate(testPeerCert("spiffe://prod/ns/billing/sa/api", nil, "billing"))
+	orders := PeerFromCertificate(testPeerCert("", []string{"orders.internal"}, "orders"))
+	// CN 不受 CA 约束：伪装成 SPIFFE ID 的 CN 不匹配 spiffe:// 模式，带 SAN
+	// 的证书不再匹配 CN，只有没有 SAN 的旧证书按 CN 匹配。
+	spoofed := PeerFromCertificate(testPeerCert("", nil, "spiffe://prod/ns/billing/sa/api"))
+	legacy := PeerFromCertificate(testPeerCert("", nil, "legacy-client"))
+	policy := PeerPolicy{Rules: []PeerRule{
+		{Match: "/payments.*", Allow: []string{"spiffe://prod/ns/billing/*"}},
+		{Match: "/admin/*", Allow: []string{"*.internal"}},
+		{Match: "/closed/*"},
+		{Match: "/legacy/*", Allow: []string{"legacy-client", "orders"}},
+	}}
+	tests := []struct {
+		target string
+		peer   Peer
+		ok     bool
+		want   error
+	}{
+		{"/payments.Payments/Charge", billing, true, nil},
+		{"/payments.Payments/Charge", orders, true, ErrPeerNotAllowed},
+		{"/payments.Payments/Charge", Peer{}, false, ErrPeerRequired},
+		{"/admin/users", orders, true, nil},
+		{"/closed/x", billing, true, ErrPeerNotAllowed},
+		{"/payments.Payments/Charge", spoofed, true, ErrPeerNotAllowed},
+		{"/legacy/x", legacy, true, nil},
+		{"/legacy/x", orders, true, ErrPeerNotAllowed},
+		{"/public", Peer{}, false, nil},
+	}
+	for _, tt := range tests {
+		if err := policy.Authorize(tt.target, tt.peer, tt.ok); !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("Authorize(%q, %q) = %v, want %v", tt.target, tt.peer.ID(), err, tt.want)
+		}
+	}
+
+	policy.DenyUnmatched = true
+	if err := policy.Authorize("/public", Peer{}, false); !errors.Is(err, ErrPeerRequired) {
+		t.Errorf("DenyUnmatched without peer = %v", err)
+	}
+	if err := policy.Authorize("/public", billing, true); !errors.Is(err, ErrPeerNotAllowed) {
+		t.Errorf("DenyUnmatched with peer = %v", err)
+	}
+	if err := (PeerPolicy{Rules: []PeerRule{{Allow: []string{"*"}}}}).Validate(); err == nil {
+		t.Error("Validate() accepted a rule without match")
+	}
+}
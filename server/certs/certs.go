@@ -0,0 +1,396 @@
+// Package certs 从文件加载服务端 TLS 证书并热加载：证书、私钥与可选的
+// 客户端 CA（mTLS）文件变化后原子替换，后续握手即使用新证书，轮换证书
+// （cert-manager、Vault 签发的短期证书）无需重启。证书到期时间经健康
+// 检查与指标暴露，临近到期时记录警告。server/http 与 server/grpc 的
+// WithTLSFiles 基于本包。
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// DefaultReloadInterval 是检查证书文件变化的缺省间隔。
+	DefaultReloadInterval = 10 * time.Second
+	// DefaultExpiryWarning 是缺省的临近到期警告阈值。
+	DefaultExpiryWarning = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrNotLoaded 表示证书尚未加载（服务器 Start 之前）。
+	ErrNotLoaded = errors.New("certs: certificate not loaded")
+	// ErrExpired 由 CheckHealth 在服务端证书或客户端 CA 已过期时返回。
+	ErrExpired = errors.New("certs: certificate expired")
+)
+
+// Options 是文件证书的配置项，可作为配置段（如 "server.http.tls"）解码：
+//
+//	tls:
+//	  cert_file: /etc/tls/tls.crt
+//	  key_file: /etc/tls/tls.key
+//	  client_ca_file: /etc/tls/ca.crt
+type Options struct {
+	// CertFile 是 PEM 证书链文件，叶子证书在前。
+	CertFile string `mapstructure:"cert_file"`
+	// KeyFile 是 PEM 私钥文件。
+	KeyFile string `mapstructure:"key_file"`
+	// ClientCAFile 非空时启用 mTLS：客户端证书须由其中的 CA 签发。
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	// OptionalClientCert 为 true 时客户端可以不出示证书（出示则须通过
+	// 校验）；缺省要求客户端证书。仅在 ClientCAFile 非空时生效。
+	OptionalClientCert bool `mapstructure:"optional_client_cert"`
+	// ReloadInterval 是检查文件变化的间隔，缺省 DefaultReloadInterval。
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+	// ExpiryWarning 是临近到期警告阈值，缺省 DefaultExpiryWarning。
+	ExpiryWarning time.Duration `mapstructure:"expiry_warning"`
+
+	// Name 标识证书所属的服务器，用于日志与指标属性（缺省由服务器
+	// 填写为 "http"、"grpc"）。
+	Name string `mapstructure:"-"`
+	// Logger 缺省为 slog.Default()。
+	Logger *slog.Logger `mapstructure:"-"`
+	// MeterProvider 缺省取 otel.GetMeterProvider()。
+	MeterProvider metric.MeterProvider `mapstructure:"-"`
+}
+
+// Reloader 持有当前证书并在文件变化后重新加载，并发安全。
+//
+// 文件检查是惰性的：握手、CheckHealth 与指标采集时若距上次检查超过
+// ReloadInterval 即比较文件的修改时间与大小，变化时重新解析。解析失败
+// （如证书与私钥尚未同时写完）时继续使用已加载的证书并记录警告，下一
+// 个间隔重试。Kubernetes Secret 卷以符号链接原子切换，同样能被检测到。
+type Reloader struct {
+	o Options
+
+	state     atomic.Pointer[state]
+	lastCheck atomic.Int64
+	// mu 串行化加载。
+	mu          sync.Mutex
+	metricsOnce sync.Once
+	metricsErr  error
+	reloads     metric.Int64Counter
+	attrs       attribute.Set
+}
+
+// state 是一次成功加载的结果。
+type state struct {
+	cert  *tls.Certificate
+	leaf  *x509.Certificate
+	pool  *x509.CertPool
+	files []fileStamp
+	// caFirst 与 caLast 是客户端 CA 中最早与最晚的到期时间：前者用于
+	// 临近到期警告与指标，后者用于健康检查（轮换期间新旧 CA 并存）。
+	caFirst, caLast time.Time
+
+	warnedCert atomic.Bool
+	warnedCA   atomic.Bool
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// New 创建 Reloader，证书在 Load 时加载。
+func New(o Options) *Reloader {
+	if o.ReloadInterval <= 0 {
+		o.ReloadInterval = DefaultReloadInterval
+	}
+	if o.ExpiryWarning <= 0 {
+		o.ExpiryWarning = DefaultExpiryWarning
+	}
+	if o.Logger == nil {
+		o.Logger = slog.Default()
+	}
+	if o.MeterProvider == nil {
+		o.MeterProvider = otel.GetMeterProvider()
+	}
+	return &Reloader{o: o, attrs: attribute.NewSet(attribute.String("server", o.Name))}
+}
+
+// Load 加载证书与客户端 CA，文件缺失或内容非法时返回错误；服务器在
+// Start 时调用。重复调用时强制重新加载。指标注册失败时每次调用都返回
+// 该错误。
+func (r *Reloader) Load() error {
+	r.metricsOnce.Do(func() { r.metricsErr = r.initMetrics() })
+	if r.metricsErr != nil {
+		return r.metricsErr
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastCheck.Store(time.Now().UnixNano())
+	st, err := r.load()
+	if err != nil {
+		return err
+	}
+	r.state.Store(st)
+	r.o.Logger.Info("TLS certificate loaded", r.logAttrs(st)...)
+	return nil
+}
+
+// TLSConfig 返回使用当前证书的 tls.Config：服务端证书经 GetCertificate
+// 取得；配置了 ClientCAFile 时经 GetConfigForClient 为每次握手返回带
+// 当前 CA 池的配置。base 非 nil 时作为模板（MinVersion、CipherSuites、
+// NextProtos 等），其 Certificates 被忽略；MinVersion 缺省 TLS 1.2。
+//
+// GetConfigForClient 返回的配置取代外层配置，外层之后追加的 NextProtos
+// （如 http.Server.ServeTLS 追加的 "h2"）不会生效，需要 ALPN 时应在
+// base 中设置。
+func (r *Reloader) TLSConfig(base *tls.Config) *tls.Config {
+	cfg := &tls.Config{}
+	if base != nil {
+		cfg = base.Clone()
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	cfg.Certificates = nil
+	cfg.GetCertificate = r.GetCertificate
+	if r.o.ClientCAFile == "" {
+		return cfg
+	}
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	if r.o.OptionalClientCert {
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	tmpl := cfg.Clone()
+	tmpl.GetCertificate = nil
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		st := r.current()
+		if st == nil {
+			return nil, ErrNotLoaded
+		}
+		c := tmpl.Clone()
+		c.Certificates = []tls.Certificate{*st.cert}
+		c.ClientCAs = st.pool
+		return c, nil
+	}
+	return cfg
+}
+
+// GetCertificate 返回当前服务端证书，可直接用作 tls.Config.GetCertificate。
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	st := r.current()
+	if st == nil {
+		return nil, ErrNotLoaded
+	}
+	return st.cert, nil
+}
+
+// NotAfter 返回当前服务端证书的到期时间，尚未加载时返回零值。
+func (r *Reloader) NotAfter() time.Time {
+	if st := r.current(); st != nil {
+		return st.leaf.NotAfter
+	}
+	return time.Time{}
+}
+
+// CheckHealth 实现 lynx.Checker：证书尚未加载、服务端证书已过期或客户端
+// CA 全部过期时返回错误。CA 轮换期间新旧 CA 并存，只要仍有未过期的 CA
+// 即视为健康。临近到期只记录警告，不影响健康状态——同批实例的证书往往
+// 同时到期，提前摘除全部实例只会放大故障。
+func (r *Reloader) CheckHealth() error {
+	st := r.current()
+	if st == nil {
+		return ErrNotLoaded
+	}
+	now := time.Now()
+	if now.After(st.leaf.NotAfter) {
+		return fmt.Errorf("%w: %s certificate %q expired at %s", ErrExpired, r.o.Name, st.leaf.Subject, st.leaf.NotAfter.Format(time.RFC3339))
+	}
+	if st.pool != nil && now.After(st.caLast) {
+		return fmt.Errorf("%w: %s client CA expired at %s", ErrExpired, r.o.Name, st.caLast.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// current 按间隔检查文件变化并返回当前状态。
+func (r *Reloader) current() *state {
+	now := time.Now().UnixNano()
+	if last := r.lastCheck.Load(); last != 0 && now-last >= int64(r.o.ReloadInterval) && r.lastCheck.CompareAndSwap(last, now) {
+		r.refresh()
+	}
+	st := r.state.Load()
+	if st != nil {
+		r.warnExpiry(st)
+	}
+	return st
+}
+
+// refresh 在文件变化时重新加载，失败时保留已加载的证书。
+func (r *Reloader) refresh() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.state.Load()
+	if old == nil {
+		return
+	}
+	stamps, err := r.stat()
+	if err == nil && equalStamps(stamps, old.files) {
+		return
+	}
+	st, err := r.load()
+	if err != nil {
+		r.reloads.Add(context.Background(), 1, metric.WithAttributeSet(r.attrs), metric.WithAttributes(attribute.String("result", "failure")))
+		r.o.Logger.Warn("TLS certificate reload failed, using previous certificate", "server", r.o.Name, "error", err)
+		return
+	}
+	r.state.Store(st)
+	r.reloads.Add(context.Background(), 1, metric.WithAttributeSet(r.attrs), metric.WithAttributes(attribute.String("result", "success")))
+	r.o.Logger.Info("TLS certificate reloaded", r.logAttrs(st)...)
+}
+
+// load 读取并解析全部文件。文件状态在读取之前记录：读取期间发生的
+// 变化会在下一次检查时再次加载。
+func (r *Reloader) load() (*state, error) {
+	stamps, err := r.stat()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(r.o.CertFile, r.o.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("certs: load key pair: %w", err)
+	}
+	st := &state{cert: &cert, leaf: cert.Leaf, files: stamps}
+	if st.leaf == nil {
+		if st.leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, fmt.Errorf("certs: parse certificate: %w", err)
+		}
+	}
+	if r.o.ClientCAFile != "" {
+		if st.pool, st.caFirst, st.caLast, err = loadPool(r.o.ClientCAFile); err != nil {
+			return nil, err
+		}
+	}
+	return st, nil
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.o.CertFile, r.o.KeyFile}
+	if r.o.ClientCAFile != "" {
+		files = append(files, r.o.ClientCAFile)
+	}
+	return files
+}
+
+func (r *Reloader) stat() ([]fileStamp, error) {
+	files := r.files()
+	stamps := make([]fileStamp, len(files))
+	for i, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return nil, fmt.Errorf("certs: %w", err)
+		}
+		stamps[i] = fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+	}
+	return stamps, nil
+}
+
+func equalStamps(a, b []fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
+
+// loadPool 读取 PEM CA 文件，返回 CA 池与其中最早、最晚的到期时间。
+func loadPool(path string) (pool *x509.CertPool, first, last time.Time, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("certs: client CA: %w", err)
+	}
+	pool = x509.NewCertPool()
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, time.Time{}, time.Time{}, fmt.Errorf("certs: parse client CA %s: %w", path, err)
+		}
+		pool.AddCert(c)
+		if first.IsZero() || c.NotAfter.Before(first) {
+			first = c.NotAfter
+		}
+		if c.NotAfter.After(last) {
+			last = c.NotAfter
+		}
+	}
+	if first.IsZero() {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("certs: no certificates in client CA file %s", path)
+	}
+	return pool, first, last, nil
+}
+
+// warnExpiry 在证书进入警告阈值时记录一次警告（每个加载的证书一次）。
+func (r *Reloader) warnExpiry(st *state) {
+	now := time.Now()
+	if left := st.leaf.NotAfter.Sub(now); left < r.o.ExpiryWarning && st.warnedCert.CompareAndSwap(false, true) {
+		r.o.Logger.Warn("TLS certificate expires soon", append(r.logAttrs(st), "remaining", left.Round(time.Second).String())...)
+	}
+	if st.pool != nil {
+		if left := st.caFirst.Sub(now); left < r.o.ExpiryWarning && st.warnedCA.CompareAndSwap(false, true) {
+			r.o.Logger.Warn("TLS client CA expires soon", "server", r.o.Name, "file", r.o.ClientCAFile,
+				"not_after", st.caFirst.Format(time.RFC3339), "remaining", left.Round(time.Second).String())
+		}
+	}
+}
+
+func (r *Reloader) logAttrs(st *state) []any {
+	return []any{
+		"server", r.o.Name,
+		"subject", st.leaf.Subject.String(),
+		"not_after", st.leaf.NotAfter.Format(time.RFC3339),
+	}
+}
+
+func (r *Reloader) initMetrics() error {
+	meter := r.o.MeterProvider.Meter("github.com/lynx-go/lynx/server/certs")
+	var err error
+	if r.reloads, err = meter.Int64Counter("lynx.tls.certificate.reloads",
+		metric.WithDescription("TLS certificate reloads triggered by file changes, by result."),
+		metric.WithUnit("{reload}"),
+	); err != nil {
+		return err
+	}
+	expiry, err := meter.Float64ObservableGauge("lynx.tls.certificate.expiry",
+		metric.WithDescription("Seconds until the TLS certificate expires; negative once expired."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return err
+	}
+	serverAttrs := metric.WithAttributeSet(attribute.NewSet(append(r.attrs.ToSlice(), attribute.String("certificate", "server"))...))
+	caAttrs := metric.WithAttributeSet(attribute.NewSet(append(r.attrs.ToSlice(), attribute.String("certificate", "client_ca"))...))
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		st := r.current()
+		if st == nil {
+			return nil
+		}
+		o.ObserveFloat64(expiry, time.Until(st.leaf.NotAfter).Seconds(), serverAttrs)
+		if st.pool != nil {
+			o.ObserveFloat64(expiry, time.Until(st.caFirst).Seconds(), caAttrs)
+		}
+		return nil
+	}, expiry)
+	return err
+}
@@ -0,0 +1,405 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// testCA 是测试用的自签 CA，证书均在内存中生成，不提交证书文件。
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+var serial int64
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	return newCAUntil(t, name, time.Now().Add(365*24*time.Hour))
+}
+
+// newCAUntil 创建到期时间为 notAfter 的 CA。
+func newCAUntil(t *testing.T, name string, notAfter time.Time) *testCA {
+	t.Helper()
+	key := newKey(t)
+	serial++
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-2 * time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue 签发 cn 的证书，返回证书与私钥的 PEM。
+func (ca *testCA) issue(t *testing.T, cn string, notAfter time.Time, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key := newKey(t)
+	serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-2 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func (ca *testCA) clientCert(t *testing.T, cn string) tls.Certificate {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, cn, time.Now().Add(time.Hour), x509.ExtKeyUsageClientAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+var mtime = time.Now().Add(-time.Hour)
+
+// writeFile 写入文件并推进修改时间，保证文件变化可被检测。
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mtime = mtime.Add(time.Second)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type testFiles struct {
+	cert, key, ca string
+}
+
+func newFiles(t *testing.T, ca *testCA, cn string, notAfter time.Time) testFiles {
+	t.Helper()
+	dir := t.TempDir()
+	f := testFiles{cert: filepath.Join(dir, "tls.crt"), key: filepath.Join(dir, "tls.key"), ca: filepath.Join(dir, "ca.crt")}
+	f.rotate(t, ca, cn, notAfter)
+	return f
+}
+
+func (f testFiles) rotate(t *testing.T, ca *testCA, cn string, notAfter time.Time) {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, cn, notAfter, x509.ExtKeyUsageServerAuth)
+	writeFile(t, f.cert, certPEM)
+	writeFile(t, f.key, keyPEM)
+}
+
+// handshake 以 cfg 为服务端完成一次 TLS 握手，返回服务端证书的 CN 与
+// 双方的握手错误。
+func handshake(t *testing.T, cfg *tls.Config, ca *testCA, clientCert *tls.Certificate) (string, error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	serverErr := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer conn.Close()
+		serverErr <- tls.Server(conn, cfg).Handshake()
+	}()
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	ccfg := &tls.Config{RootCAs: pool, ServerName: "localhost"}
+	if clientCert != nil {
+		ccfg.Certificates = []tls.Certificate{*clientCert}
+	}
+	conn, err := tls.Dial("tcp", ln.Addr().String(), ccfg)
+	if err != nil {
+		return "", errors.Join(err, <-serverErr)
+	}
+	defer conn.Close()
+	if err := <-serverErr; err != nil {
+		return "", err
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestReloadOnFileChange(t *testing.T) {
+	ca := newCA(t, "ca")
+	f := newFiles(t, ca, "first", time.Now().Add(24*time.Hour))
+	var logs bytes.Buffer
+	r := New(Options{CertFile: f.cert, KeyFile: f.key, ReloadInterval: time.Nanosecond, Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	cfg := r.TLSConfig(nil)
+
+	if _, err := r.GetCertificate(nil); !errors.Is(err, ErrNotLoaded) {
+		t.Fatalf("GetCertificate before Load error = %v, want ErrNotLoaded", err)
+	}
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if cn, err := handshake(t, cfg, ca, nil); err != nil || cn != "first" {
+		t.Fatalf("handshake = %q, %v, want first", cn, err)
+	}
+
+	f.rotate(t, ca, "second", time.Now().Add(24*time.Hour))
+	if cn, err := handshake(t, cfg, ca, nil); err != nil || cn != "second" {
+		t.Fatalf("handshake after rotation = %q, %v, want second", cn, err)
+	}
+
+	// 半写入的文件解析失败：保留已加载的证书并记录警告。
+	writeFile(t, f.key, []byte("partial"))
+	if cn, err := handshake(t, cfg, ca, nil); err != nil || cn != "second" {
+		t.Fatalf("handshake after bad key = %q, %v, want second", cn, err)
+	}
+	if !strings.Contains(logs.String(), "reload failed") {
+		t.Errorf("logs = %q, want reload failure warning", logs.String())
+	}
+	if cfg.MinVersion != tls.VersionTLS12 {
+		t.Errorf("MinVersion = %x, want TLS 1.2", cfg.MinVersion)
+	}
+}
+
+func TestReloadInterval(t *testing.T) {
+	ca := newCA(t, "ca")
+	f := newFiles(t, ca, "first", time.Now().Add(24*time.Hour))
+	r := New(Options{CertFile: f.cert, KeyFile: f.key, ReloadInterval: time.Hour})
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	f.rotate(t, ca, "second", time.Now().Add(24*time.Hour))
+	if cn, _ := handshake(t, r.TLSConfig(nil), ca, nil); cn != "first" {
+		t.Errorf("handshake = %q within reload interval, want first", cn)
+	}
+}
+
+func TestClientCAReload(t *testing.T) {
+	ca1, ca2 := newCA(t, "ca1"), newCA(t, "ca2")
+	f := newFiles(t, ca1, "server", time.Now().Add(24*time.Hour))
+	writeFile(t, f.ca, ca1.pem)
+	r := New(Options{CertFile: f.cert, KeyFile: f.key, ClientCAFile: f.ca, ReloadInterval: time.Nanosecond})
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	cfg := r.TLSConfig(&tls.Config{NextProtos: []string{"h2"}})
+	c1, c2 := ca1.clientCert(t, "alice"), ca2.clientCert(t, "bob")
+
+	if _, err := handshake(t, cfg, ca1, &c1); err != nil {
+		t.Fatalf("handshake with ca1 client = %v", err)
+	}
+	if _, err := handshake(t, cfg, ca1, &c2); err == nil {
+		t.Fatal("handshake with ca2 client succeeded before CA rotation")
+	}
+	if _, err := handshake(t, cfg, ca1, nil); err == nil {
+		t.Fatal("handshake without client certificate succeeded")
+	}
+
+	writeFile(t, f.ca, append(append([]byte{}, ca1.pem...), ca2.pem...))
+	if _, err := handshake(t, cfg, ca1, &c2); err != nil {
+		t.Fatalf("handshake with ca2 client after CA rotation = %v", err)
+	}
+
+	opt := New(Options{CertFile: f.cert, KeyFile: f.key, ClientCAFile: f.ca, OptionalClientCert: true})
+	if err := opt.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := handshake(t, opt.TLSConfig(nil), ca1, nil); err != nil {
+		t.Errorf("optional client cert: handshake without certificate = %v", err)
+	}
+}
+
+func TestCheckHealthAndExpiryWarning(t *testing.T) {
+	ca := newCA(t, "ca")
+	f := newFiles(t, ca, "soon", time.Now().Add(time.Hour))
+	var logs bytes.Buffer
+	r := New(Options{CertFile: f.cert, KeyFile: f.key, ReloadInterval: time.Nanosecond, Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	if err := r.CheckHealth(); !errors.Is(err, ErrNotLoaded) {
+		t.Fatalf("CheckHealth before Load = %v, want ErrNotLoaded", err)
+	}
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CheckHealth(); err != nil {
+		t.Fatalf("CheckHealth near expiry = %v, want nil", err)
+	}
+	_ = r.CheckHealth()
+	if n := strings.Count(logs.String(), "expires soon"); n != 1 {
+		t.Errorf("expiry warnings = %d, want 1: %s", n, logs.String())
+	}
+
+	f.rotate(t, ca, "expired", time.Now().Add(-time.Hour))
+	if err := r.CheckHealth(); !errors.Is(err, ErrExpired) {
+		t.Errorf("CheckHealth with expired certificate = %v, want ErrExpired", err)
+	}
+	if got := r.NotAfter(); !got.Before(time.Now()) {
+		t.Errorf("NotAfter() = %v, want in the past", got)
+	}
+}
+
+// TestCheckHealthClientCARotation：CA 文件中仍有未过期的 CA 时健康，全部
+// 过期时返回 ErrExpired；临近到期警告取最早的到期时间。
+func TestCheckHealthClientCARotation(t *testing.T) {
+	oldCA, curCA := newCAUntil(t, "old", time.Now().Add(-time.Hour)), newCA(t, "new")
+	f := newFiles(t, curCA, "server", time.Now().Add(24*time.Hour))
+	writeFile(t, f.ca, append(append([]byte{}, oldCA.pem...), curCA.pem...))
+	var logs bytes.Buffer
+	r := New(Options{CertFile: f.cert, KeyFile: f.key, ClientCAFile: f.ca, ReloadInterval: time.Nanosecond, Logger: slog.New(slog.NewTextHandler(&logs, nil))})
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CheckHealth(); err != nil {
+		t.Fatalf("CheckHealth during CA rotation = %v, want nil", err)
+	}
+	if !strings.Contains(logs.String(), "TLS client CA expires soon") {
+		t.Errorf("no client CA expiry warning: %s", logs.String())
+	}
+
+	writeFile(t, f.ca, oldCA.pem)
+	if err := r.CheckHealth(); !errors.Is(err, ErrExpired) {
+		t.Errorf("CheckHealth with only expired CA = %v, want ErrExpired", err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	ca := newCA(t, "ca")
+	f := newFiles(t, ca, "server", time.Now().Add(time.Hour))
+	tests := map[string]Options{
+		"missing cert": {CertFile: f.cert + ".missing", KeyFile: f.key},
+		"bad key":      {CertFile: f.cert, KeyFile: f.cert},
+		"missing CA":   {CertFile: f.cert, KeyFile: f.key, ClientCAFile: f.ca},
+		"empty CA":     {CertFile: f.cert, KeyFile: f.key, ClientCAFile: f.key},
+	}
+	for name, o := range tests {
+		if err := New(o).Load(); err == nil {
+			t.Errorf("%s: Load() error = nil", name)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	ca := newCA(t, "ca")
+	f := newFiles(t, ca, "server", time.Now().Add(48*time.Hour))
+	writeFile(t, f.ca, ca.pem)
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	r := New(Options{CertFile: f.cert, KeyFile: f.key, ClientCAFile: f.ca, ReloadInterval: time.Nanosecond, Name: "http", MeterProvider: mp})
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	f.rotate(t, ca, "rotated", time.Now().Add(48*time.Hour))
+	if _, err := r.GetCertificate(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var md metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &md); err != nil {
+		t.Fatal(err)
+	}
+	expiry := map[string]float64{}
+	var reloads int64
+	for _, sm := range md.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[float64]:
+				for _, dp := range data.DataPoints {
+					kind, _ := dp.Attributes.Value(attribute.Key("certificate"))
+					if server, _ := dp.Attributes.Value(attribute.Key("server")); server.AsString() != "http" {
+						t.Errorf("server attribute = %q, want http", server.AsString())
+					}
+					expiry[kind.AsString()] = dp.Value
+				}
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					if result, _ := dp.Attributes.Value(attribute.Key("result")); result.AsString() == "success" {
+						reloads += dp.Value
+					}
+				}
+			}
+		}
+	}
+	if v := expiry["server"]; v < 47*3600 || v > 48*3600 {
+		t.Errorf("server expiry = %v, want about 48h", v)
+	}
+	if v := expiry["client_ca"]; v < 364*24*3600 {
+		t.Errorf("client_ca expiry = %v, want about 1 year", v)
+	}
+	if reloads != 1 {
+		t.Errorf("successful reloads = %d, want 1", reloads)
+	}
+}
+
+// failingMeterProvider 的 Meter 无法创建 counter。
+type failingMeterProvider struct{ noop.MeterProvider }
+
+func (failingMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter { return failingMeter{} }
+
+type failingMeter struct{ noop.Meter }
+
+func (failingMeter) Int64Counter(string, ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return nil, errors.New("counter unavailable")
+}
+
+// TestLoadMetricsError：指标注册失败时每次 Load 都返回错误，不会带着
+// nil 指标继续运行。
+func TestLoadMetricsError(t *testing.T) {
+	ca := newCA(t, "ca")
+	f := newFiles(t, ca, "server", time.Now().Add(time.Hour))
+	r := New(Options{CertFile: f.cert, KeyFile: f.key, MeterProvider: failingMeterProvider{}})
+	for i := range 2 {
+		if err := r.Load(); err == nil {
+			t.Fatalf("Load() #%d error = nil", i+1)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// httpRuleExtension 是 google.api.http 方法选项的字段号
+// （google/api/annotations.proto）。
+const httpRuleExtension protowire.Number = 72295728
+
+// google.api.HttpRule 的字段号。
+const (
+	ruleGet                protowire.Number = 2
+	rulePut                protowire.Number = 3
+	rulePost               protowire.Number = 4
+	ruleDelete             protowire.Number = 5
+	rulePatch              protowire.Number = 6
+	ruleBody               protowire.Number = 7
+	ruleCustom             protowire.Number = 8
+	ruleAdditionalBindings protowire.Number = 11
+	ruleResponseBody       protowire.Number = 12
+
+	customKind protowire.Number = 1
+	customPath protowire.Number = 2
+)
+
+// annotatedRules 返回方法上 google.api.http 注解声明的绑定（含
+// additional_bindings），没有注解时返回 nil。
+//
+// 注解直接从方法选项的 wire 编码中解析，不依赖 googleapis 的生成代码：
+// 未链接 annotations.pb.go 时扩展字段保留为未知字段，链接时照常编码，
+// 两种情况结果相同。
+func annotatedRules(md protoreflect.MethodDescriptor) ([]HTTPRule, error) {
+	opts := md.Options()
+	if opts == nil {
+		return nil, nil
+	}
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	var rules []HTTPRule
+	err = eachField(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		if num != httpRuleExtension || typ != protowire.BytesType {
+			return nil
+		}
+		rule, additional, err := parseHTTPRule(v)
+		if err != nil {
+			return err
+		}
+		rules = append(append(rules, rule), additional...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("google.api.http option of %s: %w", md.FullName(), err)
+	}
+	return rules, nil
+}
+
+// parseHTTPRule 解析一条 google.api.HttpRule 及其 additional_bindings
+// （嵌套的 additional_bindings 按规范忽略）。
+func parseHTTPRule(b []byte) (HTTPRule, []HTTPRule, error) {
+	var (
+		rule       HTTPRule
+		additional []HTTPRule
+	)
+	err := eachField(b, func(num protowire.Number, typ protowire.Type, v []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case ruleGet:
+			rule.Method, rule.Path = http.MethodGet, string(v)
+		case rulePut:
+			rule.Method, rule.Path = http.MethodPut, string(v)
+		case rulePost:
+			rule.Method, rule.Path = http.MethodPost, string(v)
+		case ruleDelete:
+			rule.Method, rule.Path = http.MethodDelete, string(v)
+		case rulePatch:
+			rule.Method, rule.Path = http.MethodPatch, string(v)
+		case ruleCustom:
+			return eachField(v, func(num protowire.Number, typ protowire.Type, v []byte) error {
+				switch {
+				case typ != protowire.BytesType:
+				case num == customKind:
+					rule.Method = string(v)
+				case num == customPath:
+					rule.Path = string(v)
+				}
+				return nil
+			})
+		case ruleBody:
+			rule.Body = string(v)
+		case ruleResponseBody:
+			rule.ResponseBody = string(v)
+		case ruleAdditionalBindings:
+			r, _, err := parseHTTPRule(v)
+			if err != nil {
+				return err
+			}
+			additional = append(additional, r)
+		}
+		return nil
+	})
+	if err == nil && rule.Path == "" {
+		err = fmt.Errorf("http rule without a path")
+	}
+	return rule, additional, err
+}
+
+// eachField 依次以字段号、wire 类型与值（BytesType 时为内容）调用 fn。
+func eachField(b []byte, fn func(protowire.Number, protowire.Type, []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		var v []byte
+		if typ == protowire.BytesType {
+			v, n = protowire.ConsumeBytes(b)
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		if err := fn(num, typ, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
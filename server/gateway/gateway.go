@@ -0,0 +1,418 @@
+// Package gateway 把注册在 grpc.Server 上的 gRPC 服务以 HTTP/JSON 路由
+// 暴露在 server/http 的 Router 上（gRPC-JSON 转码）。路由由方法的
+// google.api.http 注解或显式规则（WithRule）确定，语义同 grpc-gateway：
+// 路径变量、查询参数与请求体填充请求消息，响应消息以 protojson 编码。
+//
+// 调用在进程内完成：请求经 grpc.Server.ServeHTTP 进入 gRPC 处理链
+// （拦截器、otelgrpc 等照常生效），不经过网络，也不需要 gRPC 监听端口。
+// HTTP 请求的 Context 原样传入，request_id、user_id 等 logging 属性、
+// 截止时间与取消信号随之延续；HTTP 头部转为 gRPC metadata（如
+// authorization、x-request-id）。gRPC 状态经 HTTPStatusFromCode 映射为
+// HTTP 状态码，由服务器级 ErrorHandler（见 http.WithErrorHandler）写
+// 错误响应。
+//
+// 只支持一元方法；注解了 HTTP 绑定的流式方法被跳过。
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	lynxhttp "github.com/lynx-go/lynx/server/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// DefaultMaxBodyBytes 是请求体的缺省上限，与 gRPC 服务端缺省的最大接收
+// 消息大小一致。
+const DefaultMaxBodyBytes = 4 << 20
+
+// HTTPRule 是一条 HTTP 绑定，字段语义同 google.api.HttpRule。
+type HTTPRule struct {
+	// Method 是 HTTP 方法，如 "GET"；为空时匹配任意方法。
+	Method string
+	// Path 是路径模板，如 "/v1/orders/{id}" 或
+	// "/v1/{name=shelves/*/books/*}:archive"。
+	Path string
+	// Body 指定请求体映射到的字段："*" 为整个请求消息，字段路径为该
+	// 字段，为空时没有请求体（全部非路径字段来自查询参数）。
+	Body string
+	// ResponseBody 指定作为响应体的响应消息字段，为空时为整个响应消息。
+	ResponseBody string
+}
+
+// Options 是网关的配置项。
+type Options struct {
+	// Rules 按 gRPC 完整方法名（"/pkg.Service/Method"）显式指定 HTTP
+	// 绑定，优先于该方法的注解；引用未注册的方法时 Register panic。
+	Rules map[string][]HTTPRule
+	// IgnoreAnnotations 为 true 时不读取 google.api.http 注解，只注册
+	// Rules 中的方法。
+	IgnoreAnnotations bool
+	// MarshalOptions 是响应的 JSON 编码选项，缺省输出零值字段
+	// （EmitUnpopulated），字段名为 lowerCamelCase。
+	MarshalOptions protojson.MarshalOptions
+	// UnmarshalOptions 是请求体的 JSON 解码选项，缺省忽略未知字段。
+	UnmarshalOptions protojson.UnmarshalOptions
+	// MaxBodyBytes 是请求体上限，超出时响应 413，缺省
+	// DefaultMaxBodyBytes。
+	MaxBodyBytes int64
+}
+
+// Option 用于配置 Options 的选项函数。
+type Option func(*Options)
+
+// WithRule 为 gRPC 方法 fullMethod（"/pkg.Service/Method"）显式指定
+// HTTP 绑定，取代其注解。多次调用同一方法时绑定累加。
+func WithRule(fullMethod string, rules ...HTTPRule) Option {
+	return func(o *Options) {
+		if o.Rules == nil {
+			o.Rules = make(map[string][]HTTPRule)
+		}
+		o.Rules[fullMethod] = append(o.Rules[fullMethod], rules...)
+	}
+}
+
+// WithIgnoreAnnotations 设置是否忽略 google.api.http 注解。
+func WithIgnoreAnnotations(ignore bool) Option {
+	return func(o *Options) {
+		o.IgnoreAnnotations = ignore
+	}
+}
+
+// WithMarshalOptions 设置响应的 JSON 编码选项。
+func WithMarshalOptions(mo protojson.MarshalOptions) Option {
+	return func(o *Options) {
+		o.MarshalOptions = mo
+	}
+}
+
+// WithUnmarshalOptions 设置请求体的 JSON 解码选项。
+func WithUnmarshalOptions(uo protojson.UnmarshalOptions) Option {
+	return func(o *Options) {
+		o.UnmarshalOptions = uo
+	}
+}
+
+// WithMaxBodyBytes 设置请求体上限。
+func WithMaxBodyBytes(n int64) Option {
+	return func(o *Options) {
+		o.MaxBodyBytes = n
+	}
+}
+
+// Register 把 srv 上已注册服务的 HTTP 绑定注册为 r 的路由，应在全部
+// gRPC 服务注册之后、服务器启动之前调用：
+//
+//	grpcSrv := grpc.NewServer()
+//	pb.RegisterOrdersServer(grpcSrv.GetServer(), orders)
+//	router := http.NewRouter(http.WithRequestID())
+//	gateway.Register(router.Group("/api"), grpcSrv.GetServer())
+//	app.Register(http.NewServer(router), grpcSrv)
+//
+// 服务与消息的描述符取自 protoregistry.GlobalFiles（生成代码在 init 时
+// 注册）。规则非法、字段不存在或路由冲突时 panic，与 Router.Handle 一致。
+//
+// 网关调用与 gRPC 服务器的监听端口无关，但 grpc.Server.GracefulStop
+// 会立即中断经 ServeHTTP 进行中的调用：应用按注册顺序停止服务，HTTP
+// 服务器应先于 gRPC 服务器注册，使其先排空在途请求（同 server/mux）。
+func Register(r *lynxhttp.Router, srv *grpc.Server, opts ...Option) {
+	routes, err := newRoutes(srv, opts...)
+	if err != nil {
+		panic(err)
+	}
+	for _, rt := range routes {
+		r.Handle(rt.pattern, rt)
+	}
+}
+
+type gateway struct {
+	srv  *grpc.Server
+	opts Options
+}
+
+// binding 是一条已解析的绑定。
+type binding struct {
+	method   string
+	desc     protoreflect.MethodDescriptor
+	tmpl     *pathTemplate
+	vars     []fieldPath
+	body     string
+	bodyPath fieldPath
+	respPath fieldPath
+}
+
+// route 是一个 ServeMux 模式上的全部绑定：匹配相同请求的模板（如
+// "/v1/items/{id}" 与 "/v1/{name=items/*}:cancel"）共用一个模式，运行时
+// 按自定义动词分派。
+type route struct {
+	g       *gateway
+	pattern string
+	// wildcards 是 pattern 中的通配符名，各绑定的通配符按位置与之对应。
+	wildcards []string
+	bindings  []*binding
+}
+
+func newRoutes(srv *grpc.Server, opts ...Option) ([]*route, error) {
+	o := Options{
+		MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+		UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		MaxBodyBytes:     DefaultMaxBodyBytes,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	g := &gateway{srv: srv, opts: o}
+
+	explicit := make(map[string][]HTTPRule, len(o.Rules))
+	for m, rules := range o.Rules {
+		explicit[m] = rules
+	}
+	info := srv.GetServiceInfo()
+	services := make([]string, 0, len(info))
+	for name := range info {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+
+	var (
+		routes []*route
+		index  = make(map[string]*route)
+	)
+	for _, name := range services {
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			// 没有描述符的服务（手写 ServiceDesc）只能经显式规则暴露，
+			// 此时在下面报告为未知方法。
+			continue
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			md := methods.Get(i)
+			full := "/" + name + "/" + string(md.Name())
+			rules, isExplicit := explicit[full]
+			delete(explicit, full)
+			if !isExplicit && !o.IgnoreAnnotations {
+				if rules, err = annotatedRules(md); err != nil {
+					return nil, fmt.Errorf("gateway: %w", err)
+				}
+			}
+			if len(rules) == 0 {
+				continue
+			}
+			if md.IsStreamingClient() || md.IsStreamingServer() {
+				if isExplicit {
+					return nil, fmt.Errorf("gateway: %s is a streaming method", full)
+				}
+				continue
+			}
+			for _, rule := range rules {
+				b, err := newBinding(full, md, rule)
+				if err != nil {
+					return nil, fmt.Errorf("gateway: %s: %w", full, err)
+				}
+				method := rule.Method
+				if method == "*" {
+					method = ""
+				}
+				key := method + " " + b.tmpl.key
+				rt := index[key]
+				if rt == nil {
+					pattern := b.tmpl.pattern
+					if method != "" {
+						pattern = method + " " + pattern
+					}
+					rt = &route{g: g, pattern: pattern, wildcards: b.tmpl.wildcards}
+					index[key] = rt
+					routes = append(routes, rt)
+				}
+				rt.bindings = append(rt.bindings, b)
+			}
+		}
+	}
+	if len(explicit) > 0 {
+		missing := make([]string, 0, len(explicit))
+		for m := range explicit {
+			missing = append(missing, m)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("gateway: rules for unregistered methods %v", missing)
+	}
+	for _, rt := range routes {
+		// 带动词的绑定优先匹配，不带动词的作为兜底。
+		sort.SliceStable(rt.bindings, func(i, j int) bool {
+			return rt.bindings[i].tmpl.verb != "" && rt.bindings[j].tmpl.verb == ""
+		})
+		seen := make(map[string]*binding, len(rt.bindings))
+		for _, b := range rt.bindings {
+			if prev := seen[b.tmpl.verb]; prev != nil {
+				return nil, fmt.Errorf("gateway: %s and %s both bind %q", prev.method, b.method, rt.pattern)
+			}
+			seen[b.tmpl.verb] = b
+		}
+	}
+	return routes, nil
+}
+
+func newBinding(full string, md protoreflect.MethodDescriptor, rule HTTPRule) (*binding, error) {
+	tmpl, err := parseTemplate(rule.Path)
+	if err != nil {
+		return nil, err
+	}
+	b := &binding{method: full, desc: md, tmpl: tmpl, body: rule.Body}
+	for _, v := range tmpl.vars {
+		fp, err := resolveField(md.Input(), v.field)
+		if err != nil {
+			return nil, fmt.Errorf("path variable: %w", err)
+		}
+		if fp[len(fp)-1].IsList() || fp[len(fp)-1].IsMap() {
+			return nil, fmt.Errorf("path variable %q must be a singular field", v.field)
+		}
+		b.vars = append(b.vars, fp)
+	}
+	if rule.Body != "" && rule.Body != "*" {
+		if b.bodyPath, err = resolveField(md.Input(), rule.Body); err != nil {
+			return nil, fmt.Errorf("body: %w", err)
+		}
+	}
+	if rule.ResponseBody != "" {
+		if b.respPath, err = resolveField(md.Output(), rule.ResponseBody); err != nil {
+			return nil, fmt.Errorf("response_body: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// ServeHTTP 按动词选出绑定，构造请求消息，进程内调用 gRPC 方法并写出
+// JSON 响应；任何错误都经服务器级 ErrorHandler 响应。
+func (rt *route) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	wild := make([]string, len(rt.wildcards))
+	for i, name := range rt.wildcards {
+		wild[i] = r.PathValue(name)
+	}
+	var (
+		b    *binding
+		vals []string
+	)
+	for _, c := range rt.bindings {
+		if v, ok := c.tmpl.values(wild); ok {
+			b, vals = c, v
+			break
+		}
+	}
+	if b == nil {
+		rt.writeError(w, r, lynxhttp.NewAPIError(http.StatusNotFound, ""))
+		return
+	}
+	in, err := rt.g.decode(r, b, vals)
+	if err != nil {
+		rt.writeError(w, r, err)
+		return
+	}
+	payload, st := rt.g.invoke(r, b.method, in.Interface())
+	if st.Code() != codes.OK {
+		rt.writeError(w, r, statusError(st))
+		return
+	}
+	out := newMessage(b.desc.Output())
+	if err := proto.Unmarshal(payload, out.Interface()); err != nil {
+		rt.writeError(w, r, fmt.Errorf("gateway: unmarshal response: %w", err))
+		return
+	}
+	var body []byte
+	if b.respPath != nil {
+		body, err = marshalField(rt.g.opts.MarshalOptions, out, b.respPath)
+	} else {
+		body, err = rt.g.opts.MarshalOptions.Marshal(out.Interface())
+	}
+	if err != nil {
+		rt.writeError(w, r, fmt.Errorf("gateway: encode response: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+func (rt *route) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	lynxhttp.ErrorHandlerFrom(r.Context())(r.Context(), w, r, err)
+}
+
+// decode 依次以请求体、路径变量与查询参数填充请求消息，后者覆盖前者。
+// 请求体为 "*" 时不读取查询参数；未知的查询参数被忽略。
+func (g *gateway) decode(r *http.Request, b *binding, vals []string) (protoreflect.Message, error) {
+	in := newMessage(b.desc.Input())
+	if b.body != "" && r.Body != nil && r.Body != http.NoBody {
+		data, err := io.ReadAll(io.LimitReader(r.Body, g.opts.MaxBodyBytes+1))
+		if err != nil {
+			var mbe *http.MaxBytesError
+			if errors.As(err, &mbe) {
+				return nil, lynxhttp.NewAPIError(http.StatusRequestEntityTooLarge, "request body too large")
+			}
+			return nil, lynxhttp.NewAPIError(http.StatusBadRequest, "failed to read request body").WithCause(err)
+		}
+		if int64(len(data)) > g.opts.MaxBodyBytes {
+			return nil, lynxhttp.NewAPIError(http.StatusRequestEntityTooLarge, "request body too large")
+		}
+		if len(data) > 0 {
+			if b.bodyPath != nil {
+				data = wrapJSON(b.bodyPath, data)
+			}
+			if err := g.opts.UnmarshalOptions.Unmarshal(data, in.Interface()); err != nil {
+				return nil, lynxhttp.NewAPIError(http.StatusBadRequest, "invalid request body").WithCause(err)
+			}
+		}
+	}
+
+	var fields []lynxhttp.FieldError
+	for i, fp := range b.vars {
+		if err := setField(in, fp, []string{vals[i]}); err != nil {
+			fields = append(fields, lynxhttp.FieldError{Field: fp.String(), Message: err.Error(), Code: "invalid"})
+		}
+	}
+	if b.body != "*" {
+		query := r.URL.Query()
+		keys := make([]string, 0, len(query))
+		for k := range query {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fp, err := resolveField(b.desc.Input(), k)
+			if err != nil || b.bound(fp) {
+				continue
+			}
+			if err := setField(in, fp, query[k]); err != nil {
+				fields = append(fields, lynxhttp.FieldError{Field: k, Message: err.Error(), Code: "invalid"})
+			}
+		}
+	}
+	if len(fields) > 0 {
+		return nil, &lynxhttp.APIError{Status: http.StatusBadRequest, Message: "validation failed", Fields: fields}
+	}
+	return in, nil
+}
+
+// bound 报告字段 fp 是否已由路径变量或请求体绑定，查询参数不能覆盖。
+func (b *binding) bound(fp fieldPath) bool {
+	if b.bodyPath != nil && b.bodyPath.covers(fp) {
+		return true
+	}
+	for _, v := range b.vars {
+		if v.covers(fp) {
+			return true
+		}
+	}
+	return false
+}
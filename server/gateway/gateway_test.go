@@ -0,0 +1,430 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lynx-go/lynx/logging"
+	lynxhttp "github.com/lynx-go/lynx/server/http"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const testService = "lynx.gateway.test.Items"
+
+// httpOption 以 wire 编码构造 google.api.http 方法选项。
+func httpOption(method, path, body, responseBody string, additional ...[]byte) *descriptorpb.MethodOptions {
+	var rule []byte
+	num := map[string]protowire.Number{"GET": ruleGet, "POST": rulePost, "PATCH": rulePatch}[method]
+	rule = protowire.AppendTag(rule, num, protowire.BytesType)
+	rule = protowire.AppendString(rule, path)
+	if body != "" {
+		rule = protowire.AppendTag(rule, ruleBody, protowire.BytesType)
+		rule = protowire.AppendString(rule, body)
+	}
+	if responseBody != "" {
+		rule = protowire.AppendTag(rule, ruleResponseBody, protowire.BytesType)
+		rule = protowire.AppendString(rule, responseBody)
+	}
+	for _, a := range additional {
+		rule = protowire.AppendTag(rule, ruleAdditionalBindings, protowire.BytesType)
+		rule = protowire.AppendBytes(rule, a)
+	}
+	var raw []byte
+	raw = protowire.AppendTag(raw, httpRuleExtension, protowire.BytesType)
+	raw = protowire.AppendBytes(raw, rule)
+	opts := &descriptorpb.MethodOptions{}
+	opts.ProtoReflect().SetUnknown(raw)
+	return opts
+}
+
+func getRule(path string) []byte {
+	b := protowire.AppendTag(nil, ruleGet, protowire.BytesType)
+	return protowire.AppendString(b, path)
+}
+
+// testFile 注册测试用的服务描述符：
+//
+//	message Filter { string q = 1; }
+//	message GetItemRequest { string id = 1; int32 version = 2; repeated string tags = 3; Filter filter = 4; }
+//	message Item { string id = 1; string name = 2; int32 version = 3; repeated string tags = 4; }
+//	message CreateItemRequest { string parent = 1; Item item = 2; }
+//	service Items {
+//	  rpc GetItem(GetItemRequest) returns (Item)        // GET /v1/items/{id}，附加 GET /v1/{id=items/*}:fetch
+//	  rpc CreateItem(CreateItemRequest) returns (Item)  // POST /v1/{parent=shelves/*}/items，body "item"
+//	  rpc ListTags(GetItemRequest) returns (Item)       // GET /v1/items/{id}/tags，response_body "tags"
+//	  rpc Fail(GetItemRequest) returns (Item)           // POST /v1/fail/{id}，body "*"
+//	  rpc Unbound(GetItemRequest) returns (Item)        // 无注解
+//	}
+var testFile = func() protoreflect.FileDescriptor {
+	str := func(name string, num int32) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name: proto.String(name), Number: proto.Int32(num), JsonName: proto.String(name),
+			Type:  descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+	}
+	with := func(f *descriptorpb.FieldDescriptorProto, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		f.Type, f.Label = typ.Enum(), label.Enum()
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		optional = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		repeated = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		int32T   = descriptorpb.FieldDescriptorProto_TYPE_INT32
+		stringT  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		messageT = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	method := func(name, in string, opts *descriptorpb.MethodOptions) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name: proto.String(name), InputType: proto.String(".lynx.gateway.test." + in),
+			OutputType: proto.String(".lynx.gateway.test.Item"), Options: opts,
+		}
+	}
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("lynx/gateway/test/items.proto"),
+		Package: proto.String("lynx.gateway.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Filter"), Field: []*descriptorpb.FieldDescriptorProto{str("q", 1)}},
+			{Name: proto.String("GetItemRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				str("id", 1),
+				with(str("version", 2), int32T, optional, ""),
+				with(str("tags", 3), stringT, repeated, ""),
+				with(str("filter", 4), messageT, optional, ".lynx.gateway.test.Filter"),
+			}},
+			{Name: proto.String("Item"), Field: []*descriptorpb.FieldDescriptorProto{
+				str("id", 1),
+				str("name", 2),
+				with(str("version", 3), int32T, optional, ""),
+				with(str("tags", 4), stringT, repeated, ""),
+			}},
+			{Name: proto.String("CreateItemRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				str("parent", 1),
+				with(str("item", 2), messageT, optional, ".lynx.gateway.test.Item"),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Items"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("GetItem", "GetItemRequest", httpOption("GET", "/v1/items/{id}", "", "", getRule("/v1/{id=items/*}:fetch"))),
+				method("CreateItem", "CreateItemRequest", httpOption("POST", "/v1/{parent=shelves/*}/items", "item", "")),
+				method("ListTags", "GetItemRequest", httpOption("GET", "/v1/items/{id}/tags", "", "tags")),
+				method("Fail", "GetItemRequest", httpOption("POST", "/v1/fail/{id}", "*", "")),
+				method("Unbound", "GetItemRequest", nil),
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+		panic(err)
+	}
+	return fd
+}()
+
+// itemsImpl 以 dynamicpb 实现测试服务：GetItem/ListTags 回显请求，
+// CreateItem 返回 item 并把 parent 写入 name，Fail 按 id 返回错误。
+func itemsImpl(ctx context.Context, method string, in *dynamicpb.Message) (proto.Message, error) {
+	sd := testFile.Services().ByName("Items")
+	itemDesc := sd.Methods().ByName("GetItem").Output()
+	out := dynamicpb.NewMessage(itemDesc)
+	get := func(name string) protoreflect.Value {
+		return in.Get(in.Descriptor().Fields().ByName(protoreflect.Name(name)))
+	}
+	set := func(name string, v protoreflect.Value) {
+		out.Set(itemDesc.Fields().ByName(protoreflect.Name(name)), v)
+	}
+	switch method {
+	case "GetItem", "ListTags":
+		set("id", get("id"))
+		set("version", get("version"))
+		tags := out.Mutable(itemDesc.Fields().ByName("tags")).List()
+		for l, i := get("tags").List(), 0; i < l.Len(); i++ {
+			tags.Append(l.Get(i))
+		}
+		filter := get("filter").Message()
+		set("name", filter.Get(filter.Descriptor().Fields().ByName("q")))
+	case "CreateItem":
+		item := get("item").Message().Interface()
+		out = dynamicpb.NewMessage(itemDesc)
+		proto.Merge(out, item)
+		set("name", protoreflect.ValueOfString(get("parent").String()+"/"+out.Get(itemDesc.Fields().ByName("name")).String()))
+	case "Fail":
+		switch id := get("id").String(); id {
+		case "bad":
+			st, _ := status.New(codes.InvalidArgument, "bad item").WithDetails(&errdetails.BadRequest{
+				FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "id", Description: "must not be bad"}},
+			})
+			return nil, st.Err()
+		case "missing":
+			return nil, status.Error(codes.NotFound, "item missing not found")
+		default:
+			return nil, status.Error(codes.Internal, "database password leaked: hunter2")
+		}
+	}
+	return out, nil
+}
+
+func registerItems(s *grpc.Server) {
+	sd := testFile.Services().ByName("Items")
+	desc := grpc.ServiceDesc{ServiceName: testService, HandlerType: (*any)(nil), Metadata: testFile.Path()}
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+		name := string(md.Name())
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: name,
+			Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				in := dynamicpb.NewMessage(md.Input())
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				h := func(ctx context.Context, req any) (any, error) { return itemsImpl(ctx, name, req.(*dynamicpb.Message)) }
+				if interceptor == nil {
+					return h(ctx, in)
+				}
+				return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + testService + "/" + name}, h)
+			},
+		})
+	}
+	s.RegisterService(&desc, struct{}{})
+}
+
+// seen 记录 gRPC 处理链中观察到的请求属性。
+type seen struct {
+	requestID string
+	auth      []string
+}
+
+func newTestServer(t *testing.T, opts ...Option) (*httptest.Server, *seen) {
+	t.Helper()
+	got := &seen{}
+	capture := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		for _, a := range logging.AttrsFrom(ctx) {
+			if a.Key == logging.FieldRequestID {
+				got.requestID = a.Value.String()
+			}
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		got.auth = md.Get("authorization")
+		return handler(ctx, req)
+	}
+	gs := grpc.NewServer(grpc.UnaryInterceptor(capture))
+	registerItems(gs)
+	hs := health.NewServer()
+	hs.SetServingStatus("orders", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(gs, hs)
+
+	router := lynxhttp.NewRouter(lynxhttp.WithRequestID())
+	Register(router.Group("/api"), gs, opts...)
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+	return ts, got
+}
+
+func do(t *testing.T, method, url, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(lynxhttp.RequestIDHeader, "req-1")
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("Grpc-Timeout", "1n")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+func decodeJSON(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatalf("invalid JSON %q: %v", s, err)
+	}
+	return m
+}
+
+func TestAnnotatedGet(t *testing.T) {
+	ts, got := newTestServer(t)
+	code, body := do(t, http.MethodGet, ts.URL+"/api/v1/items/abc?version=3&tags=a&tags=b&filter.q=hello&unknown=1", "")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", code, body)
+	}
+	want := map[string]any{"id": "abc", "name": "hello", "version": float64(3), "tags": []any{"a", "b"}}
+	if m := decodeJSON(t, body); !reflect.DeepEqual(m, want) {
+		t.Errorf("body = %v, want %v", m, want)
+	}
+	if got.requestID != "req-1" {
+		t.Errorf("request_id in gRPC ctx = %q, want req-1", got.requestID)
+	}
+	if len(got.auth) != 1 || got.auth[0] != "Bearer token" {
+		t.Errorf("authorization metadata = %v", got.auth)
+	}
+}
+
+func TestAdditionalBindingWithVerb(t *testing.T) {
+	ts, _ := newTestServer(t)
+	code, body := do(t, http.MethodGet, ts.URL+"/api/v1/items/x1:fetch", "")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", code, body)
+	}
+	if id := decodeJSON(t, body)["id"]; id != "items/x1" {
+		t.Errorf("id = %v, want items/x1", id)
+	}
+}
+
+func TestBodyField(t *testing.T) {
+	ts, _ := newTestServer(t)
+	code, body := do(t, http.MethodPost, ts.URL+"/api/v1/shelves/s1/items", `{"name":"book","tags":["x"]}`)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", code, body)
+	}
+	m := decodeJSON(t, body)
+	if m["name"] != "shelves/s1/book" || !reflect.DeepEqual(m["tags"], []any{"x"}) {
+		t.Errorf("body = %v", m)
+	}
+
+	code, _ = do(t, http.MethodPost, ts.URL+"/api/v1/shelves/s1/items", `{"name":`)
+	if code != http.StatusBadRequest {
+		t.Errorf("invalid body status = %d, want 400", code)
+	}
+}
+
+func TestResponseBody(t *testing.T) {
+	ts, _ := newTestServer(t)
+	tags := func(url string) []string {
+		code, body := do(t, http.MethodGet, url, "")
+		var got []string
+		if err := json.Unmarshal([]byte(body), &got); code != http.StatusOK || err != nil {
+			t.Fatalf("got %d %s, want 200 with a JSON array", code, body)
+		}
+		return got
+	}
+	if got := tags(ts.URL + "/api/v1/items/a/tags?tags=x&tags=y"); !reflect.DeepEqual(got, []string{"x", "y"}) {
+		t.Errorf("tags = %v, want [x y]", got)
+	}
+	if got := tags(ts.URL + "/api/v1/items/a/tags"); got == nil || len(got) != 0 {
+		t.Errorf("empty tags = %#v, want []", got)
+	}
+}
+
+func TestInvalidQuery(t *testing.T) {
+	ts, _ := newTestServer(t)
+	code, body := do(t, http.MethodGet, ts.URL+"/api/v1/items/a?version=x", "")
+	if code != http.StatusBadRequest || !strings.Contains(body, "version") {
+		t.Errorf("got %d %s, want 400 mentioning version", code, body)
+	}
+}
+
+func TestStatusMapping(t *testing.T) {
+	ts, _ := newTestServer(t)
+
+	code, body := do(t, http.MethodPost, ts.URL+"/api/v1/fail/missing", `{}`)
+	if code != http.StatusNotFound || !strings.Contains(body, "item missing not found") {
+		t.Errorf("NotFound: got %d %s", code, body)
+	}
+
+	code, body = do(t, http.MethodPost, ts.URL+"/api/v1/fail/bad", `{}`)
+	if code != http.StatusBadRequest {
+		t.Errorf("InvalidArgument status = %d, want 400", code)
+	}
+	if !strings.Contains(body, "must not be bad") {
+		t.Errorf("field violations missing from %s", body)
+	}
+
+	code, body = do(t, http.MethodPost, ts.URL+"/api/v1/fail/other", `{}`)
+	if code != http.StatusInternalServerError {
+		t.Errorf("Internal status = %d, want 500", code)
+	}
+	if strings.Contains(body, "hunter2") {
+		t.Errorf("internal message leaked: %s", body)
+	}
+}
+
+func TestExplicitRule(t *testing.T) {
+	ts, _ := newTestServer(t, WithRule("/grpc.health.v1.Health/Check", HTTPRule{Method: http.MethodGet, Path: "/health/{service}"}))
+	code, body := do(t, http.MethodGet, ts.URL+"/api/health/orders", "")
+	if code != http.StatusOK || decodeJSON(t, body)["status"] != "SERVING" {
+		t.Errorf("got %d %s, want SERVING", code, body)
+	}
+	if code, _ = do(t, http.MethodGet, ts.URL+"/api/health/unknown", ""); code != http.StatusNotFound {
+		t.Errorf("unknown service status = %d, want 404", code)
+	}
+}
+
+func TestIgnoreAnnotations(t *testing.T) {
+	ts, _ := newTestServer(t, WithIgnoreAnnotations(true))
+	if code, _ := do(t, http.MethodGet, ts.URL+"/api/v1/items/abc", ""); code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 with annotations ignored", code)
+	}
+}
+
+func TestRegisterErrors(t *testing.T) {
+	gs := grpc.NewServer()
+	registerItems(gs)
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{"unknown method", WithRule("/"+testService+"/Nope", HTTPRule{Method: "GET", Path: "/x"})},
+		{"unknown field", WithRule("/"+testService+"/Unbound", HTTPRule{Method: "GET", Path: "/x/{nope}"})},
+		{"repeated path variable", WithRule("/"+testService+"/Unbound", HTTPRule{Method: "GET", Path: "/x/{tags}"})},
+		{"bad response body", WithRule("/"+testService+"/Unbound", HTTPRule{Method: "GET", Path: "/x", ResponseBody: "nope"})},
+		{"duplicate binding", WithRule("/"+testService+"/Unbound", HTTPRule{Method: "GET", Path: "/v1/items/{id}"})},
+	}
+	for _, tt := range tests {
+		if _, err := newRoutes(gs, tt.opt); err == nil {
+			t.Errorf("%s: newRoutes() error = nil", tt.name)
+		}
+	}
+}
+
+func TestHTTPStatusFromCode(t *testing.T) {
+	tests := map[codes.Code]int{
+		codes.OK:                http.StatusOK,
+		codes.InvalidArgument:   http.StatusBadRequest,
+		codes.Unauthenticated:   http.StatusUnauthorized,
+		codes.PermissionDenied:  http.StatusForbidden,
+		codes.NotFound:          http.StatusNotFound,
+		codes.AlreadyExists:     http.StatusConflict,
+		codes.ResourceExhausted: http.StatusTooManyRequests,
+		codes.Unimplemented:     http.StatusNotImplemented,
+		codes.Unavailable:       http.StatusServiceUnavailable,
+		codes.DeadlineExceeded:  http.StatusGatewayTimeout,
+		codes.DataLoss:          http.StatusInternalServerError,
+	}
+	for c, want := range tests {
+		if got := HTTPStatusFromCode(c); got != want {
+			t.Errorf("HTTPStatusFromCode(%v) = %d, want %d", c, got, want)
+		}
+	}
+}
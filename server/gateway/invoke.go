@@ -0,0 +1,199 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	lynxhttp "github.com/lynx-go/lynx/server/http"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// skipHeaders 是不作为 gRPC metadata 转发的 HTTP 头部：逐跳头部与由
+// 网关自行设置的头部。"Grpc-" 前缀与 "-Bin" 后缀的头部同样不转发，
+// 避免客户端伪造 gRPC 保留头部或二进制 metadata。
+var skipHeaders = map[string]bool{
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Proxy-Connection":  true,
+	"Te":                true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"Content-Type":      true,
+	"Content-Length":    true,
+	"Accept-Encoding":   true,
+}
+
+// invoke 在进程内调用 gRPC 方法：把 in 编码为 gRPC 帧，构造 HTTP/2
+// 请求交给 grpc.Server.ServeHTTP，再从记录的响应中解析状态与响应消息。
+// 请求 Context（logging 属性、认证信息、截止时间）原样传给 gRPC 处理链。
+func (g *gateway) invoke(r *http.Request, fullMethod string, in proto.Message) ([]byte, *status.Status) {
+	payload, err := proto.Marshal(in)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "marshal request: %v", err)
+	}
+	frame := make([]byte, 5+len(payload))
+	binary.BigEndian.PutUint32(frame[1:5], uint32(len(payload)))
+	copy(frame[5:], payload)
+
+	header := make(http.Header, len(r.Header)+1)
+	for k, vv := range r.Header {
+		if skipHeaders[k] || strings.HasPrefix(k, "Grpc-") || strings.HasSuffix(k, "-Bin") {
+			continue
+		}
+		header[k] = vv
+	}
+	header.Set("Content-Type", "application/grpc")
+	req := (&http.Request{
+		Method:        http.MethodPost,
+		URL:           &url.URL{Path: fullMethod},
+		RequestURI:    fullMethod,
+		Proto:         "HTTP/2.0",
+		ProtoMajor:    2,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(frame)),
+		ContentLength: int64(len(frame)),
+		Host:          r.Host,
+		RemoteAddr:    r.RemoteAddr,
+		TLS:           r.TLS,
+	}).WithContext(r.Context())
+
+	rec := &recorder{header: make(http.Header)}
+	g.srv.ServeHTTP(rec, req)
+	return rec.result()
+}
+
+// recorder 是记录 gRPC 响应的 ResponseWriter。handler transport 的写入
+// 都发生在 ServeHTTP 的调用 goroutine 中，无需加锁。
+type recorder struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (w *recorder) Header() http.Header         { return w.header }
+func (w *recorder) Write(p []byte) (int, error) { return w.body.Write(p) }
+func (w *recorder) Flush()                      {}
+
+func (w *recorder) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// result 解析 gRPC 状态与第一个响应帧。
+func (w *recorder) result() ([]byte, *status.Status) {
+	raw := w.header.Get("Grpc-Status")
+	if raw == "" {
+		// handler transport 在建立流之前以纯 HTTP 错误拒绝了请求。
+		return nil, status.Newf(codes.Internal, "grpc handler responded %d: %s", w.code, strings.TrimSpace(w.body.String()))
+	}
+	code, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, status.Newf(codes.Internal, "malformed grpc-status %q", raw)
+	}
+	if codes.Code(code) != codes.OK {
+		return nil, decodeStatus(codes.Code(code), w.header)
+	}
+	b := w.body.Bytes()
+	if len(b) < 5 {
+		return nil, status.New(codes.Internal, "missing response message")
+	}
+	if b[0]&1 != 0 {
+		return nil, status.New(codes.Internal, "compressed response message is not supported")
+	}
+	n := binary.BigEndian.Uint32(b[1:5])
+	if uint64(len(b)-5) < uint64(n) {
+		return nil, status.New(codes.Internal, "truncated response message")
+	}
+	return b[5 : 5+n], nil
+}
+
+// decodeStatus 从响应头部还原非 OK 状态，含 Grpc-Status-Details-Bin 中
+// 的详情。
+func decodeStatus(code codes.Code, h http.Header) *status.Status {
+	msg := h.Get("Grpc-Message")
+	// Grpc-Message 以百分号编码非 ASCII 字节；解码失败时保留原文。
+	if m, err := url.PathUnescape(msg); err == nil {
+		msg = m
+	}
+	if raw := h.Get("Grpc-Status-Details-Bin"); raw != "" {
+		b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(raw, "="))
+		if err == nil {
+			p := new(spb.Status)
+			if proto.Unmarshal(b, p) == nil && codes.Code(p.GetCode()) == code {
+				return status.FromProto(p)
+			}
+		}
+	}
+	return status.New(code, msg)
+}
+
+// HTTPStatusFromCode 返回 gRPC 状态码对应的 HTTP 状态码，映射同
+// google.rpc.Code 的文档与 grpc-gateway：
+//
+//	OK 200; InvalidArgument、FailedPrecondition、OutOfRange 400;
+//	Unauthenticated 401; PermissionDenied 403; NotFound 404;
+//	AlreadyExists、Aborted 409; ResourceExhausted 429; Canceled 499;
+//	Unimplemented 501; Unavailable 503; DeadlineExceeded 504;
+//	其余（Unknown、Internal、DataLoss）500。
+func HTTPStatusFromCode(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return 499
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// statusError 把非 OK 状态转换为 APIError：状态消息作为对外消息，
+// google.rpc.BadRequest 详情转为字段级错误，gRPC 状态码名写入扩展成员
+// "grpcCode"。Unknown、Internal 与 DataLoss 的消息可能含内部细节，
+// 只进入 Cause（日志），响应使用状态码文本。
+func statusError(st *status.Status) *lynxhttp.APIError {
+	msg := st.Message()
+	switch st.Code() {
+	case codes.Unknown, codes.Internal, codes.DataLoss:
+		msg = ""
+	}
+	e := lynxhttp.NewAPIError(HTTPStatusFromCode(st.Code()), msg).
+		WithCause(st.Err()).
+		WithExtension("grpcCode", st.Code().String())
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				e.Fields = append(e.Fields, lynxhttp.FieldError{Field: v.GetField(), Message: v.GetDescription(), Code: v.GetReason()})
+			}
+		}
+	}
+	return e
+}
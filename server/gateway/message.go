@@ -0,0 +1,202 @@
+package gateway
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// fieldPath 是从消息根到某字段的字段描述链。
+type fieldPath []protoreflect.FieldDescriptor
+
+// String 返回以 proto 字段名表示的路径，如 "book.id"。
+func (p fieldPath) String() string {
+	names := make([]string, len(p))
+	for i, fd := range p {
+		names[i] = string(fd.Name())
+	}
+	return strings.Join(names, ".")
+}
+
+// covers 报告 p 是否等于 q 或为 q 的前缀。
+func (p fieldPath) covers(q fieldPath) bool {
+	if len(p) > len(q) {
+		return false
+	}
+	for i := range p {
+		if p[i] != q[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// newMessage 创建 md 类型的空消息：优先使用已注册的生成类型，否则为
+// dynamicpb 消息。
+func newMessage(md protoreflect.MessageDescriptor) protoreflect.Message {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(md.FullName()); err == nil {
+		return mt.New()
+	}
+	return dynamicpb.NewMessage(md)
+}
+
+// resolveField 在 md 中按 path（"a.b.c"，各段可为 proto 字段名或 JSON
+// 名）查找字段。中间字段须为非 repeated 的消息字段。
+func resolveField(md protoreflect.MessageDescriptor, path string) (fieldPath, error) {
+	var fp fieldPath
+	for i, name := range strings.Split(path, ".") {
+		if i > 0 {
+			prev := fp[i-1]
+			if prev.Kind() != protoreflect.MessageKind || prev.IsList() || prev.IsMap() {
+				return nil, fmt.Errorf("field %q is not a singular message", prev.FullName())
+			}
+			md = prev.Message()
+		}
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return nil, fmt.Errorf("message %s has no field %q", md.FullName(), name)
+		}
+		fp = append(fp, fd)
+	}
+	return fp, nil
+}
+
+// setField 把字符串形式的 values 写入 msg 中 fp 指向的字段：repeated
+// 字段逐个追加，单值字段只接受一个值。
+func setField(msg protoreflect.Message, fp fieldPath, values []string) error {
+	for _, fd := range fp[:len(fp)-1] {
+		msg = msg.Mutable(fd).Message()
+	}
+	fd := fp[len(fp)-1]
+	switch {
+	case fd.IsMap():
+		return fmt.Errorf("map fields cannot be set from the URL")
+	case fd.IsList():
+		list := msg.Mutable(fd).List()
+		for _, s := range values {
+			v, err := parseValue(fd, s, list.NewElement)
+			if err != nil {
+				return err
+			}
+			list.Append(v)
+		}
+		return nil
+	case len(values) > 1:
+		return fmt.Errorf("multiple values for a singular field")
+	}
+	v, err := parseValue(fd, values[0], func() protoreflect.Value { return msg.NewField(fd) })
+	if err != nil {
+		return err
+	}
+	msg.Set(fd, v)
+	return nil
+}
+
+// parseValue 按字段类型解析 s。消息类型（Timestamp、Duration、FieldMask、
+// 包装类型等）按其 JSON 字符串形式解析，newMsg 提供空消息。
+func parseValue(fd protoreflect.FieldDescriptor, s string, newMsg func() protoreflect.Value) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown enum value %q", s)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	case protoreflect.BytesKind:
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+			if b, err := enc.DecodeString(s); err == nil {
+				return protoreflect.ValueOfBytes(b), nil
+			}
+		}
+		return protoreflect.Value{}, fmt.Errorf("invalid base64 value")
+	case protoreflect.MessageKind:
+		v := newMsg()
+		quoted, _ := json.Marshal(s)
+		if err := protojson.Unmarshal(quoted, v.Message().Interface()); err != nil {
+			return protoreflect.Value{}, err
+		}
+		return v, nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+}
+
+// wrapJSON 把 data 包装为以 fp 为路径的 JSON 对象，如 {"book":data}，
+// 用于把请求体解码到子字段。
+func wrapJSON(fp fieldPath, data []byte) []byte {
+	for i := len(fp) - 1; i >= 0; i-- {
+		key, _ := json.Marshal(string(fp[i].Name()))
+		data = append(append(append([]byte{'{'}, key...), ':'), append(data, '}')...)
+	}
+	return data
+}
+
+// marshalField 以 JSON 编码 msg 中 fp 指向的字段（response_body）。
+func marshalField(mo protojson.MarshalOptions, msg protoreflect.Message, fp fieldPath) ([]byte, error) {
+	for _, fd := range fp[:len(fp)-1] {
+		msg = msg.Get(fd).Message()
+	}
+	fd := fp[len(fp)-1]
+	if fd.Kind() == protoreflect.MessageKind && !fd.IsList() && !fd.IsMap() {
+		return mo.Marshal(msg.Get(fd).Message().Interface())
+	}
+	// 标量、repeated 与 map 字段没有独立的 JSON 编码：编码只含该字段的
+	// 消息后取出其成员。
+	tmp := msg.Type().New()
+	if msg.Has(fd) {
+		tmp.Set(fd, msg.Get(fd))
+	}
+	mo.EmitUnpopulated = true
+	b, err := mo.Marshal(tmp.Interface())
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, err
+	}
+	key := fd.JSONName()
+	if mo.UseProtoNames {
+		key = string(fd.Name())
+	}
+	if raw, ok := obj[key]; ok {
+		return raw, nil
+	}
+	// 未设置的 oneof 成员不输出。
+	return []byte("null"), nil
+}
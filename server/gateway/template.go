@@ -0,0 +1,241 @@
+package gateway
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pathTemplate 是编译后的 google.api.http 路径模板：
+//
+//	Template = "/" Segments [ ":" Verb ]
+//	Segment  = "*" | "**" | LITERAL | "{" FieldPath [ "=" Segments ] "}"
+//
+// 模板被转换为 ServeMux 模式：每个 "*" 为单段通配符，"**" 为剩余路径
+// 通配符（须位于末尾），变量的值由其子模板各段拼接而成。
+type pathTemplate struct {
+	// pattern 是 ServeMux 路径模式，如 "/v1/shelves/{_0}/books/{book}"。
+	pattern string
+	// key 是去掉通配符名的模式，如 "/v1/shelves/{}/books/{}"。key 相同
+	// 的模板匹配相同的请求，须共用一个 ServeMux 模式，通配符按位置对应。
+	key string
+	// wildcards 是模式中的通配符名，按出现顺序。
+	wildcards []string
+	// vars 是模板中的变量，按出现顺序。
+	vars []pathVar
+	// verb 是需在运行时从最后一个通配符值中剥离的自定义动词（不含
+	// ":"）；最后一段为字面量时动词直接并入字面量，verb 为空。
+	verb string
+}
+
+// pathVar 是模板变量：field 为请求消息的字段路径，parts 为组成其值的
+// 各段。
+type pathVar struct {
+	field string
+	parts []pathPart
+}
+
+// pathPart 是变量值的一段：字面量，或 wildcard 位置上的通配符值
+// （literal 为空时）。
+type pathPart struct {
+	literal  string
+	wildcard int
+}
+
+// parseTemplate 解析并编译路径模板。
+func parseTemplate(tmpl string) (*pathTemplate, error) {
+	if !strings.HasPrefix(tmpl, "/") {
+		return nil, fmt.Errorf("path template %q must start with /", tmpl)
+	}
+	path, verb, err := splitVerb(tmpl[1:])
+	if err != nil {
+		return nil, fmt.Errorf("path template %q: %w", tmpl, err)
+	}
+	t := &pathTemplate{}
+	var (
+		pattern, key strings.Builder
+		rest, wild   bool
+	)
+	literal := func(s string) {
+		pattern.WriteString("/" + s)
+		key.WriteString("/" + s)
+		wild = false
+	}
+	// wildcard 追加通配符并返回其位置；name 为空时按位置生成。
+	wildcard := func(name string, r bool) int {
+		i := len(t.wildcards)
+		if name == "" {
+			name = "_" + strconv.Itoa(i)
+		}
+		t.wildcards = append(t.wildcards, name)
+		if r {
+			pattern.WriteString("/{" + name + "...}")
+			key.WriteString("/{...}")
+		} else {
+			pattern.WriteString("/{" + name + "}")
+			key.WriteString("/{}")
+		}
+		rest, wild = r, true
+		return i
+	}
+	for _, raw := range splitSegments(path) {
+		if rest {
+			return nil, fmt.Errorf("path template %q: ** must be the last segment", tmpl)
+		}
+		switch {
+		case raw == "":
+			return nil, fmt.Errorf("path template %q: empty segment", tmpl)
+		case raw == "*" || raw == "**":
+			wildcard("", raw == "**")
+		case raw[0] == '{':
+			if raw[len(raw)-1] != '}' {
+				return nil, fmt.Errorf("path template %q: unterminated variable %q", tmpl, raw)
+			}
+			field, sub, hasSub := strings.Cut(raw[1:len(raw)-1], "=")
+			if !validFieldPath(field) {
+				return nil, fmt.Errorf("path template %q: invalid field path %q", tmpl, field)
+			}
+			if !hasSub {
+				sub = "*"
+			}
+			v := pathVar{field: field}
+			subs := strings.Split(sub, "/")
+			for _, s := range subs {
+				if rest {
+					return nil, fmt.Errorf("path template %q: ** must be the last segment", tmpl)
+				}
+				switch {
+				case s == "*" || s == "**":
+					// 单段变量以字段名（合法标识符时）命名通配符，使路由
+					// 模板可读，如 "/v1/orders/{id}"。
+					name := ""
+					if len(subs) == 1 && !strings.Contains(field, ".") {
+						name = field
+					}
+					v.parts = append(v.parts, pathPart{wildcard: wildcard(name, s == "**")})
+				case s == "" || strings.ContainsAny(s, "{}=*"):
+					return nil, fmt.Errorf("path template %q: invalid segment %q in variable", tmpl, s)
+				default:
+					literal(s)
+					v.parts = append(v.parts, pathPart{literal: s})
+				}
+			}
+			t.vars = append(t.vars, v)
+		case strings.ContainsAny(raw, "{}*"):
+			return nil, fmt.Errorf("path template %q: invalid segment %q", tmpl, raw)
+		default:
+			literal(raw)
+		}
+	}
+	if verb != "" && !wild {
+		// 末段为字面量时动词直接并入字面量，由 ServeMux 匹配。
+		pattern.WriteString(":" + verb)
+		key.WriteString(":" + verb)
+		verb = ""
+	}
+	t.pattern, t.key, t.verb = pattern.String(), key.String(), verb
+	return t, nil
+}
+
+// splitVerb 从 path 末尾（最后一个不在变量内的 "/" 之后、变量之外）
+// 切出 ":verb"。
+func splitVerb(path string) (string, string, error) {
+	depth, colon := 0, -1
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '/':
+			if depth == 0 {
+				colon = -1
+			}
+		case ':':
+			if depth == 0 {
+				colon = i
+			}
+		}
+		if depth < 0 || depth > 1 {
+			return "", "", fmt.Errorf("unbalanced braces")
+		}
+	}
+	if depth != 0 {
+		return "", "", fmt.Errorf("unbalanced braces")
+	}
+	if colon < 0 {
+		return path, "", nil
+	}
+	verb := path[colon+1:]
+	if verb == "" || strings.ContainsAny(verb, "{}*") {
+		return "", "", fmt.Errorf("invalid verb %q", verb)
+	}
+	return path[:colon], verb, nil
+}
+
+// splitSegments 按不在变量内的 "/" 切分路径。
+func splitSegments(path string) []string {
+	var segs []string
+	depth, start := 0, 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '/':
+			if depth == 0 {
+				segs = append(segs, path[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(segs, path[start:])
+}
+
+// validFieldPath 报告 p 是否为以 "." 分隔的标识符序列。
+func validFieldPath(p string) bool {
+	if p == "" {
+		return false
+	}
+	for _, name := range strings.Split(p, ".") {
+		if name == "" {
+			return false
+		}
+		for i, c := range name {
+			if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (i == 0 || c < '0' || c > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// values 由按位置排列的通配符值 wild 计算各变量的值；verb 非空而最后
+// 一个通配符值不以该动词结尾时 ok 为 false。
+func (t *pathTemplate) values(wild []string) (vals []string, ok bool) {
+	if t.verb != "" {
+		suffix := ":" + t.verb
+		last := len(wild) - 1
+		if last < 0 || len(wild[last]) <= len(suffix) || !strings.HasSuffix(wild[last], suffix) {
+			return nil, false
+		}
+		wild = append(wild[:last:last], strings.TrimSuffix(wild[last], suffix))
+	}
+	vals = make([]string, len(t.vars))
+	for i, v := range t.vars {
+		var b strings.Builder
+		for j, p := range v.parts {
+			if j > 0 {
+				b.WriteByte('/')
+			}
+			if p.literal != "" {
+				b.WriteString(p.literal)
+			} else {
+				b.WriteString(wild[p.wildcard])
+			}
+		}
+		vals[i] = b.String()
+	}
+	return vals, true
+}
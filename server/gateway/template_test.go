@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl, pattern, key, verb string
+	}{
+		{"/v1/orders/{id}", "/v1/orders/{id}", "/v1/orders/{}", ""},
+		{"/v1/{name=shelves/*/books/*}", "/v1/shelves/{_0}/books/{_1}", "/v1/shelves/{}/books/{}", ""},
+		{"/v1/{book.id}", "/v1/{_0}", "/v1/{}", ""},
+		{"/v1/files/{path=**}", "/v1/files/{path...}", "/v1/files/{...}", ""},
+		{"/v1/orders:batchGet", "/v1/orders:batchGet", "/v1/orders:batchGet", ""},
+		{"/v1/{name=operations/*}:cancel", "/v1/operations/{_0}", "/v1/operations/{}", "cancel"},
+		{"/v1/*/x", "/v1/{_0}/x", "/v1/{}/x", ""},
+	}
+	for _, tt := range tests {
+		pt, err := parseTemplate(tt.tmpl)
+		if err != nil {
+			t.Errorf("parseTemplate(%q) error = %v", tt.tmpl, err)
+			continue
+		}
+		if pt.pattern != tt.pattern || pt.key != tt.key || pt.verb != tt.verb {
+			t.Errorf("parseTemplate(%q) = %q, %q, %q, want %q, %q, %q", tt.tmpl, pt.pattern, pt.key, pt.verb, tt.pattern, tt.key, tt.verb)
+		}
+	}
+
+	for _, bad := range []string{"v1/x", "/v1//x", "/v1/{id", "/v1/{a.}", "/v1/**/x", "/v1/{id}:", "/v1/{a={b}}"} {
+		if _, err := parseTemplate(bad); err == nil {
+			t.Errorf("parseTemplate(%q) error = nil", bad)
+		}
+	}
+}
+
+func TestTemplateValues(t *testing.T) {
+	pt, err := parseTemplate("/v1/{name=shelves/*/books/*}:archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals, ok := pt.values([]string{"s1", "b2:archive"})
+	if !ok || !reflect.DeepEqual(vals, []string{"shelves/s1/books/b2"}) {
+		t.Errorf("values = %v, %v", vals, ok)
+	}
+	if _, ok := pt.values([]string{"s1", "b2"}); ok {
+		t.Error("values matched without the verb")
+	}
+}
@@ -0,0 +1,70 @@
+package grpc
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/server/certs"
+	"github.com/lynx-go/lynx/server/listener"
+)
+
+// ConfigKey 是 gRPC 服务的配置段。
+const ConfigKey = "server.grpc"
+
+// Config 是 "server.grpc" 配置段（OptionsFromConfig 使用）：
+//
+//	server:
+//	  grpc:
+//	    addr: ":9090"
+//	    timeout: 30s
+//	    reflection: false
+//	    tls:
+//	      cert_file: /etc/tls/tls.crt
+//	      key_file: /etc/tls/tls.key
+//	    proxy_protocol:
+//	      trusted_sources: ["10.0.0.0/8"]
+type Config struct {
+	// Addr 对应 WithAddr，空时不设置（NewServer 缺省 DefaultGRPCAddr）。
+	Addr string `mapstructure:"addr"`
+	// Timeout 对应 WithTimeout，0 时不设置。
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Reflection 对应 WithReflection，未配置时按运行环境取缺省值。
+	Reflection *bool `mapstructure:"reflection"`
+	// TLS 对应 WithTLSFiles。
+	TLS *certs.Options `mapstructure:"tls"`
+	// ProxyProtocol 对应 WithProxyProtocol。
+	ProxyProtocol *listener.ProxyProtocolOptions `mapstructure:"proxy_protocol"`
+}
+
+// OptionsFromConfig 读取 "server.grpc" 段，返回对应的 Options，追加在
+// NewServer 的其他选项之后。配置非法时返回错误而不 panic。
+func OptionsFromConfig(cfg lynx.Config) ([]Option, error) {
+	var c Config
+	if err := cfg.UnmarshalKey(ConfigKey, &c); err != nil {
+		return nil, err
+	}
+	var opts []Option
+	if c.Addr != "" {
+		opts = append(opts, WithAddr(c.Addr))
+	}
+	if c.Timeout > 0 {
+		opts = append(opts, WithTimeout(c.Timeout))
+	}
+	if c.Reflection != nil {
+		opts = append(opts, WithReflection(*c.Reflection))
+	}
+	if c.TLS != nil {
+		if c.TLS.CertFile == "" || c.TLS.KeyFile == "" {
+			return nil, errors.New("grpc: tls: cert_file and key_file are required")
+		}
+		opts = append(opts, WithTLSFiles(*c.TLS))
+	}
+	if c.ProxyProtocol != nil {
+		if _, err := listener.NewProxyProtocol(*c.ProxyProtocol); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithProxyProtocol(*c.ProxyProtocol))
+	}
+	return opts, nil
+}
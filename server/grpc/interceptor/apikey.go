@@ -0,0 +1,86 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/lynx-go/lynx/server/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyMetadataKey is the incoming metadata key carrying the API key.
+const APIKeyMetadataKey = "x-api-key"
+
+// healthMethods are exempt from authentication so that probes and load
+// balancers keep working.
+var healthMethods = []string{"/grpc.health.v1.Health/"}
+
+// APIKey returns a unary server interceptor that authenticates requests by
+// the APIKeyMetadataKey metadata value looked up in store. Unknown or missing
+// keys are rejected with codes.Unauthenticated and store failures with
+// codes.Unavailable. On success the context carries the identity (see
+// auth.WithAPIKey): auth.APIKeyFrom and auth.FromContext return it and the
+// api_key_id and user_id log attributes propagate downstream. Health checks
+// and methods matched by exempt (same syntax as Maintenance) pass through.
+// It panics if store is nil.
+func APIKey(store auth.APIKeyStore, exempt ...string) grpc.UnaryServerInterceptor {
+	if store == nil {
+		panic("interceptor: APIKey store is nil")
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticateAPIKey(ctx, store, info.FullMethod, exempt)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// APIKeyStream is the stream counterpart of APIKey.
+func APIKeyStream(store auth.APIKeyStore, exempt ...string) grpc.StreamServerInterceptor {
+	if store == nil {
+		panic("interceptor: APIKey store is nil")
+	}
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateAPIKey(ss.Context(), store, info.FullMethod, exempt)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticateAPIKey(ctx context.Context, store auth.APIKeyStore, method string, exempt []string) (context.Context, error) {
+	if methodAllowed(method, healthMethods) || methodAllowed(method, exempt) {
+		return ctx, nil
+	}
+	var key string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(APIKeyMetadataKey); len(v) > 0 {
+			key = v[0]
+		}
+	}
+	if key == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing API key")
+	}
+	k, ok, err := store.Lookup(ctx, key)
+	if err != nil {
+		slog.ErrorContext(ctx, "interceptor: look up API key failed", "method", method, "error", err)
+		return nil, status.Error(codes.Unavailable, "API key lookup failed")
+	}
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	return auth.WithAPIKey(ctx, k), nil
+}
+
+// contextStream overrides the context of a grpc.ServerStream.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,76 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lynx-go/lynx/server/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type storeFunc func(ctx context.Context, key string) (auth.APIKey, bool, error)
+
+func (f storeFunc) Lookup(ctx context.Context, key string) (auth.APIKey, bool, error) {
+	return f(ctx, key)
+}
+
+func TestAPIKey(t *testing.T) {
+	keys, err := auth.NewStaticAPIKeys([]auth.APIKeyEntry{{APIKey: auth.APIKey{ID: "ci", Subject: "svc-ci"}, Key: "k-ci"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unary := APIKey(keys, "/test.Public/")
+	call := func(method, key string) (string, error) {
+		ctx := context.Background()
+		if key != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(APIKeyMetadataKey, key))
+		}
+		resp, err := unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			k, _ := auth.APIKeyFrom(ctx)
+			return k.ID, nil
+		})
+		id, _ := resp.(string)
+		return id, err
+	}
+
+	if id, err := call("/test.Service/Get", "k-ci"); err != nil || id != "ci" {
+		t.Fatalf("valid key: id = %q, err = %v", id, err)
+	}
+	for _, key := range []string{"", "wrong"} {
+		if _, err := call("/test.Service/Get", key); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("key %q: err = %v", key, err)
+		}
+	}
+	for _, method := range []string{"/grpc.health.v1.Health/Check", "/test.Public/Ping"} {
+		if _, err := call(method, ""); err != nil {
+			t.Errorf("%s: err = %v", method, err)
+		}
+	}
+
+	stream := APIKeyStream(storeFunc(func(context.Context, string) (auth.APIKey, bool, error) {
+		return auth.APIKey{}, false, errors.New("store down")
+	}))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(APIKeyMetadataKey, "k"))
+	err = stream(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Watch"},
+		func(interface{}, grpc.ServerStream) error { return nil })
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("store error: err = %v", err)
+	}
+
+	stream = APIKeyStream(keys)
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs(APIKeyMetadataKey, "k-ci"))
+	err = stream(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Watch"},
+		func(_ interface{}, ss grpc.ServerStream) error {
+			if c, ok := auth.FromContext(ss.Context()); !ok || c.Subject != "svc-ci" {
+				t.Errorf("stream claims = %+v, %v", c, ok)
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
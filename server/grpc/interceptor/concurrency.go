@@ -0,0 +1,115 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lynx-go/lynx/server/limiter"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// PriorityMetadataKey is the incoming metadata key read by
+// PriorityFromMetadata; values are low, normal and critical.
+const PriorityMetadataKey = "x-priority"
+
+// ShedMessage is the status message returned for shed requests.
+const ShedMessage = "server overloaded"
+
+// criticalMethods are always admitted: health checks and reflection.
+var criticalMethods = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.v1.ServerReflection/",
+	"/grpc.reflection.v1alpha.ServerReflection/",
+}
+
+// PriorityFunc returns the priority class of an RPC.
+type PriorityFunc func(ctx context.Context, fullMethod string) limiter.Priority
+
+// DefaultPriority is the default PriorityFunc: health and reflection
+// methods are critical, everything else is normal.
+func DefaultPriority(_ context.Context, fullMethod string) limiter.Priority {
+	if methodAllowed(fullMethod, criticalMethods) {
+		return limiter.PriorityCritical
+	}
+	return limiter.PriorityNormal
+}
+
+// PriorityFromMetadata is an opt-in PriorityFunc for requests from trusted
+// hops: health and reflection methods are critical, otherwise the
+// PriorityMetadataKey metadata value is used (normal when absent or
+// unknown). Clients can set the metadata themselves and claim critical, so
+// only use it when the edge strips or rewrites the key.
+func PriorityFromMetadata(ctx context.Context, fullMethod string) limiter.Priority {
+	if methodAllowed(fullMethod, criticalMethods) {
+		return limiter.PriorityCritical
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(PriorityMetadataKey); len(v) > 0 {
+			p, _ := limiter.ParsePriority(v[0])
+			return p
+		}
+	}
+	return limiter.PriorityNormal
+}
+
+// ConcurrencyLimit returns a unary server interceptor that admits requests
+// through the adaptive limiter l and rejects the rest with
+// codes.Unavailable (ShedMessage). Completed RPCs feed their latency back
+// into l; DeadlineExceeded, Unavailable and ResourceExhausted results count
+// as drops, client cancellation is ignored. priority may be nil, in which
+// case DefaultPriority is used. It panics if l is nil.
+func ConcurrencyLimit(l *limiter.Limiter, priority PriorityFunc) grpc.UnaryServerInterceptor {
+	if l == nil {
+		panic("interceptor: ConcurrencyLimit limiter is nil")
+	}
+	if priority == nil {
+		priority = DefaultPriority
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		token, ok := l.Acquire(ctx, priority(ctx, info.FullMethod))
+		if !ok {
+			return nil, status.Error(codes.Unavailable, ShedMessage)
+		}
+		outcome := limiter.Ignore
+		defer func() { token.Release(outcome) }()
+		resp, err = handler(ctx, req)
+		outcome = rpcOutcome(ctx, err)
+		return resp, err
+	}
+}
+
+// ConcurrencyLimitStream is the stream counterpart of ConcurrencyLimit.
+// A stream holds its slot until it ends, but its duration is not a latency
+// signal, so streams never adjust the limit.
+func ConcurrencyLimitStream(l *limiter.Limiter, priority PriorityFunc) grpc.StreamServerInterceptor {
+	if l == nil {
+		panic("interceptor: ConcurrencyLimit limiter is nil")
+	}
+	if priority == nil {
+		priority = DefaultPriority
+	}
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		token, ok := l.Acquire(ss.Context(), priority(ss.Context(), info.FullMethod))
+		if !ok {
+			return status.Error(codes.Unavailable, ShedMessage)
+		}
+		defer token.Release(limiter.Ignore)
+		return handler(srv, ss)
+	}
+}
+
+func rpcOutcome(ctx context.Context, err error) limiter.Outcome {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return limiter.Ignore
+	}
+	switch status.Code(err) {
+	case codes.DeadlineExceeded, codes.Unavailable, codes.ResourceExhausted:
+		return limiter.Dropped
+	case codes.Canceled:
+		return limiter.Ignore
+	}
+	return limiter.Success
+}
@@ -0,0 +1,74 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lynx-go/lynx/server/limiter"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newLimiter(t *testing.T, opts ...limiter.Option) *limiter.Limiter {
+	t.Helper()
+	l, err := limiter.New(opts...)
+	if err != nil {
+		t.Fatalf("limiter.New() error = %v", err)
+	}
+	return l
+}
+
+func TestConcurrencyLimitShedsAndAdmitsCritical(t *testing.T) {
+	l := newLimiter(t, limiter.WithInitialLimit(1))
+	tok, _ := l.Acquire(context.Background(), limiter.PriorityNormal)
+	defer tok.Release(limiter.Ignore)
+	ic := ConcurrencyLimit(l, nil)
+	ok := func(ctx context.Context, req interface{}) (interface{}, error) { return "resp", nil }
+
+	_, err := ic(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, ok)
+	if status.Code(err) != codes.Unavailable || status.Convert(err).Message() != ShedMessage {
+		t.Errorf("shed error = %v, want Unavailable %q", err, ShedMessage)
+	}
+
+	if _, err := ic(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, ok); err != nil {
+		t.Errorf("health check error = %v, want admitted", err)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(PriorityMetadataKey, "critical"))
+	if _, err := ic(ctx, "req", &grpc.UnaryServerInfo{FullMethod: "/test.Service/Admin"}, ok); status.Code(err) != codes.Unavailable {
+		t.Errorf("client-declared critical error = %v, want Unavailable by default", err)
+	}
+	trusted := ConcurrencyLimit(l, PriorityFromMetadata)
+	if _, err := trusted(ctx, "req", &grpc.UnaryServerInfo{FullMethod: "/test.Service/Admin"}, ok); err != nil {
+		t.Errorf("critical metadata with PriorityFromMetadata error = %v, want admitted", err)
+	}
+
+	stream := ConcurrencyLimitStream(l, nil)
+	err = stream(nil, &fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/test.Service/Watch"},
+		func(interface{}, grpc.ServerStream) error { return nil })
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("stream shed error = %v, want Unavailable", err)
+	}
+}
+
+func TestConcurrencyLimitOutcome(t *testing.T) {
+	l := newLimiter(t, limiter.WithAlgorithm(&limiter.AIMD{Backoff: 0.5}), limiter.WithInitialLimit(8))
+	ic := ConcurrencyLimit(l, nil)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+	_, _ = ic(context.Background(), "req", info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.DeadlineExceeded, "slow")
+	})
+	if got := l.Limit(); got != 4 {
+		t.Errorf("Limit() after DeadlineExceeded = %d, want 4", got)
+	}
+	_, _ = ic(context.Background(), "req", info, func(context.Context, interface{}) (interface{}, error) {
+		return nil, status.Error(codes.InvalidArgument, "bad")
+	})
+	if got := l.Limit(); got != 4 {
+		t.Errorf("Limit() after InvalidArgument = %d, want unchanged 4", got)
+	}
+	if l.InFlight() != 0 {
+		t.Errorf("InFlight() = %d, want 0", l.InFlight())
+	}
+}
@@ -1,10 +1,12 @@
-// Package interceptor 提供 gRPC 服务端拦截器：日志与 panic 恢复
-// （一元与流式各一套）。
+// Package interceptor 提供 gRPC 服务端拦截器：日志、panic 恢复、维护
+// 模式拦截、自适应并发限制、API key 认证与 mTLS 对端授权（一元与流式
+// 各一套）。
 package interceptor
 
 import (
 	"context"
 	"log/slog"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
@@ -69,3 +71,35 @@ func RecoveryStream() grpc.StreamServerInterceptor {
 		return handler(srv, ss)
 	}
 }
+
+// Maintenance returns a unary server interceptor that rejects requests with
+// codes.Unavailable while enabled reports true, except for methods matched by
+// allowlist (a full method name such as "/pkg.Svc/Method", or a prefix ending
+// with "/" such as "/pkg.Svc/").
+func Maintenance(enabled func() bool, message string, allowlist ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if enabled() && !methodAllowed(info.FullMethod, allowlist) {
+			return nil, status.Error(codes.Unavailable, message)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// MaintenanceStream is the stream counterpart of Maintenance.
+func MaintenanceStream(enabled func() bool, message string, allowlist ...string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if enabled() && !methodAllowed(info.FullMethod, allowlist) {
+			return status.Error(codes.Unavailable, message)
+		}
+		return handler(srv, ss)
+	}
+}
+
+func methodAllowed(method string, allowlist []string) bool {
+	for _, p := range allowlist {
+		if method == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(method, p)) {
+			return true
+		}
+	}
+	return false
+}
@@ -127,3 +127,41 @@ func TestRecoveryStreamRecoversPanic(t *testing.T) {
 		t.Errorf("status code = %v, want %v", code, codes.Internal)
 	}
 }
+
+func TestMaintenanceRejectsUnlessAllowed(t *testing.T) {
+	enabled := true
+	interceptor := Maintenance(func() bool { return enabled }, "down", "/admin.Svc/", "/test.Service/Ping")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	tests := []struct {
+		method string
+		want   codes.Code
+	}{
+		{"/test.Service/Method", codes.Unavailable},
+		{"/test.Service/Ping", codes.OK},
+		{"/admin.Svc/Migrate", codes.OK},
+	}
+	for _, tt := range tests {
+		_, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+		if got := status.Code(err); got != tt.want {
+			t.Errorf("%s: code = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+
+	enabled = false
+	if _, err := interceptor(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, handler); err != nil {
+		t.Errorf("outside maintenance error = %v, want nil", err)
+	}
+}
+
+func TestMaintenanceStreamRejects(t *testing.T) {
+	interceptor := MaintenanceStream(func() bool { return true }, "down")
+	called := false
+	err := interceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}, func(srv interface{}, ss grpc.ServerStream) error {
+		called = true
+		return nil
+	})
+	if status.Code(err) != codes.Unavailable || called {
+		t.Errorf("err = %v, called = %v; want Unavailable without calling handler", err, called)
+	}
+}
@@ -0,0 +1,73 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	"github.com/lynx-go/lynx/server/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// peerContext returns a context whose connection presented a verified client
+// certificate with the given SPIFFE ID.
+func peerContext(spiffe string) context.Context {
+	u, _ := url.Parse(spiffe)
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "client"}, URIs: []*url.URL{u}}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{
+		State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+	}})
+}
+
+func TestPeerAuth(t *testing.T) {
+	policy := auth.PeerPolicy{Rules: []auth.PeerRule{
+		{Match: "/payments.*", Allow: []string{"spiffe://prod/ns/billing/*"}},
+	}}
+	unary := PeerAuth(policy)
+	call := func(ctx context.Context, method string) (string, error) {
+		resp, err := unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, _ interface{}) (interface{}, error) {
+			p, _ := auth.PeerFrom(ctx)
+			return p.ID(), nil
+		})
+		id, _ := resp.(string)
+		return id, err
+	}
+
+	billing := peerContext("spiffe://prod/ns/billing/sa/api")
+	if id, err := call(billing, "/payments.Payments/Charge"); err != nil || id != "spiffe://prod/ns/billing/sa/api" {
+		t.Fatalf("allowed peer = %q, %v", id, err)
+	}
+	if _, err := call(peerContext("spiffe://prod/ns/orders/sa/api"), "/payments.Payments/Charge"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("disallowed peer error = %v, want PermissionDenied", err)
+	}
+	if _, err := call(context.Background(), "/payments.Payments/Charge"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("no peer error = %v, want Unauthenticated", err)
+	}
+	if _, err := call(context.Background(), "/orders.Orders/Get"); err != nil {
+		t.Errorf("unmatched method error = %v", err)
+	}
+
+	policy.DenyUnmatched = true
+	if _, err := PeerAuth(policy)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"},
+		func(context.Context, interface{}) (interface{}, error) { return nil, nil }); err != nil {
+		t.Errorf("health check error = %v", err)
+	}
+
+	stream := PeerAuthStream(policy)
+	err := stream(nil, &contextStream{ctx: billing}, &grpc.StreamServerInfo{FullMethod: "/payments.Payments/Watch"}, func(_ interface{}, ss grpc.ServerStream) error {
+		if _, ok := auth.PeerFrom(ss.Context()); !ok {
+			t.Error("stream context has no peer")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("stream error = %v", err)
+	}
+}
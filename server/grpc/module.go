@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
+	"google.golang.org/grpc"
+)
+
+// Registrar 把 gRPC 服务注册到服务器，经 Module 的 []Registrar 分组汇集：
+//
+//	func NewRegistrars(orders *Orders) []grpc.Registrar {
+//		return []grpc.Registrar{func(s gogrpc.ServiceRegistrar) { pb.RegisterOrdersServer(s, orders) }}
+//	}
+type Registrar func(grpc.ServiceRegistrar)
+
+// newModuleServer 以 "server.grpc" 段与应用提供的选项创建服务器并注册
+// 全部服务。
+func newModuleServer(app lynx.App, regs []Registrar, extra []Option) (*Server, error) {
+	opts, err := OptionsFromConfig(app.Config())
+	if err != nil {
+		return nil, err
+	}
+	base := []Option{WithLogger(app.Logger("logger", "grpc")), WithHealthCheckers(app.HealthCheckers)}
+	s := NewServer(append(append(base, opts...), extra...)...)
+	for _, reg := range regs {
+		reg(s.GetServer())
+	}
+	return s, nil
+}
+
+// Module 是 gRPC 服务的 boot 模块：按 "server.grpc" 段（见
+// OptionsFromConfig）创建并注册 Server，汇集 []Registrar 分组注册 gRPC
+// 服务；应用可再提供 []grpc.Option 分组追加选项（如拦截器，后者优先）。
+// 可用 modules.grpc.enabled: false 关闭。
+var Module = boot.Module{
+	Name:      "grpc",
+	Providers: []any{newModuleServer},
+	Groups:    []any{[]Registrar(nil), []Option(nil)},
+	Defaults:  map[string]any{ConfigKey + ".addr": DefaultGRPCAddr},
+	ConfigKey: ConfigKey,
+	Config:    Config{},
+}
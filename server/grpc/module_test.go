@@ -0,0 +1,77 @@
+package grpc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
+	"github.com/lynx-go/lynx/boot/boottest"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+)
+
+func configFromYAML(t *testing.T, doc string) lynx.Config {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(doc)); err != nil {
+		t.Fatal(err)
+	}
+	return lynx.NewViperConfig(v)
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	opts, err := OptionsFromConfig(configFromYAML(t, `
+server:
+  grpc:
+    addr: "127.0.0.1:0"
+    timeout: 5s
+    reflection: false
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(opts...)
+	if s.o.Addr != "127.0.0.1:0" || s.o.Timeout != 5*time.Second || s.o.Reflection || !s.o.reflectionSet {
+		t.Errorf("options = addr %q timeout %v reflection %v (set %v)", s.o.Addr, s.o.Timeout, s.o.Reflection, s.o.reflectionSet)
+	}
+
+	for _, doc := range []string{
+		"server:\n  grpc:\n    tls:\n      cert_file: a.crt\n",
+		"server:\n  grpc:\n    proxy_protocol:\n      trusted_sources: [bogus]\n",
+	} {
+		if _, err := OptionsFromConfig(configFromYAML(t, doc)); err == nil {
+			t.Errorf("OptionsFromConfig(%q) error = nil", doc)
+		}
+	}
+}
+
+func TestModule(t *testing.T) {
+	c := boot.NewContainer()
+	if err := c.Install(Module); err != nil {
+		t.Fatal(err)
+	}
+	var registered grpc.ServiceRegistrar
+	err := c.Provide(func() []Registrar {
+		return []Registrar{func(s grpc.ServiceRegistrar) { registered = s }}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := boottest.NewApp(configFromYAML(t, "name: demo\n"))
+	if err := c.Bind(app); err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	srv, ok := app.Services[0].(*Server)
+	if !ok {
+		t.Fatalf("registered %T, want *Server", app.Services[0])
+	}
+	if srv.o.Addr != DefaultGRPCAddr {
+		t.Errorf("Addr = %q, want default %q", srv.o.Addr, DefaultGRPCAddr)
+	}
+	if registered != srv.GetServer() {
+		t.Error("Registrar not called with the server's *grpc.Server")
+	}
+}
@@ -8,12 +8,16 @@ import (
 	"fmt"
 	"log/slog"
 	"net"
+	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/server/certs"
 	"github.com/lynx-go/lynx/server/grpc/interceptor"
+	"github.com/lynx-go/lynx/server/listener"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
@@ -29,11 +33,41 @@ const (
 	DefaultGRPCAddr          = ":9090"
 	DefaultTimeout           = 60 * time.Second
 	DefaultHealthCheckPeriod = 10 * time.Second
+	// DefaultMaintenanceMessage 是维护期间 UNAVAILABLE 状态的消息。
+	DefaultMaintenanceMessage = "service under maintenance"
 )
 
+// maintenanceAlwaysAllowed 是维护期间始终放行的方法前缀：健康检查与反射。
+var maintenanceAlwaysAllowed = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.v1.ServerReflection/",
+	"/grpc.reflection.v1alpha.ServerReflection/",
+}
+
+// MaintenanceOptions 配置维护模式（lynx.Maintenance）下的请求拦截：维护
+// 期间非白名单方法返回 codes.Unavailable。健康检查与反射服务始终放行。
+type MaintenanceOptions struct {
+	// Allowlist 是维护期间仍放行的方法：完整方法名（"/pkg.Svc/Method"）
+	// 精确匹配，以 "/" 结尾（"/pkg.Svc/"）时按前缀匹配。
+	Allowlist []string
+	// Message 是 UNAVAILABLE 状态的消息；空时使用 DefaultMaintenanceMessage。
+	Message string
+	// State 是维护状态；nil 时由 Init 取应用 Context 携带的状态。
+	State *lynx.Maintenance
+}
+
 // Options 是 gRPC 服务服务的配置项。
 type Options struct {
-	Addr               string
+	// Addr 是监听地址："host:port"，或 "unix:/path/to.sock" 监听 Unix
+	// domain socket（见 listener.Listen）。
+	Addr string
+	// ListenAddrs 是 Addr 之外的监听地址（见 WithListenAddrs）。
+	ListenAddrs []string
+	// Listeners 是调用方提供的监听器（见 WithListener）。
+	Listeners []net.Listener
+	// UnixSocketMode 是 Unix domain socket 文件的权限，0 时为
+	// listener.DefaultUnixSocketMode。
+	UnixSocketMode     os.FileMode
 	Timeout            time.Duration
 	Logger             *slog.Logger
 	Interceptors       []grpc.UnaryServerInterceptor
@@ -48,15 +82,58 @@ type Options struct {
 	// TLSConfig 非 nil 时启用 TLS 传输（credentials.NewTLS），与 HTTP 侧
 	// WithTLSConfig 语义对齐。
 	TLSConfig *tls.Config
+	// TLSFiles 非 nil 时以文件中的证书启用 TLS 并热加载（见 WithTLSFiles）。
+	TLSFiles *certs.Options
+	// ProxyProtocol 非 nil 时在全部监听器上解析 PROXY protocol 头（见
+	// WithProxyProtocol）。
+	ProxyProtocol *listener.ProxyProtocolOptions
+	// Reflection 控制是否注册 gRPC 反射服务；未经 WithReflection 显式设置时
+	// 按运行环境取缺省值：prod 关闭，其余开启。
+	Reflection bool
+	// reflectionSet 标记 Reflection 是否由 WithReflection 显式设置。
+	reflectionSet bool
+	// Maintenance 配置维护模式下的请求拦截（见 WithMaintenance）。
+	Maintenance MaintenanceOptions
+	// addrSet 标记 Addr 是否由 WithAddr 显式设置：使用 WithListener 且
+	// 未显式设置时不监听缺省地址。
+	addrSet bool
 }
 
 // Option 用于配置 gRPC 服务 Options 的选项函数。
 type Option func(*Options)
 
-// WithAddr 设置 gRPC 服务监听地址。
+// WithAddr 设置 gRPC 服务监听地址："host:port"，或 "unix:/path/to.sock"
+// 监听 Unix domain socket。为空时不监听该地址；没有任何监听地址与监听器
+// 时 gRPC 只经 HTTP 服务器提供（见 server/mux），Start 阻塞至 Stop。
 func WithAddr(addr string) Option {
 	return func(o *Options) {
 		o.Addr = addr
+		o.addrSet = true
+	}
+}
+
+// WithListenAddrs 追加 Addr 之外的监听地址，同一服务同时监听多个地址，
+// 如公网 TCP 加供 sidecar 访问的本地 socket。
+func WithListenAddrs(addrs ...string) Option {
+	return func(o *Options) {
+		o.ListenAddrs = append(o.ListenAddrs, addrs...)
+	}
+}
+
+// WithListener 在调用方提供的监听器上提供服务，可多次使用。使用后若未经
+// WithAddr 显式设置地址，不再监听缺省地址。监听器由 Server 接管，Stop
+// 时关闭。
+func WithListener(ln net.Listener) Option {
+	return func(o *Options) {
+		o.Listeners = append(o.Listeners, ln)
+	}
+}
+
+// WithUnixSocketMode 设置 Unix domain socket 文件的权限，缺省
+// listener.DefaultUnixSocketMode（0660）。
+func WithUnixSocketMode(mode os.FileMode) Option {
+	return func(o *Options) {
+		o.UnixSocketMode = mode
 	}
 }
 
@@ -123,6 +200,43 @@ func WithTLSConfig(cfg *tls.Config) Option {
 	}
 }
 
+// WithTLSFiles 以文件中的证书与私钥启用 TLS 传输，文件变化后热加载，
+// ClientCAFile 非空时启用 mTLS（客户端 CA 同样热加载），见 certs.Reloader。
+// 证书在 Start 时加载，失败时 Start 返回错误；同时设置 WithTLSConfig 时
+// 其作为模板。证书过期时 CheckHealth 返回错误，到期时间以
+// lynx.tls.certificate.expiry 指标暴露。
+func WithTLSFiles(o certs.Options) Option {
+	return func(o2 *Options) {
+		o2.TLSFiles = &o
+	}
+}
+
+// WithProxyProtocol 在全部监听器上解析 PROXY protocol（v1/v2）头，
+// peer.FromContext 的地址与日志中的对端为负载均衡器之前的客户端地址。
+// 配置非法时 NewServer panic。
+func WithProxyProtocol(o listener.ProxyProtocolOptions) Option {
+	return func(opts *Options) {
+		opts.ProxyProtocol = &o
+	}
+}
+
+// WithReflection 显式开启或关闭 gRPC 反射服务，覆盖按运行环境的缺省值
+// （prod 关闭，其余开启）。
+func WithReflection(enabled bool) Option {
+	return func(o *Options) {
+		o.Reflection = enabled
+		o.reflectionSet = true
+	}
+}
+
+// WithMaintenance 配置维护模式下的拦截行为；未设置时按缺省值拦截应用
+// Context 携带的维护状态（脱离框架单用且未设置 State 时不拦截）。
+func WithMaintenance(o MaintenanceOptions) Option {
+	return func(opts *Options) {
+		opts.Maintenance = o
+	}
+}
+
 // WithTracerProvider sets the OpenTelemetry TracerProvider used by the
 // server's stats handler. When nil, the global (noop by default) provider is
 // used. The provider's lifecycle is the caller's responsibility.
@@ -159,15 +273,29 @@ func NewServer(opts ...Option) *Server {
 	if options.HealthCheckPeriod <= 0 {
 		options.HealthCheckPeriod = DefaultHealthCheckPeriod
 	}
+	if options.Maintenance.Message == "" {
+		options.Maintenance.Message = DefaultMaintenanceMessage
+	}
+	allowlist := append(append([]string{}, maintenanceAlwaysAllowed...), options.Maintenance.Allowlist...)
 
 	s := &Server{
 		logger: options.Logger,
 		o:      options,
+		stopCh: make(chan struct{}),
+	}
+	if options.ProxyProtocol != nil {
+		p, err := listener.NewProxyProtocol(*options.ProxyProtocol)
+		if err != nil {
+			panic(err)
+		}
+		s.proxy = p
 	}
 	// Recovery 在最外层：链内任意一环（含用户拦截器）panic 都能被恢复。
+	// 维护拦截在日志之后：被拒绝的请求同样记录日志。
 	unaryInterceptors := []grpc.UnaryServerInterceptor{
 		interceptor.Recovery(),
 		interceptor.Logging(s.logger),
+		interceptor.Maintenance(s.inMaintenance, options.Maintenance.Message, allowlist...),
 	}
 	unaryInterceptors = append(unaryInterceptors, options.Interceptors...)
 	// 流式 RPC 同样需要日志与 panic 恢复：gRPC 对流式 handler 的 panic
@@ -175,6 +303,7 @@ func NewServer(opts ...Option) *Server {
 	streamInterceptors := []grpc.StreamServerInterceptor{
 		interceptor.RecoveryStream(),
 		interceptor.LoggingStream(s.logger),
+		interceptor.MaintenanceStream(s.inMaintenance, options.Maintenance.Message, allowlist...),
 	}
 	streamInterceptors = append(streamInterceptors, options.StreamInterceptors...)
 	statsOpts := []otelgrpc.Option{}
@@ -192,7 +321,21 @@ func NewServer(opts ...Option) *Server {
 	grpcOpts = append(grpcOpts, options.ServerOptions...)
 	// TLSConfig 非 nil 时启用 TLS 传输。装配在 ServerOptions 之后：
 	// grpc 对重复 Creds 取最后应用者，保证 TLSConfig 优先（见 WithTLSConfig）。
-	if options.TLSConfig != nil {
+	// TLSFiles 的证书在 Start 时加载，握手时经 GetCertificate 取当前证书。
+	if options.TLSFiles != nil {
+		co := *options.TLSFiles
+		if co.Name == "" {
+			co.Name = s.Name()
+		}
+		if co.Logger == nil {
+			co.Logger = options.Logger
+		}
+		if co.MeterProvider == nil {
+			co.MeterProvider = options.MeterProvider
+		}
+		s.certs = certs.New(co)
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(s.certs.TLSConfig(options.TLSConfig))))
+	} else if options.TLSConfig != nil {
 		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(options.TLSConfig)))
 	}
 
@@ -201,20 +344,34 @@ func NewServer(opts ...Option) *Server {
 	// Register health check service
 	s.health = health.NewServer()
 	grpc_health_v1.RegisterHealthServer(s.server, s.health)
-	// Register reflection service：注册必须在 Serve 之前完成（Serve 后
-	// 注册服务会 panic）；放在 NewServer 中保证一次创建即注册，Start 可
-	// 安全重入。
-	reflection.Register(s.server)
+	// Reflection service：注册必须在 Serve 之前完成（Serve 后注册服务会
+	// panic）。显式 WithReflection 时在此一次决定；否则推迟到 Init 按运行
+	// 环境决定，脱离框架单用（未 Init）时由 Start 在 Serve 前兜底开启。
+	// registerReflection 以 sync.Once 保证只决定一次，Start 可安全重入。
+	if options.reflectionSet {
+		s.registerReflection(options.Reflection)
+	}
 	return s
 }
 
+// registerReflection 决定是否注册反射服务，仅首次调用生效。
+func (s *Server) registerReflection(enabled bool) {
+	s.reflectionOnce.Do(func() {
+		if enabled {
+			reflection.Register(s.server)
+		}
+	})
+}
+
 // Server 是 gRPC 服务，实现 lynx.Service 接口。
 type Server struct {
-	// mu guards listener, which is written by Start and read by Stop on a
+	// mu guards listeners, which are written by Start and read by Stop on a
 	// different goroutine during shutdown.
-	mu           sync.Mutex
-	server       *grpc.Server
-	listener     net.Listener
+	mu        sync.Mutex
+	server    *grpc.Server
+	listeners []net.Listener
+	// addrs 是 listeners 实际绑定的地址，Stop 后仍可读（mu 保护）。
+	addrs        []net.Addr
 	logger       *slog.Logger
 	o            Options
 	health       *health.Server
@@ -223,15 +380,28 @@ type Server struct {
 	// startHealthPoller 时，poller 启动即在同锁段内发现并取消自身，
 	// 不会泄漏无人取消的轮询 goroutine。
 	stopped bool
+	// draining 标记已进入排水（mu 保护）：健康轮询不再恢复 SERVING。
+	draining bool
+	// stopCh 在 Stop 时关闭，无监听器时 Start 据此返回。
+	stopCh  chan struct{}
 	running atomic.Bool
+	// reflectionOnce 保证反射服务的注册决定只发生一次（见 registerReflection）。
+	reflectionOnce sync.Once
+	// certs 在 WithTLSFiles 时加载并热加载证书。
+	certs *certs.Reloader
+	// proxy 在 WithProxyProtocol 时包装监听器。
+	proxy *listener.ProxyProtocol
 }
 
-// CheckHealth 实现健康检查，服务未处于运行状态时返回错误。
+// CheckHealth 实现健康检查，服务未处于运行状态或 WithTLSFiles 的证书已
+// 过期时返回错误。
 func (s *Server) CheckHealth() error {
 	if !s.running.Load() {
 		return grpc.ErrServerStopped
 	}
-	// Check if the server is still serving
+	if s.certs != nil {
+		return s.certs.CheckHealth()
+	}
 	return nil
 }
 
@@ -240,21 +410,48 @@ func (s *Server) Name() string {
 	return "grpc"
 }
 
-// Init 初始化服务，gRPC 服务无需在初始化阶段做额外工作。
+// Init 按运行环境决定反射服务的缺省值：prod 关闭，其余开启；已由
+// WithReflection 显式设置时不生效。未在 WithMaintenance 中指定 State 时
+// 取应用 Context 携带的维护状态。ctx 为 nil（脱离框架单用）时推迟到 Start。
 func (s *Server) Init(ctx lynx.AppContext) error {
+	if ctx == nil {
+		return nil
+	}
+	s.registerReflection(!lynx.EnvFromContext(ctx.Context()).IsProd())
+	if s.o.Maintenance.State == nil {
+		s.o.Maintenance.State = lynx.MaintenanceFromContext(ctx.Context())
+	}
 	return nil
 }
 
+// inMaintenance 报告是否处于维护状态：State 在 Init（先于 Serve）写入，
+// 拦截器在请求路径上只读。
+func (s *Server) inMaintenance() bool {
+	return s.o.Maintenance.State.Enabled()
+}
+
 // Start 启动 gRPC 服务并开始监听，阻塞至服务退出。
+//
+// 同时在 Addr、WithListenAddrs 的地址与 WithListener 的监听器上提供服务；
+// 任一地址监听失败时不启动并返回错误，任一监听器异常退出时停止整个服务
+// 并返回该错误。
 func (s *Server) Start(ctx context.Context) error {
-	s.logger.InfoContext(ctx, "starting gRPC server, listening on "+s.o.Addr)
+	// 未经 Init 决定时（脱离框架单用）沿用 v1.x 缺省：开启反射。
+	s.registerReflection(true)
 
-	lis, err := net.Listen("tcp", s.o.Addr)
+	if s.certs != nil {
+		if err := s.certs.Load(); err != nil {
+			return err
+		}
+	}
+	lns, err := s.listen()
 	if err != nil {
 		return err
 	}
+	s.logger.InfoContext(ctx, "starting gRPC server, listening on "+joinAddrs(lns))
 	s.mu.Lock()
-	s.listener = lis
+	s.listeners = lns
+	s.addrs = listener.Addrs(lns)
 	s.mu.Unlock()
 
 	// Set the server to healthy, for both the named and the standard empty
@@ -269,7 +466,80 @@ func (s *Server) Start(ctx context.Context) error {
 	if s.o.HealthCheck != nil {
 		s.startHealthPoller()
 	}
-	return s.server.Serve(lis)
+	if len(lns) == 0 {
+		<-s.stopCh
+		return nil
+	}
+	errc := make(chan error, len(lns))
+	for _, ln := range lns {
+		go func() { errc <- s.server.Serve(ln) }()
+	}
+	var first error
+	for range lns {
+		err := <-errc
+		if first != nil || err == nil {
+			continue
+		}
+		s.mu.Lock()
+		stopped := s.stopped
+		s.mu.Unlock()
+		if stopped {
+			// Stop 先关闭监听器，Serve 因 Accept 失败返回，属正常关停。
+			continue
+		}
+		// 一个监听器异常退出：停止整个服务，不以部分地址继续运行。
+		first = err
+		s.server.Stop()
+	}
+	return first
+}
+
+// listen 绑定全部监听地址并与 WithListener 的监听器合并；失败时关闭
+// 全部监听器（包括接管的 WithListener 监听器）。
+func (s *Server) listen() ([]net.Listener, error) {
+	var addrs []string
+	if s.o.Addr != "" && (s.o.addrSet || len(s.o.Listeners) == 0) {
+		addrs = append(addrs, s.o.Addr)
+	}
+	addrs = append(addrs, s.o.ListenAddrs...)
+	lns, err := listener.ListenAll(addrs, s.o.UnixSocketMode)
+	if err != nil {
+		listener.CloseAll(s.o.Listeners)
+		return nil, err
+	}
+	lns = append(lns, s.o.Listeners...)
+	if s.proxy != nil {
+		for i, ln := range lns {
+			lns[i] = s.proxy.Listener(ln)
+		}
+	}
+	return lns, nil
+}
+
+// Addr 返回第一个监听器实际绑定的地址（以端口 0 监听时为系统分配的
+// 端口）；Start 尚未完成监听时返回 nil。
+func (s *Server) Addr() net.Addr {
+	if addrs := s.Addrs(); len(addrs) > 0 {
+		return addrs[0]
+	}
+	return nil
+}
+
+// Addrs 返回全部监听器实际绑定的地址，顺序为 Addr、WithListenAddrs、
+// WithListener；Start 尚未完成监听时返回 nil。
+func (s *Server) Addrs() []net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addrs
+}
+
+// joinAddrs 把监听地址拼为日志文本。
+func joinAddrs(lns []net.Listener) string {
+	parts := make([]string, len(lns))
+	for i, ln := range lns {
+		parts[i] = ln.Addr().Network() + "://" + ln.Addr().String()
+	}
+	return strings.Join(parts, ", ")
 }
 
 // startHealthPoller 按 HealthCheckPeriod 轮询 app 级健康检查器并同步到
@@ -309,10 +579,27 @@ func (s *Server) updateHealthStatus() {
 			break
 		}
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return
+	}
 	s.health.SetServingStatus("", status)
 	s.health.SetServingStatus("grpc", status)
 }
 
+// Drain 实现 lynx.Drainer：排水开始即把 health 服务置为 NOT_SERVING，
+// 不必等待下一个健康轮询周期，负载均衡器尽快摘流；在途与新的 RPC 照常
+// 处理，直到 Stop。
+func (s *Server) Drain(ctx context.Context) {
+	s.logger.InfoContext(ctx, "draining gRPC server: health set to NOT_SERVING")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.draining = true
+	s.health.SetServingStatus("grpc", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	s.health.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+}
+
 // Stop 优雅关停 gRPC 服务：先关闭监听器，再等待在途请求完成，超时后强制停止。
 // 返回错误（如强制停止）使调用方感知关停失败。
 func (s *Server) Stop(ctx context.Context) error {
@@ -323,6 +610,9 @@ func (s *Server) Stop(ctx context.Context) error {
 	}
 	s.running.Store(false)
 	s.mu.Lock()
+	if !s.stopped {
+		close(s.stopCh)
+	}
 	s.stopped = true
 	if s.healthCancel != nil {
 		s.healthCancel()
@@ -330,12 +620,12 @@ func (s *Server) Stop(ctx context.Context) error {
 	}
 	s.mu.Unlock()
 
-	// Close the listener first to stop accepting new connections
+	// Close the listeners first to stop accepting new connections
 	s.mu.Lock()
-	lis := s.listener
-	s.listener = nil
+	lns := s.listeners
+	s.listeners = nil
 	s.mu.Unlock()
-	if lis != nil {
+	for _, lis := range lns {
 		if err := lis.Close(); err != nil {
 			s.logger.ErrorContext(ctx, "error closing gRPC listener", "error", err)
 		}
@@ -388,3 +678,5 @@ func (s *Server) GetServer() *grpc.Server {
 var _ lynx.Service = (*Server)(nil)
 
 var _ lynx.Checker = (*Server)(nil)
+
+var _ lynx.Drainer = (*Server)(nil)
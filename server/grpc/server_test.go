@@ -13,6 +13,8 @@ import (
 	"log/slog"
 	"math/big"
 	"net"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync/atomic"
@@ -20,27 +22,97 @@ import (
 	"time"
 
 	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/server/certs"
+	"github.com/lynx-go/lynx/server/listener"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/encoding"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
-// TestReflectionRegisteredAtNewServer 回归：反射服务在 NewServer 时即注册
-// （P0-2），而不是留到 Start 中（Serve 之后注册服务会 panic）。
-func TestReflectionRegisteredAtNewServer(t *testing.T) {
-	s := NewServer()
+// envAppContext 是携带运行环境的最小 lynx.AppContext 实现。
+type envAppContext struct{ env lynx.Env }
+
+func (c envAppContext) Context() context.Context {
+	return lynx.ContextWithEnv(context.Background(), c.env)
+}
+func (envAppContext) Config() lynx.Config            { return nil }
+func (envAppContext) Logger(...any) *slog.Logger     { return slog.Default() }
+func (envAppContext) HealthCheckers() []lynx.Checker { return nil }
+func (envAppContext) Close()                         {}
+
+func hasReflection(s *Server) bool {
 	info := s.server.GetServiceInfo()
 	for _, name := range []string{"grpc.reflection.v1.ServerReflection", "grpc.reflection.v1alpha.ServerReflection"} {
 		if _, ok := info[name]; !ok {
-			t.Errorf("reflection service %q not registered after NewServer", name)
+			return false
 		}
 	}
+	return true
+}
+
+// TestReflectionRegisteredAtNewServer 回归：显式 WithReflection 时反射服务
+// 在 NewServer 时即注册（P0-2），而不是留到 Serve 之后（会 panic）。
+func TestReflectionRegisteredAtNewServer(t *testing.T) {
+	if !hasReflection(NewServer(WithReflection(true))) {
+		t.Error("reflection service not registered after NewServer(WithReflection(true))")
+	}
+	if hasReflection(NewServer(WithReflection(false))) {
+		t.Error("reflection service registered despite WithReflection(false)")
+	}
+}
+
+// TestReflectionEnvDefault：未显式设置时 Init 按运行环境决定——dev 开启、
+// prod 关闭；显式 WithReflection 优先于运行环境。
+func TestReflectionEnvDefault(t *testing.T) {
+	dev := NewServer()
+	if err := dev.Init(envAppContext{env: lynx.EnvDev}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if !hasReflection(dev) {
+		t.Error("reflection should be on in dev")
+	}
+	prod := NewServer()
+	if err := prod.Init(envAppContext{env: lynx.EnvProd}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if hasReflection(prod) {
+		t.Error("reflection should be off in prod")
+	}
+	forced := NewServer(WithReflection(true))
+	if err := forced.Init(envAppContext{env: lynx.EnvProd}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if !hasReflection(forced) {
+		t.Error("explicit WithReflection(true) should win over prod default")
+	}
+}
+
+// TestReflectionStandaloneStart：脱离框架单用（未 Init）时 Start 在 Serve
+// 前兜底开启反射，与 v1.x 缺省一致。
+func TestReflectionStandaloneStart(t *testing.T) {
+	s := NewServer(WithAddr("127.0.0.1:0"))
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Start(context.Background()) }()
+	deadline := time.Now().Add(2 * time.Second)
+	for !s.running.Load() && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !hasReflection(s) {
+		t.Error("reflection should be registered by Start when Init was skipped")
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+	<-errCh
 }
 
 func TestNewServerDefaults(t *testing.T) {
@@ -377,11 +449,9 @@ func TestHealthStatusFollowsCheckers(t *testing.T) {
 
 	var addr string
 	for i := 0; i < 200 && addr == ""; i++ {
-		s.mu.Lock()
-		if s.listener != nil {
-			addr = s.listener.Addr().String()
+		if a := s.Addr(); a != nil {
+			addr = a.String()
 		}
-		s.mu.Unlock()
 		time.Sleep(10 * time.Millisecond)
 	}
 	if addr == "" {
@@ -574,6 +644,63 @@ func TestTLSServer(t *testing.T) {
 	}
 }
 
+// writeTLSFiles 把 testTLSConfig 的证书与私钥写入临时目录。
+func writeTLSFiles(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	cert := testTLSConfig(t).Certificates[0]
+	keyDER, err := x509.MarshalECPrivateKey(cert.PrivateKey.(*ecdsa.PrivateKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// TestTLSFiles 验证 WithTLSFiles 在 Start 时加载文件证书并以 TLS 提供
+// 服务，CheckHealth 反映证书状态；文件缺失时 Start 返回错误。
+func TestTLSFiles(t *testing.T) {
+	certFile, keyFile := writeTLSFiles(t)
+	addr := freeAddr(t)
+	s := NewServer(WithAddr(addr), WithTLSFiles(certs.Options{CertFile: certFile, KeyFile: keyFile}))
+	startErr := make(chan error, 1)
+	go func() { startErr <- s.Start(context.Background()) }()
+	defer func() {
+		_ = s.Stop(context.Background())
+		select {
+		case <-startErr:
+		case <-time.After(5 * time.Second):
+			t.Error("Start() did not return after Stop()")
+		}
+	}()
+	waitRunning(t, s)
+	if err := s.CheckHealth(); err != nil {
+		t.Errorf("CheckHealth() = %v", err)
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check over TLS: %v", err)
+	}
+
+	bad := NewServer(WithAddr(freeAddr(t)), WithTLSFiles(certs.Options{CertFile: certFile + ".missing", KeyFile: keyFile}))
+	if err := bad.Start(context.Background()); err == nil {
+		t.Error("Start() error = nil with missing certificate file")
+	}
+}
+
 // TestTLSRejectsPlaintext 验证同一 TLS 服务上明文调用失败（TLS 强制）。
 func TestTLSRejectsPlaintext(t *testing.T) {
 	addr := freeAddr(t)
@@ -646,3 +773,285 @@ func TestTLSPriorityOverServerOptionsCreds(t *testing.T) {
 		t.Fatal("plaintext client over dual-Creds server succeeded, want TLSConfig to win")
 	}
 }
+
+// maintenanceAppContext 是携带维护状态的最小 lynx.AppContext 实现。
+type maintenanceAppContext struct {
+	envAppContext
+	m *lynx.Maintenance
+}
+
+func (c maintenanceAppContext) Context() context.Context {
+	return lynx.ContextWithMaintenance(context.Background(), c.m)
+}
+
+// TestMaintenanceRejectsRPCs 验证 Init 取应用 Context 的维护状态：维护期间
+// 业务方法返回 UNAVAILABLE，健康检查与白名单方法放行。
+func TestMaintenanceRejectsRPCs(t *testing.T) {
+	addr := freeAddr(t)
+	m := lynx.NewMaintenance()
+	s := NewServer(WithAddr(addr), WithMaintenance(MaintenanceOptions{Allowlist: []string{"/test.Echo/Allowed"}}))
+	if err := s.Init(maintenanceAppContext{m: m}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	// echo 按生成代码的方式经拦截器链调用，FullMethod 须与实际方法一致。
+	echo := func(method string) grpc.MethodHandler {
+		return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: method}
+			return interceptor(ctx, &struct{}{}, info, func(context.Context, interface{}) (interface{}, error) {
+				return &struct{}{}, nil
+			})
+		}
+	}
+	s.GetServer().RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Echo",
+		HandlerType: (*blockerService)(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "Do", Handler: echo("/test.Echo/Do")},
+			{MethodName: "Allowed", Handler: echo("/test.Echo/Allowed")},
+		},
+		Metadata: "test.proto",
+	}, struct{}{})
+
+	startErr := make(chan error, 1)
+	go func() { startErr <- s.Start(context.Background()) }()
+	waitRunning(t, s)
+	defer func() {
+		_ = s.Stop(context.Background())
+		<-startErr
+	}()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	invoke := func(method string) codes.Code {
+		err := conn.Invoke(context.Background(), method, &struct{}{}, &struct{}{}, grpc.ForceCodec(rawCodec{}))
+		return status.Code(err)
+	}
+
+	if got := invoke("/test.Echo/Do"); got != codes.OK {
+		t.Fatalf("outside maintenance code = %v, want OK", got)
+	}
+	m.Set(true)
+	if got := invoke("/test.Echo/Do"); got != codes.Unavailable {
+		t.Errorf("maintenance code = %v, want Unavailable", got)
+	}
+	if got := invoke("/test.Echo/Allowed"); got != codes.OK {
+		t.Errorf("allowlisted code = %v, want OK", got)
+	}
+	if _, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Errorf("health Check during maintenance error = %v, want nil", err)
+	}
+}
+
+// waitAddrs 等待 Start 完成监听并返回实际地址。
+func waitAddrs(t *testing.T, s *Server) []net.Addr {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		if addrs := s.Addrs(); addrs != nil {
+			return addrs
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("server did not start listening")
+	return nil
+}
+
+func checkHealth(t *testing.T, target string) {
+	t.Helper()
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient(%s): %v", target, err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil || resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("Check(%s) = %v, %v, want SERVING", target, resp, err)
+	}
+}
+
+// TestMultipleListeners 验证同时监听 TCP（端口 0）、Unix domain socket
+// 与调用方提供的监听器，Addr 报告实际端口，Stop 清理 socket 文件。
+func TestMultipleListeners(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "grpc.sock")
+	extra, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(
+		WithAddr("127.0.0.1:0"),
+		WithListenAddrs("unix:"+sock),
+		WithListener(extra),
+		WithUnixSocketMode(0o600),
+	)
+	startErr := make(chan error, 1)
+	go func() { startErr <- s.Start(context.Background()) }()
+
+	addrs := waitAddrs(t, s)
+	if len(addrs) != 3 {
+		t.Fatalf("Addrs() = %v, want 3", addrs)
+	}
+	if tcp := s.Addr().(*net.TCPAddr); tcp.Port == 0 {
+		t.Errorf("Addr() = %v, want bound port", tcp)
+	}
+	if fi, err := os.Stat(sock); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("socket stat = %v, %v, want mode 0600", fi, err)
+	}
+	checkHealth(t, s.Addr().String())
+	checkHealth(t, "unix://"+sock)
+	checkHealth(t, extra.Addr().String())
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	select {
+	case err := <-startErr:
+		if err != nil {
+			t.Errorf("Start() = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after Stop")
+	}
+	if _, err := os.Stat(sock); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("socket file left after Stop: %v", err)
+	}
+}
+
+// TestWithListenerSkipsDefaultAddr 验证只提供监听器时不再监听缺省地址。
+func TestWithListenerSkipsDefaultAddr(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(WithListener(ln))
+	go func() { _ = s.Start(context.Background()) }()
+	defer s.Stop(context.Background())
+	addrs := waitAddrs(t, s)
+	if len(addrs) != 1 || addrs[0].String() != ln.Addr().String() {
+		t.Fatalf("Addrs() = %v, want [%v]", addrs, ln.Addr())
+	}
+	checkHealth(t, ln.Addr().String())
+}
+
+// TestStartListenError 验证任一地址监听失败时 Start 返回错误并释放已
+// 绑定的地址。
+func TestStartListenError(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "grpc.sock")
+	s := NewServer(WithAddr("unix:"+sock), WithListenAddrs("256.0.0.1:0"))
+	if err := s.Start(context.Background()); err == nil {
+		t.Fatal("Start() = nil, want listen error")
+	}
+	if _, err := os.Stat(sock); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("socket file left after failed Start: %v", err)
+	}
+}
+
+// TestDrainSetsNotServing 验证 Drain 立即置 NOT_SERVING，健康轮询不再
+// 恢复 SERVING。
+func TestDrainSetsNotServing(t *testing.T) {
+	s := NewServer(
+		WithAddr("127.0.0.1:0"),
+		WithHealthCheckers(func() []lynx.Checker { return nil }),
+		WithHealthCheckPeriod(10*time.Millisecond),
+	)
+	go func() { _ = s.Start(context.Background()) }()
+	defer s.Stop(context.Background())
+	addr := waitAddrs(t, s)[0].String()
+	checkHealth(t, addr)
+
+	s.Drain(context.Background())
+	time.Sleep(50 * time.Millisecond) // 跨过若干轮询周期
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("status after Drain = %v, want NOT_SERVING", resp.Status)
+	}
+}
+
+// TestStartWithoutListener 验证无监听地址时 Start 不监听、运行至 Stop。
+func TestStartWithoutListener(t *testing.T) {
+	s := NewServer(WithAddr(""))
+	startErr := make(chan error, 1)
+	go func() { startErr <- s.Start(context.Background()) }()
+	waitRunning(t, s)
+	if addrs := s.Addrs(); len(addrs) != 0 {
+		t.Errorf("Addrs() = %v, want none", addrs)
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	select {
+	case err := <-startErr:
+		if err != nil {
+			t.Errorf("Start() = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after Stop")
+	}
+}
+
+// TestProxyProtocol 验证 WithProxyProtocol 使 peer 地址为 PROXY 头中的
+// 源地址。
+func TestProxyProtocol(t *testing.T) {
+	addr := freeAddr(t)
+	peers := make(chan string, 1)
+	s := NewServer(
+		WithAddr(addr),
+		WithProxyProtocol(listener.ProxyProtocolOptions{TrustedSources: []string{"127.0.0.0/8"}}),
+		WithInterceptors(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if p, ok := peer.FromContext(ctx); ok {
+				select {
+				case peers <- p.Addr.String():
+				default:
+				}
+			}
+			return handler(ctx, req)
+		}),
+	)
+	startErr := make(chan error, 1)
+	go func() { startErr <- s.Start(context.Background()) }()
+	defer func() {
+		_ = s.Stop(context.Background())
+		select {
+		case <-startErr:
+		case <-time.After(5 * time.Second):
+			t.Error("Start() did not return after Stop()")
+		}
+	}()
+	waitRunning(t, s)
+
+	dialer := func(ctx context.Context, target string) (net.Conn, error) {
+		c, err := (&net.Dialer{}).DialContext(ctx, "tcp", target)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(c, "PROXY TCP4 203.0.113.7 192.0.2.1 51000 443\r\n"); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+		return c, nil
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithContextDialer(dialer))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if got := <-peers; got != "203.0.113.7:51000" {
+		t.Errorf("peer address = %q, want 203.0.113.7:51000", got)
+	}
+}
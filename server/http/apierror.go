@@ -0,0 +1,228 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// FieldError 描述一个字段级错误（如请求参数校验失败）。
+type FieldError struct {
+	// Field 是字段路径，如 "email" 或 "items[0].qty"。
+	Field string `json:"field"`
+	// Message 是面向调用方的说明。
+	Message string `json:"message"`
+	// Code 是可选的机器可读错误码，如 "required"。
+	Code string `json:"code,omitempty"`
+}
+
+// DetailedError 由携带字段级详情的错误实现。DefaultErrorHandler 与
+// ProblemErrorHandler 经 errors.As 查找，把详情渲染进响应体。
+type DetailedError interface {
+	error
+	FieldErrors() []FieldError
+}
+
+// APIError 是可直接返回给调用方的业务错误：状态码、对外消息与字段级
+// 详情都视为公开内容，原因错误（Cause）只用于日志，从不写入响应。
+// 实现 StatusError 与 DetailedError。
+//
+// With* 方法就地修改并返回接收者，便于链式构造；包级预置的 Err* 值为
+// 共享实例，不应修改。
+type APIError struct {
+	// Status 是 HTTP 状态码。
+	Status int
+	// Message 是对外消息（problem+json 的 detail）；为空时取状态码文本。
+	Message string
+	// Type 是 problem 类型 URI，为空时为 "about:blank"。
+	Type string
+	// Title 是 problem 标题，为空时取状态码文本。
+	Title string
+	// Fields 是字段级详情。
+	Fields []FieldError
+	// Extensions 是 problem+json 的扩展成员，不覆盖标准成员。
+	Extensions map[string]any
+	// Cause 是内部原因，只出现在 Error() 与日志中。
+	Cause error
+
+	// quiet 为 true 时 5xx 不记 Error 日志：用于限流、过载、维护等框架
+	// 主动拒绝，避免过载时放大日志量。
+	quiet bool
+}
+
+// NewAPIError 创建状态码为 status、对外消息为 message 的 APIError。
+func NewAPIError(status int, message string) *APIError {
+	return &APIError{Status: status, Message: message}
+}
+
+// 框架主动拒绝请求时使用的错误，经服务器级 ErrorHandler（见
+// WithErrorHandler）写响应，可用 errors.Is 识别。
+var (
+	// ErrRateLimited 由 RateLimit 与 KeyedRateLimit 的缺省处理使用（429）。
+	ErrRateLimited = &APIError{Status: http.StatusTooManyRequests, Message: "rate limit exceeded", quiet: true}
+	// ErrOverloaded 由 ConcurrencyLimit 的缺省处理使用（503）。
+	ErrOverloaded = &APIError{Status: http.StatusServiceUnavailable, Message: "server overloaded", quiet: true}
+	// ErrRequestTimeout 由 Timeout 中间件使用（503）；handler 返回的错误
+	// 包装 context.DeadlineExceeded 时同样按它响应。
+	ErrRequestTimeout = &APIError{Status: http.StatusServiceUnavailable, Message: "request timeout", quiet: true}
+	// ErrUnauthorized 由 JWTAuth、APIKeyAuth、PeerAuth 与 RequireScopes/
+	// RequireRoles/RequirePeer 在缺少或无法校验凭据时使用（401）。
+	ErrUnauthorized = &APIError{Status: http.StatusUnauthorized, Message: "unauthorized", quiet: true}
+	// ErrForbidden 由 RequireScopes/RequireRoles、PeerAuth/RequirePeer 在
+	// 权限不足时使用（403）。
+	ErrForbidden = &APIError{Status: http.StatusForbidden, Message: "forbidden", quiet: true}
+	// ErrBodyTooLarge 由 BodyLimit 在请求体超过 MaxBytes 时使用（413）。
+	ErrBodyTooLarge = &APIError{Status: http.StatusRequestEntityTooLarge, Message: "request body too large", quiet: true}
+	// ErrBodyTimeout 由 BodyLimit 在请求体读取超时或低于最低吞吐时使用
+	// （408），也是此时请求体读取返回的错误。
+	ErrBodyTimeout = &APIError{Status: http.StatusRequestTimeout, Message: "request body timeout", quiet: true}
+)
+
+// Error 返回对外消息，有原因错误时附加其内容（仅用于日志）。
+func (e *APIError) Error() string {
+	msg := e.publicMessage()
+	if e.Cause != nil {
+		return msg + ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+// Unwrap 返回原因错误。
+func (e *APIError) Unwrap() error { return e.Cause }
+
+// StatusCode 实现 StatusError；Status 未设置时为 500。
+func (e *APIError) StatusCode() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+// FieldErrors 实现 DetailedError。
+func (e *APIError) FieldErrors() []FieldError { return e.Fields }
+
+// WithField 追加一条字段级详情。
+func (e *APIError) WithField(field, message string) *APIError {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+	return e
+}
+
+// WithCause 设置内部原因错误。
+func (e *APIError) WithCause(err error) *APIError {
+	e.Cause = err
+	return e
+}
+
+// WithType 设置 problem 类型 URI 与标题。
+func (e *APIError) WithType(uri, title string) *APIError {
+	e.Type, e.Title = uri, title
+	return e
+}
+
+// WithExtension 设置一个 problem+json 扩展成员。
+func (e *APIError) WithExtension(key string, value any) *APIError {
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]any)
+	}
+	e.Extensions[key] = value
+	return e
+}
+
+func (e *APIError) publicMessage() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return http.StatusText(e.StatusCode())
+}
+
+// resolvedError 是错误到响应的解析结果。
+type resolvedError struct {
+	status int
+	// message 是对外消息；plain 为 true 时来自普通错误：4xx 取其 Error()，
+	// 5xx 取状态码文本（不对外暴露内部错误信息，原因见 5xx 日志）。
+	message string
+	plain   bool
+	api     *APIError
+	fields  []FieldError
+	quiet   bool
+}
+
+// resolveError 按约定解析错误：APIError 优先，其次 StatusError，包装
+// context.DeadlineExceeded 的错误按 ErrRequestTimeout，其余 500。
+func resolveError(err error) resolvedError {
+	var res resolvedError
+	var de DetailedError
+	if errors.As(err, &de) {
+		res.fields = de.FieldErrors()
+	}
+	var ae *APIError
+	if errors.As(err, &ae) {
+		res.api, res.status, res.message, res.quiet = ae, ae.StatusCode(), ae.publicMessage(), ae.quiet
+		return res
+	}
+	var se StatusError
+	switch {
+	case errors.As(err, &se):
+		res.status, res.message, res.plain = se.StatusCode(), err.Error(), true
+	case errors.Is(err, context.DeadlineExceeded):
+		res.status, res.message = ErrRequestTimeout.Status, ErrRequestTimeout.Message
+	default:
+		res.status, res.message, res.plain = http.StatusInternalServerError, err.Error(), true
+	}
+	if res.plain && res.status >= http.StatusInternalServerError {
+		res.message = http.StatusText(res.status)
+	}
+	return res
+}
+
+// ProblemErrorHandler 是以 RFC 7807 problem+json 写响应的 ErrorHandler
+// （Content-Type: application/problem+json），经 WithErrorHandler 启用：
+//   - type/title 取自 APIError（缺省 "about:blank" 与状态码文本），status
+//     为状态码，instance 为请求的 request_id（见 WithRequestID）；
+//   - detail 为 APIError 的对外消息；普通错误只在 4xx 时以 Error() 作为
+//     detail，5xx 不输出 detail，避免泄露内部信息；
+//   - 字段级详情（DetailedError）输出为扩展成员 "errors"，APIError 的
+//     Extensions 作为其余扩展成员（不覆盖标准成员）。
+//
+// 响应已开始与 5xx 日志的处理同 DefaultErrorHandler。
+func ProblemErrorHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	res := resolveError(err)
+	if tw, ok := w.(headerWriter); ok && tw.headerWritten() {
+		logHTTPError(ctx, "http handler error after response started", r, tw.statusCode(), err)
+		return
+	}
+	body := map[string]any{}
+	title := http.StatusText(res.status)
+	typ := "about:blank"
+	if res.api != nil {
+		for k, v := range res.api.Extensions {
+			body[k] = v
+		}
+		if res.api.Type != "" {
+			typ = res.api.Type
+		}
+		if res.api.Title != "" {
+			title = res.api.Title
+		}
+	}
+	body["type"] = typ
+	body["title"] = title
+	body["status"] = res.status
+	if !res.plain || res.status < http.StatusInternalServerError {
+		body["detail"] = res.message
+	}
+	if id := RequestIDFrom(ctx); id != "" {
+		body["instance"] = id
+	}
+	if len(res.fields) > 0 {
+		body["errors"] = res.fields
+	}
+	b, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(res.status)
+	_, _ = w.Write(b)
+	if res.status >= http.StatusInternalServerError && !res.quiet {
+		logHTTPError(ctx, "http handler error", r, res.status, err)
+	}
+}
@@ -0,0 +1,192 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDefaultErrorHandlerAPIError：APIError 写对外消息与字段详情，
+// 原因错误只进日志。
+func TestDefaultErrorHandlerAPIError(t *testing.T) {
+	capture, restore := useCaptureLogger(false)
+	defer restore()
+
+	h := NewErrorHandler(nil, func(context.Context, http.ResponseWriter, *http.Request) error {
+		return NewAPIError(http.StatusUnprocessableEntity, "invalid order").
+			WithField("qty", "must be positive").
+			WithCause(errors.New("sql: constraint qty_positive"))
+	})
+	rec := doRequest(h, http.MethodPost, "/orders")
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", rec.Code)
+	}
+	want := `{"error":{"message":"invalid order","fields":[{"field":"qty","message":"must be positive"}]}}`
+	if got := strings.TrimSpace(rec.Body.String()); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+
+	h = NewErrorHandler(nil, func(context.Context, http.ResponseWriter, *http.Request) error {
+		return NewAPIError(http.StatusBadGateway, "").WithCause(errors.New("dial tcp 10.0.0.7:5432: refused"))
+	})
+	rec = doRequest(h, http.MethodGet, "/")
+	if strings.Contains(rec.Body.String(), "10.0.0.7") || !strings.Contains(rec.Body.String(), "Bad Gateway") {
+		t.Errorf("5xx body = %s, want status text without the cause", rec.Body.String())
+	}
+	if r, ok := capture.hasError("http handler error"); !ok || !strings.Contains(r.attrs["error"], "10.0.0.7") {
+		t.Errorf("log = %+v, want the cause in the error log", r)
+	}
+}
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("Content-Type = %q, want application/problem+json", ct)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode problem: %v (%s)", err, rec.Body.String())
+	}
+	return body
+}
+
+func TestProblemErrorHandler(t *testing.T) {
+	_, restore := useCaptureLogger(false)
+	defer restore()
+
+	tests := []struct {
+		name string
+		err  error
+		want map[string]any
+		omit []string
+	}{
+		{
+			name: "api error",
+			err: NewAPIError(http.StatusConflict, "order already paid").
+				WithType("https://errors.example.com/order-state", "Invalid order state").
+				WithExtension("order_id", "o-1").
+				WithExtension("status", "ignored"),
+			want: map[string]any{
+				"type": "https://errors.example.com/order-state", "title": "Invalid order state",
+				"status": float64(409), "detail": "order already paid", "order_id": "o-1", "instance": "req-1",
+			},
+		},
+		{
+			name: "plain 5xx hides detail",
+			err:  errors.New("pq: password authentication failed"),
+			want: map[string]any{"type": "about:blank", "title": "Internal Server Error", "status": float64(500)},
+			omit: []string{"detail"},
+		},
+		{
+			name: "status error 4xx keeps detail",
+			err:  fmt.Errorf("lookup: %w", &notFoundError{msg: "user not found"}),
+			want: map[string]any{"status": float64(404), "detail": "lookup: user not found"},
+		},
+		{
+			name: "deadline",
+			err:  fmt.Errorf("query: %w", context.DeadlineExceeded),
+			want: map[string]any{"status": float64(503), "detail": "request timeout"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := WithRequestID()(NewErrorHandler(ProblemErrorHandler, func(context.Context, http.ResponseWriter, *http.Request) error {
+				return tt.err
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(RequestIDHeader, "req-1")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			body := decodeProblem(t, rec)
+			for k, v := range tt.want {
+				if body[k] != v {
+					t.Errorf("%s = %v, want %v (body %v)", k, body[k], v, body)
+				}
+			}
+			for _, k := range tt.omit {
+				if _, ok := body[k]; ok {
+					t.Errorf("%s present in %v", k, body)
+				}
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	ProblemErrorHandler(context.Background(), rec, httptest.NewRequest(http.MethodPost, "/", nil),
+		NewAPIError(http.StatusBadRequest, "validation failed").WithField("email", "required"))
+	fields, _ := decodeProblem(t, rec)["errors"].([]any)
+	if len(fields) != 1 || fields[0].(map[string]any)["field"] != "email" {
+		t.Errorf("errors = %v, want the email field", fields)
+	}
+}
+
+// TestServerErrorHandler：WithErrorHandler 作用于 NewErrorHandler(nil)、
+// Recovery、RateLimit 与 Timeout 的缺省响应；框架拒绝不记 Error 日志。
+func TestServerErrorHandler(t *testing.T) {
+	capture, restore := useCaptureLogger(false)
+	defer restore()
+
+	r := NewRouter()
+	r.Get("/err", NewErrorHandler(nil, func(context.Context, http.ResponseWriter, *http.Request) error {
+		return errors.New("secret internals")
+	}).ServeHTTP)
+	r.Get("/panic", func(http.ResponseWriter, *http.Request) { panic("boom") })
+	r.Get("/limited", func(http.ResponseWriter, *http.Request) {}, RateLimit(1, WithBurst(1)))
+	r.Get("/slow", func(_ http.ResponseWriter, r *http.Request) { <-r.Context().Done() }, Timeout(time.Millisecond))
+	srv := NewServer(r, WithErrorHandler(ProblemErrorHandler), WithMiddleware(Recovery()))
+	h := srv.buildHandler(context.Background())
+
+	for _, tt := range []struct {
+		target string
+		status int
+	}{
+		{"/err", http.StatusInternalServerError},
+		{"/panic", http.StatusInternalServerError},
+		{"/slow", http.StatusServiceUnavailable},
+	} {
+		rec := serve(h, http.MethodGet, tt.target)
+		if body := decodeProblem(t, rec); body["status"] != float64(tt.status) {
+			t.Errorf("%s: problem = %v, want status %d", tt.target, body, tt.status)
+		}
+		if strings.Contains(rec.Body.String(), "secret") || strings.Contains(rec.Body.String(), "boom") {
+			t.Errorf("%s: body leaks internals: %s", tt.target, rec.Body.String())
+		}
+	}
+	serve(h, http.MethodGet, "/limited")
+	capture.records = nil
+	rec := serve(h, http.MethodGet, "/limited")
+	if body := decodeProblem(t, rec); body["status"] != float64(http.StatusTooManyRequests) || body["detail"] != "rate limit exceeded" {
+		t.Errorf("rate limited problem = %v", body)
+	}
+	if _, ok := capture.hasError("http handler error"); ok {
+		t.Error("rate limit rejection logged as an error")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	h := Timeout(time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		if r.URL.Path == "/partial" {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	rec := serve(h, http.MethodGet, "/")
+	if rec.Code != http.StatusServiceUnavailable || strings.TrimSpace(rec.Body.String()) != `{"error":{"message":"request timeout"}}` {
+		t.Errorf("timeout response = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(h, http.MethodGet, "/partial"); rec.Code != http.StatusAccepted {
+		t.Errorf("started response rewritten: status = %d, want 202", rec.Code)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Timeout(0) did not panic")
+		}
+	}()
+	Timeout(0)
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lynx-go/lynx/server/auth"
+)
+
+type storeFunc func(ctx context.Context, key string) (auth.APIKey, bool, error)
+
+func (f storeFunc) Lookup(ctx context.Context, key string) (auth.APIKey, bool, error) {
+	return f(ctx, key)
+}
+
+func apiKeyRequest(h http.Handler, target, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if key != "" {
+		req.Header.Set(APIKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	keys, err := auth.NewStaticAPIKeys([]auth.APIKeyEntry{
+		{APIKey: auth.APIKey{ID: "worker", Subject: "svc-worker", Scopes: []string{"orders:read"}}, Key: "k-worker"},
+		{APIKey: auth.APIKey{ID: "anon"}, Key: "k-anon"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter(APIKeyAuth(keys, WithAuthExemptPaths("/healthz")))
+	r.Get("/orders", func(w http.ResponseWriter, r *http.Request) {
+		k, _ := auth.APIKeyFrom(r.Context())
+		_, _ = w.Write([]byte(k.ID))
+	}, RequireScopes("orders:read"))
+	r.Get("/healthz", func(http.ResponseWriter, *http.Request) {})
+
+	var entry *Entry
+	h := NewHandler(loggerFunc(func(e *Entry) { entry = e }), r)
+
+	rec := apiKeyRequest(h, "/orders", "k-worker")
+	if rec.Code != http.StatusOK || rec.Body.String() != "worker" {
+		t.Fatalf("status = %d body = %q", rec.Code, rec.Body)
+	}
+	if entry.APIKeyID != "worker" || entry.UserID != "svc-worker" {
+		t.Fatalf("request log entry = %+v", entry)
+	}
+
+	for key, status := range map[string]int{
+		"":       http.StatusUnauthorized,
+		"wrong":  http.StatusUnauthorized,
+		"k-anon": http.StatusForbidden,
+	} {
+		if rec := apiKeyRequest(h, "/orders", key); rec.Code != status {
+			t.Errorf("key %q: status = %d, want %d", key, rec.Code, status)
+		}
+	}
+	if rec := apiKeyRequest(h, "/healthz", ""); rec.Code != http.StatusOK {
+		t.Fatalf("exempt path status = %d", rec.Code)
+	}
+}
+
+func TestAPIKeyAuthStoreError(t *testing.T) {
+	store := storeFunc(func(context.Context, string) (auth.APIKey, bool, error) {
+		return auth.APIKey{}, false, errors.New("store down")
+	})
+	h := APIKeyAuth(store, WithOptionalAuth())(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	if rec := apiKeyRequest(h, "/", "k"); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("store error status = %d", rec.Code)
+	}
+	if rec := apiKeyRequest(h, "/", ""); rec.Code != http.StatusOK {
+		t.Fatalf("optional status = %d", rec.Code)
+	}
+}
+
+func TestOptionsFromConfigAPIKeys(t *testing.T) {
+	opts, err := OptionsFromConfig(configFromYAML(t, `
+server:
+  http:
+    api_keys:
+      keys:
+        - id: ci
+          key: k-ci
+          scopes: [deploy]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}), opts...)
+	h := srv.buildHandler(context.Background())
+	if rec := apiKeyRequest(h, "/", "k-ci"); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if rec := apiKeyRequest(h, "/", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("missing key status = %d", rec.Code)
+	}
+
+	for _, doc := range []string{
+		"server:\n  http:\n    api_keys:\n      optional: true\n",
+		"server:\n  http:\n    api_keys:\n      file: /nonexistent/keys.json\n",
+	} {
+		if _, err := OptionsFromConfig(configFromYAML(t, doc)); err == nil {
+			t.Errorf("config accepted:\n%s", doc)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/lynx-go/lynx/logging"
+	"github.com/lynx-go/lynx/server/auth"
+)
+
+// AuthOption 用于配置 JWTAuth 与 APIKeyAuth 中间件的选项函数。
+type AuthOption func(*authOptions)
+
+type authOptions struct {
+	token    func(r *http.Request) string
+	optional bool
+	exempt   []string
+}
+
+// WithTokenFunc 设置提取令牌的函数，缺省读取 "Authorization: Bearer"
+// （JWTAuth）或 X-API-Key（APIKeyAuth）请求头。返回空串表示请求未携带
+// 令牌。
+func WithTokenFunc(fn func(r *http.Request) string) AuthOption {
+	return func(o *authOptions) {
+		o.token = fn
+	}
+}
+
+// WithOptionalAuth 允许请求不携带令牌（匿名访问）：此时不设置声明，交由
+// 分组级 RequireScopes/RequireRoles 决定是否拒绝；携带了无效令牌的请求
+// 仍然拒绝。
+func WithOptionalAuth() AuthOption {
+	return func(o *authOptions) {
+		o.optional = true
+	}
+}
+
+// WithAuthExemptPaths 设置免认证的路径：以 "/" 结尾时按前缀匹配，否则
+// 精确匹配。
+func WithAuthExemptPaths(paths ...string) AuthOption {
+	return func(o *authOptions) {
+		o.exempt = append(o.exempt, paths...)
+	}
+}
+
+// BearerToken 返回 "Authorization: Bearer <token>" 中的令牌，不存在时返回
+// 空串。
+func BearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// JWTAuth 返回 Bearer 令牌认证中间件：以 v 校验令牌（签名、iss/aud/exp/
+// nbf，见 auth.Verifier），声明经 auth.FromContext / auth.ClaimsAs 提供给
+// handler；sub 写入 logging.FieldUserID 日志属性，请求链内的业务日志、
+// request log、client/http、client/grpc 与 pubsub 发布因而自动携带并传播
+// user_id。
+//
+// 缺少令牌（WithOptionalAuth 除外）或令牌无效时，经服务器级 ErrorHandler
+// 写 ErrUnauthorized（401），并设置 WWW-Authenticate（RFC 6750）。
+// 分组级的权限要求用 RequireScopes/RequireRoles：
+//
+//	r := http.NewRouter(http.JWTAuth(verifier))
+//	admin := r.Group("/admin", http.RequireRoles("admin"))
+func JWTAuth(v *auth.Verifier, opts ...AuthOption) Middleware {
+	o := authOptions{token: BearerToken}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if pathAllowed(o.exempt, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			token := o.token(r)
+			if token == "" {
+				if o.optional {
+					next.ServeHTTP(w, r)
+					return
+				}
+				unauthorized(w, r, "")
+				return
+			}
+			claims, err := v.Verify(r.Context(), token)
+			if err != nil {
+				slog.DebugContext(r.Context(), "http: token rejected", "path", r.URL.Path, "client_ip", ClientIP(r).String(), "error", err)
+				unauthorized(w, r, "invalid_token")
+				return
+			}
+			ctx := auth.NewContext(r.Context(), claims)
+			if claims.Subject != "" {
+				ctx = logging.WithAttrs(ctx, slog.String(logging.FieldUserID, claims.Subject))
+				if h, ok := ctx.Value(keyRoute).(*routeHolder); ok {
+					h.userID = claims.Subject
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScopes 返回要求声明包含全部 scopes 的中间件，用于分组或路由：
+// 未认证时写 ErrUnauthorized（401），缺少 scope 时写 ErrForbidden（403，
+// WWW-Authenticate 带 error="insufficient_scope"）。须位于 JWTAuth 之内。
+func RequireScopes(scopes ...string) Middleware {
+	return requireClaims(func(c *auth.Claims) bool {
+		for _, s := range scopes {
+			if !c.HasScope(s) {
+				return false
+			}
+		}
+		return true
+	}, `, scope="`+strings.Join(scopes, " ")+`"`)
+}
+
+// RequireRoles 返回要求声明包含 roles 之一的中间件，语义同
+// RequireScopes。
+func RequireRoles(roles ...string) Middleware {
+	return requireClaims(func(c *auth.Claims) bool {
+		for _, role := range roles {
+			if c.HasRole(role) {
+				return true
+			}
+		}
+		return false
+	}, "")
+}
+
+func requireClaims(allowed func(*auth.Claims) bool, challenge string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.FromContext(r.Context())
+			if !ok {
+				unauthorized(w, r, "")
+				return
+			}
+			if !allowed(claims) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`+challenge)
+				writeError(w, r, ErrForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// unauthorized 写 401；code 为 RFC 6750 错误码，未携带凭据时为空。
+func unauthorized(w http.ResponseWriter, r *http.Request, code string) {
+	challenge := "Bearer"
+	if code != "" {
+		challenge += ` error="` + code + `"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	writeError(w, r, ErrUnauthorized)
+}
@@ -0,0 +1,157 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lynx-go/lynx/logging"
+	"github.com/lynx-go/lynx/server/auth"
+)
+
+var jwtSecret = strings.Repeat("j", 32)
+
+func hs256Token(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	enc := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+	}
+	signed := enc(map[string]string{"alg": auth.AlgHS256, "typ": "JWT"}) + "." + enc(claims)
+	m := hmac.New(sha256.New, []byte(jwtSecret))
+	m.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+func testVerifier(t *testing.T) *auth.Verifier {
+	t.Helper()
+	v, err := auth.NewVerifier(auth.VerifierOptions{Secret: jwtSecret, Audience: []string{"api"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func authRequest(h http.Handler, target, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestJWTAuth(t *testing.T) {
+	capture, restore := useCaptureLogger(true)
+	defer restore()
+
+	r := NewRouter(JWTAuth(testVerifier(t), WithAuthExemptPaths("/public/")))
+	r.Get("/me", func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := auth.FromContext(r.Context())
+		if !ok {
+			t.Error("claims missing")
+			return
+		}
+		slog.InfoContext(r.Context(), "handled")
+		_, _ = w.Write([]byte(claims.Subject))
+	})
+	r.Get("/public/ping", func(http.ResponseWriter, *http.Request) {})
+
+	var entry *Entry
+	h := NewHandler(loggerFunc(func(e *Entry) { entry = e }), r)
+
+	rec := authRequest(h, "/me", hs256Token(t, map[string]any{"sub": "u-1", "aud": "api"}))
+	if rec.Code != http.StatusOK || rec.Body.String() != "u-1" {
+		t.Fatalf("status = %d body = %q", rec.Code, rec.Body)
+	}
+	if entry == nil || entry.UserID != "u-1" {
+		t.Fatalf("request log entry = %+v", entry)
+	}
+	if len(capture.records) != 1 || capture.records[0].attrs[logging.FieldUserID] != "u-1" {
+		t.Fatalf("log records = %+v", capture.records)
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		challenge string
+	}{
+		{"missing", "", "Bearer"},
+		{"wrong audience", hs256Token(t, map[string]any{"sub": "u-1", "aud": "other"}), `Bearer error="invalid_token"`},
+		{"expired", hs256Token(t, map[string]any{"sub": "u-1", "aud": "api", "exp": time.Now().Add(-time.Hour).Unix()}), `Bearer error="invalid_token"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := authRequest(h, "/me", tt.token)
+			if rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") != tt.challenge {
+				t.Fatalf("status = %d WWW-Authenticate = %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+	if rec := authRequest(h, "/public/ping", ""); rec.Code != http.StatusOK {
+		t.Fatalf("exempt path status = %d", rec.Code)
+	}
+}
+
+func TestRequireScopesAndRoles(t *testing.T) {
+	r := NewRouter(JWTAuth(testVerifier(t), WithOptionalAuth()))
+	r.Get("/open", func(http.ResponseWriter, *http.Request) {})
+	orders := r.Group("/orders", RequireScopes("orders:read"))
+	orders.Get("/", func(http.ResponseWriter, *http.Request) {})
+	admin := r.Group("/admin", RequireRoles("admin", "ops"))
+	admin.Get("/stats", func(http.ResponseWriter, *http.Request) {})
+
+	reader := hs256Token(t, map[string]any{"sub": "u-1", "aud": "api", "scope": "orders:read"})
+	ops := hs256Token(t, map[string]any{"sub": "u-2", "aud": "api", "roles": []string{"ops"}})
+	tests := []struct {
+		target, token string
+		status        int
+	}{
+		{"/open", "", http.StatusOK},
+		{"/open", "garbage", http.StatusUnauthorized},
+		{"/orders/", "", http.StatusUnauthorized},
+		{"/orders/", reader, http.StatusOK},
+		{"/orders/", ops, http.StatusForbidden},
+		{"/admin/stats", ops, http.StatusOK},
+		{"/admin/stats", reader, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		rec := authRequest(r, tt.target, tt.token)
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.target, rec.Code, tt.status)
+		}
+		if tt.status == http.StatusForbidden && tt.target == "/orders/" &&
+			rec.Header().Get("WWW-Authenticate") != `Bearer error="insufficient_scope", scope="orders:read"` {
+			t.Errorf("WWW-Authenticate = %q", rec.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	for header, want := range map[string]string{
+		"Bearer abc":  "abc",
+		"bearer  abc": "abc",
+		"Basic abc":   "",
+		"Bearer":      "",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", header)
+		if got := BearerToken(req); got != want {
+			t.Errorf("BearerToken(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package http
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 绑定请求参数的结构体标签：path 取路由通配符（r.PathValue），query 取
+// 查询参数，header 取请求头；请求体按 json 等编解码器标签解码。
+const (
+	tagPath     = "path"
+	tagQuery    = "query"
+	tagHeader   = "header"
+	tagValidate = "validate"
+)
+
+// paramField 是一个需要从路径、查询参数或请求头绑定的字段。
+type paramField struct {
+	index  []int
+	source string // tagPath、tagQuery 或 tagHeader
+	name   string
+}
+
+// ruleField 是一个带 validate 标签的字段。
+type ruleField struct {
+	index []int
+	name  string // 错误中的字段名
+	rules []rule
+	// nested 非 nil 时字段（或切片元素）是需要递归校验的结构体。
+	nested *structInfo
+	slice  bool
+}
+
+type rule struct {
+	name, arg string
+}
+
+// structInfo 缓存请求类型的绑定与校验元数据。
+type structInfo struct {
+	params []paramField
+	rules  []ruleField
+}
+
+var structInfos sync.Map // reflect.Type -> *structInfo
+
+// inspectStruct 解析 t（结构体类型）的标签；非结构体返回空元数据。
+// 规则非法（未知规则、参数类型错误）时 panic：与 ServeMux 的模式冲突
+// 一样属于编程错误，在注册路由时暴露。
+func inspectStruct(t reflect.Type) *structInfo {
+	if v, ok := structInfos.Load(t); ok {
+		return v.(*structInfo)
+	}
+	// 递归中途的元数据只记在本地：完整解析后才存入全局缓存，并发的首次
+	// 请求不会读到半成品，panic 也不会留下残缺的缓存项。
+	building := map[reflect.Type]*structInfo{}
+	buildStruct(t, building)
+	for typ, info := range building {
+		structInfos.LoadOrStore(typ, info)
+	}
+	v, _ := structInfos.Load(t)
+	return v.(*structInfo)
+}
+
+// buildStruct 解析 t 的元数据；building 记录本次解析中的类型，防止自引用
+// 类型无限递归。
+func buildStruct(t reflect.Type, building map[reflect.Type]*structInfo) *structInfo {
+	if v, ok := structInfos.Load(t); ok {
+		return v.(*structInfo)
+	}
+	if info, ok := building[t]; ok {
+		return info
+	}
+	info := &structInfo{}
+	building[t] = info
+	if t.Kind() == reflect.Struct {
+		for _, f := range reflect.VisibleFields(t) {
+			if !f.IsExported() || f.Anonymous {
+				continue
+			}
+			for _, src := range []string{tagPath, tagQuery, tagHeader} {
+				if name := f.Tag.Get(src); name != "" && name != "-" {
+					info.params = append(info.params, paramField{index: f.Index, source: src, name: name})
+				}
+			}
+			rf := ruleField{index: f.Index, name: fieldName(f)}
+			if tag := f.Tag.Get(tagValidate); tag != "" && tag != "-" {
+				rf.rules = parseRules(t, f, tag)
+			}
+			elem := f.Type
+			if elem.Kind() == reflect.Slice {
+				elem, rf.slice = elem.Elem(), true
+			}
+			for elem.Kind() == reflect.Pointer {
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.Struct && elem != reflect.TypeFor[time.Time]() {
+				_, cyclic := building[elem]
+				// 自引用类型的规则尚未解析完，无法判断是否需要递归，保守地保留。
+				if n := buildStruct(elem, building); len(n.rules) > 0 || cyclic {
+					rf.nested = n
+				}
+			}
+			if len(rf.rules) > 0 || rf.nested != nil {
+				info.rules = append(info.rules, rf)
+			}
+		}
+	}
+	return info
+}
+
+// fieldName 返回错误中使用的字段名：参数标签名、json 名，最后是字段名。
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{tagPath, tagQuery, tagHeader, "json"} {
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+var validRules = map[string]bool{"required": true, "min": true, "max": true, "len": true, "oneof": true, "pattern": true}
+
+func parseRules(t reflect.Type, f reflect.StructField, tag string) []rule {
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if !validRules[name] {
+			panic(fmt.Sprintf("http: %s.%s: unknown validate rule %q", t, f.Name, name))
+		}
+		switch name {
+		case "min", "max", "len":
+			if _, err := strconv.ParseFloat(arg, 64); err != nil {
+				panic(fmt.Sprintf("http: %s.%s: validate rule %s needs a number, got %q", t, f.Name, name, arg))
+			}
+		case "pattern":
+			regexpCache.LoadOrStore(arg, regexp.MustCompile(arg))
+		}
+		rules = append(rules, rule{name: name, arg: arg})
+	}
+	return rules
+}
+
+var regexpCache sync.Map // string -> *regexp.Regexp
+
+// bindParams 把路径、查询参数与请求头写入 v（结构体指针指向的值），
+// 返回全部转换失败的字段。
+func bindParams(r *http.Request, v reflect.Value, info *structInfo) []FieldError {
+	var errs []FieldError
+	query := r.URL.Query()
+	for _, p := range info.params {
+		var values []string
+		switch p.source {
+		case tagPath:
+			if s := r.PathValue(p.name); s != "" {
+				values = []string{s}
+			}
+		case tagQuery:
+			values = query[p.name]
+		case tagHeader:
+			values = r.Header.Values(p.name)
+		}
+		if len(values) == 0 {
+			continue
+		}
+		f := fieldByIndex(v, p.index, true)
+		if !f.IsValid() {
+			continue
+		}
+		if err := setField(f, values); err != nil {
+			errs = append(errs, FieldError{Field: p.name, Message: err.Error(), Code: "invalid"})
+		}
+	}
+	return errs
+}
+
+// fieldByIndex 与 reflect.Value.FieldByIndex 相同，但途经 nil 嵌入指针时
+// 不 panic：alloc 为 true 时分配零值结构体，否则（或嵌入字段未导出、
+// 无法分配时）返回零 Value。
+func fieldByIndex(v reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc || !v.CanSet() {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+var typeTextUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// setField 把字符串值转换后写入字段：支持字符串、布尔、整数、浮点、
+// time.Duration、encoding.TextUnmarshaler（含 time.Time）、它们的指针与
+// 切片（切片接收全部值，逗号分隔的单值同样拆分）。
+func setField(f reflect.Value, values []string) error {
+	if f.Kind() == reflect.Slice && !f.Type().Implements(typeTextUnmarshaler) && f.Type().Elem().Kind() != reflect.Uint8 {
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		s := reflect.MakeSlice(f.Type(), len(values), len(values))
+		for i, val := range values {
+			if err := setScalar(s.Index(i), strings.TrimSpace(val)); err != nil {
+				return err
+			}
+		}
+		f.Set(s)
+		return nil
+	}
+	return setScalar(f, values[0])
+}
+
+func setScalar(f reflect.Value, s string) error {
+	if f.Kind() == reflect.Pointer {
+		if f.IsNil() {
+			f.Set(reflect.New(f.Type().Elem()))
+		}
+		return setScalar(f.Elem(), s)
+	}
+	if f.CanAddr() && f.Addr().Type().Implements(typeTextUnmarshaler) {
+		if err := f.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("invalid value %q", s)
+		}
+		return nil
+	}
+	if f.Type() == reflect.TypeFor[time.Duration]() {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", s)
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, f.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+	return nil
+}
+
+// validateStruct 按 validate 标签校验 v（结构体值），字段名以 prefix
+// 为前缀，返回全部失败的字段。
+//
+// 支持的规则（逗号分隔）：
+//   - required：非零值（指针非 nil、字符串/切片/map 非空）；
+//   - min=N / max=N：数值的取值范围，字符串、切片、map 的长度范围；
+//   - len=N：字符串、切片、map 的长度；
+//   - oneof=a b c：取值为空格分隔的候选之一；
+//   - pattern=RE：字符串匹配正则（RE 中不能含逗号）。
+//
+// 除 required 外，零值指针跳过其余规则；嵌套结构体及其切片递归校验，
+// 字段名形如 "items[0].qty"。
+func validateStruct(v reflect.Value, info *structInfo, prefix string) []FieldError {
+	var errs []FieldError
+	for _, rf := range info.rules {
+		f := fieldByIndex(v, rf.index, false)
+		if !f.IsValid() {
+			continue // 途经 nil 嵌入指针，没有可校验的值
+		}
+		name := prefix + rf.name
+		if msg, code := checkRules(f, rf.rules); msg != "" {
+			errs = append(errs, FieldError{Field: name, Message: msg, Code: code})
+			continue
+		}
+		if rf.nested == nil {
+			continue
+		}
+		if rf.slice {
+			for i := 0; i < f.Len(); i++ {
+				if e := indirect(f.Index(i)); e.IsValid() {
+					errs = append(errs, validateStruct(e, rf.nested, fmt.Sprintf("%s[%d].", name, i))...)
+				}
+			}
+		} else if e := indirect(f); e.IsValid() {
+			errs = append(errs, validateStruct(e, rf.nested, name+".")...)
+		}
+	}
+	return errs
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// checkRules 返回第一条失败规则的说明与规则名，全部通过时返回空串。
+func checkRules(f reflect.Value, rules []rule) (string, string) {
+	for _, r := range rules {
+		if r.name == "required" {
+			if f.IsZero() {
+				return "is required", r.name
+			}
+			continue
+		}
+		v := indirect(f)
+		if !v.IsValid() {
+			continue
+		}
+		if msg := checkRule(v, r); msg != "" {
+			return msg, r.name
+		}
+	}
+	return "", ""
+}
+
+func checkRule(v reflect.Value, r rule) string {
+	n, _ := strconv.ParseFloat(r.arg, 64)
+	size, isLen := measure(v)
+	switch r.name {
+	case "min":
+		if size < n {
+			if isLen {
+				return fmt.Sprintf("length must be at least %s", r.arg)
+			}
+			return fmt.Sprintf("must be at least %s", r.arg)
+		}
+	case "max":
+		if size > n {
+			if isLen {
+				return fmt.Sprintf("length must be at most %s", r.arg)
+			}
+			return fmt.Sprintf("must be at most %s", r.arg)
+		}
+	case "len":
+		if size != n {
+			return fmt.Sprintf("length must be %s", r.arg)
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, opt := range strings.Fields(r.arg) {
+			if s == opt {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of [%s]", r.arg)
+	case "pattern":
+		re, _ := regexpCache.Load(r.arg)
+		if v.Kind() == reflect.String && !re.(*regexp.Regexp).MatchString(v.String()) {
+			return fmt.Sprintf("must match %s", r.arg)
+		}
+	}
+	return ""
+}
+
+// measure 返回用于 min/max/len 比较的量：数值本身，或字符串（按字符）、
+// 切片、map 的长度。
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(len([]rune(v.String()))), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false
+	}
+	return 0, false
+}
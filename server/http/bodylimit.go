@@ -0,0 +1,264 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// DefaultMinRateGrace 是 BodyLimitOptions.MinRate 生效前的缺省宽限时间。
+const DefaultMinRateGrace = 5 * time.Second
+
+// 请求体被拒绝的原因，即指标 lynx.http.request.body.rejected 的 reason
+// 属性。
+const (
+	bodyTooLarge = "too_large"
+	bodyTimeout  = "timeout"
+	bodyTooSlow  = "too_slow"
+)
+
+// BodyLimitOptions 配置请求体限制（见 BodyLimit），可作为配置段解码。
+type BodyLimitOptions struct {
+	// MaxBytes 是请求体大小上限，0 表示不限制。Content-Length 超过上限
+	// 的请求不调用 handler，直接写 ErrBodyTooLarge（413）；分块传输的
+	// 请求体读取超过上限时返回 *http.MaxBytesError。
+	MaxBytes int64 `mapstructure:"max_bytes"`
+	// ReadTimeout 是自请求到达起读取请求体的截止时间，0 表示不设置。
+	// 设置后取代服务器的 WithReadTimeout 作用于该请求的请求体，上传路由
+	// 可据此放宽或收紧。
+	ReadTimeout time.Duration `mapstructure:"read_timeout"`
+	// MinRate 是读取请求体的最低吞吐（字节/秒），0 表示不限制。只计
+	// handler 阻塞在读取上的时间：经过 MinRateGrace 之后，已读字节数须
+	// 不低于 MinRate × 累计读取等待时间，否则视为慢速客户端断开。
+	MinRate int64 `mapstructure:"min_rate"`
+	// MinRateGrace 是 MinRate 生效前的宽限时间，缺省 DefaultMinRateGrace。
+	MinRateGrace time.Duration `mapstructure:"min_rate_grace"`
+	// MeterProvider 缺省取 otel.GetMeterProvider()。
+	MeterProvider metric.MeterProvider `mapstructure:"-"`
+}
+
+// BodyLimit 返回请求体限制中间件，通常挂在单个路由或分组上，为上传等
+// 路由设置独立的大小上限、读取截止时间与最低吞吐：
+//
+//	r.Post("/uploads", upload, http.BodyLimit(http.BodyLimitOptions{
+//		MaxBytes:    100 << 20,
+//		ReadTimeout: 10 * time.Minute,
+//		MinRate:     16 << 10,
+//	}))
+//
+// 读取超过 ReadTimeout 或低于 MinRate 时请求体读取返回 ErrBodyTimeout，
+// handler 返回且尚未写响应时写 ErrBodyTimeout（408）；超过 MaxBytes 时
+// 同样写 ErrBodyTooLarge（413）。截止时间经 http.ResponseController 设置
+// 在连接上，阻塞中的读取同样会被中断（底层不支持时在每次读取返回后
+// 检查）。被拒绝的请求计入指标 lynx.http.request.body.rejected（属性
+// reason 为 too_large、timeout 或 too_slow）。配置非法时 panic。
+func BodyLimit(o BodyLimitOptions) Middleware {
+	l, err := newBodyLimiter(o)
+	if err != nil {
+		panic(err)
+	}
+	return l.middleware
+}
+
+type bodyLimiter struct {
+	o        BodyLimitOptions
+	rejected metric.Int64Counter
+}
+
+func newBodyLimiter(o BodyLimitOptions) (*bodyLimiter, error) {
+	if o.MaxBytes < 0 || o.ReadTimeout < 0 || o.MinRate < 0 || o.MinRateGrace < 0 {
+		return nil, errors.New("http: body limit values must be >= 0")
+	}
+	if o.MinRateGrace == 0 {
+		o.MinRateGrace = DefaultMinRateGrace
+	}
+	if o.MeterProvider == nil {
+		o.MeterProvider = otel.GetMeterProvider()
+	}
+	rejected, err := o.MeterProvider.Meter("github.com/lynx-go/lynx/server/http").Int64Counter("lynx.http.request.body.rejected",
+		metric.WithDescription("Requests rejected because the body was too large or arrived too slowly, by reason."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &bodyLimiter{o: o, rejected: rejected}, nil
+}
+
+func (l *bodyLimiter) reject(ctx context.Context, reason string) {
+	l.rejected.Add(ctx, 1, metric.WithAttributes(attribute.String("reason", reason)))
+}
+
+func (l *bodyLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if l.o.MaxBytes > 0 && r.ContentLength > l.o.MaxBytes {
+			l.reject(r.Context(), bodyTooLarge)
+			writeError(w, r, ErrBodyTooLarge)
+			return
+		}
+		b := &limitedBody{ReadCloser: r.Body, l: l, ctx: r.Context(), ctl: http.NewResponseController(w), start: time.Now()}
+		if l.o.MaxBytes > 0 {
+			b.ReadCloser = http.MaxBytesReader(w, r.Body, l.o.MaxBytes)
+		}
+		r.Body = b
+		tw := &trackedWriter{ResponseWriter: w}
+		next.ServeHTTP(tw, r)
+		b.clearDeadline()
+		if b.rejected != nil && !tw.headerWritten() {
+			writeError(tw, r, b.rejected)
+		}
+	})
+}
+
+// limitedBody 在读取请求体时执行 BodyLimitOptions 的限制。
+type limitedBody struct {
+	io.ReadCloser
+	l     *bodyLimiter
+	ctx   context.Context
+	ctl   *http.ResponseController
+	start time.Time
+	// n 是已读字节数，waited 是累计阻塞在读取上的时间。
+	n      int64
+	waited time.Duration
+	// deadlineSet 标记已在连接上设置截止时间，noDeadline 标记底层不支持，
+	// eof 标记请求体已读完。
+	deadlineSet bool
+	noDeadline  bool
+	eof         bool
+	// rejected 是拒绝原因对应的错误，超时后读取一直返回它。
+	rejected error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if errors.Is(b.rejected, ErrBodyTimeout) {
+		return 0, b.rejected
+	}
+	if b.eof {
+		// 请求体读完后 net/http 在连接上发起后台读取（并清除截止时间），
+		// 此时再设置截止时间会使其超时而取消请求 Context。
+		return b.ReadCloser.Read(p)
+	}
+	now := time.Now()
+	deadline, reason := b.deadline(now)
+	if !deadline.IsZero() && !b.noDeadline {
+		if err := b.ctl.SetReadDeadline(deadline); err != nil {
+			b.noDeadline = true
+		} else {
+			b.deadlineSet = true
+		}
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	b.waited += time.Since(now)
+
+	var mbe *http.MaxBytesError
+	switch {
+	case errors.As(err, &mbe):
+		b.fail(bodyTooLarge, ErrBodyTooLarge)
+	case !deadline.IsZero() && (isTimeout(err) || (err == nil && time.Now().After(deadline))):
+		b.fail(reason, ErrBodyTimeout)
+		return n, b.rejected
+	case errors.Is(err, io.EOF):
+		b.eof = true
+	}
+	return n, err
+}
+
+// deadline 返回本次读取的截止时间与超时时的拒绝原因，不限制时为零值。
+func (b *limitedBody) deadline(now time.Time) (time.Time, string) {
+	var (
+		d      time.Time
+		reason string
+	)
+	if b.l.o.ReadTimeout > 0 {
+		d, reason = b.start.Add(b.l.o.ReadTimeout), bodyTimeout
+	}
+	if rate := b.l.o.MinRate; rate > 0 {
+		// 已读 n 字节允许累计等待 grace + n/rate，扣除已等待的时间即本次
+		// 读取的预算。
+		budget := b.l.o.MinRateGrace + time.Duration(float64(b.n)/float64(rate)*float64(time.Second)) - b.waited
+		if t := now.Add(budget); d.IsZero() || t.Before(d) {
+			d, reason = t, bodyTooSlow
+		}
+	}
+	return d, reason
+}
+
+func (b *limitedBody) fail(reason string, err error) {
+	if b.rejected != nil {
+		return
+	}
+	b.rejected = err
+	b.l.reject(b.ctx, reason)
+}
+
+func (b *limitedBody) clearDeadline() {
+	if b.deadlineSet {
+		b.deadlineSet = false
+		_ = b.ctl.SetReadDeadline(time.Time{})
+	}
+}
+
+func isTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// newBodyLimits 按 BodyLimitsConfig 构造中间件：请求路径匹配的第一条
+// 路由规则生效，未匹配时使用缺省限制。
+func newBodyLimits(c BodyLimitsConfig) (Middleware, error) {
+	def, err := newBodyLimiter(c.BodyLimitOptions)
+	if err != nil {
+		return nil, err
+	}
+	type route struct {
+		path string
+		l    *bodyLimiter
+	}
+	routes := make([]route, 0, len(c.Routes))
+	for i, rc := range c.Routes {
+		if rc.Path == "" {
+			return nil, fmt.Errorf("http: body_limits route %d: path is required", i)
+		}
+		l, err := newBodyLimiter(rc.BodyLimitOptions)
+		if err != nil {
+			return nil, fmt.Errorf("http: body_limits route %q: %w", rc.Path, err)
+		}
+		routes = append(routes, route{path: rc.Path, l: l})
+	}
+	return func(next http.Handler) http.Handler {
+		handlers := make([]http.Handler, len(routes))
+		for i, rt := range routes {
+			handlers[i] = rt.l.middleware(next)
+		}
+		fallback := def.middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for i, rt := range routes {
+				if pathAllowed([]string{rt.path}, r.URL.Path) {
+					handlers[i].ServeHTTP(w, r)
+					return
+				}
+			}
+			fallback.ServeHTTP(w, r)
+		})
+	}, nil
+}
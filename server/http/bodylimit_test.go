@@ -0,0 +1,219 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// bodyRejections returns lynx.http.request.body.rejected by reason.
+func bodyRejections(t *testing.T, reader *sdkmetric.ManualReader) map[string]int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok || m.Name != "lynx.http.request.body.rejected" {
+				continue
+			}
+			for _, dp := range sum.DataPoints {
+				reason, _ := dp.Attributes.Value("reason")
+				counts[reason.AsString()] += dp.Value
+			}
+		}
+	}
+	return counts
+}
+
+// readAllHandler reads the body and answers with its length; on a read
+// error it writes nothing so that BodyLimit writes the rejection.
+func readAllHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprint(w, len(data))
+}
+
+func TestBodyLimitMaxBytes(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	called := false
+	h := BodyLimit(BodyLimitOptions{MaxBytes: 8, MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			readAllHandler(w, r)
+		}))
+
+	// Content-Length 超限：不调用 handler。
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("0123456789")))
+	if rec.Code != http.StatusRequestEntityTooLarge || called {
+		t.Errorf("Content-Length over limit: status = %d, handler called = %v, want 413 without handler", rec.Code, called)
+	}
+
+	// 未知长度：读取超限时写 413。
+	req := httptest.NewRequest(http.MethodPost, "/", io.NopCloser(strings.NewReader("0123456789")))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("chunked over limit: status = %d, want 413", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("01234567")))
+	if rec.Code != http.StatusOK || rec.Body.String() != "8" {
+		t.Errorf("within limit: status = %d, body = %q", rec.Code, rec.Body)
+	}
+
+	if got := bodyRejections(t, reader)[bodyTooLarge]; got != 2 {
+		t.Errorf("rejected{too_large} = %d, want 2", got)
+	}
+}
+
+// slowUpload sends headers announcing size bytes, then only part of the
+// body, and returns the response status.
+func slowUpload(t *testing.T, addr string, size int) int {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	_, err = fmt.Fprintf(conn, "POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: %d\r\n\r\n0123456789", size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("ReadResponse() error = %v", err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestBodyLimitSlowClient(t *testing.T) {
+	tests := []struct {
+		name   string
+		o      BodyLimitOptions
+		reason string
+	}{
+		{"min rate", BodyLimitOptions{MinRate: 1 << 20, MinRateGrace: 100 * time.Millisecond}, bodyTooSlow},
+		{"read timeout", BodyLimitOptions{ReadTimeout: 200 * time.Millisecond}, bodyTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			tt.o.MeterProvider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+			readErr := make(chan error, 1)
+			srv := httptest.NewServer(BodyLimit(tt.o)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err := io.ReadAll(r.Body)
+				readErr <- err
+			})))
+			defer srv.Close()
+
+			start := time.Now()
+			if code := slowUpload(t, srv.Listener.Addr().String(), 1<<20); code != http.StatusRequestTimeout {
+				t.Errorf("status = %d, want 408", code)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("rejected after %v, want well before the client gave up", elapsed)
+			}
+			if err := <-readErr; !errors.Is(err, ErrBodyTimeout) {
+				t.Errorf("body read error = %v, want ErrBodyTimeout", err)
+			}
+			if got := bodyRejections(t, reader)[tt.reason]; got != 1 {
+				t.Errorf("rejected{%s} = %d, want 1", tt.reason, got)
+			}
+		})
+	}
+}
+
+// TestBodyLimitDeadlineClearedAfterBody 回归：请求体读完后不再设置截止
+// 时间，handler 继续处理超过截止时间也不会被取消。
+func TestBodyLimitDeadlineClearedAfterBody(t *testing.T) {
+	srv := httptest.NewServer(BodyLimit(BodyLimitOptions{ReadTimeout: 100 * time.Millisecond})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := io.ReadAll(r.Body); err != nil {
+				return
+			}
+			_, _ = r.Body.Read(make([]byte, 1)) // 解码器常在 EOF 之后再读一次
+			time.Sleep(300 * time.Millisecond)
+			if err := r.Context().Err(); err != nil {
+				t.Errorf("request context error = %v after the body was read", err)
+			}
+			_, _ = io.WriteString(w, "ok")
+		})))
+	defer srv.Close()
+
+	for i := 0; i < 2; i++ { // 第二次复用 keep-alive 连接
+		resp, err := http.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d status = %d, want 200", i, resp.StatusCode)
+		}
+	}
+}
+
+func TestBodyLimitInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("BodyLimit with negative MaxBytes did not panic")
+		}
+	}()
+	BodyLimit(BodyLimitOptions{MaxBytes: -1})
+}
+
+func TestBodyLimitsRoutes(t *testing.T) {
+	mw, err := newBodyLimits(BodyLimitsConfig{
+		BodyLimitOptions: BodyLimitOptions{MaxBytes: 4},
+		Routes: []BodyLimitRoute{
+			{Path: "/uploads/", BodyLimitOptions: BodyLimitOptions{MaxBytes: 16}},
+			{Path: "/raw"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw(http.HandlerFunc(readAllHandler))
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/orders", http.StatusRequestEntityTooLarge},
+		{"/uploads/a", http.StatusOK},
+		{"/raw", http.StatusOK},
+		{"/raw/x", http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader("0123456789")))
+		if rec.Code != tt.want {
+			t.Errorf("POST %s status = %d, want %d", tt.path, rec.Code, tt.want)
+		}
+	}
+
+	if _, err := newBodyLimits(BodyLimitsConfig{Routes: []BodyLimitRoute{{}}}); err == nil {
+		t.Error("route without path accepted")
+	}
+}
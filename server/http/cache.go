@@ -0,0 +1,621 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// CacheStatusHeader 标记响应是否来自缓存（"HIT" 或 "MISS"）。
+const CacheStatusHeader = "X-Cache"
+
+// ResponseCache 的缺省值。
+const (
+	// DefaultCacheMaxBytes 是缓存总大小上限。
+	DefaultCacheMaxBytes = 64 << 20
+	// DefaultCacheMaxEntryBytes 是单个响应体上限，更大的响应不缓存。
+	DefaultCacheMaxEntryBytes = 1 << 20
+)
+
+// cacheableStatus 是可缓存的状态码（RFC 9110 §15.1 默认可缓存的子集）。
+var cacheableStatus = []int{
+	http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusMultipleChoices,
+	http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone,
+}
+
+// CacheOption 用于配置 ResponseCache 的选项函数。
+type CacheOption func(*ResponseCache)
+
+// WithCacheMaxBytes 设置缓存总大小上限（响应体与响应头），缺省
+// DefaultCacheMaxBytes；超出时淘汰最久未访问的条目（LRU）。
+func WithCacheMaxBytes(n int64) CacheOption {
+	return func(c *ResponseCache) {
+		c.maxBytes = n
+	}
+}
+
+// WithCacheMaxEntryBytes 设置单个响应体上限，缺省
+// DefaultCacheMaxEntryBytes。超出上限的响应改为直接流式写出，不缓存。
+func WithCacheMaxEntryBytes(n int64) CacheOption {
+	return func(c *ResponseCache) {
+		c.maxEntryBytes = n
+	}
+}
+
+// WithCacheDefaultTTL 设置响应未指定 max-age/s-maxage 时的缓存时间，
+// 缺省 0（只缓存显式声明了新鲜期的响应）。
+func WithCacheDefaultTTL(d time.Duration) CacheOption {
+	return func(c *ResponseCache) {
+		c.defaultTTL = d
+	}
+}
+
+// WithCacheKeyHeaders 设置参与缓存键的请求头（如 "Accept"、
+// "Accept-Language"）。响应的 Vary 列出其他请求头时不缓存。
+func WithCacheKeyHeaders(names ...string) CacheOption {
+	return func(c *ResponseCache) {
+		for _, n := range names {
+			c.keyHeaders = append(c.keyHeaders, http.CanonicalHeaderKey(n))
+		}
+	}
+}
+
+// WithCacheCredentialHeaders 替换标识调用方身份的请求头，缺省为
+// Authorization、Cookie 与 APIKeyHeader（X-API-Key）。携带其中任一请求头
+// 的请求只缓存声明 public 或 s-maxage 的响应；自定义认证头部（如经
+// WithTokenFunc 改读其他头部的 API key）需在此列出。
+func WithCacheCredentialHeaders(names ...string) CacheOption {
+	return func(c *ResponseCache) {
+		c.credHeaders = nil
+		for _, n := range names {
+			c.credHeaders = append(c.credHeaders, http.CanonicalHeaderKey(n))
+		}
+	}
+}
+
+// WithCacheName 设置指标属性 cache=<name>，缺省 "default"。
+func WithCacheName(name string) CacheOption {
+	return func(c *ResponseCache) {
+		c.name = name
+	}
+}
+
+// WithCacheMeterProvider 设置导出指标的 MeterProvider，缺省取
+// otel.GetMeterProvider()。
+func WithCacheMeterProvider(mp metric.MeterProvider) CacheOption {
+	return func(c *ResponseCache) {
+		c.meterProvider = mp
+	}
+}
+
+// CacheStats 是 ResponseCache 的累计统计。
+type CacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+	Bytes   int64
+}
+
+// ResponseCache 是 GET/HEAD 响应的进程内缓存，经 Middleware 挂到路由或
+// 分组上，并发安全：
+//   - 缓存键为路径、查询参数（规范化排序）与 WithCacheKeyHeaders 选定的
+//     请求头；HEAD 读取 GET 的条目；
+//   - 遵循 Cache-Control：请求 no-store 绕过缓存，no-cache 或 max-age=0
+//     强制回源并刷新条目；响应 no-store、no-cache、private、带 Set-Cookie
+//     或 Vary 包含未选定的请求头时不缓存；新鲜期取 s-maxage、max-age，
+//     均无时取 WithCacheDefaultTTL。带身份凭据（Authorization、Cookie、
+//     X-API-Key，见 WithCacheCredentialHeaders）的请求只缓存声明 public
+//     或 s-maxage 的响应；
+//   - 同一键的并发未命中只执行一次 handler（singleflight），其余请求
+//     等待并共享结果；
+//   - 缓存的响应缺少 ETag 时自动生成，If-None-Match 匹配时返回 304；
+//   - 不安全方法（POST 等）的请求完成后，同一路径的条目失效；其他数据
+//     变更由 handler 调用 Invalidate/InvalidatePrefix/Purge。
+//
+// handler 的响应先缓冲再写出，SSE 等流式响应在首次 Flush 时改为直接
+// 写出且不缓存。
+//
+// 导出的 otel 指标（属性 cache=<name>）：
+//   - lynx.http.cache.lookups：查找次数（counter，result=hit|miss）；
+//   - lynx.http.cache.entries、lynx.http.cache.size：条目数与字节数（gauge）。
+type ResponseCache struct {
+	name          string
+	maxBytes      int64
+	maxEntryBytes int64
+	defaultTTL    time.Duration
+	keyHeaders    []string
+	credHeaders   []string
+	meterProvider metric.MeterProvider
+	now           func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // 前端最近访问
+	size    int64
+	flights map[string]*cacheFlight
+	hits    int64
+	misses  int64
+
+	lookups metric.Int64Counter
+	attr    metric.MeasurementOption
+}
+
+type cacheEntry struct {
+	key     string
+	path    string
+	status  int
+	header  http.Header
+	body    []byte
+	etag    string
+	stored  time.Time
+	expires time.Time
+	size    int64
+}
+
+// cacheFlight 是一次进行中的回源，entry 在 done 关闭后可读，响应不可
+// 缓存时为 nil。
+type cacheFlight struct {
+	done  chan struct{}
+	entry *cacheEntry
+}
+
+// NewResponseCache 创建响应缓存。指标注册失败时返回错误。
+func NewResponseCache(opts ...CacheOption) (*ResponseCache, error) {
+	c := &ResponseCache{
+		name:          "default",
+		maxBytes:      DefaultCacheMaxBytes,
+		maxEntryBytes: DefaultCacheMaxEntryBytes,
+		now:           time.Now,
+		entries:       map[string]*list.Element{},
+		order:         list.New(),
+		flights:       map[string]*cacheFlight{},
+		credHeaders:   []string{"Authorization", "Cookie", APIKeyHeader},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.meterProvider == nil {
+		c.meterProvider = otel.GetMeterProvider()
+	}
+	if err := c.initMetrics(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *ResponseCache) initMetrics() error {
+	meter := c.meterProvider.Meter("github.com/lynx-go/lynx/server/http")
+	attrs := attribute.NewSet(attribute.String("cache", c.name))
+	c.attr = metric.WithAttributeSet(attrs)
+	var err error
+	if c.lookups, err = meter.Int64Counter("lynx.http.cache.lookups",
+		metric.WithDescription("Response cache lookups by result."),
+		metric.WithUnit("{lookup}"),
+	); err != nil {
+		return err
+	}
+	entries, err := meter.Int64ObservableGauge("lynx.http.cache.entries",
+		metric.WithDescription("Responses currently cached."),
+		metric.WithUnit("{entry}"),
+	)
+	if err != nil {
+		return err
+	}
+	size, err := meter.Int64ObservableGauge("lynx.http.cache.size",
+		metric.WithDescription("Bytes currently cached."),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		s := c.Stats()
+		o.ObserveInt64(entries, int64(s.Entries), metric.WithAttributeSet(attrs))
+		o.ObserveInt64(size, s.Bytes, metric.WithAttributeSet(attrs))
+		return nil
+	}, entries, size)
+	return err
+}
+
+// Stats 返回累计命中、未命中次数与当前条目数、字节数。
+func (c *ResponseCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries), Bytes: c.size}
+}
+
+// Invalidate 删除路径为 path 的全部条目（任意查询参数与请求头）。
+func (c *ResponseCache) Invalidate(path string) {
+	c.removeWhere(func(e *cacheEntry) bool { return e.path == path })
+}
+
+// InvalidatePrefix 删除路径以 prefix 开头的全部条目。
+func (c *ResponseCache) InvalidatePrefix(prefix string) {
+	c.removeWhere(func(e *cacheEntry) bool { return strings.HasPrefix(e.path, prefix) })
+}
+
+// Purge 清空缓存。
+func (c *ResponseCache) Purge() {
+	c.removeWhere(func(*cacheEntry) bool { return true })
+}
+
+func (c *ResponseCache) removeWhere(match func(*cacheEntry) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, el := range c.entries {
+		if match(el.Value.(*cacheEntry)) {
+			c.removeLocked(el)
+		}
+	}
+}
+
+func (c *ResponseCache) removeLocked(el *list.Element) {
+	e := el.Value.(*cacheEntry)
+	c.order.Remove(el)
+	delete(c.entries, e.key)
+	c.size -= e.size
+}
+
+// Middleware 返回使用该缓存的中间件。
+func (c *ResponseCache) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				c.Invalidate(r.URL.Path)
+				return
+			}
+			reqCC := parseCacheControl(r.Header.Values("Cache-Control"))
+			if reqCC.has("no-store") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := c.key(r)
+			revalidate := reqCC.has("no-cache") || reqCC["max-age"] == "0"
+			if !revalidate {
+				if e := c.lookup(key, reqCC); e != nil {
+					c.record(r.Context(), true)
+					c.serve(w, r, e, "HIT")
+					return
+				}
+			}
+			c.record(r.Context(), false)
+			if r.Method == http.MethodHead {
+				// HEAD 的响应没有响应体，不能作为 GET 的条目。
+				next.ServeHTTP(w, r)
+				return
+			}
+			c.fill(w, r, next, key, revalidate)
+		})
+	}
+}
+
+// fill 回源：同一键只有一个请求（leader）执行 handler，其余等待其结果；
+// 结果不可缓存时等待者各自执行 handler。
+func (c *ResponseCache) fill(w http.ResponseWriter, r *http.Request, next http.Handler, key string, revalidate bool) {
+	c.mu.Lock()
+	if f, ok := c.flights[key]; ok && !revalidate {
+		c.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-r.Context().Done():
+			return
+		}
+		if f.entry != nil {
+			c.serve(w, r, f.entry, "HIT")
+		} else {
+			next.ServeHTTP(w, r)
+		}
+		return
+	}
+	f := &cacheFlight{done: make(chan struct{})}
+	c.flights[key] = f
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		if c.flights[key] == f {
+			delete(c.flights, key)
+		}
+		c.mu.Unlock()
+		close(f.done)
+	}()
+
+	rec := &cacheRecorder{w: w, header: http.Header{}, max: c.maxEntryBytes}
+	next.ServeHTTP(rec, r)
+	if rec.passthrough {
+		return
+	}
+	if e := c.newEntry(r, key, rec); e != nil {
+		c.store(e)
+		f.entry = e
+		c.serve(w, r, e, "MISS")
+		return
+	}
+	rec.commit()
+}
+
+// newEntry 按 Cache-Control 判断响应是否可缓存，不可缓存时返回 nil。
+func (c *ResponseCache) newEntry(r *http.Request, key string, rec *cacheRecorder) *cacheEntry {
+	status := rec.status()
+	if !slices.Contains(cacheableStatus, status) || rec.header.Get("Set-Cookie") != "" {
+		return nil
+	}
+	cc := parseCacheControl(rec.header.Values("Cache-Control"))
+	if cc.has("no-store") || cc.has("no-cache") || cc.has("private") {
+		return nil
+	}
+	if c.hasCredentials(r) && !cc.has("public") && !cc.has("s-maxage") {
+		return nil
+	}
+	for _, v := range rec.header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" || (name != "" && name != "Accept-Encoding" && !slices.Contains(c.keyHeaders, name)) {
+				return nil
+			}
+		}
+	}
+	ttl := c.defaultTTL
+	if v, ok := cc["s-maxage"]; ok {
+		ttl = parseSeconds(v)
+	} else if v, ok := cc["max-age"]; ok {
+		ttl = parseSeconds(v)
+	}
+	if ttl <= 0 {
+		return nil
+	}
+	now := c.now()
+	body := rec.buf.Bytes()
+	header := rec.header.Clone()
+	etag := header.Get("ETag")
+	if etag == "" {
+		sum := sha256.Sum256(body)
+		etag = `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+		header.Set("ETag", etag)
+	}
+	return &cacheEntry{
+		key:     key,
+		path:    r.URL.Path,
+		status:  status,
+		header:  header,
+		body:    body,
+		etag:    etag,
+		stored:  now,
+		expires: now.Add(ttl),
+		size:    int64(len(key)+len(body)) + headerSize(header),
+	}
+}
+
+// hasCredentials 报告请求是否携带标识调用方身份的请求头。
+func (c *ResponseCache) hasCredentials(r *http.Request) bool {
+	for _, name := range c.credHeaders {
+		if r.Header.Get(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *ResponseCache) store(e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[e.key]; ok {
+		c.removeLocked(el)
+	}
+	if e.size > c.maxBytes {
+		return
+	}
+	c.entries[e.key] = c.order.PushFront(e)
+	c.size += e.size
+	for c.size > c.maxBytes {
+		c.removeLocked(c.order.Back())
+	}
+}
+
+// lookup 返回新鲜的条目；过期条目随即删除。
+func (c *ResponseCache) lookup(key string, reqCC cacheControl) *cacheEntry {
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	e := el.Value.(*cacheEntry)
+	if !now.Before(e.expires) {
+		c.removeLocked(el)
+		return nil
+	}
+	if v, ok := reqCC["max-age"]; ok && now.Sub(e.stored) > parseSeconds(v) {
+		return nil
+	}
+	c.order.MoveToFront(el)
+	return e
+}
+
+func (c *ResponseCache) record(ctx context.Context, hit bool) {
+	result := "miss"
+	c.mu.Lock()
+	if hit {
+		c.hits++
+		result = "hit"
+	} else {
+		c.misses++
+	}
+	c.mu.Unlock()
+	c.lookups.Add(ctx, 1, c.attr, metric.WithAttributes(attribute.String("result", result)))
+}
+
+// serve 写出条目；If-None-Match 匹配时写 304。
+func (c *ResponseCache) serve(w http.ResponseWriter, r *http.Request, e *cacheEntry, status string) {
+	h := w.Header()
+	for k, v := range e.header {
+		h[k] = slices.Clone(v)
+	}
+	h.Set(CacheStatusHeader, status)
+	h.Set("Age", strconv.Itoa(int(c.now().Sub(e.stored)/time.Second)))
+	if etagMatch(r.Header.Get("If-None-Match"), e.etag) {
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Length", strconv.Itoa(len(e.body)))
+	w.WriteHeader(e.status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(e.body)
+	}
+}
+
+// key 由路径、规范化的查询参数与选定的请求头组成；HEAD 与 GET 共用。
+func (c *ResponseCache) key(r *http.Request) string {
+	var b strings.Builder
+	b.WriteString(r.URL.Path)
+	b.WriteByte('?')
+	b.WriteString(r.URL.Query().Encode())
+	for _, name := range c.keyHeaders {
+		b.WriteByte(0)
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strings.Join(r.Header.Values(name), ","))
+	}
+	return b.String()
+}
+
+// etagMatch 按弱比较（RFC 9110 §13.1.2）判断 If-None-Match 是否匹配。
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(t), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheControl 是解析后的 Cache-Control 指令（名称小写，无值时为空串）。
+type cacheControl map[string]string
+
+func (cc cacheControl) has(name string) bool {
+	_, ok := cc[name]
+	return ok
+}
+
+func parseCacheControl(values []string) cacheControl {
+	cc := cacheControl{}
+	for _, v := range values {
+		for _, d := range strings.Split(v, ",") {
+			name, val, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if name != "" {
+				cc[strings.ToLower(name)] = strings.Trim(val, `"`)
+			}
+		}
+	}
+	return cc
+}
+
+func parseSeconds(s string) time.Duration {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+// cacheRecorder 缓冲 handler 的响应。响应体超过上限、Flush 或 Hijack
+// 时改为直接写出（passthrough），该响应不再缓存。
+type cacheRecorder struct {
+	w           http.ResponseWriter
+	header      http.Header
+	max         int64
+	code        int
+	buf         bytes.Buffer
+	passthrough bool
+}
+
+func (rec *cacheRecorder) Header() http.Header {
+	if rec.passthrough {
+		return rec.w.Header()
+	}
+	return rec.header
+}
+
+func (rec *cacheRecorder) WriteHeader(code int) {
+	if rec.passthrough {
+		rec.w.WriteHeader(code)
+		return
+	}
+	if rec.code == 0 {
+		rec.code = code
+	}
+}
+
+func (rec *cacheRecorder) Write(p []byte) (int, error) {
+	if rec.passthrough {
+		return rec.w.Write(p)
+	}
+	if rec.code == 0 {
+		rec.code = http.StatusOK
+	}
+	if int64(rec.buf.Len()+len(p)) > rec.max {
+		rec.commit()
+		return rec.w.Write(p)
+	}
+	return rec.buf.Write(p)
+}
+
+func (rec *cacheRecorder) status() int {
+	if rec.code == 0 {
+		return http.StatusOK
+	}
+	return rec.code
+}
+
+// commit 把已缓冲的响应写出并切换为 passthrough。
+func (rec *cacheRecorder) commit() {
+	if rec.passthrough {
+		return
+	}
+	rec.passthrough = true
+	h := rec.w.Header()
+	for k, v := range rec.header {
+		h[k] = v
+	}
+	rec.w.WriteHeader(rec.status())
+	if rec.buf.Len() > 0 {
+		_, _ = rec.w.Write(rec.buf.Bytes())
+	}
+	rec.buf = bytes.Buffer{}
+}
+
+func (rec *cacheRecorder) Flush() {
+	rec.commit()
+	_ = http.NewResponseController(rec.w).Flush()
+}
+
+func (rec *cacheRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	rec.passthrough = true
+	return http.NewResponseController(rec.w).Hijack()
+}
+
+func (rec *cacheRecorder) Unwrap() http.ResponseWriter { return rec.w }
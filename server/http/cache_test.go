@@ -0,0 +1,327 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func newTestCache(t *testing.T, opts ...CacheOption) *ResponseCache {
+	t.Helper()
+	c, err := NewResponseCache(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func cacheRequest(h http.Handler, method, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header[http.CanonicalHeaderKey(k)] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// countingHandler 以 Cache-Control cc 响应，正文含调用次数。
+func countingHandler(calls *atomic.Int32, cc string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if cc != "" {
+			w.Header().Set("Cache-Control", cc)
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%s #%d", r.URL.RequestURI(), n)
+	})
+}
+
+func TestResponseCacheHitMissAndETag(t *testing.T) {
+	c := newTestCache(t)
+	var calls atomic.Int32
+	h := c.Middleware()(countingHandler(&calls, "max-age=60"))
+
+	miss := cacheRequest(h, http.MethodGet, "/items?b=2&a=1", nil)
+	if miss.Code != http.StatusOK || miss.Header().Get(CacheStatusHeader) != "MISS" || miss.Body.String() != "/items?b=2&a=1 #1" {
+		t.Fatalf("miss: status = %d headers = %v body = %q", miss.Code, miss.Header(), miss.Body)
+	}
+	etag := miss.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag not generated")
+	}
+	// 查询参数顺序不同的请求命中同一条目。
+	hit := cacheRequest(h, http.MethodGet, "/items?a=1&b=2", nil)
+	if hit.Header().Get(CacheStatusHeader) != "HIT" || hit.Body.String() != miss.Body.String() || hit.Header().Get("Age") == "" {
+		t.Fatalf("hit: headers = %v body = %q", hit.Header(), hit.Body)
+	}
+	head := cacheRequest(h, http.MethodHead, "/items?a=1&b=2", nil)
+	if head.Header().Get(CacheStatusHeader) != "HIT" || head.Body.Len() != 0 {
+		t.Fatalf("HEAD: headers = %v body = %q", head.Header(), head.Body)
+	}
+	for _, inm := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		rec := cacheRequest(h, http.MethodGet, "/items?a=1&b=2", http.Header{"If-None-Match": {inm}})
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 || rec.Header().Get("ETag") != etag {
+			t.Errorf("If-None-Match %s: status = %d", inm, rec.Code)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("handler calls = %d, want 1", n)
+	}
+	if s := c.Stats(); s.Hits != 6 || s.Misses != 1 || s.Entries != 1 || s.Bytes <= 0 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestResponseCacheControl(t *testing.T) {
+	tests := []struct {
+		name      string
+		respCC    string
+		reqHeader http.Header
+		wantCalls int32
+	}{
+		{"max-age", "max-age=60", nil, 1},
+		{"s-maxage", "s-maxage=60", nil, 1},
+		{"no explicit ttl", "", nil, 2},
+		{"no-store", "no-store, max-age=60", nil, 2},
+		{"no-cache", "no-cache", nil, 2},
+		{"private", "private, max-age=60", nil, 2},
+		{"request no-store", "max-age=60", http.Header{"Cache-Control": {"no-store"}}, 2},
+		{"request no-cache", "max-age=60", http.Header{"Cache-Control": {"no-cache"}}, 2},
+		{"authorization", "max-age=60", http.Header{"Authorization": {"Bearer x"}}, 2},
+		{"authorization public", "public, max-age=60", http.Header{"Authorization": {"Bearer x"}}, 1},
+		{"cookie", "max-age=60", http.Header{"Cookie": {"session=s1"}}, 2},
+		{"api key", "max-age=60", http.Header{APIKeyHeader: {"k1"}}, 2},
+		{"api key s-maxage", "s-maxage=60", http.Header{APIKeyHeader: {"k1"}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			h := newTestCache(t).Middleware()(countingHandler(&calls, tt.respCC))
+			cacheRequest(h, http.MethodGet, "/x", tt.reqHeader)
+			cacheRequest(h, http.MethodGet, "/x", tt.reqHeader)
+			if n := calls.Load(); n != tt.wantCalls {
+				t.Fatalf("handler calls = %d, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+
+	var calls atomic.Int32
+	h := newTestCache(t, WithCacheDefaultTTL(time.Minute)).Middleware()(countingHandler(&calls, ""))
+	cacheRequest(h, http.MethodGet, "/x", nil)
+	cacheRequest(h, http.MethodGet, "/x", nil)
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("default TTL: handler calls = %d, want 1", n)
+	}
+}
+
+// TestResponseCacheCredentialHeaders：不同 API key 的响应互不可见（含
+// WithCacheDefaultTTL），自定义凭据头同样生效。
+func TestResponseCacheCredentialHeaders(t *testing.T) {
+	whoami := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "key=%s token=%s", r.Header.Get(APIKeyHeader), r.Header.Get("X-Token"))
+	})
+	h := newTestCache(t, WithCacheDefaultTTL(time.Minute)).Middleware()(whoami)
+	for _, key := range []string{"k1", "k2"} {
+		rec := cacheRequest(h, http.MethodGet, "/me", http.Header{APIKeyHeader: {key}})
+		if want := "key=" + key + " token="; rec.Body.String() != want {
+			t.Fatalf("%s: body = %q, want %q", key, rec.Body, want)
+		}
+	}
+
+	h = newTestCache(t, WithCacheDefaultTTL(time.Minute), WithCacheCredentialHeaders("X-Token")).Middleware()(whoami)
+	for _, token := range []string{"t1", "t2"} {
+		rec := cacheRequest(h, http.MethodGet, "/me", http.Header{"X-Token": {token}})
+		if want := "key= token=" + token; rec.Body.String() != want {
+			t.Fatalf("%s: body = %q, want %q", token, rec.Body, want)
+		}
+	}
+}
+
+func TestResponseCacheExpiryAndKeyHeaders(t *testing.T) {
+	c := newTestCache(t, WithCacheKeyHeaders("accept-language"))
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	var calls atomic.Int32
+	h := c.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "max-age=10")
+		w.Header().Set("Vary", "Accept-Language")
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	en := http.Header{"Accept-Language": {"en"}}
+	cacheRequest(h, http.MethodGet, "/x", en)
+	if rec := cacheRequest(h, http.MethodGet, "/x", http.Header{"Accept-Language": {"zh"}}); rec.Body.String() != "zh" {
+		t.Fatalf("key header ignored: body = %q", rec.Body)
+	}
+	cacheRequest(h, http.MethodGet, "/x", en)
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("handler calls = %d, want 2", n)
+	}
+	now = now.Add(11 * time.Second)
+	cacheRequest(h, http.MethodGet, "/x", en)
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("after expiry handler calls = %d, want 3", n)
+	}
+
+	// Vary 列出未选定的请求头时不缓存。
+	calls.Store(0)
+	h = newTestCache(t).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "max-age=10")
+		w.Header().Set("Vary", "Accept-Language")
+	}))
+	cacheRequest(h, http.MethodGet, "/x", nil)
+	cacheRequest(h, http.MethodGet, "/x", nil)
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("unselected Vary: handler calls = %d, want 2", n)
+	}
+}
+
+func TestResponseCacheSingleflight(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	h := newTestCache(t).Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("slow"))
+	}))
+	var wg sync.WaitGroup
+	bodies := make([]string, 10)
+	for i := range bodies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bodies[i] = cacheRequest(h, http.MethodGet, "/slow", nil).Body.String()
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("handler calls = %d, want 1", n)
+	}
+	for i, b := range bodies {
+		if b != "slow" {
+			t.Fatalf("request %d body = %q", i, b)
+		}
+	}
+}
+
+func TestResponseCacheInvalidation(t *testing.T) {
+	c := newTestCache(t)
+	var calls atomic.Int32
+	h := c.Middleware()(countingHandler(&calls, "max-age=60"))
+	get := func(target string) string { return cacheRequest(h, http.MethodGet, target, nil).Body.String() }
+
+	get("/orders/1")
+	get("/orders/1?expand=items")
+	get("/orders/2")
+	get("/users/1")
+	c.Invalidate("/orders/1")
+	if s := c.Stats(); s.Entries != 2 {
+		t.Fatalf("after Invalidate entries = %d, want 2", s.Entries)
+	}
+	c.InvalidatePrefix("/orders/")
+	if s := c.Stats(); s.Entries != 1 {
+		t.Fatalf("after InvalidatePrefix entries = %d, want 1", s.Entries)
+	}
+	// 不安全方法使同一路径失效。
+	cacheRequest(h, http.MethodPost, "/users/1", nil)
+	if s := c.Stats(); s.Entries != 0 {
+		t.Fatalf("after POST entries = %d, want 0", s.Entries)
+	}
+	get("/users/1")
+	c.Purge()
+	if s := c.Stats(); s.Entries != 0 || s.Bytes != 0 {
+		t.Fatalf("after Purge stats = %+v", s)
+	}
+}
+
+func TestResponseCacheBounds(t *testing.T) {
+	body := strings.Repeat("x", 100)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		if r.URL.Path == "/large" {
+			_, _ = w.Write([]byte(body))
+			_, _ = w.Write([]byte(body))
+			return
+		}
+		if r.URL.Path == "/stream" {
+			_, _ = w.Write([]byte("part"))
+			http.NewResponseController(w).Flush()
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	})
+	c := newTestCache(t, WithCacheMaxEntryBytes(150), WithCacheMaxBytes(400))
+	h := c.Middleware()(handler)
+
+	if rec := cacheRequest(h, http.MethodGet, "/large", nil); rec.Body.Len() != 200 {
+		t.Fatalf("large body = %d bytes", rec.Body.Len())
+	}
+	if rec := cacheRequest(h, http.MethodGet, "/stream", nil); rec.Body.String() != "part" || !rec.Flushed {
+		t.Fatalf("stream body = %q flushed = %v", rec.Body, rec.Flushed)
+	}
+	if s := c.Stats(); s.Entries != 0 {
+		t.Fatalf("oversized or streamed responses cached: %+v", s)
+	}
+	for i := range 5 {
+		cacheRequest(h, http.MethodGet, fmt.Sprintf("/p/%d", i), nil)
+	}
+	if s := c.Stats(); s.Bytes > 400 || s.Entries >= 5 {
+		t.Fatalf("size bound not enforced: %+v", s)
+	}
+	// 最近写入的条目保留。
+	if rec := cacheRequest(h, http.MethodGet, "/p/4", nil); rec.Header().Get(CacheStatusHeader) != "HIT" {
+		t.Fatal("most recent entry evicted")
+	}
+}
+
+func TestResponseCacheMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	c := newTestCache(t, WithCacheName("api"), WithCacheMeterProvider(mp))
+	var calls atomic.Int32
+	h := c.Middleware()(countingHandler(&calls, "max-age=60"))
+	cacheRequest(h, http.MethodGet, "/x", nil)
+	cacheRequest(h, http.MethodGet, "/x", nil)
+	cacheRequest(h, http.MethodGet, "/x", nil)
+
+	var md metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &md); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int64{}
+	for _, sm := range md.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				for _, dp := range data.DataPoints {
+					got[m.Name] = dp.Value
+				}
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					if v, _ := dp.Attributes.Value("cache"); v.AsString() != "api" {
+						continue
+					}
+					result, _ := dp.Attributes.Value("result")
+					got[m.Name+"."+result.AsString()] = dp.Value
+				}
+			}
+		}
+	}
+	if got["lynx.http.cache.lookups.hit"] != 2 || got["lynx.http.cache.lookups.miss"] != 1 || got["lynx.http.cache.entries"] != 1 {
+		t.Fatalf("metrics = %v", got)
+	}
+}
@@ -0,0 +1,203 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// DefaultClientIPHeaders 是缺省读取的转发头部。
+var DefaultClientIPHeaders = []string{"X-Forwarded-For"}
+
+// ClientIPOptions 配置客户端 IP 的解析（见 WithClientIP），可作为配置段
+// "server.http.client_ip" 解码。
+type ClientIPOptions struct {
+	// TrustedProxies 是受信任代理（ingress、负载均衡器）的 IP 或 CIDR，
+	// 如 "10.0.0.0/8"。只有直连对端是受信任代理时才读取转发头部。
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// Headers 是读取客户端 IP 的转发头部，按顺序取请求中第一个存在的，
+	// 支持 "X-Forwarded-For"、"Forwarded"（RFC 7239 的 for= 参数）与
+	// 单值头部（如 "X-Real-IP"）；缺省 DefaultClientIPHeaders。只应列出
+	// 受信任代理会设置或覆盖的头部：代理原样转发的头部可被客户端伪造。
+	Headers []string `mapstructure:"headers"`
+}
+
+// clientIPResolver 按受信任代理与转发头部解析客户端 IP。
+type clientIPResolver struct {
+	trusted []netip.Prefix
+	headers []string
+}
+
+func newClientIPResolver(o ClientIPOptions) (*clientIPResolver, error) {
+	c := &clientIPResolver{headers: o.Headers}
+	if len(c.headers) == 0 {
+		c.headers = DefaultClientIPHeaders
+	}
+	for _, s := range o.TrustedProxies {
+		p, err := parsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", s, err)
+		}
+		c.trusted = append(c.trusted, p)
+	}
+	return c, nil
+}
+
+// resolve 返回请求的客户端 IP：直连对端不受信任时即为对端地址，否则
+// 从转发头部自右向左跳过受信任代理，取第一个非受信任地址；全部受信任
+// 时取最左端地址，头部值非法时停在最后一个合法地址。
+func (c *clientIPResolver) resolve(r *http.Request) netip.Addr {
+	peer := remoteAddrIP(r)
+	if !peer.IsValid() || !isTrusted(peer, c.trusted) {
+		return peer
+	}
+	var hops []string
+	for _, name := range c.headers {
+		values := r.Header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		switch http.CanonicalHeaderKey(name) {
+		case "Forwarded":
+			hops = forwardedFor(values)
+		case "X-Forwarded-For":
+			for _, v := range values {
+				hops = append(hops, strings.Split(v, ",")...)
+			}
+		default:
+			// 单值头部（X-Real-IP 等）只取最后一个值：由最近的代理设置。
+			hops = values[len(values)-1:]
+		}
+		break
+	}
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, ok := parseHop(hops[i])
+		if !ok {
+			break
+		}
+		client = ip
+		if !isTrusted(client, c.trusted) {
+			break
+		}
+	}
+	return client
+}
+
+// forwardedFor 返回 Forwarded 头部各元素的 for= 参数值；缺少 for= 的元素
+// 记为空串（解析时停止）。
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, elem := range strings.Split(v, ",") {
+			hop := ""
+			for _, pair := range strings.Split(elem, ";") {
+				k, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(k, "for") {
+					hop = strings.Trim(val, `"`)
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// parseHop 解析转发头部中的地址："1.2.3.4"、"2001:db8::1"，以及带端口
+// 的 "1.2.3.4:80"、"[2001:db8::1]:80"、"[2001:db8::1]"。"unknown" 与
+// 混淆标识（"_hidden"）返回 false。
+func parseHop(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if ip, err := netip.ParseAddr(s); err == nil {
+		return ip.Unmap(), true
+	}
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().Unmap(), true
+	}
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		if ip, err := netip.ParseAddr(s[1 : len(s)-1]); err == nil {
+			return ip.Unmap(), true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// remoteAddrIP 返回直连对端的 IP，无法解析（如 Unix domain socket）时
+// 返回零值。
+func remoteAddrIP(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return ip.Unmap()
+}
+
+// parsePrefix 解析 IP 或 CIDR；单个 IP 视为全长前缀。
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		return p.Masked(), err
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	a = a.Unmap()
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+func isTrusted(ip netip.Addr, trusted []netip.Prefix) bool {
+	for _, p := range trusted {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+type clientIPCtx struct{}
+
+// ClientIPFrom 返回服务器经 WithClientIP 解析的客户端 IP；服务器未配置
+// WithClientIP 时返回 false。
+func ClientIPFrom(ctx context.Context) (netip.Addr, bool) {
+	ip, ok := ctx.Value(clientIPCtx{}).(netip.Addr)
+	return ip, ok
+}
+
+// ClientIP 返回请求的客户端 IP：服务器配置了 WithClientIP 时为其解析
+// 结果，否则为直连对端地址（启用 PROXY protocol 时即负载均衡器之前的
+// 客户端地址）。无法解析时返回零值。
+func ClientIP(r *http.Request) netip.Addr {
+	if ip, ok := ClientIPFrom(r.Context()); ok {
+		return ip
+	}
+	return remoteAddrIP(r)
+}
+
+// RealIP 返回按 o 解析客户端 IP 的中间件，结果经 ClientIPFrom/ClientIP
+// 提供。服务器经 WithClientIP 配置时在处理链最外层自动安装（request log
+// 同样使用解析结果），只在脱离 Server 单用 Router 时需要直接使用。
+// 配置非法时 panic。
+func RealIP(o ClientIPOptions) Middleware {
+	res, err := newClientIPResolver(o)
+	if err != nil {
+		panic("http: RealIP " + err.Error())
+	}
+	return res.middleware
+}
+
+func (c *clientIPResolver) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := c.resolve(r); ip.IsValid() {
+			r = r.WithContext(context.WithValue(r.Context(), clientIPCtx{}, ip))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
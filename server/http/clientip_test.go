@@ -0,0 +1,188 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/lynx-go/lynx/server/listener"
+)
+
+func TestClientIPResolve(t *testing.T) {
+	res, err := newClientIPResolver(ClientIPOptions{
+		TrustedProxies: []string{"10.0.0.0/8", "::1"},
+		Headers:        []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    string
+	}{
+		{"untrusted peer ignores headers", "203.0.113.9:80", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.9"},
+		{"xff skips trusted proxies", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"xff spoofed left entry", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "6.6.6.6, 198.51.100.1"}, "198.51.100.1"},
+		{"xff all trusted", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "10.1.1.1, 10.0.0.2"}, "10.1.1.1"},
+		{"xff invalid hop", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "garbage, 10.0.0.2"}, "10.0.0.2"},
+		{"forwarded quoted ipv6 with port", "10.0.0.1:80", map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https, for=10.0.0.2`}, "2001:db8::1"},
+		{"forwarded takes precedence", "10.0.0.1:80", map[string]string{"Forwarded": "for=192.0.2.60", "X-Forwarded-For": "198.51.100.1"}, "192.0.2.60"},
+		{"forwarded obfuscated", "10.0.0.1:80", map[string]string{"Forwarded": "for=_hidden"}, "10.0.0.1"},
+		{"x-real-ip", "[::1]:80", map[string]string{"X-Real-IP": "198.51.100.7"}, "198.51.100.7"},
+		{"no headers", "10.0.0.1:80", nil, "10.0.0.1"},
+		{"ipv4-mapped peer", "[::ffff:203.0.113.9]:80", nil, "203.0.113.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := res.resolve(r); got.String() != tt.want {
+				t.Errorf("resolve() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestClientIPDefaultHeaders 验证缺省只读取 X-Forwarded-For。
+func TestClientIPDefaultHeaders(t *testing.T) {
+	res, err := newClientIPResolver(ClientIPOptions{TrustedProxies: []string{"10.0.0.1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:80"
+	r.Header.Set("Forwarded", "for=6.6.6.6")
+	r.Header.Set("X-Real-IP", "6.6.6.6")
+	if got := res.resolve(r); got.String() != "10.0.0.1" {
+		t.Errorf("resolve() = %v, want peer address", got)
+	}
+}
+
+func TestClientIPFallback(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("X-Forwarded-For", "6.6.6.6")
+	if _, ok := ClientIPFrom(r.Context()); ok {
+		t.Error("ClientIPFrom() ok = true without RealIP")
+	}
+	if got := ClientIP(r); got.String() != "192.0.2.1" {
+		t.Errorf("ClientIP() = %v, want 192.0.2.1", got)
+	}
+	r.RemoteAddr = "@"
+	if got := ClientIP(r); got.IsValid() {
+		t.Errorf("ClientIP() = %v, want zero value", got)
+	}
+}
+
+func TestRealIPInvalidProxy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("RealIP with an invalid proxy did not panic")
+		}
+	}()
+	RealIP(ClientIPOptions{TrustedProxies: []string{"not-a-cidr"}})
+}
+
+// TestRealIPKeyByIP 验证 KeyByIP 优先使用 RealIP 解析的客户端 IP。
+func TestRealIPKeyByIP(t *testing.T) {
+	var key string
+	h := RealIP(ClientIPOptions{TrustedProxies: []string{"10.0.0.0/8"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = KeyByIP()(r)
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:80"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if key != "ip:198.51.100.1" {
+		t.Errorf("key = %q, want ip:198.51.100.1", key)
+	}
+}
+
+// TestServerClientIP 验证 WithClientIP 在处理链最外层解析客户端 IP。
+func TestServerClientIP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, ClientIP(r).String())
+	}), WithListener(ln), WithClientIP(ClientIPOptions{TrustedProxies: []string{"127.0.0.1"}}))
+	go func() { _ = srv.Start(context.Background()) }()
+	defer srv.Stop(context.Background())
+	waitAddrs(t, srv)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://"+ln.Addr().String()+"/", nil)
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "198.51.100.1" {
+		t.Errorf("ClientIP() = %q, want 198.51.100.1", body)
+	}
+}
+
+// TestServerProxyProtocol 验证 WithProxyProtocol 使 ClientIP 为 PROXY 头
+// 中的源地址。
+func TestServerProxyProtocol(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, ClientIP(r).String())
+	}), WithListener(ln), WithProxyProtocol(listener.ProxyProtocolOptions{TrustedSources: []string{"127.0.0.0/8"}}))
+	go func() { _ = srv.Start(context.Background()) }()
+	defer srv.Stop(context.Background())
+	waitAddrs(t, srv)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, err = io.WriteString(conn, "PROXY TCP4 203.0.113.7 192.0.2.1 51000 443\r\n"+
+		"GET / HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if got, want := string(body), netip.MustParseAddr("203.0.113.7").String(); got != want {
+		t.Errorf("ClientIP() = %q, want %s", got, want)
+	}
+}
+
+// TestServerProxyProtocolMissingHeader 验证缺少 PROXY 头的连接被关闭。
+func TestServerProxyProtocolMissingHeader(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(http.NotFoundHandler(), WithListener(ln), WithProxyProtocol(listener.ProxyProtocolOptions{}))
+	go func() { _ = srv.Start(context.Background()) }()
+	defer srv.Stop(context.Background())
+	waitAddrs(t, srv)
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/")
+	if err == nil {
+		_ = resp.Body.Close()
+		t.Fatalf("GET without PROXY header status = %d, want connection error", resp.StatusCode)
+	}
+}
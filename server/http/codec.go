@@ -0,0 +1,178 @@
+package http
+
+import (
+	"encoding/json"
+	"mime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Codec 编解码一种媒体类型的请求体与响应体。
+type Codec interface {
+	// ContentType 返回媒体类型，如 "application/json"（不含参数）。
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec 是 application/json 编解码器（encoding/json）。
+type JSONCodec struct{}
+
+// ContentType 实现 Codec。
+func (JSONCodec) ContentType() string { return "application/json" }
+
+// Marshal 实现 Codec。
+func (JSONCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal 实现 Codec。
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// Codecs 是按媒体类型索引的编解码器注册表，并发安全。第一个注册的编解码
+// 器是缺省编解码器：请求未声明 Content-Type 或 Accept 时使用。
+type Codecs struct {
+	mu     sync.RWMutex
+	order  []Codec
+	byType map[string]Codec
+}
+
+// NewCodecs 创建注册表并按顺序注册 codecs。
+func NewCodecs(codecs ...Codec) *Codecs {
+	c := &Codecs{byType: make(map[string]Codec)}
+	for _, codec := range codecs {
+		c.Register(codec)
+	}
+	return c
+}
+
+// DefaultCodecs 是 JSON 等类型化 handler 缺省使用的注册表，只含
+// JSONCodec；可在启动时 Register protobuf、msgpack 等编解码器。
+var DefaultCodecs = NewCodecs(JSONCodec{})
+
+// Register 注册编解码器，同一媒体类型后注册者覆盖先注册者（保持原有
+// 顺序位置）。
+func (c *Codecs) Register(codec Codec) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ct := strings.ToLower(codec.ContentType())
+	if _, ok := c.byType[ct]; ok {
+		for i, prev := range c.order {
+			if strings.EqualFold(prev.ContentType(), ct) {
+				c.order[i] = codec
+			}
+		}
+	} else {
+		c.order = append(c.order, codec)
+	}
+	c.byType[ct] = codec
+}
+
+// Lookup 按 Content-Type 头部（可带参数，如 "; charset=utf-8"）查找编解码
+// 器；header 为空时返回缺省编解码器。
+func (c *Codecs) Lookup(header string) (Codec, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if header == "" {
+		if len(c.order) == 0 {
+			return nil, false
+		}
+		return c.order[0], true
+	}
+	mt, _, err := mime.ParseMediaType(header)
+	if err != nil {
+		return nil, false
+	}
+	codec, ok := c.byType[mt]
+	return codec, ok
+}
+
+// ContentTypes 返回已注册的媒体类型，顺序同注册顺序。
+func (c *Codecs) ContentTypes() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]string, len(c.order))
+	for i, codec := range c.order {
+		out[i] = codec.ContentType()
+	}
+	return out
+}
+
+// Negotiate 按 Accept 头部（RFC 9110 q 值与 "*/*"、"type/*" 通配）选择
+// 响应编解码器：q 值高者优先，同 q 值时更具体的范围优先，再按注册顺序。
+// Accept 为空时返回缺省编解码器；没有可接受的类型时返回 false。
+func (c *Codecs) Negotiate(accept string) (Codec, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return c.Lookup("")
+	}
+	ranges := parseAccept(accept)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var (
+		best        Codec
+		bestQ       float64
+		bestSpecial int
+	)
+	for _, codec := range c.order {
+		ct := strings.ToLower(codec.ContentType())
+		q, specificity := -1.0, -1
+		for _, ar := range ranges {
+			if s := ar.match(ct); s > specificity {
+				q, specificity = ar.q, s
+			}
+		}
+		if specificity < 0 || q <= 0 {
+			continue
+		}
+		if best == nil || q > bestQ || (q == bestQ && specificity > bestSpecial) {
+			best, bestQ, bestSpecial = codec, q, specificity
+		}
+	}
+	return best, best != nil
+}
+
+// acceptRange 是 Accept 头部中的一个媒体范围。
+type acceptRange struct {
+	typ, sub string
+	q        float64
+}
+
+// match 返回 ct 与范围匹配的具体程度（2 精确、1 type/*、0 */*），不匹配
+// 时为 -1。
+func (a acceptRange) match(ct string) int {
+	typ, sub, _ := strings.Cut(ct, "/")
+	switch {
+	case a.typ == "*" && a.sub == "*":
+		return 0
+	case a.typ == typ && a.sub == "*":
+		return 1
+	case a.typ == typ && a.sub == sub:
+		return 2
+	}
+	return -1
+}
+
+func parseAccept(header string) []acceptRange {
+	var out []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			// "*" 是部分客户端发送的非标准写法。
+			if strings.TrimSpace(part) != "*" {
+				continue
+			}
+			mt, params = "*/*", nil
+		}
+		typ, sub, ok := strings.Cut(mt, "/")
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		out = append(out, acceptRange{typ: typ, sub: sub, q: q})
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package http
+
+import "testing"
+
+func TestCodecsNegotiate(t *testing.T) {
+	codecs := NewCodecs(JSONCodec{}, textCodec{})
+	tests := []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"", "application/json", true},
+		{"*/*", "application/json", true},
+		{"text/plain", "text/plain", true},
+		{"text/*, application/json", "application/json", true},
+		{"*/*;q=0.1, text/plain", "text/plain", true},
+		{"application/json;q=0, */*", "text/plain", true},
+		{"application/xml", "", false},
+	}
+	for _, tt := range tests {
+		c, ok := codecs.Negotiate(tt.accept)
+		if ok != tt.ok || (ok && c.ContentType() != tt.want) {
+			t.Errorf("Negotiate(%q) = %v, %v; want %q, %v", tt.accept, c, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCodecsLookup(t *testing.T) {
+	codecs := NewCodecs(JSONCodec{})
+	if c, ok := codecs.Lookup("Application/JSON; charset=utf-8"); !ok || c.ContentType() != "application/json" {
+		t.Fatalf("Lookup = %v, %v", c, ok)
+	}
+	if _, ok := codecs.Lookup("text/plain"); ok {
+		t.Fatal("Lookup(text/plain) found a codec")
+	}
+	codecs.Register(textCodec{})
+	if got := codecs.ContentTypes(); len(got) != 2 || got[1] != "text/plain" {
+		t.Fatalf("ContentTypes = %v", got)
+	}
+}
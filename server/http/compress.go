@@ -0,0 +1,471 @@
+package http
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// 支持的内容编码（Accept-Encoding / Content-Encoding 取值）。
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingZstd    = "zstd"
+)
+
+// 压缩中间件的缺省值。
+const (
+	// DefaultCompressMinSize 是压缩响应的最小字节数，更小的响应压缩收益
+	// 抵不过开销。
+	DefaultCompressMinSize = 1024
+	// DefaultMaxDecompressedBytes 是 Decompress 解压后请求体的缺省上限。
+	DefaultMaxDecompressedBytes = 10 << 20
+)
+
+var (
+	// DefaultCompressEncodings 是缺省启用的编码，按服务端偏好排序（客户端
+	// q 值相同时靠前者优先）。
+	DefaultCompressEncodings = []string{EncodingZstd, EncodingGzip, EncodingDeflate}
+	// DefaultCompressTypes 是缺省压缩的响应类型（"text/*" 按主类型匹配，
+	// "application/*+json" 按后缀匹配）。
+	DefaultCompressTypes = []string{
+		"text/*",
+		"application/json",
+		"application/*+json",
+		"application/javascript",
+		"application/xml",
+		"application/*+xml",
+		"application/x-ndjson",
+		"image/svg+xml",
+	}
+)
+
+// ErrUnsupportedEncoding 由 Decompress 在请求体使用未启用的
+// Content-Encoding 时使用（415，响应带 Accept-Encoding 列出支持的编码）。
+var ErrUnsupportedEncoding = &APIError{Status: http.StatusUnsupportedMediaType, Message: "unsupported content encoding", quiet: true}
+
+// CompressOptions 配置 Compress 中间件，字段带 mapstructure 标签，可直接
+// 作为 "server.http.compress" 配置段（见 Config）。
+type CompressOptions struct {
+	// Encodings 是启用的编码及服务端偏好顺序，缺省 DefaultCompressEncodings。
+	Encodings []string `mapstructure:"encodings"`
+	// MinSize 是压缩的最小响应字节数：0 取 DefaultCompressMinSize，负数表示
+	// 不设下限。显式 Flush 的流式响应不受此限制。
+	MinSize int `mapstructure:"min_size"`
+	// ContentTypes 是压缩的响应类型，缺省 DefaultCompressTypes；支持
+	// "text/*" 与 "application/*+json" 两种通配。text/event-stream 始终
+	// 不压缩。
+	ContentTypes []string `mapstructure:"content_types"`
+}
+
+// DecompressOptions 配置 Decompress 中间件，字段带 mapstructure 标签，可
+// 直接作为 "server.http.decompress" 配置段（见 Config）。
+type DecompressOptions struct {
+	// Encodings 是接受的请求体编码，缺省 DefaultCompressEncodings。
+	Encodings []string `mapstructure:"encodings"`
+	// MaxBytes 是解压后请求体的上限：0 取 DefaultMaxDecompressedBytes，
+	// 负数表示不限制（不建议，见 Decompress）。
+	MaxBytes int64 `mapstructure:"max_bytes"`
+}
+
+// Compress 返回响应压缩中间件：按 Accept-Encoding（含 q 值与 "*"）在
+// Encodings 中协商编码，响应类型命中 ContentTypes 且大小达到 MinSize 时
+// 压缩，设置 Content-Encoding 与 Vary: Accept-Encoding 并去掉
+// Content-Length，强 ETag 改为弱 ETag。
+//
+// 以下响应原样透传：HEAD 请求、已设置 Content-Encoding、
+// text/event-stream（SSE）、204/206/304 等状态以及 Hijack 的连接。包装后
+// 的 ResponseWriter 保留 Flusher/Hijacker 能力（Flush 会冲刷压缩器，流式
+// 响应可边压缩边发送），Unwrap 支持 http.ResponseController。
+//
+// Encodings 含未知编码时构造期 panic。
+func Compress(o CompressOptions) Middleware {
+	mw, err := newCompress(o)
+	if err != nil {
+		panic(err)
+	}
+	return mw
+}
+
+type compressor struct {
+	encodings []string
+	minSize   int
+	types     []string
+	pools     map[string]*sync.Pool
+}
+
+func newCompress(o CompressOptions) (Middleware, error) {
+	c := &compressor{minSize: o.MinSize, pools: map[string]*sync.Pool{}}
+	if c.minSize == 0 {
+		c.minSize = DefaultCompressMinSize
+	}
+	encodings := o.Encodings
+	if len(encodings) == 0 {
+		encodings = DefaultCompressEncodings
+	}
+	for _, enc := range encodings {
+		enc = strings.ToLower(strings.TrimSpace(enc))
+		newEncoder, ok := encoders[enc]
+		if !ok {
+			return nil, fmt.Errorf("http: unsupported compression encoding %q", enc)
+		}
+		c.encodings = append(c.encodings, enc)
+		c.pools[enc] = &sync.Pool{New: func() any { return newEncoder() }}
+	}
+	types := o.ContentTypes
+	if len(types) == 0 {
+		types = DefaultCompressTypes
+	}
+	for _, t := range types {
+		c.types = append(c.types, strings.ToLower(strings.TrimSpace(t)))
+	}
+	return c.middleware, nil
+}
+
+func (c *compressor) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		enc := negotiateEncoding(r.Header.Get("Accept-Encoding"), c.encodings)
+		if enc == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, c: c, encoding: enc}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressible 判断响应类型是否在压缩范围内。
+func (c *compressor) compressible(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mt == "text/event-stream" {
+		return false
+	}
+	typ, sub, _ := strings.Cut(mt, "/")
+	for _, t := range c.types {
+		ttyp, tsub, _ := strings.Cut(t, "/")
+		switch {
+		case t == mt:
+			return true
+		case tsub == "*" && ttyp == typ:
+			return true
+		case strings.HasPrefix(tsub, "*+") && ttyp == typ && strings.HasSuffix(sub, tsub[1:]):
+			return true
+		}
+	}
+	return false
+}
+
+// compressEncoder 是 gzip.Writer、flate.Writer 与 zstd.Encoder 的共同接口。
+type compressEncoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+var encoders = map[string]func() compressEncoder{
+	EncodingGzip: func() compressEncoder { return gzip.NewWriter(nil) },
+	EncodingDeflate: func() compressEncoder {
+		w, _ := flate.NewWriter(nil, flate.DefaultCompression)
+		return w
+	},
+	EncodingZstd: func() compressEncoder {
+		// 窗口限制在 8 MiB 以内以兼容浏览器解码器（RFC 8878 §3.1.1.1.2）。
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(1<<20))
+		return w
+	},
+}
+
+// negotiateEncoding 按 Accept-Encoding 从 supported（服务端偏好序）中选择
+// 编码：q 值高者优先，同 q 值按服务端偏好；没有可用编码时返回空串。
+func negotiateEncoding(header string, supported []string) string {
+	if header == "" {
+		return ""
+	}
+	qs := map[string]float64{}
+	wildcard := -1.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if k, v, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(k) == "q" {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				q = f
+			}
+		}
+		if name == "*" {
+			wildcard = q
+		} else if name != "" {
+			qs[name] = q
+		}
+	}
+	best, bestQ := "", 0.0
+	for _, enc := range supported {
+		q, ok := qs[enc]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// compressWriter 缓冲响应开头直到能判断是否压缩（达到 MinSize、Flush 或
+// handler 返回），再决定直接透传还是经压缩器写出。
+type compressWriter struct {
+	http.ResponseWriter
+	c        *compressor
+	encoding string
+
+	code     int
+	buf      []byte
+	decided  bool
+	hijacked bool
+	enc      compressEncoder
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided || w.hijacked {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.code == 0 {
+		w.code = code
+	}
+	switch code {
+	case http.StatusNoContent, http.StatusPartialContent, http.StatusNotModified, http.StatusSwitchingProtocols:
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.c.minSize {
+			return len(p), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// decide 决定是否压缩并写出响应头与已缓冲的数据；sized 表示响应大小已
+// 满足（或无需检查）MinSize。
+func (w *compressWriter) decide(sized bool) error {
+	w.decided = true
+	h := w.Header()
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 && h.Get("Content-Encoding") == "" {
+		// 与 net/http 一致地嗅探类型；压缩后底层 writer 无法再嗅探。
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	compress := sized && len(w.buf) > 0 && h.Get("Content-Encoding") == "" &&
+		w.code != http.StatusNoContent && w.code != http.StatusPartialContent &&
+		w.code != http.StatusNotModified && w.code != http.StatusSwitchingProtocols &&
+		w.c.compressible(h.Get("Content-Type"))
+	if compress {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		w.enc = w.c.pools[w.encoding].Get().(compressEncoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.code)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close 在 handler 返回后写出剩余缓冲并结束压缩流。
+func (w *compressWriter) close() {
+	if w.hijacked {
+		return
+	}
+	if !w.decided {
+		if w.code == 0 && len(w.buf) == 0 {
+			// handler 未写响应，交由 net/http 写缺省的 200。
+			return
+		}
+		_ = w.decide(len(w.buf) >= w.c.minSize)
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		w.enc.Reset(nil)
+		w.c.pools[w.encoding].Put(w.enc)
+		w.enc = nil
+	}
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// Flush 结束缓冲阶段（不再等待 MinSize），冲刷压缩器后冲刷底层连接。
+func (w *compressWriter) Flush() {
+	if !w.decided && !w.hijacked {
+		if w.code == 0 && len(w.buf) == 0 {
+			w.code = http.StatusOK
+		}
+		_ = w.decide(true)
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	if fl, ok := w.ResponseWriter.(http.Flusher); ok {
+		fl.Flush()
+	}
+}
+
+func (w *compressWriter) Hijack() (_ net.Conn, _ *bufio.ReadWriter, err error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("underlying ResponseWriter does not support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Decompress 返回请求体解压中间件：请求带 Content-Encoding（Encodings
+// 之一，"identity" 视为未编码）时以解压流替换 r.Body，去掉
+// Content-Encoding 与 Content-Length。
+//
+// 解压后超过 MaxBytes 时读取返回 *http.MaxBytesError（JSON 等类型化
+// handler 据此响应 413），以防压缩炸弹——几 KB 的请求体可解压出数 GB。
+// 不支持的编码或多重编码经服务器级 ErrorHandler 写 ErrUnsupportedEncoding
+// （415），压缩头部损坏时写 400。
+//
+// Encodings 含未知编码时构造期 panic。
+func Decompress(o DecompressOptions) Middleware {
+	mw, err := newDecompress(o)
+	if err != nil {
+		panic(err)
+	}
+	return mw
+}
+
+func newDecompress(o DecompressOptions) (Middleware, error) {
+	limit := o.MaxBytes
+	if limit == 0 {
+		limit = DefaultMaxDecompressedBytes
+	}
+	encodings := o.Encodings
+	if len(encodings) == 0 {
+		encodings = DefaultCompressEncodings
+	}
+	accepted := map[string]bool{}
+	for _, enc := range encodings {
+		enc = strings.ToLower(strings.TrimSpace(enc))
+		if _, ok := encoders[enc]; !ok {
+			return nil, fmt.Errorf("http: unsupported decompression encoding %q", enc)
+		}
+		accepted[enc] = true
+	}
+	acceptHeader := strings.Join(encodings, ", ")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			enc := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			if enc == "" || enc == "identity" || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !accepted[enc] {
+				w.Header().Set("Accept-Encoding", acceptHeader)
+				writeError(w, r, ErrUnsupportedEncoding)
+				return
+			}
+			body, err := newDecoder(enc, r.Body)
+			if err != nil {
+				writeError(w, r, NewAPIError(http.StatusBadRequest, "invalid compressed request body").WithCause(err))
+				return
+			}
+			defer body.Close()
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			r.Body = &decompressedBody{r: body, orig: r.Body, limit: limit}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func newDecoder(enc string, r io.Reader) (io.ReadCloser, error) {
+	switch enc {
+	case EncodingGzip:
+		return gzip.NewReader(r)
+	case EncodingDeflate:
+		return flate.NewReader(r), nil
+	case EncodingZstd:
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("unsupported encoding %q", enc)
+}
+
+// decompressedBody 限制解压后读取的总字节数；Close 关闭原始请求体。
+type decompressedBody struct {
+	r     io.Reader
+	orig  io.Closer
+	limit int64
+	n     int64
+}
+
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	if b.limit >= 0 {
+		if b.n > b.limit {
+			return 0, &http.MaxBytesError{Limit: b.limit}
+		}
+		// 多读 1 字节以区分“恰好等于上限”与“超过上限”。
+		if rest := b.limit + 1 - b.n; int64(len(p)) > rest {
+			p = p[:rest]
+		}
+	}
+	n, err := b.r.Read(p)
+	b.n += int64(n)
+	if b.limit >= 0 && b.n > b.limit {
+		return n - int(b.n-b.limit), &http.MaxBytesError{Limit: b.limit}
+	}
+	return n, err
+}
+
+func (b *decompressedBody) Close() error { return b.orig.Close() }
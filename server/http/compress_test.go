@@ -0,0 +1,305 @@
+package http
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func decode(t *testing.T, enc string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch enc {
+	case EncodingGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	case EncodingDeflate:
+		r = flate.NewReader(bytes.NewReader(body))
+	case EncodingZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return string(body)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func compressRequest(h http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCompressNegotiation(t *testing.T) {
+	payload := `{"items":"` + strings.Repeat("x", 4096) + `"}`
+	h := Compress(CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "4110")
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, payload)
+	}))
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"gzip, deflate, br, zstd", EncodingZstd},
+		{"gzip;q=1.0, zstd;q=0.5", EncodingGzip},
+		{"deflate", EncodingDeflate},
+		{"*", EncodingZstd},
+		{"*;q=0.5, gzip", EncodingGzip},
+		{"br", ""},
+		{"gzip;q=0", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			rec := compressRequest(h, tt.accept)
+			if got := rec.Header().Get("Content-Encoding"); got != tt.want {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.want)
+			}
+			if got := decode(t, tt.want, rec.Body.Bytes()); got != payload {
+				t.Fatalf("body mismatch (%d bytes)", len(got))
+			}
+			if rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Fatalf("Vary = %q", rec.Header().Get("Vary"))
+			}
+			wantETag, wantLen := `"v1"`, "4110"
+			if tt.want != "" {
+				wantETag, wantLen = `W/"v1"`, ""
+			}
+			if rec.Header().Get("ETag") != wantETag || rec.Header().Get("Content-Length") != wantLen {
+				t.Fatalf("ETag = %q Content-Length = %q", rec.Header().Get("ETag"), rec.Header().Get("Content-Length"))
+			}
+		})
+	}
+}
+
+func TestCompressSkips(t *testing.T) {
+	big := strings.Repeat("a", 2048)
+	tests := []struct {
+		name string
+		fn   http.HandlerFunc
+	}{
+		{"small", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = io.WriteString(w, "short")
+		}},
+		{"image", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = io.WriteString(w, big)
+		}},
+		{"already encoded", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Encoding", "br")
+			_, _ = io.WriteString(w, big)
+		}},
+		{"event stream", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, big)
+		}},
+		{"partial content", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = io.WriteString(w, big)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := compressRequest(Compress(CompressOptions{})(tt.fn), "gzip")
+			if enc := rec.Header().Get("Content-Encoding"); enc == EncodingGzip {
+				t.Fatal("response compressed")
+			}
+			if rec.Body.Len() != len(big) && rec.Body.String() != "short" {
+				t.Fatalf("body = %d bytes", rec.Body.Len())
+			}
+		})
+	}
+}
+
+func TestCompressSniffAndStatus(t *testing.T) {
+	h := Compress(CompressOptions{MinSize: -1})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, "<html><body>hi</body></html>")
+	}))
+	rec := compressRequest(h, "gzip")
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Encoding") != EncodingGzip ||
+		!strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("status = %d headers = %v", rec.Code, rec.Header())
+	}
+	if got := decode(t, EncodingGzip, rec.Body.Bytes()); got != "<html><body>hi</body></html>" {
+		t.Fatalf("body = %q", got)
+	}
+
+	// handler 不写响应时保持 net/http 的缺省行为。
+	rec = compressRequest(Compress(CompressOptions{})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})), "gzip")
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 || rec.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("empty response: status = %d headers = %v", rec.Code, rec.Header())
+	}
+}
+
+func TestCompressFlushStreams(t *testing.T) {
+	chunks := make(chan struct{})
+	h := Compress(CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = io.WriteString(w, `{"n":1}`+"\n")
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush: %v", err)
+		}
+		<-chunks
+		_, _ = io.WriteString(w, `{"n":2}`+"\n")
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != EncodingGzip {
+		t.Fatalf("Content-Encoding = %q", resp.Header.Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第一块在 handler 继续写之前即可解压读出。
+	line := make([]byte, 8)
+	if _, err := io.ReadFull(zr, line); err != nil || string(line) != `{"n":1}`+"\n" {
+		t.Fatalf("first chunk = %q, %v", line, err)
+	}
+	close(chunks)
+	rest, _ := io.ReadAll(zr)
+	if string(rest) != `{"n":2}`+"\n" {
+		t.Fatalf("rest = %q", rest)
+	}
+}
+
+func TestCompressHijack(t *testing.T) {
+	h := Compress(CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("Hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 2\r\nConnection: close\r\n\r\nok")
+		_ = rw.Flush()
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "ok" {
+		t.Fatalf("body = %q", body)
+	}
+}
+
+func encode(t *testing.T, enc, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := encoders[enc]()
+	w.Reset(&buf)
+	if _, err := io.WriteString(w, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	var got string
+	var readErr error
+	h := Decompress(DecompressOptions{MaxBytes: 64})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "" || r.ContentLength != -1 {
+			t.Errorf("headers not cleared: %v %d", r.Header, r.ContentLength)
+		}
+		b, err := io.ReadAll(r.Body)
+		got, readErr = string(b), err
+	}))
+	post := func(enc string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("Content-Encoding", enc)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, enc := range DefaultCompressEncodings {
+		got, readErr = "", nil
+		post(enc, encode(t, enc, strings.Repeat("b", 64)))
+		if readErr != nil || got != strings.Repeat("b", 64) {
+			t.Fatalf("%s: got %d bytes, err = %v", enc, len(got), readErr)
+		}
+
+		// 压缩炸弹：解压后超过上限时返回 *http.MaxBytesError。
+		got, readErr = "", nil
+		post(enc, encode(t, enc, strings.Repeat("b", 1<<20)))
+		var mbe *http.MaxBytesError
+		if !errors.As(readErr, &mbe) || len(got) != 64 {
+			t.Fatalf("%s bomb: got %d bytes, err = %v", enc, len(got), readErr)
+		}
+	}
+
+	rec := post("br", []byte("x"))
+	if rec.Code != http.StatusUnsupportedMediaType || rec.Header().Get("Accept-Encoding") != "zstd, gzip, deflate" {
+		t.Fatalf("unsupported: status = %d headers = %v", rec.Code, rec.Header())
+	}
+	if rec := post(EncodingGzip, []byte("not gzip")); rec.Code != http.StatusBadRequest {
+		t.Fatalf("corrupt gzip status = %d", rec.Code)
+	}
+}
+
+func TestDecompressTypedHandler(t *testing.T) {
+	h := Decompress(DecompressOptions{MaxBytes: 32})(JSON(createOrder))
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(encode(t, EncodingGzip, `{"channel":"`+strings.Repeat("s", 64)+`"}`)))
+	req.Header.Set("Content-Encoding", EncodingGzip)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", rec.Code)
+	}
+}
+
+func TestCompressInvalidConfig(t *testing.T) {
+	if _, err := newCompress(CompressOptions{Encodings: []string{"br"}}); err == nil {
+		t.Error("newCompress accepted br")
+	}
+	if _, err := newDecompress(DecompressOptions{Encodings: []string{"lz4"}}); err == nil {
+		t.Error("newDecompress accepted lz4")
+	}
+}
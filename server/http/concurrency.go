@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/lynx-go/lynx/server/limiter"
+)
+
+// PriorityHeader 是 PriorityFromHeader 读取请求优先级的头部，取值
+// low、normal、critical（见 limiter.ParsePriority）。
+const PriorityHeader = "X-Priority"
+
+// ConcurrencyOption 用于配置 ConcurrencyLimit 中间件的选项函数。
+type ConcurrencyOption func(*concurrencyOptions)
+
+type concurrencyOptions struct {
+	priority func(r *http.Request) limiter.Priority
+	critical []string
+	handler  http.HandlerFunc
+}
+
+// WithPriorityFunc 设置请求优先级的判定函数，缺省全部为 normal。按请求
+// 头判定（PriorityFromHeader）只应在前置网关清洗或重写该头部时使用，
+// 否则客户端可自行声明 critical 绕过过载保护；也可按路径、认证身份判定。
+// WithCriticalPaths 的路径不经此函数。
+func WithPriorityFunc(fn func(r *http.Request) limiter.Priority) ConcurrencyOption {
+	return func(o *concurrencyOptions) {
+		o.priority = fn
+	}
+}
+
+// WithCriticalPaths 设置视为 critical（从不拒绝）的路径，缺省没有。路径
+// 以 "/" 结尾时按前缀匹配，否则精确匹配。只列出必须在过载时仍可用、且
+// 不对公网开放的端点，否则这些路径会绕过过载保护。
+func WithCriticalPaths(paths ...string) ConcurrencyOption {
+	return func(o *concurrencyOptions) {
+		o.critical = paths
+	}
+}
+
+// WithShedHandler 设置被拒绝请求的处理函数，缺省写 Retry-After: 1 并以
+// 服务器级 ErrorHandler 写 ErrOverloaded（缺省为 503 +
+// {"error":{"message":"server overloaded"}}）。
+func WithShedHandler(fn http.HandlerFunc) ConcurrencyOption {
+	return func(o *concurrencyOptions) {
+		o.handler = fn
+	}
+}
+
+// defaultShedHandler 是缺省过载响应：Retry-After + ErrOverloaded。
+func defaultShedHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Retry-After", "1")
+	writeError(w, r, ErrOverloaded)
+}
+
+// PriorityFromHeader 读取 PriorityHeader 头部作为优先级（缺失或无法识别
+// 时为 normal），经 WithPriorityFunc 启用，只用于可信的上游转发的请求。
+func PriorityFromHeader(r *http.Request) limiter.Priority {
+	p, _ := limiter.ParsePriority(r.Header.Get(PriorityHeader))
+	return p
+}
+
+// normalPriority 是缺省优先级判定：全部为 normal。
+func normalPriority(*http.Request) limiter.Priority {
+	return limiter.PriorityNormal
+}
+
+// ConcurrencyLimit 返回自适应并发限制中间件：请求按优先级向 l 申请名额，
+// 失败时交给 shed handler（缺省 503，见 WithShedHandler）；完成后以耗时
+// 更新 l 的上限。响应为 503/504、或请求因 deadline 结束时视为过载
+// （limiter.Dropped），客户端取消的请求不参与上限调整。请求缺省全部为
+// normal 优先级，critical 路径需经 WithCriticalPaths 显式声明；Server
+// 的健康检查端点独立挂载，不经此中间件。l 为 nil 时 panic。
+//
+// 与 RateLimit 的分工：RateLimit 限制到达速率，ConcurrencyLimit 限制
+// 同时处理的请求数，在下游延迟升高时自动收紧。二者可以同时使用。
+func ConcurrencyLimit(l *limiter.Limiter, opts ...ConcurrencyOption) Middleware {
+	if l == nil {
+		panic("http: ConcurrencyLimit limiter is nil")
+	}
+	o := concurrencyOptions{priority: normalPriority, handler: defaultShedHandler}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := limiter.PriorityCritical
+			if !pathAllowed(o.critical, r.URL.Path) {
+				p = o.priority(r)
+			}
+			token, ok := l.Acquire(r.Context(), p)
+			if !ok {
+				o.handler(w, r)
+				return
+			}
+			tw := &trackedWriter{ResponseWriter: w}
+			outcome := limiter.Ignore
+			defer func() { token.Release(outcome) }()
+			next.ServeHTTP(tw, r)
+			outcome = concurrencyOutcome(r.Context().Err(), tw.statusCode())
+		})
+	}
+}
+
+// concurrencyOutcome 按请求 ctx 与响应状态码判定结果。handler panic 时
+// 保持 Ignore（由 Recovery 处理，不作为延迟样本）。
+func concurrencyOutcome(ctxErr error, status int) limiter.Outcome {
+	switch {
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		return limiter.Dropped
+	case ctxErr != nil:
+		return limiter.Ignore
+	case status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout:
+		return limiter.Dropped
+	}
+	return limiter.Success
+}
@@ -0,0 +1,143 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lynx-go/lynx/server/limiter"
+)
+
+func newLimiter(t *testing.T, opts ...limiter.Option) *limiter.Limiter {
+	t.Helper()
+	l, err := limiter.New(opts...)
+	if err != nil {
+		t.Fatalf("limiter.New() error = %v", err)
+	}
+	return l
+}
+
+// TestConcurrencyLimitSheds：上限占满时 normal 请求得到 503 JSON；缺省
+// 没有 critical 路径，也不信任客户端的 X-Priority 头。
+func TestConcurrencyLimitSheds(t *testing.T) {
+	l := newLimiter(t, limiter.WithAlgorithm(&limiter.AIMD{}), limiter.WithInitialLimit(1), limiter.WithMaxLimit(1))
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	h := ConcurrencyLimit(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(entered)
+			<-release
+		}
+	}))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-entered
+
+	rec := serve(h, http.MethodGet, "/")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"error":{"message":"server overloaded"}}` {
+		t.Errorf("body = %q", got)
+	}
+	if rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("Retry-After = %q, want 1", rec.Header().Get("Retry-After"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(PriorityHeader, "critical")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("client-declared critical status = %d, want 503", rec.Code)
+	}
+
+	if rec = serve(h, http.MethodGet, "/admin/stats"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("admin path status = %d, want 503", rec.Code)
+	}
+
+	close(release)
+	<-done
+	if l.InFlight() != 0 {
+		t.Errorf("InFlight() = %d after completion, want 0", l.InFlight())
+	}
+}
+
+// TestConcurrencyLimitOutcomes：503/504 与 deadline 收缩上限，客户端
+// 取消不影响上限。
+func TestConcurrencyLimitOutcomes(t *testing.T) {
+	l := newLimiter(t, limiter.WithAlgorithm(&limiter.AIMD{Backoff: 0.5}), limiter.WithInitialLimit(8))
+	status := http.StatusOK
+	h := ConcurrencyLimit(l, WithPriorityFunc(func(*http.Request) limiter.Priority {
+		return limiter.PriorityNormal
+	}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+
+	status = http.StatusGatewayTimeout
+	serve(h, http.MethodGet, "/")
+	if got := l.Limit(); got != 4 {
+		t.Fatalf("Limit() after 504 = %d, want 4", got)
+	}
+
+	status = http.StatusOK
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if got := l.Limit(); got != 4 {
+		t.Errorf("Limit() after client cancel = %d, want unchanged 4", got)
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if got := l.Limit(); got != 2 {
+		t.Errorf("Limit() after deadline = %d, want 2", got)
+	}
+}
+
+func TestConcurrencyLimitCustomShedHandler(t *testing.T) {
+	l := newLimiter(t, limiter.WithInitialLimit(1), limiter.WithLowPriorityRatio(0.5))
+	h := ConcurrencyLimit(l, WithShedHandler(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, NewAPIError(http.StatusTooManyRequests, "too busy"))
+	}))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	tok, _ := l.Acquire(context.Background(), limiter.PriorityNormal)
+	defer tok.Release(limiter.Ignore)
+	rec := serve(h, http.MethodGet, "/")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429 from custom handler", rec.Code)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"error":{"message":"too busy"}}` {
+		t.Errorf("body = %q", got)
+	}
+}
+
+// TestConcurrencyLimitPriorityOptIn：PriorityFromHeader 经 WithPriorityFunc
+// 启用后按头部判定；WithCriticalPaths 声明的路径从不拒绝。
+func TestConcurrencyLimitPriorityOptIn(t *testing.T) {
+	l := newLimiter(t, limiter.WithInitialLimit(1), limiter.WithMaxLimit(1))
+	tok, _ := l.Acquire(context.Background(), limiter.PriorityNormal)
+	defer tok.Release(limiter.Ignore)
+	h := ConcurrencyLimit(l, WithPriorityFunc(PriorityFromHeader), WithCriticalPaths("/ops/"))(
+		http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(PriorityHeader, "critical")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("trusted critical header status = %d, want 200", rec.Code)
+	}
+	if rec := serve(h, http.MethodGet, "/ops/flags"); rec.Code != http.StatusOK {
+		t.Errorf("critical path status = %d, want 200", rec.Code)
+	}
+	if rec := serve(h, http.MethodGet, "/admin/stats"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("undeclared path status = %d, want 503", rec.Code)
+	}
+}
@@ -0,0 +1,277 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/server/auth"
+	"github.com/lynx-go/lynx/server/certs"
+	"github.com/lynx-go/lynx/server/listener"
+)
+
+// ConfigKey 是 HTTP 服务的配置段。
+const ConfigKey = "server.http"
+
+// Config 是 "server.http" 配置段（OptionsFromConfig 使用）。各中间件段
+// 存在即启用，取值同对应的 Options 结构体：
+//
+//	server:
+//	  http:
+//	    addr: ":8080"
+//	    read_header_timeout: 5s
+//	    read_timeout: 30s
+//	    conn_limits:
+//	      max_conns: 10000
+//	      max_conns_per_ip: 100
+//	    body_limits:
+//	      max_bytes: 1048576
+//	      min_rate: 1024
+//	      routes:
+//	        - path: /uploads/
+//	          max_bytes: 104857600
+//	          read_timeout: 10m
+//	          min_rate: 16384
+//	    tls:
+//	      cert_file: /etc/tls/tls.crt
+//	      key_file: /etc/tls/tls.key
+//	    client_ip:
+//	      trusted_proxies: ["10.0.0.0/8"]
+//	    proxy_protocol:
+//	      trusted_sources: ["10.0.0.0/8"]
+//	    compress:
+//	      min_size: 1024
+//	    decompress:
+//	      max_bytes: 10485760
+//	    cors:
+//	      allowed_origins: ["https://*.example.com"]
+//	      allow_credentials: true
+//	      max_age: 10m
+//	    security_headers:
+//	      content_security_policy: "default-src 'self'"
+//	    csrf:
+//	      mode: signed
+//	      secret: "<至少 32 字节的随机串>"
+//	    peer_auth:
+//	      rules:
+//	        - match: /internal/*
+//	          allow: ["spiffe://prod/ns/billing/*"]
+//	    jwt:
+//	      issuer: https://login.example.com
+//	      audience: [orders]
+//	      jwks_url: https://login.example.com/.well-known/jwks.json
+//	    api_keys:
+//	      file: /etc/orders/api-keys.json
+type Config struct {
+	// Addr 对应 WithAddr，空时不设置（NewServer 缺省 DefaultHTTPAddr）。
+	Addr string `mapstructure:"addr"`
+	// ReadHeaderTimeout、ReadTimeout 与 WriteTimeout 对应同名的 With 选项，
+	// 0 时不设置。
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	// ConnLimits 对应 WithConnLimits。
+	ConnLimits *listener.LimitOptions `mapstructure:"conn_limits"`
+	// BodyLimits 对应按路径选择的 BodyLimit。
+	BodyLimits *BodyLimitsConfig `mapstructure:"body_limits"`
+	// TLS 对应 WithTLSFiles。
+	TLS *certs.Options `mapstructure:"tls"`
+	// ClientIP 对应 WithClientIP。
+	ClientIP *ClientIPOptions `mapstructure:"client_ip"`
+	// ProxyProtocol 对应 WithProxyProtocol。
+	ProxyProtocol   *listener.ProxyProtocolOptions `mapstructure:"proxy_protocol"`
+	Compress        *CompressOptions               `mapstructure:"compress"`
+	Decompress      *DecompressOptions             `mapstructure:"decompress"`
+	CORS            *CORSOptions                   `mapstructure:"cors"`
+	SecurityHeaders *SecurityHeadersOptions        `mapstructure:"security_headers"`
+	CSRF            *CSRFOptions                   `mapstructure:"csrf"`
+	PeerAuth        *auth.PeerPolicy               `mapstructure:"peer_auth"`
+	JWT             *JWTConfig                     `mapstructure:"jwt"`
+	APIKeys         *APIKeysConfig                 `mapstructure:"api_keys"`
+}
+
+// JWTConfig 是 "server.http.jwt" 配置段：令牌校验参数同
+// auth.VerifierOptions，另有 JWTAuth 的选项。
+type JWTConfig struct {
+	auth.VerifierOptions `mapstructure:",squash"`
+	// Optional 对应 WithOptionalAuth。
+	Optional bool `mapstructure:"optional"`
+	// ExemptPaths 对应 WithAuthExemptPaths。
+	ExemptPaths []string `mapstructure:"exempt_paths"`
+}
+
+// BodyLimitsConfig 是 "server.http.body_limits" 配置段：缺省的请求体
+// 限制（BodyLimitOptions）与按路径的规则。规则按顺序匹配，第一条匹配
+// 请求路径的规则整体取代缺省限制；Path 以 "/" 结尾时按前缀匹配，否则
+// 精确匹配。
+type BodyLimitsConfig struct {
+	BodyLimitOptions `mapstructure:",squash"`
+	Routes           []BodyLimitRoute `mapstructure:"routes"`
+}
+
+// BodyLimitRoute 是 BodyLimitsConfig 的一条路径规则。
+type BodyLimitRoute struct {
+	Path             string `mapstructure:"path"`
+	BodyLimitOptions `mapstructure:",squash"`
+}
+
+// APIKeysConfig 是 "server.http.api_keys" 配置段：Keys（配置内的 key，
+// 见 auth.NewStaticAPIKeys）与 File（JSON 文件，见 auth.NewFileAPIKeys）
+// 二选一，另有 APIKeyAuth 的选项。与 jwt 段同时启用时请求须同时携带
+// 两种凭据，接受任一凭据的做法见 OptionsFromConfig。
+type APIKeysConfig struct {
+	Keys []auth.APIKeyEntry `mapstructure:"keys"`
+	File string             `mapstructure:"file"`
+	// Reload 是检查 File 变化的间隔，缺省 auth.DefaultAPIKeyFileReload。
+	Reload time.Duration `mapstructure:"reload"`
+	// Optional 对应 WithOptionalAuth。
+	Optional bool `mapstructure:"optional"`
+	// ExemptPaths 对应 WithAuthExemptPaths。
+	ExemptPaths []string `mapstructure:"exempt_paths"`
+}
+
+// OptionsFromConfig 读取 "server.http" 段，返回对应的 Options，追加在
+// NewServer 的其他选项之后：
+//
+//	opts, err := http.OptionsFromConfig(app.Config())
+//	srv := http.NewServer(router, append([]http.Option{http.WithAddr(addr)}, opts...)...)
+//
+// 中间件位于 WithMiddleware 已声明的中间件之后，顺序为 BodyLimits →
+// Compress → Decompress → CORS → SecurityHeaders → CSRF → PeerAuth →
+// JWTAuth → APIKeyAuth。Decompress 在 CSRF 之外，表单令牌可从压缩请求体
+// 读取；CORS 在 CSRF 与 JWTAuth 之外，预检请求不做校验。
+//
+// 同时配置 jwt 与 api_keys 时两个中间件依次生效，请求须同时携带两种
+// 凭据：JWTAuth 在前，会拒绝只带 API key 的请求。要接受任一凭据，把两段
+// 都设为 optional，再在需要认证的分组上挂 RequireScopes()（未认证时
+// 401）。
+//
+// 配置非法时返回错误而不 panic。
+func OptionsFromConfig(cfg lynx.Config) ([]Option, error) {
+	var c Config
+	if err := cfg.UnmarshalKey(ConfigKey, &c); err != nil {
+		return nil, err
+	}
+	var mws []Middleware
+	if c.BodyLimits != nil {
+		mw, err := newBodyLimits(*c.BodyLimits)
+		if err != nil {
+			return nil, err
+		}
+		mws = append(mws, mw)
+	}
+	if c.Compress != nil {
+		mw, err := newCompress(*c.Compress)
+		if err != nil {
+			return nil, err
+		}
+		mws = append(mws, mw)
+	}
+	if c.Decompress != nil {
+		mw, err := newDecompress(*c.Decompress)
+		if err != nil {
+			return nil, err
+		}
+		mws = append(mws, mw)
+	}
+	if c.CORS != nil {
+		mw, err := newCORS(*c.CORS)
+		if err != nil {
+			return nil, err
+		}
+		mws = append(mws, mw)
+	}
+	if c.SecurityHeaders != nil {
+		mws = append(mws, SecurityHeaders(*c.SecurityHeaders))
+	}
+	if c.CSRF != nil {
+		mw, err := newCSRF(*c.CSRF)
+		if err != nil {
+			return nil, err
+		}
+		mws = append(mws, mw)
+	}
+	if c.PeerAuth != nil {
+		mw, err := newPeerAuth(*c.PeerAuth)
+		if err != nil {
+			return nil, err
+		}
+		mws = append(mws, mw)
+	}
+	if c.JWT != nil {
+		v, err := auth.NewVerifier(c.JWT.VerifierOptions)
+		if err != nil {
+			return nil, err
+		}
+		authOpts := []AuthOption{WithAuthExemptPaths(c.JWT.ExemptPaths...)}
+		if c.JWT.Optional {
+			authOpts = append(authOpts, WithOptionalAuth())
+		}
+		mws = append(mws, JWTAuth(v, authOpts...))
+	}
+	if c.APIKeys != nil {
+		store, err := apiKeyStore(*c.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		authOpts := []AuthOption{WithAuthExemptPaths(c.APIKeys.ExemptPaths...)}
+		if c.APIKeys.Optional {
+			authOpts = append(authOpts, WithOptionalAuth())
+		}
+		mws = append(mws, APIKeyAuth(store, authOpts...))
+	}
+	var opts []Option
+	if c.Addr != "" {
+		opts = append(opts, WithAddr(c.Addr))
+	}
+	if c.ReadHeaderTimeout > 0 {
+		opts = append(opts, WithReadHeaderTimeout(c.ReadHeaderTimeout))
+	}
+	if c.ReadTimeout > 0 {
+		opts = append(opts, WithReadTimeout(c.ReadTimeout))
+	}
+	if c.WriteTimeout > 0 {
+		opts = append(opts, WithWriteTimeout(c.WriteTimeout))
+	}
+	if c.ConnLimits != nil {
+		if c.ConnLimits.MaxConns < 0 || c.ConnLimits.MaxConnsPerIP < 0 {
+			return nil, errors.New("http: conn_limits: limits must be >= 0")
+		}
+		opts = append(opts, WithConnLimits(*c.ConnLimits))
+	}
+	if c.TLS != nil {
+		if c.TLS.CertFile == "" || c.TLS.KeyFile == "" {
+			return nil, errors.New("http: tls: cert_file and key_file are required")
+		}
+		opts = append(opts, WithTLSFiles(*c.TLS))
+	}
+	if c.ClientIP != nil {
+		if _, err := newClientIPResolver(*c.ClientIP); err != nil {
+			return nil, fmt.Errorf("http: client_ip: %w", err)
+		}
+		opts = append(opts, WithClientIP(*c.ClientIP))
+	}
+	if c.ProxyProtocol != nil {
+		if _, err := listener.NewProxyProtocol(*c.ProxyProtocol); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithProxyProtocol(*c.ProxyProtocol))
+	}
+	if len(mws) > 0 {
+		opts = append(opts, WithMiddleware(mws...))
+	}
+	return opts, nil
+}
+
+func apiKeyStore(c APIKeysConfig) (auth.APIKeyStore, error) {
+	switch {
+	case c.File != "" && len(c.Keys) > 0:
+		return nil, errors.New("http: api_keys: keys and file are mutually exclusive")
+	case c.File != "":
+		return auth.NewFileAPIKeys(c.File, c.Reload)
+	case len(c.Keys) > 0:
+		return auth.NewStaticAPIKeys(c.Keys)
+	}
+	return nil, errors.New("http: api_keys: one of keys and file is required")
+}
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lynx-go/lynx"
+	"github.com/spf13/viper"
+)
+
+func configFromYAML(t *testing.T, doc string) lynx.Config {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(doc)); err != nil {
+		t.Fatal(err)
+	}
+	return lynx.NewViperConfig(v)
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	cfg := configFromYAML(t, `
+server:
+  http:
+    cors:
+      allowed_origins: ["https://app.example.com"]
+      max_age: 5m
+    security_headers:
+      referrer_policy: no-referrer
+    csrf:
+      exempt_paths: ["/public/"]
+`)
+	opts, err := OptionsFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(http.NotFoundHandler(), opts...)
+	h := srv.buildHandler(context.Background())
+
+	req := httptest.NewRequest(http.MethodOptions, "/public/x", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Max-Age") != "300" || rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+		t.Fatalf("preflight headers = %v", rec.Header())
+	}
+
+	rec = serve(h, http.MethodPost, "/orders")
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Referrer-Policy") != "no-referrer" {
+		t.Fatalf("status = %d headers = %v", rec.Code, rec.Header())
+	}
+}
+
+func TestOptionsFromConfigEmptyAndInvalid(t *testing.T) {
+	opts, err := OptionsFromConfig(configFromYAML(t, "addr: :8080\n"))
+	if err != nil || len(opts) != 0 {
+		t.Fatalf("opts = %d, err = %v", len(opts), err)
+	}
+	_, err = OptionsFromConfig(configFromYAML(t, `
+server:
+  http:
+    cors:
+      allowed_origins: ["*"]
+      allow_credentials: true
+`))
+	if err == nil {
+		t.Fatal("invalid CORS config accepted")
+	}
+
+	_, err = OptionsFromConfig(configFromYAML(t, `
+server:
+  http:
+    tls:
+      cert_file: /etc/tls/tls.crt
+`))
+	if err == nil {
+		t.Fatal("tls config without key_file accepted")
+	}
+
+	_, err = OptionsFromConfig(configFromYAML(t, `
+server:
+  http:
+    peer_auth:
+      rules:
+        - allow: ["spiffe://prod/*"]
+`))
+	if err == nil {
+		t.Fatal("peer_auth rule without match accepted")
+	}
+}
+
+func TestOptionsFromConfigTLS(t *testing.T) {
+	opts, err := OptionsFromConfig(configFromYAML(t, `
+server:
+  http:
+    tls:
+      cert_file: /etc/tls/tls.crt
+      key_file: /etc/tls/tls.key
+      client_ca_file: /etc/tls/ca.crt
+      reload_interval: 30s
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(http.NotFoundHandler(), opts...)
+	if o := srv.o.TLSFiles; o == nil || o.KeyFile != "/etc/tls/tls.key" || o.ClientCAFile != "/etc/tls/ca.crt" || o.ReloadInterval != 30*time.Second {
+		t.Fatalf("TLSFiles = %+v", o)
+	}
+}
+
+func TestOptionsFromConfigClientIP(t *testing.T) {
+	opts, err := OptionsFromConfig(configFromYAML(t, `
+server:
+  http:
+    client_ip:
+      trusted_proxies: ["10.0.0.0/8"]
+      headers: ["X-Real-IP"]
+    proxy_protocol:
+      trusted_sources: ["192.0.2.0/24"]
+      header_timeout: 2s
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(http.NotFoundHandler(), opts...)
+	if o := srv.o.ClientIP; o == nil || len(o.TrustedProxies) != 1 || o.Headers[0] != "X-Real-IP" {
+		t.Fatalf("ClientIP = %+v", o)
+	}
+	if o := srv.o.ProxyProtocol; o == nil || o.TrustedSources[0] != "192.0.2.0/24" || o.HeaderTimeout != 2*time.Second {
+		t.Fatalf("ProxyProtocol = %+v", o)
+	}
+
+	for _, yaml := range []string{`
+server:
+  http:
+    client_ip:
+      trusted_proxies: ["not-a-cidr"]
+`, `
+server:
+  http:
+    proxy_protocol:
+      trusted_sources: ["10.0.0.0/99"]
+`} {
+		if _, err := OptionsFromConfig(configFromYAML(t, yaml)); err == nil {
+			t.Errorf("invalid config accepted:%s", yaml)
+		}
+	}
+}
+
+func TestOptionsFromConfigLimits(t *testing.T) {
+	opts, err := OptionsFromConfig(configFromYAML(t, `
+server:
+  http:
+    read_header_timeout: 5s
+    read_timeout: 30s
+    conn_limits:
+      max_conns: 1000
+      max_conns_per_ip: 50
+    body_limits:
+      max_bytes: 4
+      routes:
+        - path: /uploads/
+          max_bytes: 16
+          min_rate: 1024
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}), opts...)
+	if srv.o.ReadHeaderTimeout != 5*time.Second || srv.o.ReadTimeout != 30*time.Second {
+		t.Errorf("timeouts = %v/%v", srv.o.ReadHeaderTimeout, srv.o.ReadTimeout)
+	}
+	if o := srv.o.ConnLimits; o == nil || o.MaxConns != 1000 || o.MaxConnsPerIP != 50 {
+		t.Fatalf("ConnLimits = %+v", o)
+	}
+	h := srv.buildHandler(context.Background())
+	for path, want := range map[string]int{"/orders": http.StatusRequestEntityTooLarge, "/uploads/a": http.StatusOK} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader("0123456789")))
+		if rec.Code != want {
+			t.Errorf("POST %s status = %d, want %d", path, rec.Code, want)
+		}
+	}
+
+	for _, yaml := range []string{`
+server:
+  http:
+    conn_limits:
+      max_conns: -1
+`, `
+server:
+  http:
+    body_limits:
+      routes:
+        - max_bytes: 1
+`} {
+		if _, err := OptionsFromConfig(configFromYAML(t, yaml)); err == nil {
+			t.Errorf("invalid config accepted:%s", yaml)
+		}
+	}
+}
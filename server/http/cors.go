@@ -0,0 +1,218 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCORSMethods 是 CORSOptions.AllowedMethods 为空时允许的方法。
+var DefaultCORSMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost,
+	http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// DefaultCORSHeaders 是 CORSOptions.AllowedHeaders 为空时允许的请求头。
+var DefaultCORSHeaders = []string{
+	"Accept", "Accept-Language", "Content-Language", "Content-Type",
+	"Authorization", RequestIDHeader,
+}
+
+// CORSOptions 配置 CORS 中间件，字段带 mapstructure 标签，可直接作为
+// "server.http.cors" 配置段（见 Config）。
+type CORSOptions struct {
+	// AllowedOrigins 是允许的来源：完整来源（"https://app.example.com"）、
+	// 含一个通配符的模式（"https://*.example.com"，匹配一级或多级子域）
+	// 或 "*"（任意来源）。比较不区分大小写。
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	// AllowOriginFunc 非 nil 时在 AllowedOrigins 未匹配后调用。
+	AllowOriginFunc func(origin string, r *http.Request) bool `mapstructure:"-"`
+	// AllowedMethods 是预检允许的方法，空时为 DefaultCORSMethods。
+	AllowedMethods []string `mapstructure:"allowed_methods"`
+	// AllowedHeaders 是预检允许的请求头，空时为 DefaultCORSHeaders；含 "*"
+	// 时允许预检请求的全部头部（逐个回显，兼容携带凭据的请求）。
+	AllowedHeaders []string `mapstructure:"allowed_headers"`
+	// ExposedHeaders 是允许浏览器脚本读取的响应头。
+	ExposedHeaders []string `mapstructure:"exposed_headers"`
+	// AllowCredentials 允许携带 Cookie 等凭据。规范禁止与任意来源同时
+	// 使用，AllowedOrigins 含 "*" 时构造失败。
+	AllowCredentials bool `mapstructure:"allow_credentials"`
+	// MaxAge 是预检结果的缓存时长（按秒取整），0 时不发送该头。
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+type corsPolicy struct {
+	o          CORSOptions
+	any        bool
+	exact      map[string]bool
+	patterns   [][2]string // 通配符前后部分
+	methods    map[string]bool
+	methodList string
+	headers    map[string]bool
+	anyHeader  bool
+	headerList string
+	exposed    string
+	maxAge     string
+}
+
+// CORS 返回跨域资源共享中间件：
+//   - 请求不带 Origin 时透传；
+//   - 预检请求（OPTIONS + Access-Control-Request-Method）在来源、方法与
+//     请求头均允许时以 204 返回 Allow-* 头，否则 204 不带 CORS 头（浏览器
+//     据此拒绝），预检不会到达 handler；
+//   - 简单与实际请求在来源允许时写 Allow-Origin、Allow-Credentials 与
+//     Expose-Headers 后交给 handler；
+//   - 响应总是带 Vary: Origin（预检另带 Access-Control-Request-*），避免
+//     共享缓存把一个来源的响应给另一个来源。
+//
+// 配置非法（见 CORSOptions.AllowCredentials、来源模式含多个通配符）时
+// panic。
+func CORS(o CORSOptions) Middleware {
+	mw, err := newCORS(o)
+	if err != nil {
+		panic(err)
+	}
+	return mw
+}
+
+func newCORS(o CORSOptions) (Middleware, error) {
+	p := &corsPolicy{o: o, exact: map[string]bool{}, methods: map[string]bool{}, headers: map[string]bool{}}
+	for _, origin := range o.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch n := strings.Count(origin, "*"); {
+		case origin == "*":
+			p.any = true
+		case n == 0:
+			p.exact[origin] = true
+		case n == 1:
+			before, after, _ := strings.Cut(origin, "*")
+			p.patterns = append(p.patterns, [2]string{before, after})
+		default:
+			return nil, fmt.Errorf("http: CORS origin pattern %q has more than one wildcard", origin)
+		}
+	}
+	if p.any && o.AllowCredentials {
+		return nil, errors.New(`http: CORS allowed origin "*" cannot be used with credentials`)
+	}
+	methods := o.AllowedMethods
+	if len(methods) == 0 {
+		methods = DefaultCORSMethods
+	}
+	methods = append([]string(nil), methods...)
+	for i, m := range methods {
+		methods[i] = strings.ToUpper(m)
+		p.methods[methods[i]] = true
+	}
+	p.methodList = strings.Join(methods, ", ")
+	headers := o.AllowedHeaders
+	if len(headers) == 0 {
+		headers = DefaultCORSHeaders
+	}
+	for _, h := range headers {
+		if h == "*" {
+			p.anyHeader = true
+		}
+		p.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	p.headerList = strings.Join(headers, ", ")
+	p.exposed = strings.Join(o.ExposedHeaders, ", ")
+	if o.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(o.MaxAge.Round(time.Second) / time.Second))
+	}
+	return p.middleware, nil
+}
+
+func (p *corsPolicy) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		h := w.Header()
+		if origin != "" && r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Add("Vary", "Origin")
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			if p.allowOrigin(origin, r) {
+				p.preflight(h, r, origin)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.Add("Vary", "Origin")
+		if origin != "" && p.allowOrigin(origin, r) {
+			p.setOrigin(h, origin)
+			if p.exposed != "" {
+				h.Set("Access-Control-Expose-Headers", p.exposed)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// preflight 在方法与请求头都允许时写预检响应头。
+func (p *corsPolicy) preflight(h http.Header, r *http.Request, origin string) {
+	if !p.methods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] {
+		return
+	}
+	requested := parseHeaderList(r.Header.Values("Access-Control-Request-Headers"))
+	if !p.anyHeader {
+		for _, name := range requested {
+			if !p.headers[http.CanonicalHeaderKey(name)] {
+				return
+			}
+		}
+	}
+	p.setOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", p.methodList)
+	switch {
+	case p.anyHeader && len(requested) > 0:
+		h.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	case !p.anyHeader:
+		h.Set("Access-Control-Allow-Headers", p.headerList)
+	}
+	if p.maxAge != "" {
+		h.Set("Access-Control-Max-Age", p.maxAge)
+	}
+}
+
+func (p *corsPolicy) setOrigin(h http.Header, origin string) {
+	if p.any {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if p.o.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (p *corsPolicy) allowOrigin(origin string, r *http.Request) bool {
+	lower := strings.ToLower(origin)
+	if p.any || p.exact[lower] {
+		return true
+	}
+	for _, pat := range p.patterns {
+		if len(lower) > len(pat[0])+len(pat[1]) && strings.HasPrefix(lower, pat[0]) && strings.HasSuffix(lower, pat[1]) {
+			// 通配部分只能是主机名标签，不能跨越协议、端口或路径。
+			mid := lower[len(pat[0]) : len(lower)-len(pat[1])]
+			if !strings.ContainsAny(mid, "/:@") {
+				return true
+			}
+		}
+	}
+	return p.o.AllowOriginFunc != nil && p.o.AllowOriginFunc(origin, r)
+}
+
+// parseHeaderList 拆分逗号分隔的头部名列表。
+func parseHeaderList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				out = append(out, name)
+			}
+		}
+	}
+	return out
+}
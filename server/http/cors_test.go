@@ -0,0 +1,124 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func corsRequest(h http.Handler, method, origin string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/items", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORSPreflight(t *testing.T) {
+	reached := false
+	h := CORS(CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedHeaders:   []string{"Content-Type", "X-Tenant"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { reached = true }))
+
+	tests := []struct {
+		name    string
+		origin  string
+		method  string
+		headers string
+		allowed bool
+	}{
+		{"exact origin", "https://app.example.com", "PUT", "content-type, x-tenant", true},
+		{"pattern origin", "https://a.b.example.org", "DELETE", "", true},
+		{"origin case", "HTTPS://APP.EXAMPLE.COM", "GET", "", true},
+		{"unknown origin", "https://evil.com", "PUT", "", false},
+		{"pattern crossing port", "https://x.example.org:8443", "GET", "", false},
+		{"pattern lookalike", "https://example.org.evil.com", "GET", "", false},
+		{"method not allowed", "https://app.example.com", "CONNECT", "", false},
+		{"header not allowed", "https://app.example.com", "PUT", "X-Other", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{"Access-Control-Request-Method": {tt.method}}
+			if tt.headers != "" {
+				header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			rec := corsRequest(h, http.MethodOptions, tt.origin, header)
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("status = %d, want 204", rec.Code)
+			}
+			got := rec.Header().Get("Access-Control-Allow-Origin")
+			if tt.allowed != (got == tt.origin) {
+				t.Fatalf("Allow-Origin = %q, allowed = %v", got, tt.allowed)
+			}
+			if tt.allowed {
+				if rec.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+					rec.Header().Get("Access-Control-Max-Age") != "600" ||
+					rec.Header().Get("Access-Control-Allow-Headers") != "Content-Type, X-Tenant" {
+					t.Fatalf("preflight headers = %v", rec.Header())
+				}
+			}
+			if vary := strings.Join(rec.Header().Values("Vary"), ","); !strings.Contains(vary, "Access-Control-Request-Method") {
+				t.Fatalf("Vary = %q", vary)
+			}
+		})
+	}
+	if reached {
+		t.Fatal("preflight reached the handler")
+	}
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	h := CORS(CORSOptions{AllowedOrigins: []string{"*"}, ExposedHeaders: []string{RequestIDHeader}})(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusTeapot) }))
+
+	rec := corsRequest(h, http.MethodGet, "https://any.site", nil)
+	if rec.Code != http.StatusTeapot || rec.Header().Get("Access-Control-Allow-Origin") != "*" ||
+		rec.Header().Get("Access-Control-Expose-Headers") != RequestIDHeader {
+		t.Fatalf("status = %d headers = %v", rec.Code, rec.Header())
+	}
+	if rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatal("credentials allowed with wildcard origin")
+	}
+
+	// 不带 Origin 的 OPTIONS 不是预检，交给 handler。
+	rec = corsRequest(h, http.MethodOptions, "", http.Header{"Access-Control-Request-Method": {"GET"}})
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("non-CORS OPTIONS status = %d", rec.Code)
+	}
+}
+
+func TestCORSAllowOriginFuncAndAnyHeader(t *testing.T) {
+	h := CORS(CORSOptions{
+		AllowOriginFunc: func(origin string, _ *http.Request) bool { return strings.HasSuffix(origin, ".internal") },
+		AllowedHeaders:  []string{"*"},
+	})(http.NotFoundHandler())
+	rec := corsRequest(h, http.MethodOptions, "http://tool.internal", http.Header{
+		"Access-Control-Request-Method":  {"POST"},
+		"Access-Control-Request-Headers": {"X-A, X-B"},
+	})
+	if rec.Header().Get("Access-Control-Allow-Origin") != "http://tool.internal" ||
+		rec.Header().Get("Access-Control-Allow-Headers") != "X-A, X-B" {
+		t.Fatalf("headers = %v", rec.Header())
+	}
+}
+
+func TestCORSInvalidConfig(t *testing.T) {
+	for _, o := range []CORSOptions{
+		{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+		{AllowedOrigins: []string{"https://*.*.example.com"}},
+	} {
+		if _, err := newCORS(o); err == nil {
+			t.Errorf("newCORS(%+v) succeeded", o)
+		}
+	}
+}
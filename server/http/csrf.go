@@ -0,0 +1,268 @@
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CSRFOptions.Mode 的取值。
+const (
+	// CSRFDoubleSubmit：Cookie 保存随机令牌（脚本可读），不安全方法的请求
+	// 须在请求头或表单字段中回传相同的值。
+	CSRFDoubleSubmit = "double_submit"
+	// CSRFSigned：Cookie 保存随机值（HttpOnly），令牌为以 Secret 对该值
+	// 签名的 HMAC，攻击者即使能写入 Cookie 也无法伪造令牌。
+	CSRFSigned = "signed"
+)
+
+// CSRF 的缺省值。
+const (
+	DefaultCSRFCookieName = "_csrf"
+	DefaultCSRFHeader     = "X-CSRF-Token"
+	DefaultCSRFFormField  = "csrf_token"
+	DefaultCSRFMaxAge     = 12 * time.Hour
+)
+
+// ErrCSRFInvalid 由 CSRF 中间件在令牌缺失、不匹配或来源不可信时使用（403）。
+var ErrCSRFInvalid = &APIError{Status: http.StatusForbidden, Message: "invalid CSRF token", quiet: true}
+
+// CSRFOptions 配置 CSRF 中间件，字段带 mapstructure 标签，可直接作为
+// "server.http.csrf" 配置段（见 Config）。
+type CSRFOptions struct {
+	// Mode 是 CSRFDoubleSubmit（缺省）或 CSRFSigned。
+	Mode string `mapstructure:"mode"`
+	// Secret 是 CSRFSigned 模式的 HMAC 密钥，至少 32 字节。
+	Secret string `mapstructure:"secret"`
+	// CookieName 缺省 DefaultCSRFCookieName。
+	CookieName string `mapstructure:"cookie_name"`
+	// CookiePath 缺省 "/"。
+	CookiePath string `mapstructure:"cookie_path"`
+	// CookieDomain 缺省为空（仅当前主机）。
+	CookieDomain string `mapstructure:"cookie_domain"`
+	// InsecureCookie 去掉 Cookie 的 Secure 属性，仅用于本地 HTTP 开发。
+	InsecureCookie bool `mapstructure:"insecure_cookie"`
+	// SameSite 是 Cookie 的 SameSite 属性：lax（缺省）、strict 或 none。
+	SameSite string `mapstructure:"same_site"`
+	// MaxAge 是 Cookie 有效期，缺省 DefaultCSRFMaxAge。
+	MaxAge time.Duration `mapstructure:"max_age"`
+	// HeaderName 是回传令牌的请求头，缺省 DefaultCSRFHeader；CSRFSigned
+	// 模式下安全方法的响应同样以该头下发令牌。
+	HeaderName string `mapstructure:"header_name"`
+	// FormField 是回传令牌的表单字段，缺省 DefaultCSRFFormField。
+	FormField string `mapstructure:"form_field"`
+	// TrustedOrigins 是除本主机外允许发起不安全请求的来源
+	// （"https://app.example.com"）。请求带 Origin 且不在其中时拒绝。
+	TrustedOrigins []string `mapstructure:"trusted_origins"`
+	// ExemptPaths 是免检路径：以 "/" 结尾时按前缀匹配，否则精确匹配（如
+	// 使用 Bearer 认证的 API 与 Webhook）。
+	ExemptPaths []string `mapstructure:"exempt_paths"`
+	// ErrorHandler 非 nil 时替代缺省响应（经服务器级 ErrorHandler 写
+	// ErrCSRFInvalid）。
+	ErrorHandler http.Handler `mapstructure:"-"`
+}
+
+type csrfTokenCtx struct{}
+
+// CSRFToken 返回 CSRF 中间件为本次请求生成的令牌，用于渲染表单隐藏字段
+// 或页面 meta；请求未经 CSRF 中间件时返回空串。
+func CSRFToken(ctx context.Context) string {
+	s, _ := ctx.Value(csrfTokenCtx{}).(string)
+	return s
+}
+
+type csrfProtector struct {
+	o       CSRFOptions
+	secret  []byte
+	origins map[string]bool
+	same    http.SameSite
+}
+
+// CSRF 返回面向 Cookie 认证浏览器应用的 CSRF 防护中间件：
+//   - 每个请求确保存在 CSRF Cookie（缺失时生成），令牌经 CSRFToken(ctx)
+//     提供给 handler；
+//   - 不安全方法（GET/HEAD/OPTIONS/TRACE 以外）须在 HeaderName 请求头或
+//     FormField 表单字段回传令牌，校验按 Mode（恒定时间比较）；请求带
+//     Origin 时还须是本主机或 TrustedOrigins 之一；
+//   - 失败时经服务器级 ErrorHandler 写 ErrCSRFInvalid（403）。
+//
+// 配置非法（未知 Mode、SameSite，signed 模式 Secret 不足 32 字节）时 panic。
+func CSRF(o CSRFOptions) Middleware {
+	mw, err := newCSRF(o)
+	if err != nil {
+		panic(err)
+	}
+	return mw
+}
+
+func newCSRF(o CSRFOptions) (Middleware, error) {
+	if o.Mode == "" {
+		o.Mode = CSRFDoubleSubmit
+	}
+	if o.CookieName == "" {
+		o.CookieName = DefaultCSRFCookieName
+	}
+	if o.CookiePath == "" {
+		o.CookiePath = "/"
+	}
+	if o.MaxAge <= 0 {
+		o.MaxAge = DefaultCSRFMaxAge
+	}
+	if o.HeaderName == "" {
+		o.HeaderName = DefaultCSRFHeader
+	}
+	if o.FormField == "" {
+		o.FormField = DefaultCSRFFormField
+	}
+	p := &csrfProtector{o: o, origins: map[string]bool{}}
+	switch o.Mode {
+	case CSRFDoubleSubmit:
+	case CSRFSigned:
+		if len(o.Secret) < 32 {
+			return nil, errors.New("http: CSRF signed mode requires a secret of at least 32 bytes")
+		}
+		p.secret = []byte(o.Secret)
+	default:
+		return nil, fmt.Errorf("http: unknown CSRF mode %q", o.Mode)
+	}
+	switch strings.ToLower(o.SameSite) {
+	case "", "lax":
+		p.same = http.SameSiteLaxMode
+	case "strict":
+		p.same = http.SameSiteStrictMode
+	case "none":
+		if o.InsecureCookie {
+			return nil, errors.New("http: CSRF SameSite=None requires a secure cookie")
+		}
+		p.same = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("http: unknown CSRF SameSite %q", o.SameSite)
+	}
+	for _, origin := range o.TrustedOrigins {
+		p.origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+	return p.middleware, nil
+}
+
+func (p *csrfProtector) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if pathAllowed(p.o.ExemptPaths, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		secret := ""
+		if c, err := r.Cookie(p.o.CookieName); err == nil && validCSRFValue(c.Value) {
+			secret = c.Value
+		}
+		if !csrfSafeMethod(r.Method) && !p.verify(r, secret) {
+			if p.o.ErrorHandler != nil {
+				p.o.ErrorHandler.ServeHTTP(w, r)
+			} else {
+				writeError(w, r, ErrCSRFInvalid)
+			}
+			return
+		}
+		if secret == "" {
+			secret = randomCSRFValue()
+			http.SetCookie(w, &http.Cookie{
+				Name:     p.o.CookieName,
+				Value:    secret,
+				Path:     p.o.CookiePath,
+				Domain:   p.o.CookieDomain,
+				MaxAge:   int(p.o.MaxAge / time.Second),
+				Secure:   !p.o.InsecureCookie,
+				HttpOnly: p.o.Mode == CSRFSigned,
+				SameSite: p.same,
+			})
+		}
+		token := p.token(secret)
+		if p.o.Mode == CSRFSigned && csrfSafeMethod(r.Method) {
+			w.Header().Set(p.o.HeaderName, token)
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfTokenCtx{}, token)))
+	})
+}
+
+// verify 校验不安全请求的来源与令牌。
+func (p *csrfProtector) verify(r *http.Request, secret string) bool {
+	if origin := r.Header.Get("Origin"); origin != "" && !p.trustedOrigin(r, origin) {
+		return false
+	}
+	if secret == "" {
+		return false
+	}
+	sent := r.Header.Get(p.o.HeaderName)
+	if sent == "" {
+		sent = r.PostFormValue(p.o.FormField)
+	}
+	if sent == "" {
+		return false
+	}
+	if p.o.Mode == CSRFDoubleSubmit {
+		return subtle.ConstantTimeCompare([]byte(sent), []byte(secret)) == 1
+	}
+	nonce, mac, ok := strings.Cut(sent, ".")
+	if !ok {
+		return false
+	}
+	want := p.sign(secret, nonce)
+	return subtle.ConstantTimeCompare([]byte(mac), []byte(want)) == 1
+}
+
+func (p *csrfProtector) trustedOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return p.origins[strings.ToLower(origin)]
+}
+
+// token 返回下发给客户端的令牌：double_submit 为 Cookie 值本身，signed
+// 为 "nonce.HMAC(secret, cookie.nonce)"（每次请求不同，抵御 BREACH）。
+func (p *csrfProtector) token(secret string) string {
+	if p.o.Mode == CSRFDoubleSubmit {
+		return secret
+	}
+	nonce := randomCSRFValue()
+	return nonce + "." + p.sign(secret, nonce)
+}
+
+func (p *csrfProtector) sign(secret, nonce string) string {
+	m := hmac.New(sha256.New, p.secret)
+	m.Write([]byte(secret))
+	m.Write([]byte{'.'})
+	m.Write([]byte(nonce))
+	return base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+func randomCSRFValue() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// validCSRFValue 拒绝格式不对的 Cookie 值（如被篡改），使其按缺失处理。
+func validCSRFValue(v string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(v)
+	return err == nil && len(b) == 32
+}
+
+func csrfSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
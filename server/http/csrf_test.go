@@ -0,0 +1,138 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// csrfClient 模拟浏览器：保存 Cookie 并随请求发送。
+type csrfClient struct {
+	h       http.Handler
+	cookies map[string]*http.Cookie
+}
+
+func (c *csrfClient) do(method, target string, header http.Header, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, vs := range header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	for _, ck := range c.cookies {
+		req.AddCookie(ck)
+	}
+	rec := httptest.NewRecorder()
+	c.h.ServeHTTP(rec, req)
+	for _, ck := range rec.Result().Cookies() {
+		c.cookies[ck.Name] = ck
+	}
+	return rec
+}
+
+func csrfHandler(o CSRFOptions) (http.Handler, *string) {
+	var token string
+	h := CSRF(o)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = CSRFToken(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+	return h, &token
+}
+
+func TestCSRFDoubleSubmit(t *testing.T) {
+	h, token := csrfHandler(CSRFOptions{ExemptPaths: []string{"/webhooks/"}})
+	c := &csrfClient{h: h, cookies: map[string]*http.Cookie{}}
+
+	// 没有 Cookie 的不安全请求被拒绝。
+	if rec := c.do(http.MethodPost, "/orders", nil, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("POST without cookie = %d, want 403", rec.Code)
+	}
+	rec := c.do(http.MethodGet, "/form", nil, "")
+	ck := c.cookies[DefaultCSRFCookieName]
+	if rec.Code != http.StatusOK || ck == nil || ck.HttpOnly || !ck.Secure || ck.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("cookie = %+v", ck)
+	}
+	if *token != ck.Value {
+		t.Fatalf("CSRFToken = %q, want cookie value", *token)
+	}
+
+	wrong := "x" + ck.Value[1:]
+	if wrong == ck.Value {
+		wrong = "y" + ck.Value[1:]
+	}
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		status int
+	}{
+		{"header token", http.Header{DefaultCSRFHeader: {ck.Value}}, "", http.StatusOK},
+		{"form token", http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			url.Values{DefaultCSRFFormField: {ck.Value}}.Encode(), http.StatusOK},
+		{"missing token", nil, "", http.StatusForbidden},
+		{"wrong token", http.Header{DefaultCSRFHeader: {wrong}}, "", http.StatusForbidden},
+		{"same host origin", http.Header{DefaultCSRFHeader: {ck.Value}, "Origin": {"http://example.com"}}, "", http.StatusOK},
+		{"cross origin", http.Header{DefaultCSRFHeader: {ck.Value}, "Origin": {"https://evil.com"}}, "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := c.do(http.MethodPost, "/orders", tt.header, tt.body); rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+
+	c.cookies = map[string]*http.Cookie{}
+	if rec := c.do(http.MethodPost, "/webhooks/stripe", nil, ""); rec.Code != http.StatusOK {
+		t.Fatalf("exempt path status = %d", rec.Code)
+	}
+}
+
+func TestCSRFSigned(t *testing.T) {
+	secret := strings.Repeat("s", 32)
+	h, _ := csrfHandler(CSRFOptions{Mode: CSRFSigned, Secret: secret, TrustedOrigins: []string{"https://app.example.com"}})
+	c := &csrfClient{h: h, cookies: map[string]*http.Cookie{}}
+
+	rec := c.do(http.MethodGet, "/", nil, "")
+	token := rec.Header().Get(DefaultCSRFHeader)
+	ck := c.cookies[DefaultCSRFCookieName]
+	if token == "" || ck == nil || !ck.HttpOnly {
+		t.Fatalf("token = %q cookie = %+v", token, ck)
+	}
+	if token2 := c.do(http.MethodGet, "/", nil, "").Header().Get(DefaultCSRFHeader); token2 == token {
+		t.Fatal("signed token not randomized per request")
+	}
+	if rec := c.do(http.MethodPost, "/", http.Header{DefaultCSRFHeader: {token}, "Origin": {"https://app.example.com"}}, ""); rec.Code != http.StatusOK {
+		t.Fatalf("valid signed token = %d", rec.Code)
+	}
+	// 以 Cookie 值本身（double submit 的做法）或另一个密钥签名的令牌都无效。
+	if rec := c.do(http.MethodPost, "/", http.Header{DefaultCSRFHeader: {ck.Value}}, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("cookie value as token = %d", rec.Code)
+	}
+	other, _ := newCSRF(CSRFOptions{Mode: CSRFSigned, Secret: strings.Repeat("o", 32)})
+	forged := ""
+	other(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) { forged = CSRFToken(r.Context()) })).
+		ServeHTTP(httptest.NewRecorder(), func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.AddCookie(ck)
+			return r
+		}())
+	if rec := c.do(http.MethodPost, "/", http.Header{DefaultCSRFHeader: {forged}}, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("token signed with another secret = %d", rec.Code)
+	}
+}
+
+func TestCSRFInvalidConfig(t *testing.T) {
+	for _, o := range []CSRFOptions{
+		{Mode: "cookie"},
+		{Mode: CSRFSigned, Secret: "short"},
+		{SameSite: "none", InsecureCookie: true},
+		{SameSite: "loose"},
+	} {
+		if _, err := newCSRF(o); err == nil {
+			t.Errorf("newCSRF(%+v) succeeded", o)
+		}
+	}
+}
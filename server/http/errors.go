@@ -27,11 +27,17 @@ type StatusError interface {
 }
 
 // DefaultErrorHandler 是框架默认的 ErrorHandler：
-//   - 错误实现 StatusError 时以其 StatusCode() 为状态码，否则 500；
-//   - 响应体统一为 {"error":{"message":<err.Error()>}}（application/json）；
+//   - 错误实现 StatusError 时以其 StatusCode() 为状态码；包装
+//     context.DeadlineExceeded 时按 ErrRequestTimeout（503）；否则 500；
+//   - 响应体统一为 {"error":{"message":...}}（application/json）：APIError
+//     取其对外消息；其余错误 4xx 取 err.Error()，5xx 取状态码文本
+//     （http.StatusText，原因只写入日志）；携带字段级详情
+//     （DetailedError）时追加 "fields" 数组；
 //   - 仅 5xx 记一条 Error 日志（slog.ErrorContext，日志器为
 //     slog.Default()），字段 method/path/status/error；非 5xx（业务
-//     声明的 4xx 等）不记日志，由业务自行决定是否需要记录。
+//     声明的 4xx 等）与框架主动拒绝（ErrOverloaded 等）不记日志。
+//
+// 需要向客户端说明 5xx 原因时返回 APIError（见 NewAPIError）。
 //
 // 若响应已开始（w 已写过 header——NewErrorHandler 传入的包装器可标记），
 // 不再改写响应、仅记录一条 Error 日志（status 取已写入的状态码），避免
@@ -41,17 +47,41 @@ func DefaultErrorHandler(ctx context.Context, w http.ResponseWriter, r *http.Req
 		logHTTPError(ctx, "http handler error after response started", r, tw.statusCode(), err)
 		return
 	}
-	status := http.StatusInternalServerError
-	var se StatusError
-	if errors.As(err, &se) {
-		status = se.StatusCode()
+	res := resolveError(err)
+	writeJSONBody(w, res.status, errorResponse{Error: errorMessage{Message: res.message, Fields: res.fields}})
+	if res.status >= http.StatusInternalServerError && !res.quiet {
+		logHTTPError(ctx, "http handler error", r, res.status, err)
 	}
-	writeJSONError(w, status, err)
-	if status >= http.StatusInternalServerError {
-		logHTTPError(ctx, "http handler error", r, status, err)
+}
+
+type errorHandlerCtx struct{}
+
+// withErrorHandler 返回在请求 Context 中放入服务器级 ErrorHandler 的
+// 中间件（Server 在处理链最外层安装）。
+func withErrorHandler(h ErrorHandler) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), errorHandlerCtx{}, h)))
+		})
 	}
 }
 
+// ErrorHandlerFrom 返回请求 Context 中的服务器级 ErrorHandler（见
+// WithErrorHandler），未设置时返回 DefaultErrorHandler。NewErrorHandler
+// 传 nil、Recovery、RateLimit、ConcurrencyLimit、Maintenance 与 Timeout
+// 的缺省处理都经它写错误响应；自定义中间件也可用它保持响应格式一致。
+func ErrorHandlerFrom(ctx context.Context) ErrorHandler {
+	if h, ok := ctx.Value(errorHandlerCtx{}).(ErrorHandler); ok {
+		return h
+	}
+	return DefaultErrorHandler
+}
+
+// writeError 以请求 Context 中的 ErrorHandler 写错误响应。
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	ErrorHandlerFrom(r.Context())(r.Context(), w, r, err)
+}
+
 // HandleFunc 是带错误返回的业务 handler 签名：ctx 为请求上下文
 // （r.Context()），w 为响应 writer，r 为请求。返回 nil 表示业务已自行
 // 写好响应；返回错误时由 NewErrorHandler 交给 ErrorHandler 处理。
@@ -59,10 +89,9 @@ type HandleFunc func(ctx context.Context, w http.ResponseWriter, r *http.Request
 
 // NewErrorHandler 将返回错误的业务 handler 包装为标准 http.Handler：
 //   - fn 返回 nil：表示业务已自行写好响应，不做任何处理；
-//   - fn 返回错误：调用 h 写错误响应，h 为 nil 时使用
-//     DefaultErrorHandler；
-//   - fn 内 panic 不在此处恢复（panic 恢复由专门的 Recovery 中间件负责，
-//     不在 v1.1 范围内）。
+//   - fn 返回错误：调用 h 写错误响应，h 为 nil 时使用服务器级
+//     ErrorHandler（WithErrorHandler，未设置时为 DefaultErrorHandler）；
+//   - fn 内 panic 不在此处恢复（panic 恢复由 Recovery 中间件负责）。
 //
 // 传给 fn 的 w 是轻量包装 writer：记录响应是否已开始（首次
 // WriteHeader/Write 即视为已开始），并保留 Flusher/Hijacker 能力
@@ -70,18 +99,15 @@ type HandleFunc func(ctx context.Context, w http.ResponseWriter, r *http.Request
 // 若 fn 已写过响应头后又返回错误，h 仍会被调用，但 DefaultErrorHandler
 // 检测到响应已开始后不再改写响应、仅记日志，不会触发 superfluous
 // WriteHeader；自定义 h 需自行处理该情形。
-//
-// 服务器级默认 ErrorHandler（WithErrorHandler 选项，h 传 nil 时的兜底
-// 改取服务器级默认）不在 v1.1 范围内，定位 v1.2；当前 h 传 nil 一律使用
-// 包级 DefaultErrorHandler。
 func NewErrorHandler(h ErrorHandler, fn HandleFunc) http.Handler {
-	if h == nil {
-		h = DefaultErrorHandler
-	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		tw := &trackedWriter{ResponseWriter: w}
 		if err := fn(r.Context(), tw, r); err != nil {
-			h(r.Context(), tw, r, err)
+			eh := h
+			if eh == nil {
+				eh = ErrorHandlerFrom(r.Context())
+			}
+			eh(r.Context(), tw, r, err)
 		}
 	})
 }
@@ -92,12 +118,12 @@ type errorResponse struct {
 }
 
 type errorMessage struct {
-	Message string `json:"message"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
 }
 
-// writeJSONError 以统一 JSON 错误体写错误响应。
-func writeJSONError(w http.ResponseWriter, status int, err error) {
-	b, _ := json.Marshal(errorResponse{Error: errorMessage{Message: err.Error()}})
+func writeJSONBody(w http.ResponseWriter, status int, body any) {
+	b, _ := json.Marshal(body)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_, _ = w.Write(b)
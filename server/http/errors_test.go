@@ -134,7 +134,7 @@ func TestDefaultErrorHandlerWrappedStatusError(t *testing.T) {
 	}
 }
 
-// TestDefaultErrorHandlerPlainError：普通 error → 500 + JSON 错误体 +
+// TestDefaultErrorHandlerPlainError：普通 error → 500 + 状态码文本错误体 +
 // Error 日志（method/path/status/error 四字段）。
 func TestDefaultErrorHandlerPlainError(t *testing.T) {
 	capture, restore := useCaptureLogger(false)
@@ -151,8 +151,8 @@ func TestDefaultErrorHandlerPlainError(t *testing.T) {
 	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
 		t.Errorf("Content-Type = %q, want application/json", ct)
 	}
-	if got := strings.TrimSpace(rec.Body.String()); got != `{"error":{"message":"EOF"}}` {
-		t.Errorf("body = %q, want EOF JSON body", got)
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"error":{"message":"Internal Server Error"}}` {
+		t.Errorf("body = %q, want status text JSON body", got)
 	}
 
 	rec2, ok := capture.hasError("http handler error")
@@ -0,0 +1,328 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lynx-go/lynx/logging"
+)
+
+// IdempotencyKeyHeader 是携带幂等键的请求头名。
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader 标记重放的响应（值为 "true"）。
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// Idempotency 中间件的缺省值。
+const (
+	// DefaultIdempotencyTTL 是已完成响应的保留时间。
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// DefaultIdempotencyLockTTL 是进行中记录的保留时间：持有者崩溃时，
+	// 超过该时间后同一键可重新执行。
+	DefaultIdempotencyLockTTL = time.Minute
+	// DefaultIdempotencyMaxBodyBytes 是参与指纹计算的请求体上限，也是
+	// 保存的响应体上限（更大的响应不保存，重复请求会重新执行）。
+	DefaultIdempotencyMaxBodyBytes = 1 << 20
+	// maxIdempotencyKeyLen 是幂等键的最大长度。
+	maxIdempotencyKeyLen = 255
+)
+
+var (
+	// ErrIdempotencyInFlight 表示同一幂等键的原请求仍在处理（409）。
+	ErrIdempotencyInFlight = &APIError{Status: http.StatusConflict, Message: "a request with this idempotency key is in progress", quiet: true}
+	// ErrIdempotencyMismatch 表示幂等键被用于不同的请求（422）。
+	ErrIdempotencyMismatch = &APIError{Status: http.StatusUnprocessableEntity, Message: "idempotency key reused with a different request", quiet: true}
+)
+
+// IdempotencyOption 用于配置 Idempotency 中间件的选项函数。
+type IdempotencyOption func(*idempotencyOptions)
+
+type idempotencyOptions struct {
+	ttl      time.Duration
+	lockTTL  time.Duration
+	maxBody  int64
+	methods  []string
+	required bool
+	scope    func(r *http.Request) string
+}
+
+// WithIdempotencyTTL 设置已完成响应的保留时间，缺省 DefaultIdempotencyTTL。
+func WithIdempotencyTTL(d time.Duration) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.ttl = d
+	}
+}
+
+// WithIdempotencyLockTTL 设置进行中记录的保留时间，缺省
+// DefaultIdempotencyLockTTL；应大于最慢请求的处理时间。
+func WithIdempotencyLockTTL(d time.Duration) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.lockTTL = d
+	}
+}
+
+// WithIdempotencyMaxBodyBytes 设置请求体与保存的响应体上限，缺省
+// DefaultIdempotencyMaxBodyBytes；请求体超出时响应 413。
+func WithIdempotencyMaxBodyBytes(n int64) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.maxBody = n
+	}
+}
+
+// WithIdempotencyMethods 设置启用幂等处理的方法，缺省 POST 与 PATCH。
+func WithIdempotencyMethods(methods ...string) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.methods = methods
+	}
+}
+
+// WithIdempotencyRequired 要求启用方法的请求必须携带幂等键，缺失时
+// 响应 400。
+func WithIdempotencyRequired() IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.required = true
+	}
+}
+
+// WithIdempotencyScope 设置幂等键的命名空间函数，不同命名空间的相同键
+// 互不影响。缺省为请求 ctx 中的 user_id（认证中间件写入），因此
+// Idempotency 应位于认证中间件之内。
+func WithIdempotencyScope(fn func(r *http.Request) string) IdempotencyOption {
+	return func(o *idempotencyOptions) {
+		o.scope = fn
+	}
+}
+
+func userIDScope(r *http.Request) string {
+	for _, a := range logging.AttrsFrom(r.Context()) {
+		if a.Key == logging.FieldUserID {
+			return a.Value.String()
+		}
+	}
+	return ""
+}
+
+// Idempotency 返回幂等键中间件，为支付等不安全请求提供重试安全
+// （client/http 会重试带 GetBody 的 POST）：
+//   - 启用方法的请求携带 Idempotency-Key 时，以方法、URL 与请求体计算
+//     指纹，在 store 中占用该键后执行 handler，并保存最终响应；
+//   - 重复请求直接重放保存的响应（带 Idempotent-Replayed: true），不再
+//     执行 handler；
+//   - 原请求仍在处理时，重复请求得到 ErrIdempotencyInFlight（409）；
+//     同一键用于指纹不同的请求时得到 ErrIdempotencyMismatch（422）。
+//
+// 只保存描述响应本身的头部（Content-Type、Location、ETag 及 handler 写入
+// 的业务头部等）：进入中间件前外层已写入且未被改写的头部、以及逐请求
+// 生成的头部（Set-Cookie、Date、Retry-After、RateLimit-*、X-Request-Id、
+// X-CSRF-Token）不保存，重放时由外层中间件为本次请求重新写入。
+//
+// 5xx 响应、panic 与超过上限的响应不保存，键随即释放，客户端可重试。
+// store 出错时写 500。store 为 nil 时 panic。
+func Idempotency(store IdempotencyStore, opts ...IdempotencyOption) Middleware {
+	if store == nil {
+		panic("http: Idempotency store is nil")
+	}
+	o := idempotencyOptions{
+		ttl:     DefaultIdempotencyTTL,
+		lockTTL: DefaultIdempotencyLockTTL,
+		maxBody: DefaultIdempotencyMaxBodyBytes,
+		methods: []string{http.MethodPost, http.MethodPatch},
+		scope:   userIDScope,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(o.methods, r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := r.Header.Get(IdempotencyKeyHeader)
+			switch {
+			case key == "" && o.required:
+				writeError(w, r, NewAPIError(http.StatusBadRequest, "missing "+IdempotencyKeyHeader+" header"))
+				return
+			case key == "":
+				next.ServeHTTP(w, r)
+				return
+			case len(key) > maxIdempotencyKeyLen:
+				writeError(w, r, NewAPIError(http.StatusBadRequest, IdempotencyKeyHeader+" too long"))
+				return
+			}
+			body, err := io.ReadAll(io.LimitReader(r.Body, o.maxBody+1))
+			if err != nil {
+				writeError(w, r, NewAPIError(http.StatusBadRequest, "failed to read request body").WithCause(err))
+				return
+			}
+			if int64(len(body)) > o.maxBody {
+				writeError(w, r, NewAPIError(http.StatusRequestEntityTooLarge, "request body too large"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			if scope := o.scope(r); scope != "" {
+				key = scope + "\x00" + key
+			}
+			fp := requestFingerprint(r, body)
+
+			ctx := r.Context()
+			prev, err := store.Begin(ctx, key, fp, o.lockTTL)
+			if err != nil {
+				writeError(w, r, fmt.Errorf("http: idempotency begin: %w", err))
+				return
+			}
+			switch {
+			case prev == nil:
+			case prev.Fingerprint != fp:
+				writeError(w, r, ErrIdempotencyMismatch)
+				return
+			case !prev.Done:
+				w.Header().Set("Retry-After", "1")
+				writeError(w, r, ErrIdempotencyInFlight)
+				return
+			default:
+				replayResponse(w, prev)
+				return
+			}
+
+			outer := w.Header().Clone()
+			rec := &idempotencyRecorder{w: w, max: o.maxBody}
+			stored := false
+			// 与请求取消解耦：客户端中途断开时仍要记录完成或释放键，否则键
+			// 会停留在进行中直到 lockTTL 到期。
+			ctx = context.WithoutCancel(ctx)
+			defer func() {
+				if !stored {
+					if err := store.Release(ctx, key); err != nil {
+						slog.ErrorContext(ctx, "http: idempotency release failed", "error", err)
+					}
+				}
+			}()
+			next.ServeHTTP(rec, r)
+			if rec.hijacked || rec.overflow || rec.status() >= 500 {
+				return
+			}
+			header := rec.header
+			if header == nil {
+				header = w.Header().Clone()
+			}
+			err = store.Complete(ctx, key, &IdempotencyRecord{
+				Fingerprint: fp,
+				Done:        true,
+				Status:      rec.status(),
+				Header:      replayHeader(header, outer),
+				Body:        rec.body.Bytes(),
+			}, o.ttl)
+			if err != nil {
+				slog.ErrorContext(ctx, "http: idempotency complete failed", "error", err)
+				return
+			}
+			stored = true
+		})
+	}
+}
+
+// requestFingerprint 是方法、URL 与请求体的 SHA-256 摘要。
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// perRequestHeaders 是逐请求生成、不应重放的响应头（规范化名称）。
+var perRequestHeaders = []string{"Set-Cookie", "Date", "Retry-After", RequestIDHeader, http.CanonicalHeaderKey(DefaultCSRFHeader)}
+
+// replayHeader 返回 h 中可重放的头部：去掉与 outer（进入中间件时的响应
+// 头）相同的头部、逐请求头部与 RateLimit-* 头部。
+func replayHeader(h, outer http.Header) http.Header {
+	out := http.Header{}
+	for k, v := range h {
+		if slices.Equal(v, outer[k]) || slices.Contains(perRequestHeaders, k) || strings.HasPrefix(k, "Ratelimit") {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func replayResponse(w http.ResponseWriter, rec *IdempotencyRecord) {
+	for k, v := range rec.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(rec.Status)
+	_, _ = w.Write(rec.Body)
+}
+
+// idempotencyRecorder 透传响应并记录状态码、响应头与响应体。
+type idempotencyRecorder struct {
+	w        http.ResponseWriter
+	max      int64
+	code     int
+	header   http.Header
+	body     bytes.Buffer
+	overflow bool
+	hijacked bool
+}
+
+func (rec *idempotencyRecorder) Header() http.Header { return rec.w.Header() }
+
+func (rec *idempotencyRecorder) WriteHeader(code int) {
+	if rec.code != 0 {
+		return
+	}
+	rec.code = code
+	rec.header = rec.w.Header().Clone()
+	rec.w.WriteHeader(code)
+}
+
+func (rec *idempotencyRecorder) Write(p []byte) (int, error) {
+	if rec.code == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if !rec.overflow {
+		if int64(rec.body.Len()+len(p)) > rec.max {
+			rec.overflow = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(p)
+		}
+	}
+	return rec.w.Write(p)
+}
+
+func (rec *idempotencyRecorder) status() int {
+	if rec.code == 0 {
+		return http.StatusOK
+	}
+	return rec.code
+}
+
+func (rec *idempotencyRecorder) Flush() {
+	if rec.code == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+	_ = http.NewResponseController(rec.w).Flush()
+}
+
+func (rec *idempotencyRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(rec.w).Hijack()
+	if err == nil {
+		rec.hijacked = true
+	}
+	return conn, rw, err
+}
+
+func (rec *idempotencyRecorder) Unwrap() http.ResponseWriter { return rec.w }
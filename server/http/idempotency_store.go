@@ -0,0 +1,328 @@
+package http
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IdempotencyRecord 是一个幂等键的状态：进行中（Done 为 false）或已完成
+// 的响应。
+type IdempotencyRecord struct {
+	// Fingerprint 是请求指纹（方法、URL 与请求体的摘要）。
+	Fingerprint string `json:"fingerprint"`
+	// Done 表示原请求已完成，Status/Header/Body 为其响应（Header 只含可
+	// 重放的头部，见 Idempotency）。
+	Done   bool        `json:"done"`
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+	// ExpiresAt 是记录的过期时间，由 Store 设置。
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IdempotencyStore 保存幂等键的状态。Idempotency 对每个带键请求调用
+// Begin，handler 完成后调用 Complete 或 Release；实现需并发安全，Begin
+// 必须是原子的“不存在则占用”。内存实现见 NewMemoryIdempotencyStore，
+// 文件实现见 NewFileIdempotencyStore；多实例部署可实现基于 Redis 等
+// 共享后端的 Store。
+type IdempotencyStore interface {
+	// Begin 在 key 不存在（或已过期）时以 fingerprint 占用它（进行中，
+	// ttl 后过期）并返回 nil；key 已存在时返回现有记录，不做修改。
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error)
+	// Complete 以已完成的 rec 替换 key 的记录，ttl 后过期。
+	Complete(ctx context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error
+	// Release 删除 key 的记录，使重试可以重新执行。
+	Release(ctx context.Context, key string) error
+}
+
+// DefaultIdempotencyStoreMaxBytes 是内存幂等 Store 的缺省容量上限。
+const DefaultIdempotencyStoreMaxBytes = 64 << 20
+
+// idempotencyEntryOverhead 是每条记录在响应体、响应头与键之外的估算开销。
+const idempotencyEntryOverhead = 128
+
+// MemoryIdempotencyStoreOption 用于配置内存幂等 Store 的选项函数。
+type MemoryIdempotencyStoreOption func(*MemoryIdempotencyStore)
+
+// WithIdempotencyStoreMaxBytes 设置内存 Store 的容量上限（键、指纹、
+// 响应头与响应体的估算字节数），缺省 DefaultIdempotencyStoreMaxBytes。
+// 超出时淘汰最久未访问的记录（LRU）；被淘汰的键再次出现时重新执行
+// handler，因此上限应覆盖 TTL 内预期的记录量。n ≤ 0 表示不限。
+func WithIdempotencyStoreMaxBytes(n int64) MemoryIdempotencyStoreOption {
+	return func(s *MemoryIdempotencyStore) {
+		s.maxBytes = n
+	}
+}
+
+// MemoryIdempotencyStore 是进程内的 IdempotencyStore：记录保存在有界
+// LRU 中（见 WithIdempotencyStoreMaxBytes），幂等键由客户端决定，容量
+// 上限防止大量不同的键耗尽内存；过期记录在访问时惰性回收（无后台
+// goroutine）。记录只在单个进程内可见，多实例部署时应使用共享 Store。
+type MemoryIdempotencyStore struct {
+	maxBytes int64
+	now      func() time.Time
+
+	mu        sync.Mutex
+	records   map[string]*list.Element
+	order     *list.List // 前端最近访问
+	size      int64
+	lastSweep time.Time
+}
+
+type memoryIdempotencyEntry struct {
+	key  string
+	rec  *IdempotencyRecord
+	size int64
+}
+
+// NewMemoryIdempotencyStore 创建内存幂等 Store。
+func NewMemoryIdempotencyStore(opts ...MemoryIdempotencyStoreOption) *MemoryIdempotencyStore {
+	s := &MemoryIdempotencyStore{
+		maxBytes: DefaultIdempotencyStoreMaxBytes,
+		now:      time.Now,
+		records:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+var _ IdempotencyStore = (*MemoryIdempotencyStore)(nil)
+
+// Begin 实现 IdempotencyStore。
+func (s *MemoryIdempotencyStore) Begin(_ context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked(now)
+	if el, ok := s.records[key]; ok {
+		if rec := el.Value.(*memoryIdempotencyEntry).rec; now.Before(rec.ExpiresAt) {
+			s.order.MoveToFront(el)
+			cp := *rec
+			return &cp, nil
+		}
+	}
+	s.putLocked(key, &IdempotencyRecord{Fingerprint: fingerprint, ExpiresAt: now.Add(ttl)})
+	return nil, nil
+}
+
+// Complete 实现 IdempotencyStore。超过容量上限的记录不保存，键随即
+// 释放。
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error {
+	cp := *rec
+	cp.ExpiresAt = s.now().Add(ttl)
+	s.mu.Lock()
+	s.putLocked(key, &cp)
+	s.mu.Unlock()
+	return nil
+}
+
+// Release 实现 IdempotencyStore。
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	if el, ok := s.records[key]; ok {
+		s.removeLocked(el)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// Len 返回当前保留的记录数。
+func (s *MemoryIdempotencyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// putLocked 保存 key 的记录并按容量上限从 LRU 尾部淘汰。
+func (s *MemoryIdempotencyStore) putLocked(key string, rec *IdempotencyRecord) {
+	if el, ok := s.records[key]; ok {
+		s.removeLocked(el)
+	}
+	e := &memoryIdempotencyEntry{key: key, rec: rec,
+		size: int64(len(key)+len(rec.Fingerprint)+len(rec.Body)+idempotencyEntryOverhead) + headerSize(rec.Header)}
+	if s.maxBytes > 0 && e.size > s.maxBytes {
+		return
+	}
+	s.records[key] = s.order.PushFront(e)
+	s.size += e.size
+	for s.maxBytes > 0 && s.size > s.maxBytes {
+		s.removeLocked(s.order.Back())
+	}
+}
+
+func (s *MemoryIdempotencyStore) removeLocked(el *list.Element) {
+	e := el.Value.(*memoryIdempotencyEntry)
+	s.order.Remove(el)
+	delete(s.records, e.key)
+	s.size -= e.size
+}
+
+// sweepLocked 每分钟至多一次回收过期记录。
+func (s *MemoryIdempotencyStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for _, el := range s.records {
+		if !now.Before(el.Value.(*memoryIdempotencyEntry).rec.ExpiresAt) {
+			s.removeLocked(el)
+		}
+	}
+}
+
+// FileIdempotencyStore 把每个幂等键保存为目录下的一个 JSON 文件（文件名
+// 为键的 SHA-256），进程重启后记录仍然有效；同一目录可由同一主机上的
+// 多个进程共享（占用以 O_EXCL 创建文件实现）。过期文件在访问时回收。
+type FileIdempotencyStore struct {
+	dir string
+	now func() time.Time
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewFileIdempotencyStore 创建以 dir 为目录的幂等 Store，目录不存在时
+// 创建。
+func NewFileIdempotencyStore(dir string) (*FileIdempotencyStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("http: idempotency store: %w", err)
+	}
+	return &FileIdempotencyStore{dir: dir, now: time.Now}, nil
+}
+
+var _ IdempotencyStore = (*FileIdempotencyStore)(nil)
+
+func (s *FileIdempotencyStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Begin 实现 IdempotencyStore。
+func (s *FileIdempotencyStore) Begin(_ context.Context, key, fingerprint string, ttl time.Duration) (*IdempotencyRecord, error) {
+	now := s.now()
+	s.sweep(now)
+	path := s.path(key)
+	data, err := json.Marshal(&IdempotencyRecord{Fingerprint: fingerprint, ExpiresAt: now.Add(ttl)})
+	if err != nil {
+		return nil, err
+	}
+	// 过期记录删除后重试一次；其他进程同时占用时以其记录为准。
+	for range 2 {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			_, err = f.Write(data)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				_ = os.Remove(path)
+				return nil, fmt.Errorf("http: idempotency store: %w", err)
+			}
+			return nil, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("http: idempotency store: %w", err)
+		}
+		rec, err := readIdempotencyRecord(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		case err != nil:
+			// 其他进程刚创建、尚未写完的文件视为进行中；超过 ttl 仍无法
+			// 解析的文件视为损坏并删除。
+			if fi, serr := os.Stat(path); serr == nil && now.Sub(fi.ModTime()) < ttl {
+				return &IdempotencyRecord{Fingerprint: fingerprint}, nil
+			}
+		case now.Before(rec.ExpiresAt):
+			return rec, nil
+		}
+		_ = os.Remove(path)
+	}
+	return nil, fmt.Errorf("http: idempotency store: cannot claim key")
+}
+
+// Complete 实现 IdempotencyStore。记录先写临时文件再重命名，读取方不会
+// 看到写了一半的记录。
+func (s *FileIdempotencyStore) Complete(_ context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error {
+	cp := *rec
+	cp.ExpiresAt = s.now().Add(ttl)
+	data, err := json.Marshal(&cp)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("http: idempotency store: %w", err)
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return fmt.Errorf("http: idempotency store: %w", err)
+	}
+	return nil
+}
+
+// Release 实现 IdempotencyStore。
+func (s *FileIdempotencyStore) Release(_ context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("http: idempotency store: %w", err)
+	}
+	return nil
+}
+
+// sweep 每分钟至多一次删除过期记录。
+func (s *FileIdempotencyStore) sweep(now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < time.Minute {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(s.dir, e.Name())
+		if rec, err := readIdempotencyRecord(path); err == nil && !now.Before(rec.ExpiresAt) {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+func readIdempotencyRecord(path string) (*IdempotencyRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec IdempotencyRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("http: idempotency store: parse %s: %w", path, err)
+	}
+	return &rec, nil
+}
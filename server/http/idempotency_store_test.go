@@ -0,0 +1,127 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testIdempotencyStore(t *testing.T, s IdempotencyStore, advance func(time.Duration)) {
+	t.Helper()
+	ctx := context.Background()
+	if prev, err := s.Begin(ctx, "k", "fp", time.Minute); err != nil || prev != nil {
+		t.Fatalf("Begin = %+v, %v", prev, err)
+	}
+	if prev, err := s.Begin(ctx, "k", "other", time.Minute); err != nil || prev == nil || prev.Done || prev.Fingerprint != "fp" {
+		t.Fatalf("Begin in flight = %+v, %v", prev, err)
+	}
+	rec := &IdempotencyRecord{Fingerprint: "fp", Done: true, Status: http.StatusCreated,
+		Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"id":1}`)}
+	if err := s.Complete(ctx, "k", rec, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	prev, err := s.Begin(ctx, "k", "fp", time.Minute)
+	if err != nil || prev == nil || !prev.Done || prev.Status != http.StatusCreated || string(prev.Body) != `{"id":1}` ||
+		prev.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Begin completed = %+v, %v", prev, err)
+	}
+
+	advance(2 * time.Hour)
+	if prev, err := s.Begin(ctx, "k", "fp2", time.Minute); err != nil || prev != nil {
+		t.Fatalf("Begin after expiry = %+v, %v", prev, err)
+	}
+	if err := s.Release(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if prev, err := s.Begin(ctx, "k", "fp3", time.Minute); err != nil || prev != nil {
+		t.Fatalf("Begin after release = %+v, %v", prev, err)
+	}
+	if err := s.Release(ctx, "missing"); err != nil {
+		t.Fatalf("Release missing = %v", err)
+	}
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	s := NewMemoryIdempotencyStore()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	testIdempotencyStore(t, s, func(d time.Duration) { now = now.Add(d) })
+	if len(s.records) != 1 {
+		t.Fatalf("records = %d, want expired records swept", len(s.records))
+	}
+}
+
+// TestMemoryIdempotencyStoreBounded：大量不同的键不会突破容量上限，
+// 最久未访问的记录先被淘汰，超过上限的单条记录不保存。
+func TestMemoryIdempotencyStoreBounded(t *testing.T) {
+	ctx := context.Background()
+	body := make([]byte, 1000)
+	s := NewMemoryIdempotencyStore(WithIdempotencyStoreMaxBytes(10 << 10))
+	for i := range 100 {
+		key := fmt.Sprintf("k%d", i)
+		if _, err := s.Begin(ctx, key, "fp", time.Minute); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Complete(ctx, key, &IdempotencyRecord{Fingerprint: "fp", Done: true, Body: body}, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			continue
+		}
+		// k0 保持最近访问，不被淘汰。
+		if prev, _ := s.Begin(ctx, "k0", "fp", time.Minute); prev == nil || !prev.Done {
+			t.Fatalf("k0 evicted after %d keys", i)
+		}
+	}
+	if s.size > 10<<10 || s.Len() >= 10 {
+		t.Fatalf("size = %d, entries = %d, want within 10 KiB", s.size, s.Len())
+	}
+	if prev, _ := s.Begin(ctx, "k1", "fp", time.Minute); prev != nil {
+		t.Fatalf("least recently used k1 still stored: %+v", prev)
+	}
+
+	_ = s.Complete(ctx, "huge", &IdempotencyRecord{Fingerprint: "fp", Done: true, Body: make([]byte, 20<<10)}, time.Hour)
+	if prev, _ := s.Begin(ctx, "huge", "fp", time.Minute); prev != nil {
+		t.Fatalf("oversized record stored: %+v", prev)
+	}
+}
+
+func TestFileIdempotencyStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "idem")
+	s, err := NewFileIdempotencyStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	testIdempotencyStore(t, s, func(d time.Duration) { now = now.Add(d) })
+
+	// 记录在新实例（进程重启）中仍然有效。
+	ctx := context.Background()
+	if err := s.Complete(ctx, "persist", &IdempotencyRecord{Fingerprint: "fp", Done: true, Status: 200}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	s2, err := NewFileIdempotencyStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev, err := s2.Begin(ctx, "persist", "fp", time.Minute); err != nil || prev == nil || !prev.Done {
+		t.Fatalf("Begin after restart = %+v, %v", prev, err)
+	}
+
+	// 无法解析的文件：刚创建时视为进行中，超过 ttl 后删除。
+	if err := os.WriteFile(s.path("partial"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if prev, err := s2.Begin(ctx, "partial", "fp", time.Minute); err != nil || prev == nil || prev.Done {
+		t.Fatalf("Begin partial = %+v, %v", prev, err)
+	}
+	s2.now = func() time.Time { return time.Now().Add(time.Hour) }
+	if prev, err := s2.Begin(ctx, "partial", "fp", time.Minute); err != nil || prev != nil {
+		t.Fatalf("Begin corrupt = %+v, %v", prev, err)
+	}
+}
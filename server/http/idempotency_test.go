@@ -0,0 +1,177 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lynx-go/lynx/logging"
+)
+
+func idempotentRequest(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency(t *testing.T) {
+	var calls atomic.Int32
+	h := Idempotency(NewMemoryIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("X-Payment", "p-1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(strings.Repeat("ok", int(n))))
+	}))
+
+	first := idempotentRequest(h, "k1", `{"amount":10}`)
+	if first.Code != http.StatusCreated || first.Body.String() != "ok" {
+		t.Fatalf("first: status = %d body = %q", first.Code, first.Body)
+	}
+	replay := idempotentRequest(h, "k1", `{"amount":10}`)
+	if replay.Code != http.StatusCreated || replay.Body.String() != "ok" ||
+		replay.Header().Get("X-Payment") != "p-1" || replay.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("replay: status = %d body = %q headers = %v", replay.Code, replay.Body, replay.Header())
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("handler calls = %d, want 1", n)
+	}
+
+	if rec := idempotentRequest(h, "k1", `{"amount":99}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("mismatch status = %d", rec.Code)
+	}
+	if rec := idempotentRequest(h, "", `{"amount":10}`); rec.Code != http.StatusCreated || calls.Load() != 2 {
+		t.Fatalf("without key: status = %d calls = %d", rec.Code, calls.Load())
+	}
+	if rec := idempotentRequest(h, strings.Repeat("k", 256), ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("long key status = %d", rec.Code)
+	}
+}
+
+// TestIdempotencyReplayHeaders：重放不带回原请求的逐请求头部，外层
+// WithRequestID 为重放请求写入新的 request_id。
+func TestIdempotencyReplayHeaders(t *testing.T) {
+	h := WithRequestID()(Idempotency(NewMemoryIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/payments/p-1")
+		w.Header().Set("Set-Cookie", "session=s1")
+		w.Header().Set("RateLimit-Remaining", "9")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"p-1"}`))
+	})))
+
+	first := idempotentRequest(h, "k1", `{"amount":10}`)
+	replay := idempotentRequest(h, "k1", `{"amount":10}`)
+	if replay.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Fatalf("second request was not replayed: %v", replay.Header())
+	}
+	firstID, replayID := first.Header().Get(RequestIDHeader), replay.Header().Get(RequestIDHeader)
+	if firstID == "" || replayID == "" || replayID == firstID {
+		t.Fatalf("request ids: first = %q, replay = %q", firstID, replayID)
+	}
+	if got := replay.Header().Values(RequestIDHeader); len(got) != 1 {
+		t.Fatalf("replay %s = %v", RequestIDHeader, got)
+	}
+	if replay.Header().Get("Content-Type") != "application/json" || replay.Header().Get("Location") != "/payments/p-1" {
+		t.Fatalf("entity headers not replayed: %v", replay.Header())
+	}
+	if replay.Header().Get("Set-Cookie") != "" || replay.Header().Get("RateLimit-Remaining") != "" {
+		t.Fatalf("per-request headers replayed: %v", replay.Header())
+	}
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	h := Idempotency(NewMemoryIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotentRequest(h, "k1", "body") }()
+	<-started
+	if rec := idempotentRequest(h, "k1", "body"); rec.Code != http.StatusConflict || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("in-flight status = %d headers = %v", rec.Code, rec.Header())
+	}
+	close(release)
+	if rec := <-done; rec.Code != http.StatusOK {
+		t.Fatalf("original status = %d", rec.Code)
+	}
+}
+
+func TestIdempotencyReleasesOnFailure(t *testing.T) {
+	var calls atomic.Int32
+	h := Idempotency(NewMemoryIdempotencyStore(), WithIdempotencyRequired())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	if rec := idempotentRequest(h, "k1", "body"); rec.Code != http.StatusBadGateway {
+		t.Fatalf("first status = %d", rec.Code)
+	}
+	if rec := idempotentRequest(h, "k1", "body"); rec.Code != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("retry after 5xx: status = %d calls = %d", rec.Code, calls.Load())
+	}
+	if rec := idempotentRequest(h, "", "body"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("missing key status = %d", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/payments", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET status = %d", rec.Code)
+	}
+}
+
+// ctxCheckingStore 像网络 Store 一样在 ctx 已取消时失败。
+type ctxCheckingStore struct{ *MemoryIdempotencyStore }
+
+func (s ctxCheckingStore) Complete(ctx context.Context, key string, rec *IdempotencyRecord, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.MemoryIdempotencyStore.Complete(ctx, key, rec, ttl)
+}
+
+// TestIdempotencyClientDisconnect：客户端在响应途中断开（请求 ctx 取消）
+// 时仍记录完成，重复请求得到重放而不是 409。
+func TestIdempotencyClientDisconnect(t *testing.T) {
+	var calls atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	h := Idempotency(ctxCheckingStore{NewMemoryIdempotencyStore()})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusCreated)
+		cancel()
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader("body"))
+	req.Header.Set(IdempotencyKeyHeader, "k1")
+	h.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+	rec := idempotentRequest(h, "k1", "body")
+	if rec.Code != http.StatusCreated || rec.Header().Get(IdempotentReplayedHeader) != "true" || calls.Load() != 1 {
+		t.Fatalf("after disconnect: status = %d headers = %v calls = %d", rec.Code, rec.Header(), calls.Load())
+	}
+}
+
+func TestIdempotencyScopedByUser(t *testing.T) {
+	var calls atomic.Int32
+	h := Idempotency(NewMemoryIdempotencyStore())(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		calls.Add(1)
+	}))
+	for _, user := range []string{"u-1", "u-2", "u-1"} {
+		req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader("body"))
+		req.Header.Set(IdempotencyKeyHeader, "k1")
+		ctx := logging.WithAttrs(context.Background(), slog.String(logging.FieldUserID, user))
+		h.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("handler calls = %d, want 2", n)
+	}
+}
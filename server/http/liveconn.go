@@ -0,0 +1,150 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// LiveConn 是登记到 Server 的长连接（SSE 流、WebSocket），使其参与优雅
+// 关停：http.Server.Shutdown 不跟踪 Hijack 的连接，也不会打断仍在写的
+// 流式响应。NewSSEStream 与 UpgradeWebSocket 自动登记；其他 WebSocket
+// 库的连接可经 TrackConn 登记。
+type LiveConn interface {
+	// GoAway 通知对端服务器即将关闭（WebSocket close 帧 1001、SSE retry
+	// 事件），请其断开并重连到其他实例。不得阻塞，可能被调用多次。
+	GoAway()
+	// Close 强制关闭连接，在关停预算耗尽时调用。
+	Close() error
+}
+
+// LiveConnsError 报告关停预算耗尽时仍未断开（随后被强制关闭）的长连接
+// 数。Stop 返回的错误包含它，由框架收集进 lynx.ShutdownErrors，可用
+// errors.As 取出。
+type LiveConnsError struct {
+	Remaining int
+}
+
+func (e *LiveConnsError) Error() string {
+	return fmt.Sprintf("http: %d live connections still open at shutdown deadline", e.Remaining)
+}
+
+// connTracker 记录 Server 的长连接。
+type connTracker struct {
+	mu       sync.Mutex
+	conns    map[*trackedConn]struct{}
+	draining bool
+	// empty 在连接数降为 0 时关闭并重建，供 wait 等待。
+	empty chan struct{}
+}
+
+type trackedConn struct {
+	c    LiveConn
+	once sync.Once
+}
+
+func (t *trackedConn) goAway() { t.once.Do(t.c.GoAway) }
+
+func newConnTracker() *connTracker {
+	return &connTracker{conns: map[*trackedConn]struct{}{}}
+}
+
+type connTrackerCtx struct{}
+
+// withConnTracker 在请求 Context 中放入 Server 的长连接登记表（Server 在
+// 处理链外层安装）。
+func withConnTracker(t *connTracker) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), connTrackerCtx{}, t)))
+		})
+	}
+}
+
+// TrackConn 把 c 登记为 ctx（请求 Context）所属 Server 的长连接，返回的
+// untrack 须在连接结束时调用（可重复调用）。Server 排水（lynx.Drainer）或
+// Stop 时调用 c.GoAway，并在关停预算内等待全部连接 untrack，超时后调用
+// c.Close。服务器已在排水时登记的连接立即收到 GoAway。请求未经 Server
+// 处理（如测试中直接调用 handler）时不做登记。
+func TrackConn(ctx context.Context, c LiveConn) (untrack func()) {
+	t, ok := ctx.Value(connTrackerCtx{}).(*connTracker)
+	if !ok {
+		return func() {}
+	}
+	tc := &trackedConn{c: c}
+	t.mu.Lock()
+	t.conns[tc] = struct{}{}
+	draining := t.draining
+	t.mu.Unlock()
+	if draining {
+		tc.goAway()
+	}
+	var once sync.Once
+	return func() { once.Do(func() { t.remove(tc) }) }
+}
+
+func (t *connTracker) remove(tc *trackedConn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.conns, tc)
+	if len(t.conns) == 0 && t.empty != nil {
+		close(t.empty)
+		t.empty = nil
+	}
+}
+
+func (t *connTracker) snapshot() []*trackedConn {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	conns := make([]*trackedConn, 0, len(t.conns))
+	for tc := range t.conns {
+		conns = append(conns, tc)
+	}
+	return conns
+}
+
+// goAway 进入排水状态并通知全部连接。
+func (t *connTracker) goAway() {
+	t.mu.Lock()
+	t.draining = true
+	t.mu.Unlock()
+	for _, tc := range t.snapshot() {
+		tc.goAway()
+	}
+}
+
+// wait 等待全部连接断开，ctx 结束时返回剩余连接数。
+func (t *connTracker) wait(ctx context.Context) int {
+	t.mu.Lock()
+	if len(t.conns) == 0 {
+		t.mu.Unlock()
+		return 0
+	}
+	if t.empty == nil {
+		t.empty = make(chan struct{})
+	}
+	empty := t.empty
+	t.mu.Unlock()
+	select {
+	case <-empty:
+		return 0
+	case <-ctx.Done():
+		return t.count()
+	}
+}
+
+func (t *connTracker) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.conns)
+}
+
+// closeAll 强制关闭剩余连接，返回关闭的数量。
+func (t *connTracker) closeAll() int {
+	conns := t.snapshot()
+	for _, tc := range conns {
+		_ = tc.c.Close()
+	}
+	return len(conns)
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// startLiveServer 在随机端口启动 Server，返回地址；测试结束时确保停止。
+func startLiveServer(t *testing.T, h http.Handler, opts ...Option) (*Server, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+	srv := NewServer(h, append([]Option{WithAddr(addr)}, opts...)...)
+	go func() { _ = srv.Start(context.Background()) }()
+	waitForDial(t, addr)
+	t.Cleanup(func() { _ = srv.Stop(context.Background()) })
+	return srv, addr
+}
+
+type fakeLiveConn struct {
+	goAway chan struct{}
+	closed chan struct{}
+}
+
+func newFakeLiveConn() *fakeLiveConn {
+	return &fakeLiveConn{goAway: make(chan struct{}), closed: make(chan struct{})}
+}
+
+func (c *fakeLiveConn) GoAway() { close(c.goAway) }
+
+func (c *fakeLiveConn) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func TestTrackConnWithoutServerIsNoop(t *testing.T) {
+	untrack := TrackConn(context.Background(), newFakeLiveConn())
+	untrack()
+	untrack()
+}
+
+func TestConnTrackerGoAwayAndWait(t *testing.T) {
+	tr := newConnTracker()
+	ctx := context.WithValue(context.Background(), connTrackerCtx{}, tr)
+	c := newFakeLiveConn()
+	untrack := TrackConn(ctx, c)
+
+	tr.goAway()
+	tr.goAway() // GoAway 只调用一次
+	select {
+	case <-c.goAway:
+	default:
+		t.Fatal("GoAway not called")
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		untrack()
+	}()
+	if n := tr.wait(context.Background()); n != 0 {
+		t.Errorf("wait() = %d, want 0", n)
+	}
+
+	// 排水中登记的连接立即收到 GoAway。
+	late := newFakeLiveConn()
+	defer TrackConn(ctx, late)()
+	select {
+	case <-late.goAway:
+	default:
+		t.Error("connection tracked while draining did not get GoAway")
+	}
+}
+
+func TestConnTrackerWaitTimeout(t *testing.T) {
+	tr := newConnTracker()
+	ctx := context.WithValue(context.Background(), connTrackerCtx{}, tr)
+	c := newFakeLiveConn()
+	TrackConn(ctx, c)
+
+	wctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if n := tr.wait(wctx); n != 1 {
+		t.Errorf("wait() = %d, want 1", n)
+	}
+	if n := tr.closeAll(); n != 1 {
+		t.Errorf("closeAll() = %d, want 1", n)
+	}
+	select {
+	case <-c.closed:
+	default:
+		t.Error("Close not called")
+	}
+}
+
+func TestServerStopReportsLiveConns(t *testing.T) {
+	registered := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("Hijack() error = %v", err)
+			return
+		}
+		c := newFakeLiveConn()
+		untrack := TrackConn(r.Context(), c)
+		close(registered)
+		go func() {
+			<-c.closed // 忽略 GoAway，直到被强制关闭
+			_ = conn.Close()
+			untrack()
+		}()
+	})
+	srv, addr := startLiveServer(t, h, WithShutdownTimeout(100*time.Millisecond))
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, _ = conn.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"))
+	select {
+	case <-registered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection was not registered")
+	}
+
+	err = srv.Stop(context.Background())
+	var lce *LiveConnsError
+	if !errors.As(err, &lce) || lce.Remaining != 1 {
+		t.Fatalf("Stop() error = %v, want *LiveConnsError{Remaining: 1}", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want context.DeadlineExceeded in chain", err)
+	}
+}
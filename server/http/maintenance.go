@@ -0,0 +1,86 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lynx-go/lynx"
+)
+
+// 维护模式响应的缺省值。
+const (
+	DefaultMaintenanceRetryAfter = 60 * time.Second
+	DefaultMaintenanceMessage    = "service under maintenance"
+)
+
+// MaintenanceOptions 配置维护模式（lynx.Maintenance）下的请求拦截：维护
+// 期间非白名单路径返回 503 + Retry-After + JSON 错误体。健康检查端点
+// （/healthz/*）独立挂载，始终放行。
+type MaintenanceOptions struct {
+	// Allowlist 是维护期间仍放行的路径：以 "/" 结尾时按前缀匹配，否则精确匹配。
+	Allowlist []string
+	// RetryAfter 是 Retry-After 响应头（按秒向上取整）；0 时使用
+	// DefaultMaintenanceRetryAfter，负值不设置该头。
+	RetryAfter time.Duration
+	// Message 是 503 JSON 错误体的消息；空时使用 DefaultMaintenanceMessage。
+	Message string
+	// Handler 非 nil 时替代缺省 503 响应（Retry-After 头仍由中间件设置）。
+	Handler http.Handler
+	// State 是维护状态；nil 时由 Server.Init 取应用 Context 携带的状态。
+	State *lynx.Maintenance
+}
+
+// WithMaintenance 配置维护模式下的拦截行为；未设置时按缺省值拦截应用
+// Context 携带的维护状态（脱离框架单用且未设置 State 时不拦截）。
+func WithMaintenance(o MaintenanceOptions) Option {
+	return func(opts *Options) {
+		opts.Maintenance = o
+	}
+}
+
+// Maintenance 返回维护模式中间件：m 处于维护状态时非白名单路径按 o 响应
+// 503，否则透传。m 为 nil 时等价于不拦截。o.State 被忽略（以 m 为准）。
+func Maintenance(m *lynx.Maintenance, o MaintenanceOptions) Middleware {
+	if o.RetryAfter == 0 {
+		o.RetryAfter = DefaultMaintenanceRetryAfter
+	}
+	if o.Message == "" {
+		o.Message = DefaultMaintenanceMessage
+	}
+	retryAfter := ""
+	if o.RetryAfter > 0 {
+		retryAfter = strconv.Itoa(int((o.RetryAfter + time.Second - 1) / time.Second))
+	}
+	reject := o.Handler
+	if reject == nil {
+		err := &APIError{Status: http.StatusServiceUnavailable, Message: o.Message, quiet: true}
+		reject = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, r, err)
+		})
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !m.Enabled() || pathAllowed(o.Allowlist, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			reject.ServeHTTP(w, r)
+		})
+	}
+}
+
+// pathAllowed 报告 path 是否在列表中：以 "/" 结尾的条目按前缀匹配，
+// 其余精确匹配。
+func pathAllowed(list []string, path string) bool {
+	for _, p := range list {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
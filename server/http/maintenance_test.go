@@ -0,0 +1,111 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lynx-go/lynx"
+)
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	m := lynx.NewMaintenance()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	h := Maintenance(m, MaintenanceOptions{Allowlist: []string{"/admin/", "/status"}})(ok)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+	if rec := serve("/api"); rec.Code != http.StatusNoContent {
+		t.Fatalf("outside maintenance status = %d, want 204", rec.Code)
+	}
+
+	m.Set(true)
+	rec := serve("/api")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("Retry-After = %q, want 60", got)
+	}
+	if !strings.Contains(rec.Body.String(), DefaultMaintenanceMessage) {
+		t.Errorf("body = %s, want default maintenance message", rec.Body)
+	}
+	for _, path := range []string{"/admin/migrate", "/status"} {
+		if rec := serve(path); rec.Code != http.StatusNoContent {
+			t.Errorf("allowlisted %s status = %d, want 204", path, rec.Code)
+		}
+	}
+	if rec := serve("/status/more"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/status/more status = %d, want 503 (exact match only)", rec.Code)
+	}
+}
+
+func TestMaintenanceMiddlewareCustomResponse(t *testing.T) {
+	m := lynx.NewMaintenance()
+	m.Set(true)
+	h := Maintenance(m, MaintenanceOptions{
+		RetryAfter: -1,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}),
+	})(http.NotFoundHandler())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want custom 418", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "" {
+		t.Errorf("Retry-After = %q, want unset for negative RetryAfter", got)
+	}
+
+	h = Maintenance(m, MaintenanceOptions{RetryAfter: 1500 * time.Millisecond, Message: "migrating"})(http.NotFoundHandler())
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2 (rounded up)", got)
+	}
+	if !strings.Contains(rec.Body.String(), "migrating") {
+		t.Errorf("body = %s, want custom message", rec.Body)
+	}
+}
+
+// maintenanceAppContext 是携带维护状态的最小 lynx.AppContext 实现。
+type maintenanceAppContext struct {
+	envAppContext
+	m *lynx.Maintenance
+}
+
+func (c maintenanceAppContext) Context() context.Context {
+	return lynx.ContextWithMaintenance(context.Background(), c.m)
+}
+
+// TestServerMaintenanceFromAppContext 验证 Init 取应用 Context 的维护状态：
+// 业务路由被拦截，健康端点不受影响。
+func TestServerMaintenanceFromAppContext(t *testing.T) {
+	m := lynx.NewMaintenance()
+	srv := NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	if err := srv.Init(maintenanceAppContext{m: m}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	h := srv.buildHandler(context.Background())
+	m.Set(true)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/orders status = %d, want 503", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz/liveness", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/healthz/liveness status = %d, want 200", rec.Code)
+	}
+}
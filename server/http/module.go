@@ -0,0 +1,32 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/boot"
+)
+
+// newModuleServer 以应用提供的 handler、"server.http" 段与应用提供的
+// 选项创建服务器。
+func newModuleServer(app lynx.App, h http.Handler, extra []Option) (*Server, error) {
+	opts, err := OptionsFromConfig(app.Config())
+	if err != nil {
+		return nil, err
+	}
+	base := []Option{WithLogger(app.Logger("logger", "http-requestlog")), WithHealthCheckers(app.HealthCheckers)}
+	return NewServer(h, append(append(base, opts...), extra...)...), nil
+}
+
+// Module 是 HTTP 服务的 boot 模块：以应用提供的 net/http.Handler（如
+// *Router）与 "server.http" 段（见 OptionsFromConfig）创建并注册 Server；
+// 应用可再提供 []http.Option 分组追加选项（后者优先）。可用
+// modules.http.enabled: false 关闭。
+var Module = boot.Module{
+	Name:      "http",
+	Providers: []any{newModuleServer},
+	Groups:    []any{[]Option(nil)},
+	Defaults:  map[string]any{ConfigKey + ".addr": DefaultHTTPAddr},
+	ConfigKey: ConfigKey,
+	Config:    Config{},
+}
@@ -0,0 +1,63 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lynx-go/lynx/boot"
+	"github.com/lynx-go/lynx/boot/boottest"
+)
+
+func TestModule(t *testing.T) {
+	tests := []struct {
+		name        string
+		yaml        string
+		extra       []Option
+		want        string
+		readTimeout time.Duration
+	}{
+		{"default addr", "name: demo\n", nil, DefaultHTTPAddr, 0},
+		{"config addr", "server:\n  http:\n    addr: \":7000\"\n    read_timeout: 5s\n", nil, ":7000", 5 * time.Second},
+		{"app options last", "server:\n  http:\n    addr: \":7000\"\n", []Option{WithAddr("127.0.0.1:0")}, "127.0.0.1:0", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := boot.NewContainer()
+			if err := c.Install(Module); err != nil {
+				t.Fatal(err)
+			}
+			err := c.Provide(
+				func() http.Handler { return http.NotFoundHandler() },
+				func() []Option { return tt.extra },
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			app := boottest.NewApp(configFromYAML(t, tt.yaml))
+			if err := c.Bind(app); err != nil {
+				t.Fatalf("Bind() error = %v", err)
+			}
+			srv, ok := app.Services[0].(*Server)
+			if !ok {
+				t.Fatalf("registered %T, want *Server", app.Services[0])
+			}
+			if srv.o.Addr != tt.want {
+				t.Errorf("Addr = %q, want %q", srv.o.Addr, tt.want)
+			}
+			if srv.o.ReadTimeout != tt.readTimeout {
+				t.Errorf("ReadTimeout = %v, want %v", srv.o.ReadTimeout, tt.readTimeout)
+			}
+		})
+	}
+}
+
+func TestModuleInvalidConfig(t *testing.T) {
+	c := boot.NewContainer()
+	_ = c.Install(Module)
+	_ = c.Provide(func() http.Handler { return http.NotFoundHandler() })
+	app := boottest.NewApp(configFromYAML(t, "server:\n  http:\n    tls:\n      cert_file: a.crt\n"))
+	if err := c.Bind(app); err == nil {
+		t.Error("Bind() with tls without key_file error = nil")
+	}
+}
@@ -0,0 +1,648 @@
+package http
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// OpenAPI 文档与 UI 的缺省挂载路径。
+const (
+	DefaultOpenAPIPath   = "/openapi.json"
+	DefaultOpenAPIUIPath = "/docs"
+)
+
+// OpenAPIOptions.UI 的取值。
+const (
+	OpenAPIUISwagger = "swagger"
+	OpenAPIUIRedoc   = "redoc"
+)
+
+// OpenAPI UI 页面前端资源的缺省地址（jsDelivr CDN，见
+// OpenAPIOptions.UIAssetsURL）。
+const (
+	DefaultSwaggerUIAssetsURL = "https://cdn.jsdelivr.net/npm/swagger-ui-dist@5"
+	DefaultRedocAssetsURL     = "https://cdn.jsdelivr.net/npm/redoc@2/bundles"
+)
+
+// OpenAPIFlag 是输出 OpenAPI 文档的命令行 flag 名（见 SetOpenAPIFlags）。
+const OpenAPIFlag = "openapi"
+
+// OpenAPIOptions 配置 OpenAPI 文档的生成与挂载（见 WithOpenAPI）。
+type OpenAPIOptions struct {
+	// Path 是文档的挂载路径，空时为 DefaultOpenAPIPath。
+	Path string
+	// Title 是 info.title，空时由 Server.Init 取应用名。
+	Title string
+	// Version 是 info.version，空时由 Server.Init 取应用版本。
+	Version string
+	// Description 是 info.description。
+	Description string
+	// Servers 是 servers[].url 列表。
+	Servers []string
+	// UI 为 OpenAPIUISwagger 或 OpenAPIUIRedoc 时在 UIPath 挂载文档页面，
+	// 空时不挂载。框架只输出一个 HTML 外壳，页面的脚本与样式由浏览器从
+	// UIAssetsURL 加载（缺省 jsDelivr CDN）：离线或内网环境需自托管资源；
+	// 页面经 SecurityHeaders 输出时，CSP 需放行该来源（script-src/
+	// style-src），Swagger UI 另有一段内联初始化脚本，需 'unsafe-inline'
+	// 或对应的 hash。
+	UI string
+	// UIPath 是文档页面的挂载路径，空时为 DefaultOpenAPIUIPath。
+	UIPath string
+	// UIAssetsURL 是 UI 前端资源所在目录的 URL（不含末尾 "/"），空时为
+	// DefaultSwaggerUIAssetsURL 或 DefaultRedocAssetsURL。自托管时指向
+	// swagger-ui-dist 包根目录（含 swagger-ui.css 与 swagger-ui-bundle.js）
+	// 或 redoc 包的 bundles 目录（含 redoc.standalone.js），可为同源路径，
+	// 如 "/static/swagger-ui"。
+	UIAssetsURL string
+	// ErrorHandler 决定错误响应的 schema：DefaultErrorHandler 为
+	// {"error":{...}}，ProblemErrorHandler 为 problem+json，其他 handler
+	// 只描述状态码。nil 时取服务器级 ErrorHandler（WithErrorHandler）。
+	ErrorHandler ErrorHandler
+}
+
+// WithOpenAPI 启用 OpenAPI 文档：Server 由其 handler（需为 *Router）的
+// 路由表生成 OpenAPI 3.1 文档，挂载在 o.Path，可选挂载 UI 页面。与健康
+// 检查端点一样独立挂载，不经过业务中间件与维护拦截；需要鉴权时可用
+// NewOpenAPI 生成后自行挂载到 Router。
+func WithOpenAPI(o OpenAPIOptions) Option {
+	return func(opts *Options) {
+		opts.OpenAPI = &o
+	}
+}
+
+// SetOpenAPIFlags 注册 --openapi flag：该 flag（配置键 OpenAPIFlag）为
+// true 时，Server 在应用 Run 开始、全部路由注册完成后把 OpenAPI 文档写到
+// 标准输出并结束运行（见 Server.PreRun），不执行钩子、不启动任何服务，
+// 便于 CI 对比文档变更。与缺省 flags 组合使用：
+//
+//	lynx.WithSetFlagsFunc(func(f *pflag.FlagSet) {
+//		lynx.DefaultSetFlagsFunc(f)
+//		http.SetOpenAPIFlags(f)
+//	})
+func SetOpenAPIFlags(f *pflag.FlagSet) {
+	f.Bool(OpenAPIFlag, false, "print the OpenAPI document to stdout and exit")
+}
+
+// WithSummary 设置类型化路由在 OpenAPI 文档中的 summary。
+func WithSummary(summary string) JSONOption {
+	return func(o *jsonOptions) {
+		o.op.summary = summary
+	}
+}
+
+// WithTags 设置类型化路由在 OpenAPI 文档中的 tags。
+func WithTags(tags ...string) JSONOption {
+	return func(o *jsonOptions) {
+		o.op.tags = tags
+	}
+}
+
+// WithOperationID 设置类型化路由在 OpenAPI 文档中的 operationId。
+func WithOperationID(id string) JSONOption {
+	return func(o *jsonOptions) {
+		o.op.id = id
+	}
+}
+
+// operationDoc 是由 JSONOption 设置的操作描述。
+type operationDoc struct {
+	id      string
+	summary string
+	tags    []string
+}
+
+// NewOpenAPI 由路由表（Router.Routes）生成 OpenAPI 3.1 JSON 文档：
+//   - JSON 创建的类型化路由：path/query/header 标签生成 parameters，其余
+//     字段生成 requestBody（GET/HEAD/DELETE 除外），响应类型生成成功响应，
+//     媒体类型取自其编解码器注册表；validate 标签映射为 required、
+//     minimum/maximum、minLength/maxLength、minItems/maxItems、enum 与
+//     pattern；具名结构体放入 components.schemas；
+//     另带 400 与 default 错误响应，schema 由 o.ErrorHandler 决定；
+//   - 其余路由只生成路径参数与缺省响应；未指定方法的路由不出现在文档中。
+func NewOpenAPI(routes []RouteInfo, o OpenAPIOptions) ([]byte, error) {
+	g := newSchemaGen()
+	errCT, errSchema := g.errorSchema(o.ErrorHandler)
+	doc := openAPIDoc{
+		OpenAPI: "3.1.0",
+		Info:    openAPIInfo{Title: o.Title, Version: o.Version, Description: o.Description},
+		Paths:   map[string]map[string]*openAPIOperation{},
+	}
+	if doc.Info.Title == "" {
+		doc.Info.Title = "API"
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = "0.0.0"
+	}
+	for _, s := range o.Servers {
+		doc.Servers = append(doc.Servers, openAPIServer{URL: s})
+	}
+	for _, rt := range routes {
+		if rt.Method == "" {
+			continue
+		}
+		p, wildcards := openAPIPath(rt.Path)
+		op := &openAPIOperation{Responses: map[string]*openAPIResponse{}}
+		bound := map[string]bool{}
+		if tr, ok := rt.Handler.(typedRoute); ok {
+			g.describe(op, rt.Method, tr.routeDoc(), bound)
+			op.Responses["400"] = errorResponseDoc("Invalid request", errCT, errSchema)
+			op.Responses["default"] = errorResponseDoc("Error", errCT, errSchema)
+		} else {
+			op.Responses["default"] = &openAPIResponse{Description: "Response"}
+		}
+		// 未由请求类型绑定的路径通配符按字符串参数描述。
+		var extra []openAPIParameter
+		for _, name := range wildcards {
+			if !bound[name] {
+				extra = append(extra, openAPIParameter{Name: name, In: tagPath, Required: true, Schema: &jsonSchema{Type: "string"}})
+			}
+		}
+		op.Parameters = append(extra, op.Parameters...)
+		if doc.Paths[p] == nil {
+			doc.Paths[p] = map[string]*openAPIOperation{}
+		}
+		doc.Paths[p][strings.ToLower(rt.Method)] = op
+	}
+	if len(g.schemas) > 0 {
+		doc.Components = &openAPIComponents{Schemas: g.schemas}
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// describe 按类型化路由的元数据填充操作。
+func (g *schemaGen) describe(op *openAPIOperation, method string, d routeDoc, bound map[string]bool) {
+	op.OperationID, op.Summary, op.Tags = d.op.id, d.op.summary, d.op.tags
+	ctypes := d.codecs.ContentTypes()
+	req := d.req
+	for req.Kind() == reflect.Pointer {
+		req = req.Elem()
+	}
+	if req.Kind() == reflect.Struct {
+		info := inspectStruct(req)
+		for _, p := range info.params {
+			f := req.FieldByIndex(p.index)
+			param := openAPIParameter{Name: p.name, In: p.source, Schema: g.paramSchema(f.Type)}
+			applyRules(param.Schema, f.Type, f.Tag.Get(tagValidate))
+			param.Required = p.source == tagPath || hasRule(f.Tag.Get(tagValidate), "required")
+			if p.source == tagPath {
+				bound[p.name] = true
+			}
+			op.Parameters = append(op.Parameters, param)
+		}
+		switch method {
+		case http.MethodGet, http.MethodHead, http.MethodDelete:
+		default:
+			var body *jsonSchema
+			if len(info.params) == 0 {
+				body = g.schema(req)
+			} else if s := g.structSchema(req, true); len(s.Properties) > 0 {
+				body = s
+			}
+			if body != nil {
+				op.RequestBody = &openAPIRequestBody{Content: mediaFor(ctypes, body)}
+			}
+		}
+	}
+	desc := http.StatusText(d.status)
+	if desc == "" {
+		desc = "Success"
+	}
+	op.Responses[strconv.Itoa(d.status)] = &openAPIResponse{Description: desc, Content: mediaFor(ctypes, g.schema(d.resp))}
+}
+
+func errorResponseDoc(desc, ct string, s *jsonSchema) *openAPIResponse {
+	r := &openAPIResponse{Description: desc}
+	if s != nil {
+		r.Content = map[string]openAPIMedia{ct: {Schema: s}}
+	}
+	return r
+}
+
+func mediaFor(ctypes []string, s *jsonSchema) map[string]openAPIMedia {
+	m := make(map[string]openAPIMedia, len(ctypes))
+	for _, ct := range ctypes {
+		m[ct] = openAPIMedia{Schema: s}
+	}
+	return m
+}
+
+// openAPIPath 把 ServeMux 路径模板转换为 OpenAPI 路径（"{name...}" 改为
+// "{name}"，去掉 "{$}"），并返回其中的通配符名。
+func openAPIPath(p string) (string, []string) {
+	var names []string
+	segs := strings.Split(p, "/")
+	out := segs[:0]
+	for _, seg := range segs {
+		if seg == "{$}" {
+			out = append(out, "")
+			continue
+		}
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			name := strings.TrimSuffix(seg[1:len(seg)-1], "...")
+			names = append(names, name)
+			seg = "{" + name + "}"
+		}
+		out = append(out, seg)
+	}
+	return strings.Join(out, "/"), names
+}
+
+// openAPIDoc 等类型是 OpenAPI 3.1 文档中用到的子集。
+type openAPIDoc struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Servers    []openAPIServer                         `json:"servers,omitempty"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components *openAPIComponents                      `json:"components,omitempty"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []openAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string      `json:"name"`
+	In       string      `json:"in"`
+	Required bool        `json:"required,omitempty"`
+	Schema   *jsonSchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Content map[string]openAPIMedia `json:"content"`
+}
+
+type openAPIMedia struct {
+	Schema *jsonSchema `json:"schema"`
+}
+
+type openAPIResponse struct {
+	Description string                  `json:"description"`
+	Content     map[string]openAPIMedia `json:"content,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*jsonSchema `json:"schemas,omitempty"`
+}
+
+// jsonSchema 是 JSON Schema 2020-12 中用到的子集。
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+}
+
+// schemaGen 由 Go 类型生成 schema，具名结构体放入 schemas 并以 $ref 引用。
+type schemaGen struct {
+	schemas map[string]*jsonSchema
+	names   map[reflect.Type]string
+}
+
+func newSchemaGen() *schemaGen {
+	return &schemaGen{schemas: map[string]*jsonSchema{}, names: map[reflect.Type]string{}}
+}
+
+var (
+	typeTime          = reflect.TypeFor[time.Time]()
+	typeDuration      = reflect.TypeFor[time.Duration]()
+	typeJSONMarshaler = reflect.TypeFor[json.Marshaler]()
+	typeTextMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// schema 返回 t 按 encoding/json 编码后的 schema。
+func (g *schemaGen) schema(t reflect.Type) *jsonSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == typeTime:
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	case implements(t, typeJSONMarshaler):
+		return &jsonSchema{}
+	case implements(t, typeTextMarshaler):
+		return &jsonSchema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &jsonSchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &jsonSchema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer", Minimum: ptr(0.0)}
+	case reflect.Float32:
+		return &jsonSchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &jsonSchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &jsonSchema{Type: "string", Format: "byte"}
+		}
+		return &jsonSchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Array:
+		return &jsonSchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t, false)
+		}
+		return &jsonSchema{Ref: "#/components/schemas/" + g.register(t)}
+	}
+	return &jsonSchema{}
+}
+
+// paramSchema 返回参数字段的 schema：time.Duration 按字符串（"2s"），
+// 切片为数组，其余同 schema。
+func (g *schemaGen) paramSchema(t reflect.Type) *jsonSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == typeDuration:
+		return &jsonSchema{Type: "string", Format: "duration"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 && !implements(t, typeTextUnmarshaler):
+		return &jsonSchema{Type: "array", Items: g.paramSchema(t.Elem())}
+	}
+	return g.schema(t)
+}
+
+var invalidSchemaName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// register 为具名结构体分配组件名并生成其 schema：名字冲突时加包名前缀。
+func (g *schemaGen) register(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	base := strings.Trim(invalidSchemaName.ReplaceAllString(t.Name(), "_"), "_")
+	name := base
+	if _, taken := g.schemas[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + base
+		for i := 2; ; i++ {
+			if _, taken := g.schemas[name]; !taken {
+				break
+			}
+			name = fmt.Sprintf("%s.%s%d", path.Base(t.PkgPath()), base, i)
+		}
+	}
+	g.names[t] = name
+	g.schemas[name] = &jsonSchema{} // 占位，支持自引用类型
+	*g.schemas[name] = *g.structSchema(t, false)
+	return name
+}
+
+// structSchema 生成结构体的 object schema。body 为 true 时跳过只由
+// path/query/header 标签绑定（无 json 标签）的字段。
+func (g *schemaGen) structSchema(t reflect.Type, body bool) *jsonSchema {
+	s := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}}
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		tag, hasJSON := f.Tag.Lookup("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if body && !hasJSON && (f.Tag.Get(tagPath) != "" || f.Tag.Get(tagQuery) != "" || f.Tag.Get(tagHeader) != "") {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fs := g.schema(f.Type)
+		rules := f.Tag.Get(tagValidate)
+		applyRules(fs, f.Type, rules)
+		if hasRule(rules, "required") {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+	return s
+}
+
+func hasRule(tag, name string) bool {
+	for _, part := range strings.Split(tag, ",") {
+		if n, _, _ := strings.Cut(strings.TrimSpace(part), "="); n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyRules 把 validate 标签映射为 schema 关键字（规则已由 JSON 构造时
+// 校验）。
+func applyRules(s *jsonSchema, t reflect.Type, tag string) {
+	if tag == "" || tag == "-" {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for _, part := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		n, _ := strconv.ParseFloat(arg, 64)
+		switch name {
+		case "min", "max", "len":
+			switch t.Kind() {
+			case reflect.String:
+				setBound(name, int(n), &s.MinLength, &s.MaxLength)
+			case reflect.Slice, reflect.Array:
+				setBound(name, int(n), &s.MinItems, &s.MaxItems)
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Float32, reflect.Float64:
+				if name != "max" {
+					s.Minimum = ptr(n)
+				}
+				if name != "min" {
+					s.Maximum = ptr(n)
+				}
+			}
+		case "oneof":
+			for _, v := range strings.Fields(arg) {
+				s.Enum = append(s.Enum, enumValue(t, v))
+			}
+		case "pattern":
+			s.Pattern = arg
+		}
+	}
+}
+
+func setBound(rule string, n int, min, max **int) {
+	if rule != "max" {
+		*min = ptr(n)
+	}
+	if rule != "min" {
+		*max = ptr(n)
+	}
+}
+
+// enumValue 按字段类型转换 oneof 候选值，使 enum 与 schema 类型一致。
+func enumValue(t reflect.Type, v string) any {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// errorSchema 返回错误响应的媒体类型与 schema；自定义 ErrorHandler 返回
+// nil schema（只描述状态码）。
+func (g *schemaGen) errorSchema(eh ErrorHandler) (string, *jsonSchema) {
+	switch {
+	case eh == nil || sameFunc(eh, DefaultErrorHandler):
+		g.names[reflect.TypeFor[errorResponse]()] = "Error"
+		g.names[reflect.TypeFor[errorMessage]()] = "ErrorMessage"
+		for _, t := range []reflect.Type{reflect.TypeFor[errorResponse](), reflect.TypeFor[errorMessage]()} {
+			name := g.names[t]
+			g.schemas[name] = g.structSchema(t, false)
+		}
+		g.schemas["Error"].Required = []string{"error"}
+		g.schemas["ErrorMessage"].Required = []string{"message"}
+		g.schemas["FieldError"].Required = []string{"field", "message"}
+		return "application/json", &jsonSchema{Ref: "#/components/schemas/Error"}
+	case sameFunc(eh, ProblemErrorHandler):
+		g.schemas["ProblemDetails"] = &jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"type":     {Type: "string", Format: "uri-reference"},
+				"title":    {Type: "string"},
+				"status":   {Type: "integer"},
+				"detail":   {Type: "string"},
+				"instance": {Type: "string"},
+				"errors":   {Type: "array", Items: g.schema(reflect.TypeFor[FieldError]())},
+			},
+			Required: []string{"type", "title", "status"},
+		}
+		g.schemas["FieldError"].Required = []string{"field", "message"}
+		return "application/problem+json", &jsonSchema{Ref: "#/components/schemas/ProblemDetails"}
+	}
+	return "", nil
+}
+
+func sameFunc(a, b ErrorHandler) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+// serveOpenAPI 返回输出文档的 handler。
+func serveOpenAPI(doc []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(doc)
+	})
+}
+
+var openAPIUITemplates = map[string]*template.Template{
+	OpenAPIUISwagger: template.Must(template.New("swagger").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.AssetsURL}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.AssetsURL}}/swagger-ui-bundle.js"></script>
+<script>window.ui = SwaggerUIBundle({url: {{.SpecURL}}, dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`)),
+	OpenAPIUIRedoc: template.Must(template.New("redoc").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<redoc spec-url="{{.SpecURL}}"></redoc>
+<script src="{{.AssetsURL}}/redoc.standalone.js"></script>
+</body>
+</html>
+`)),
+}
+
+// openAPIUIAssets 是各 UI 前端资源的缺省地址。
+var openAPIUIAssets = map[string]string{
+	OpenAPIUISwagger: DefaultSwaggerUIAssetsURL,
+	OpenAPIUIRedoc:   DefaultRedocAssetsURL,
+}
+
+// serveOpenAPIUI 返回文档页面 handler，assetsURL 为空时取缺省 CDN 地址；
+// ui 无法识别时返回 nil。
+func serveOpenAPIUI(ui, title, specURL, assetsURL string) http.Handler {
+	tmpl, ok := openAPIUITemplates[ui]
+	if !ok {
+		return nil
+	}
+	if title == "" {
+		title = "API"
+	}
+	if assetsURL == "" {
+		assetsURL = openAPIUIAssets[ui]
+	}
+	var page strings.Builder
+	data := map[string]string{"Title": title, "SpecURL": specURL, "AssetsURL": strings.TrimSuffix(assetsURL, "/")}
+	if err := tmpl.Execute(&page, data); err != nil {
+		return nil
+	}
+	html := page.String()
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(html))
+	})
+}
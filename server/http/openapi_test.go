@@ -0,0 +1,193 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lynx-go/lynx"
+	"github.com/spf13/pflag"
+)
+
+// lookup 按 "." 分隔的路径取 JSON 文档中的值。
+func lookup(t *testing.T, doc map[string]any, path string) any {
+	t.Helper()
+	var cur any = doc
+	for _, key := range strings.Split(path, ".") {
+		switch v := cur.(type) {
+		case map[string]any:
+			cur = v[key]
+		case []any:
+			i := 0
+			for _, c := range key {
+				i = i*10 + int(c-'0')
+			}
+			if i >= len(v) {
+				t.Fatalf("%s: index %d out of range", path, i)
+			}
+			cur = v[i]
+		default:
+			t.Fatalf("%s: cannot descend into %T at %q", path, cur, key)
+		}
+	}
+	return cur
+}
+
+func openAPIRouter() *Router {
+	r := NewRouter()
+	r.Handle("POST /shops/{shop}/orders", JSON(createOrder,
+		WithSuccessStatus(http.StatusCreated), WithSummary("Create order"), WithTags("orders"), WithOperationID("createOrder")))
+	r.HandleFunc("GET /files/{path...}", func(http.ResponseWriter, *http.Request) {})
+	r.HandleFunc("/legacy", func(http.ResponseWriter, *http.Request) {})
+	return r
+}
+
+func TestNewOpenAPI(t *testing.T) {
+	b, err := NewOpenAPI(openAPIRouter().Routes(), OpenAPIOptions{Title: "shop", Version: "1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	op := doc["paths"].(map[string]any)["/shops/{shop}/orders"].(map[string]any)["post"].(map[string]any)
+	checks := []struct {
+		path string
+		want any
+	}{
+		{"operationId", "createOrder"},
+		{"summary", "Create order"},
+		{"tags.0", "orders"},
+		{"parameters.0.name", "shop"},
+		{"parameters.0.in", "path"},
+		{"parameters.0.required", true},
+		{"parameters.1.name", "X-Tenant"},
+		{"parameters.1.in", "header"},
+		{"parameters.1.required", true},
+		{"parameters.3.schema.type", "array"},
+		{"parameters.4.schema.format", "duration"},
+		{"requestBody.content.application/json.schema.properties.items.minItems", 1.0},
+		{"requestBody.content.application/json.schema.properties.channel.enum.1", "app"},
+		{"responses.201.content.application/json.schema.$ref", "#/components/schemas/createOrderResp"},
+		{"responses.400.content.application/json.schema.$ref", "#/components/schemas/Error"},
+		{"responses.default.content.application/json.schema.$ref", "#/components/schemas/Error"},
+	}
+	for _, c := range checks {
+		if got := lookup(t, op, c.path); got != c.want {
+			t.Errorf("%s = %v, want %v", c.path, got, c.want)
+		}
+	}
+	// 请求体不含只由参数标签绑定的字段。
+	props := lookup(t, op, "requestBody.content").(map[string]any)["application/json"].(map[string]any)["schema"].(map[string]any)["properties"].(map[string]any)
+	for _, name := range []string{"Shop", "Tenant", "DryRun"} {
+		if _, ok := props[name]; ok {
+			t.Errorf("request body schema contains parameter field %s", name)
+		}
+	}
+
+	schemas := lookup(t, doc, "components.schemas").(map[string]any)
+	item := schemas["orderItem"].(map[string]any)
+	if got := lookup(t, item, "required.0"); got != "sku" {
+		t.Errorf("orderItem required = %v", got)
+	}
+	if got := lookup(t, item, "properties.qty.maximum"); got != 99.0 {
+		t.Errorf("qty maximum = %v", got)
+	}
+	if got := lookup(t, schemas["ErrorMessage"].(map[string]any), "properties.fields.items.$ref"); got != "#/components/schemas/FieldError" {
+		t.Errorf("error fields = %v", got)
+	}
+
+	files := lookup(t, doc, "paths").(map[string]any)["/files/{path}"].(map[string]any)["get"].(map[string]any)
+	if got := lookup(t, files, "parameters.0.name"); got != "path" {
+		t.Errorf("wildcard parameter = %v", got)
+	}
+	if _, ok := doc["paths"].(map[string]any)["/legacy"]; ok {
+		t.Error("route without method documented")
+	}
+}
+
+func TestNewOpenAPIProblemErrors(t *testing.T) {
+	b, err := NewOpenAPI(openAPIRouter().Routes(), OpenAPIOptions{ErrorHandler: ProblemErrorHandler})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte(`"application/problem+json"`)) || !bytes.Contains(b, []byte(`"ProblemDetails"`)) {
+		t.Fatalf("problem schema missing:\n%s", b)
+	}
+}
+
+// TestServerOpenAPIEndpoint：文档与 UI 页面独立挂载，错误 schema 取服务器
+// 级 ErrorHandler。
+func TestServerOpenAPIEndpoint(t *testing.T) {
+	srv := NewServer(openAPIRouter(),
+		WithErrorHandler(ProblemErrorHandler),
+		WithOpenAPI(OpenAPIOptions{Path: "/api/openapi.json", UI: OpenAPIUISwagger}))
+	h := srv.buildHandler(context.Background())
+
+	rec := serve(h, http.MethodGet, "/api/openapi.json")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("spec status = %d, Content-Type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "application/problem+json") {
+		t.Fatal("spec does not use the server error handler")
+	}
+	rec = serve(h, http.MethodGet, "/docs")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "swagger-ui") ||
+		!strings.Contains(rec.Body.String(), `url: "/api/openapi.json"`) {
+		t.Fatalf("ui status = %d body=%s", rec.Code, rec.Body)
+	}
+}
+
+// TestOpenAPIUIAssetsURL：缺省从 CDN 加载前端资源，UIAssetsURL 可改为
+// 自托管地址。
+func TestOpenAPIUIAssetsURL(t *testing.T) {
+	for _, tc := range []struct {
+		ui, assets, want string
+	}{
+		{OpenAPIUISwagger, "", DefaultSwaggerUIAssetsURL + "/swagger-ui-bundle.js"},
+		{OpenAPIUISwagger, "/static/swagger-ui/", `"/static/swagger-ui/swagger-ui-bundle.js"`},
+		{OpenAPIUIRedoc, "", DefaultRedocAssetsURL + "/redoc.standalone.js"},
+		{OpenAPIUIRedoc, "/static/redoc", `"/static/redoc/redoc.standalone.js"`},
+	} {
+		rec := serve(serveOpenAPIUI(tc.ui, "", "/openapi.json", tc.assets), http.MethodGet, "/docs")
+		if !strings.Contains(rec.Body.String(), tc.want) {
+			t.Errorf("%s(%q) body does not contain %s:\n%s", tc.ui, tc.assets, tc.want, rec.Body)
+		}
+	}
+}
+
+// TestServerOpenAPIFlag：--openapi 时 Run 在启动前输出文档（含 Register
+// 之后注册的路由）并返回 nil，不执行 OnStart 钩子、不监听端口。
+func TestServerOpenAPIFlag(t *testing.T) {
+	var (
+		out     bytes.Buffer
+		started bool
+	)
+	runner := lynx.NewRunner(func(app lynx.App) error {
+		r := openAPIRouter()
+		srv := NewServer(r, WithAddr("256.0.0.1:0"))
+		srv.stdout = &out
+		app.OnStart(func(context.Context) error { started = true; return nil })
+		app.Register(srv)
+		r.HandleFunc("GET /late", func(http.ResponseWriter, *http.Request) {})
+		return nil
+	}, lynx.WithBindConfigFunc(func(_ *pflag.FlagSet, c lynx.ConfigSource) error {
+		c.Set(OpenAPIFlag, true)
+		return nil
+	}))
+	if err := runner.RunE(); err != nil {
+		t.Fatalf("RunE() error = %v, want nil", err)
+	}
+	if started {
+		t.Error("OnStart hook ran during --openapi")
+	}
+	for _, want := range []string{`"/shops/{shop}/orders"`, `"/late"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("stdout missing %s: %s", want, out.String())
+		}
+	}
+}
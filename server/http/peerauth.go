@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/lynx-go/lynx/server/auth"
+)
+
+// PeerAuth 返回 mTLS 对端认证中间件：从经校验的客户端证书提取对端身份
+// （SPIFFE ID、DNS SAN、CN，见 auth.Peer），经 auth.PeerFrom 提供给
+// handler，并写入 auth.FieldPeerID 日志属性；再按 policy 以请求路径授权：
+//
+//	r := http.NewRouter(http.PeerAuth(auth.PeerPolicy{Rules: []auth.PeerRule{
+//		{Match: "/internal/*", Allow: []string{"spiffe://prod/ns/billing/*"}},
+//	}}))
+//
+// 匹配了规则但未出示经校验的客户端证书时写 ErrUnauthorized（401），身份
+// 不在允许列表中时写 ErrForbidden（403）。零值 policy 只提取身份。
+// 需配合要求或校验客户端证书的 TLS 配置（WithTLSFiles 的 ClientCAFile
+// 或 WithTLSConfig 的 ClientCAs/ClientAuth）；policy 非法时 panic。
+func PeerAuth(policy auth.PeerPolicy) Middleware {
+	mw, err := newPeerAuth(policy)
+	if err != nil {
+		panic(err)
+	}
+	return mw
+}
+
+func newPeerAuth(policy auth.PeerPolicy) (Middleware, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, ok := auth.PeerFromTLS(r.TLS)
+			if ok {
+				r = r.WithContext(auth.WithPeer(r.Context(), peer))
+			}
+			if err := policy.Authorize(r.URL.Path, peer, ok); err != nil {
+				peerDenied(w, r, peer, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// RequirePeer 返回要求对端身份匹配 patterns 之一（模式语法见
+// auth.PeerRule）的中间件，用于分组或路由：
+//
+//	internal := r.Group("/internal", http.RequirePeer("spiffe://prod/ns/billing/*"))
+//
+// 未出示经校验的客户端证书时写 ErrUnauthorized（401），不匹配时写
+// ErrForbidden（403）。位于 PeerAuth 之内时复用其提取的身份。
+func RequirePeer(patterns ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, ok := auth.PeerFrom(r.Context())
+			if !ok {
+				if peer, ok = auth.PeerFromTLS(r.TLS); ok {
+					r = r.WithContext(auth.WithPeer(r.Context(), peer))
+				}
+			}
+			switch {
+			case !ok:
+				peerDenied(w, r, peer, auth.ErrPeerRequired)
+			case !auth.PeerAllowed(patterns, peer):
+				peerDenied(w, r, peer, auth.ErrPeerNotAllowed)
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+func peerDenied(w http.ResponseWriter, r *http.Request, peer auth.Peer, err error) {
+	slog.DebugContext(r.Context(), "http: peer rejected", "path", r.URL.Path, "client_ip", ClientIP(r).String(), "peer", peer.ID(), "error", err)
+	if errors.Is(err, auth.ErrPeerRequired) {
+		writeError(w, r, ErrUnauthorized)
+		return
+	}
+	writeError(w, r, ErrForbidden)
+}
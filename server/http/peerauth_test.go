@@ -0,0 +1,77 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/lynx-go/lynx/server/auth"
+)
+
+// mtlsState 返回客户端证书已通过校验的 TLS 连接状态。
+func mtlsState(spiffe, cn string) *tls.ConnectionState {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+	if spiffe != "" {
+		u, _ := url.Parse(spiffe)
+		cert.URIs = []*url.URL{u}
+	}
+	return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+}
+
+func peerRequest(h http.Handler, path string, cs *tls.ConnectionState) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.TLS = cs
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPeerAuth(t *testing.T) {
+	r := NewRouter(PeerAuth(auth.PeerPolicy{Rules: []auth.PeerRule{
+		{Match: "/internal/*", Allow: []string{"spiffe://prod/ns/billing/*"}},
+	}}))
+	whoami := func(w http.ResponseWriter, r *http.Request) {
+		p, _ := auth.PeerFrom(r.Context())
+		_, _ = w.Write([]byte(p.ID()))
+	}
+	r.Get("/internal/charge", whoami)
+	r.Get("/public", whoami)
+	r.Group("/ops", RequirePeer("ops-*")).Get("/status", whoami)
+
+	billing := mtlsState("spiffe://prod/ns/billing/sa/api", "")
+	tests := []struct {
+		path string
+		cs   *tls.ConnectionState
+		code int
+		body string
+	}{
+		{"/internal/charge", billing, http.StatusOK, "spiffe://prod/ns/billing/sa/api"},
+		{"/internal/charge", mtlsState("spiffe://prod/ns/orders/sa/api", ""), http.StatusForbidden, ""},
+		{"/internal/charge", nil, http.StatusUnauthorized, ""},
+		{"/internal/charge", &tls.ConnectionState{}, http.StatusUnauthorized, ""},
+		{"/public", nil, http.StatusOK, ""},
+		{"/public", billing, http.StatusOK, "spiffe://prod/ns/billing/sa/api"},
+		{"/ops/status", mtlsState("", "ops-console"), http.StatusOK, "ops-console"},
+		{"/ops/status", billing, http.StatusForbidden, ""},
+		{"/ops/status", nil, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		rec := peerRequest(r, tt.path, tt.cs)
+		if rec.Code != tt.code || (tt.code == http.StatusOK && rec.Body.String() != tt.body) {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, rec.Code, rec.Body, tt.code, tt.body)
+		}
+	}
+}
+
+func TestPeerAuthInvalidPolicy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("PeerAuth did not panic on a rule without match")
+		}
+	}()
+	PeerAuth(auth.PeerPolicy{Rules: []auth.PeerRule{{Allow: []string{"*"}}}})
+}
@@ -1,11 +1,15 @@
 package http
 
 import (
-	"errors"
 	"fmt"
+	"log/slog"
 	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/lynx-go/lynx/logging"
 	"golang.org/x/time/rate"
 )
 
@@ -15,6 +19,7 @@ type RateLimitOption func(*rateLimitOptions)
 type rateLimitOptions struct {
 	burst   int
 	handler http.HandlerFunc
+	store   RateLimitStore
 }
 
 // WithBurst 设置令牌桶的突发容量，缺省为 max(1, rps)（rps 非整数时向上
@@ -26,17 +31,26 @@ func WithBurst(n int) RateLimitOption {
 	}
 }
 
-// WithRateLimitHandler 设置超限请求的处理函数，缺省写 429 +
-// {"error":{"message":"rate limit exceeded"}}（application/json）。
+// WithRateLimitHandler 设置超限请求的处理函数，缺省以服务器级
+// ErrorHandler 写 ErrRateLimited（缺省为 429 +
+// {"error":{"message":"rate limit exceeded"}}）。
 func WithRateLimitHandler(fn http.HandlerFunc) RateLimitOption {
 	return func(o *rateLimitOptions) {
 		o.handler = fn
 	}
 }
 
-// defaultRateLimitHandler 是缺省限流响应：429 + 统一 JSON 错误体。
-func defaultRateLimitHandler(w http.ResponseWriter, _ *http.Request) {
-	writeJSONError(w, http.StatusTooManyRequests, errors.New("rate limit exceeded"))
+// WithRateLimitStore 设置 KeyedRateLimit 保存按键状态的 Store，缺省为
+// 每个中间件实例独立的 NewMemoryRateLimitStore()。对 RateLimit 无效。
+func WithRateLimitStore(s RateLimitStore) RateLimitOption {
+	return func(o *rateLimitOptions) {
+		o.store = s
+	}
+}
+
+// defaultRateLimitHandler 是缺省限流响应：ErrRateLimited。
+func defaultRateLimitHandler(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, ErrRateLimited)
 }
 
 // RateLimit 返回服务器级令牌桶限流中间件：rps 为每秒放行请求数，中间件
@@ -44,14 +58,28 @@ func defaultRateLimitHandler(w http.ResponseWriter, _ *http.Request) {
 // 429 + JSON 错误体）。rps 必须 > 0，否则构造期直接 panic（配置错误应当
 // 在启动阶段暴露，而非运行期静默放行/拒绝全部请求）。
 //
-// v1.1 只提供服务器级全局限流（全部请求共享同一 limiter）；按路由、按
-// IP/用户维度限流定位 v1.2。
+// 全部请求共享同一 limiter；按 IP、用户、路由等维度限流见 KeyedRateLimit。
 //
 // 建议与 Recovery 中间件搭配：Recovery 声明在最外层（WithMiddleware 的
 // 第一个参数），RateLimit 随后——限流 handler 抛 panic 时同样能被恢复。
 func RateLimit(rps float64, opts ...RateLimitOption) Middleware {
+	o := newRateLimitOptions("RateLimit", rps, opts)
+	limiter := rate.NewLimiter(rate.Limit(rps), o.burst)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !limiter.Allow() {
+				o.handler(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// newRateLimitOptions 校验 rps 并应用选项。
+func newRateLimitOptions(name string, rps float64, opts []RateLimitOption) rateLimitOptions {
 	if rps <= 0 {
-		panic(fmt.Sprintf("http: RateLimit rps must be > 0, got %v", rps))
+		panic(fmt.Sprintf("http: %s rps must be > 0, got %v", name, rps))
 	}
 	o := rateLimitOptions{burst: max(1, int(math.Ceil(rps)))}
 	for _, opt := range opts {
@@ -60,10 +88,56 @@ func RateLimit(rps float64, opts ...RateLimitOption) Middleware {
 	if o.handler == nil {
 		o.handler = defaultRateLimitHandler
 	}
-	limiter := rate.NewLimiter(rate.Limit(rps), o.burst)
+	return o
+}
+
+// RateLimitKeyFunc 从请求中提取限流键。返回空串表示该请求不参与限流。
+type RateLimitKeyFunc func(r *http.Request) string
+
+// KeyedRateLimit 返回按键限流中间件：key 提取的每个键拥有独立的令牌桶
+// （rps 与 WithBurst 同 RateLimit），状态保存在 RateLimitStore 中（缺省
+// 为有界 LRU 的内存 Store，见 WithRateLimitStore）。key 返回空串的请求
+// 直接放行。
+//
+// 每个参与限流的响应携带 RateLimit-Limit（桶容量）、RateLimit-Remaining
+// （剩余令牌）与 RateLimit-Reset（补满所需秒数）头部；被拒绝的请求另带
+// Retry-After 并交给限流 handler（缺省 429 + JSON 错误体）。Store 返回
+// 错误时记 Error 日志并放行。rps ≤ 0 时构造期 panic。
+//
+// 按路由限流（KeyByRoute）依赖 Router 匹配出的路由模板，应作为 Router
+// 的分组或路由级中间件挂载；其余键可经 WithMiddleware 挂载在服务器级。
+func KeyedRateLimit(rps float64, key RateLimitKeyFunc, opts ...RateLimitOption) Middleware {
+	if key == nil {
+		panic("http: KeyedRateLimit key func is nil")
+	}
+	o := newRateLimitOptions("KeyedRateLimit", rps, opts)
+	if o.store == nil {
+		o.store = NewMemoryRateLimitStore()
+	}
+	quota := RateLimitQuota{Rate: rps, Burst: o.burst}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if !limiter.Allow() {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			res, err := o.store.Take(r.Context(), k, quota)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "rate limit store failed, request allowed",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"error", err,
+				)
+				next.ServeHTTP(w, r)
+				return
+			}
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+			if !res.Allowed {
+				h.Set("Retry-After", ceilSeconds(max(res.RetryAfter, time.Second)))
 				o.handler(w, r)
 				return
 			}
@@ -71,3 +145,86 @@ func RateLimit(rps float64, opts ...RateLimitOption) Middleware {
 		})
 	}
 }
+
+// ceilSeconds 把时长向上取整为秒数字符串。
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// KeyByIP 以客户端 IP 为键。服务器配置了 WithClientIP 时使用其解析的
+// 客户端 IP（ClientIPFrom），trustedProxies 被忽略；否则 trustedProxies
+// 是受信任代理的 IP 或 CIDR（如 "10.0.0.0/8"、"127.0.0.1"）：直连对端是
+// 受信任代理时，从 X-Forwarded-For 自右向左取第一个非受信任地址作为
+// 客户端 IP；否则使用直连对端地址，忽略客户端可伪造的转发头部。取值
+// 非法时构造期 panic。
+func KeyByIP(trustedProxies ...string) RateLimitKeyFunc {
+	res, err := newClientIPResolver(ClientIPOptions{TrustedProxies: trustedProxies})
+	if err != nil {
+		panic(fmt.Sprintf("http: KeyByIP %v", err))
+	}
+	return func(r *http.Request) string {
+		ip, ok := ClientIPFrom(r.Context())
+		if !ok {
+			ip = res.resolve(r)
+		}
+		if ip.IsValid() {
+			return "ip:" + ip.String()
+		}
+		return ""
+	}
+}
+
+// KeyByUserID 以请求 ctx 中的 user_id（logging.FieldUserID，由认证中间件
+// 经 logging.WithAttrs 写入）为键；未认证请求不参与限流，需要时与
+// KeyByIP 等组合另行限流。
+func KeyByUserID() RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		for _, a := range logging.AttrsFrom(r.Context()) {
+			if a.Key == logging.FieldUserID {
+				if v := a.Value.String(); v != "" {
+					return "user:" + v
+				}
+			}
+		}
+		return ""
+	}
+}
+
+// KeyByHeader 以请求头 name 的值为键（如 API key 头），头部缺失时不参与
+// 限流。
+func KeyByHeader(name string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		if v := r.Header.Get(name); v != "" {
+			return "header:" + name + ":" + v
+		}
+		return ""
+	}
+}
+
+// KeyByRoute 以 "METHOD 路由模板" 为键（RouteFrom），使同一路由的全部
+// 请求共享配额。路由模板在 Router 匹配后才可用，因此须作为 Router 的
+// 分组或路由级中间件挂载；模板未知时不参与限流。
+func KeyByRoute() RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		if route := RouteFrom(r.Context()); route != "" {
+			return "route:" + r.Method + " " + route
+		}
+		return ""
+	}
+}
+
+// JoinKeys 组合多个键函数（如每用户每路由：JoinKeys(KeyByUserID(),
+// KeyByRoute())），任一返回空串时整体为空串。
+func JoinKeys(keys ...RateLimitKeyFunc) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		parts := make([]string, 0, len(keys))
+		for _, k := range keys {
+			v := k(r)
+			if v == "" {
+				return ""
+			}
+			parts = append(parts, v)
+		}
+		return strings.Join(parts, "|")
+	}
+}
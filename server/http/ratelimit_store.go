@@ -0,0 +1,168 @@
+package http
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitQuota 描述一个键的令牌桶配额。
+type RateLimitQuota struct {
+	// Rate 是每秒补充的令牌数（即长期平均每秒放行请求数）。
+	Rate float64
+	// Burst 是令牌桶容量，也是 RateLimit-Limit 头部的值。
+	Burst int
+}
+
+// RateLimitResult 是一次取令牌的结果，用于决定放行与生成 RateLimit-* 头部。
+type RateLimitResult struct {
+	// Allowed 表示请求是否放行（已消耗一个令牌）。
+	Allowed bool
+	// Limit 是桶容量。
+	Limit int
+	// Remaining 是本次之后桶内剩余的整令牌数。
+	Remaining int
+	// Reset 是桶补满所需时间。
+	Reset time.Duration
+	// RetryAfter 是被拒绝时下一个令牌可用前需要等待的时间，放行时为 0。
+	RetryAfter time.Duration
+}
+
+// RateLimitStore 保存按键划分的限流状态。KeyedRateLimit 每个请求调用一次
+// Take；实现需并发安全。内存实现见 NewMemoryRateLimitStore，多实例共享
+// 配额时可实现基于 Redis 等共享后端的 Store。
+type RateLimitStore interface {
+	// Take 为 key 按 quota 尝试取一个令牌。返回错误时 KeyedRateLimit 记
+	// Error 日志并放行请求（限流后端故障不应拒绝全部流量）。
+	Take(ctx context.Context, key string, quota RateLimitQuota) (RateLimitResult, error)
+}
+
+// MemoryStoreOption 用于配置内存限流 Store 的选项函数。
+type MemoryStoreOption func(*MemoryRateLimitStore)
+
+// WithStoreMaxKeys 设置内存 Store 最多保留的键数，缺省 10000。超出时淘汰
+// 最久未访问的键（LRU）；被淘汰的键下次出现时以满桶重新开始，因此键数
+// 上限应大于预期的活跃键数。n ≤ 0 表示不限。
+func WithStoreMaxKeys(n int) MemoryStoreOption {
+	return func(s *MemoryRateLimitStore) {
+		s.maxKeys = n
+	}
+}
+
+// WithStoreIdleTimeout 设置键的空闲淘汰时间，缺省 10 分钟：超过该时间
+// 未访问的键在后续访问时被回收。空闲时间不短于 Burst/Rate 时桶早已补满，
+// 回收不影响限流结果。d ≤ 0 表示不按空闲淘汰。
+func WithStoreIdleTimeout(d time.Duration) MemoryStoreOption {
+	return func(s *MemoryRateLimitStore) {
+		s.idleTimeout = d
+	}
+}
+
+// MemoryRateLimitStore 是进程内的 RateLimitStore：每个键一个令牌桶，
+// 保存在有界 LRU 中，空闲键在访问时惰性回收（无后台 goroutine）。
+// 配额只在单个进程内生效，多实例部署时各实例分别计数。
+type MemoryRateLimitStore struct {
+	maxKeys     int
+	idleTimeout time.Duration
+	now         func() time.Time
+
+	mu    sync.Mutex
+	keys  map[string]*list.Element
+	order *list.List // 前端最近访问
+}
+
+type memoryBucket struct {
+	key      string
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewMemoryRateLimitStore 创建内存限流 Store。
+func NewMemoryRateLimitStore(opts ...MemoryStoreOption) *MemoryRateLimitStore {
+	s := &MemoryRateLimitStore{
+		maxKeys:     10000,
+		idleTimeout: 10 * time.Minute,
+		now:         time.Now,
+		keys:        make(map[string]*list.Element),
+		order:       list.New(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+var _ RateLimitStore = (*MemoryRateLimitStore)(nil)
+
+// Take 实现 RateLimitStore。同一键的配额变化时就地调整其令牌桶。
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, quota RateLimitQuota) (RateLimitResult, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictIdleLocked(now)
+
+	var b *memoryBucket
+	if el, ok := s.keys[key]; ok {
+		b = el.Value.(*memoryBucket)
+		s.order.MoveToFront(el)
+		if b.limiter.Limit() != rate.Limit(quota.Rate) {
+			b.limiter.SetLimitAt(now, rate.Limit(quota.Rate))
+		}
+		if b.limiter.Burst() != quota.Burst {
+			b.limiter.SetBurstAt(now, quota.Burst)
+		}
+	} else {
+		b = &memoryBucket{key: key, limiter: rate.NewLimiter(rate.Limit(quota.Rate), quota.Burst)}
+		s.keys[key] = s.order.PushFront(b)
+		for s.maxKeys > 0 && len(s.keys) > s.maxKeys {
+			s.removeLocked(s.order.Back())
+		}
+	}
+	b.lastSeen = now
+
+	res := RateLimitResult{Limit: quota.Burst, Allowed: b.limiter.AllowN(now, 1)}
+	tokens := b.limiter.TokensAt(now)
+	res.Remaining = max(0, int(math.Floor(tokens)))
+	res.Reset = tokenWait(float64(quota.Burst)-tokens, quota.Rate)
+	if !res.Allowed {
+		res.RetryAfter = tokenWait(1-tokens, quota.Rate)
+	}
+	return res, nil
+}
+
+// Len 返回当前保留的键数。
+func (s *MemoryRateLimitStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.keys)
+}
+
+// evictIdleLocked 从 LRU 尾部回收空闲超时的键。
+func (s *MemoryRateLimitStore) evictIdleLocked(now time.Time) {
+	if s.idleTimeout <= 0 {
+		return
+	}
+	for el := s.order.Back(); el != nil; el = s.order.Back() {
+		if now.Sub(el.Value.(*memoryBucket).lastSeen) < s.idleTimeout {
+			return
+		}
+		s.removeLocked(el)
+	}
+}
+
+func (s *MemoryRateLimitStore) removeLocked(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.keys, el.Value.(*memoryBucket).key)
+}
+
+// tokenWait 返回按 rps 补充 n 个令牌所需时间。
+func tokenWait(n, rps float64) time.Duration {
+	if n <= 0 || rps <= 0 {
+		return 0
+	}
+	return time.Duration(n / rps * float64(time.Second))
+}
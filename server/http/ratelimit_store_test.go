@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock 是可手动推进的时钟。
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestStore(clock *fakeClock, opts ...MemoryStoreOption) *MemoryRateLimitStore {
+	s := NewMemoryRateLimitStore(opts...)
+	s.now = clock.now
+	return s
+}
+
+func TestMemoryStoreTakeAndRefill(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	s := newTestStore(clock)
+	quota := RateLimitQuota{Rate: 2, Burst: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if res, _ := s.Take(ctx, "k", quota); !res.Allowed {
+			t.Fatalf("take %d rejected within burst", i)
+		}
+	}
+	res, _ := s.Take(ctx, "k", quota)
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != 500*time.Millisecond || res.Reset != time.Second {
+		t.Fatalf("over-limit result = %+v", res)
+	}
+	clock.advance(500 * time.Millisecond)
+	if res, _ := s.Take(ctx, "k", quota); !res.Allowed {
+		t.Errorf("take after refill rejected: %+v", res)
+	}
+}
+
+func TestMemoryStoreLRUBound(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	s := newTestStore(clock, WithStoreMaxKeys(2))
+	quota := RateLimitQuota{Rate: 1, Burst: 1}
+	ctx := context.Background()
+
+	_, _ = s.Take(ctx, "a", quota)
+	_, _ = s.Take(ctx, "b", quota)
+	_, _ = s.Take(ctx, "a", quota) // a 变为最近访问
+	_, _ = s.Take(ctx, "c", quota) // 淘汰 b
+	if s.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", s.Len())
+	}
+	if res, _ := s.Take(ctx, "b", quota); !res.Allowed {
+		t.Error("evicted key b should restart with a full bucket")
+	}
+	if res, _ := s.Take(ctx, "c", quota); res.Allowed {
+		t.Error("key c lost its state, but a should have been evicted instead")
+	}
+}
+
+func TestMemoryStoreIdleEviction(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	s := newTestStore(clock, WithStoreIdleTimeout(time.Minute))
+	quota := RateLimitQuota{Rate: 1, Burst: 1}
+	ctx := context.Background()
+
+	_, _ = s.Take(ctx, "a", quota)
+	_, _ = s.Take(ctx, "b", quota)
+	clock.advance(30 * time.Second)
+	_, _ = s.Take(ctx, "b", quota)
+	clock.advance(40 * time.Second)
+	_, _ = s.Take(ctx, "c", quota)
+	if s.Len() != 2 {
+		t.Errorf("Len() = %d, want 2 (a idle-evicted)", s.Len())
+	}
+}
+
+func TestMemoryStoreQuotaChange(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	s := newTestStore(clock)
+	ctx := context.Background()
+	_, _ = s.Take(ctx, "k", RateLimitQuota{Rate: 1, Burst: 1})
+	res, _ := s.Take(ctx, "k", RateLimitQuota{Rate: 1, Burst: 5})
+	if res.Limit != 5 {
+		t.Errorf("Limit = %d, want the updated burst 5", res.Limit)
+	}
+}
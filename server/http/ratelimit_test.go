@@ -1,14 +1,19 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+
+	"github.com/lynx-go/lynx/logging"
 )
 
 // TestRateLimitBurstThenReject：burst 内全过、超出后稳定 429（缺省 JSON
@@ -180,3 +185,149 @@ func TestRateLimitConcurrent(t *testing.T) {
 		t.Error("no request rejected, limiter not limiting")
 	}
 }
+
+func keyedRequest(remote, xff string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remote
+	if xff != "" {
+		r.Header.Set("X-Forwarded-For", xff)
+	}
+	return r
+}
+
+// TestKeyedRateLimitPerKey：每个键独立计数，响应携带 RateLimit-* 头部，
+// 拒绝时带 Retry-After。
+func TestKeyedRateLimitPerKey(t *testing.T) {
+	handler := KeyedRateLimit(1, KeyByIP(), WithBurst(2))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	do := func(remote string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, keyedRequest(remote, ""))
+		return rec
+	}
+
+	for i, want := range []string{"1", "0"} {
+		rec := do("192.0.2.1:1234")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want 200", i, rec.Code)
+		}
+		if got := rec.Header().Get("RateLimit-Remaining"); got != want {
+			t.Errorf("request %d RateLimit-Remaining = %q, want %q", i, got, want)
+		}
+		if got := rec.Header().Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("request %d RateLimit-Limit = %q, want 2", i, got)
+		}
+	}
+	rec := do("192.0.2.1:5678")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("over-limit status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	if got := rec.Header().Get("RateLimit-Reset"); got != "2" {
+		t.Errorf("RateLimit-Reset = %q, want 2", got)
+	}
+	if rec := do("192.0.2.2:1234"); rec.Code != http.StatusOK {
+		t.Errorf("other IP status = %d, want 200 (independent bucket)", rec.Code)
+	}
+}
+
+// TestKeyedRateLimitEmptyKey：键为空的请求直接放行，不写限流头部。
+func TestKeyedRateLimitEmptyKey(t *testing.T) {
+	handler := KeyedRateLimit(1, KeyByUserID(), WithBurst(1))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("anonymous request %d: status = %d, headers = %v", i, rec.Code, rec.Header())
+		}
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(logging.WithAttrs(r.Context(), slog.String(logging.FieldUserID, "u1")))
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+		if rec.Code != want {
+			t.Errorf("user request %d status = %d, want %d", i, rec.Code, want)
+		}
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, RateLimitQuota) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("store down")
+}
+
+// TestKeyedRateLimitStoreErrorAllows：Store 出错时放行。
+func TestKeyedRateLimitStoreErrorAllows(t *testing.T) {
+	handler := KeyedRateLimit(1, KeyByHeader("X-Api-Key"), WithRateLimitStore(failingStore{}))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Api-Key", "k")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 when the store fails", rec.Code)
+	}
+}
+
+// TestKeyedRateLimitByRoute：作为 Router 中间件时同一路由模板共享配额。
+func TestKeyedRateLimitByRoute(t *testing.T) {
+	r := NewRouter(KeyedRateLimit(1, KeyByRoute(), WithBurst(1)))
+	r.Get("/items/{id}", func(http.ResponseWriter, *http.Request) {})
+	r.Get("/other", func(http.ResponseWriter, *http.Request) {})
+	for i, tt := range []struct {
+		target string
+		want   int
+	}{
+		{"/items/1", http.StatusOK},
+		{"/items/2", http.StatusTooManyRequests},
+		{"/other", http.StatusOK},
+	} {
+		if rec := serve(r, http.MethodGet, tt.target); rec.Code != tt.want {
+			t.Errorf("request %d %s status = %d, want %d", i, tt.target, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestKeyByIPTrustedProxies(t *testing.T) {
+	key := KeyByIP("10.0.0.0/8", "::1")
+	tests := []struct {
+		remote, xff, want string
+	}{
+		{"203.0.113.9:80", "198.51.100.1", "ip:203.0.113.9"},         // 不受信任对端：忽略 XFF
+		{"10.0.0.1:80", "198.51.100.1, 10.0.0.2", "ip:198.51.100.1"}, // 跳过受信任代理
+		{"10.0.0.1:80", "6.6.6.6, 198.51.100.1", "ip:198.51.100.1"},  // 伪造的左端地址不被采信
+		{"10.0.0.1:80", "", "ip:10.0.0.1"},                           // 无 XFF
+		{"10.0.0.1:80", "10.1.1.1, 10.0.0.2", "ip:10.1.1.1"},         // 全部受信任：取最左端
+		{"[::1]:80", "2001:db8::1", "ip:2001:db8::1"},                // IPv6
+		{"[::ffff:203.0.113.9]:80", "", "ip:203.0.113.9"},            // IPv4-mapped
+		{"not-an-addr", "", ""},
+	}
+	for _, tt := range tests {
+		if got := key(keyedRequest(tt.remote, tt.xff)); got != tt.want {
+			t.Errorf("remote %s xff %q: key = %q, want %q", tt.remote, tt.xff, got, tt.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("KeyByIP with an invalid proxy did not panic")
+		}
+	}()
+	KeyByIP("not-a-cidr")
+}
+
+func TestJoinKeys(t *testing.T) {
+	key := JoinKeys(KeyByHeader("X-Tenant"), KeyByIP())
+	r := keyedRequest("192.0.2.1:1", "")
+	if got := key(r); got != "" {
+		t.Errorf("key without tenant = %q, want empty", got)
+	}
+	r.Header.Set("X-Tenant", "acme")
+	if got := key(r); got != "header:X-Tenant:acme|ip:192.0.2.1" {
+		t.Errorf("key = %q", got)
+	}
+}
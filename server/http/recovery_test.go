@@ -38,8 +38,8 @@ func TestRecoveryPanicWritesResponse(t *testing.T) {
 	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
 		t.Errorf("Content-Type = %q, want application/json", ct)
 	}
-	if got := strings.TrimSpace(string(body)); got != `{"error":{"message":"database exploded"}}` {
-		t.Errorf("body = %q, want panic JSON body", got)
+	if got := strings.TrimSpace(string(body)); got != `{"error":{"message":"Internal Server Error"}}` {
+		t.Errorf("body = %q, want status text JSON body", got)
 	}
 
 	rec, ok := capture.hasError("http handler panic recovered")
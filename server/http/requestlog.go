@@ -24,6 +24,7 @@ type Logger interface {
 func NewHandler(log Logger, h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		r, route := withRouteHolder(r)
 		sc := trace.SpanContextFromContext(r.Context())
 		ent := &Entry{
 			Request:           cloneRequestWithoutBody(r),
@@ -31,7 +32,7 @@ func NewHandler(log Logger, h http.Handler) http.Handler {
 			RequestHeaderSize: headerSize(r.Header),
 			UserAgent:         r.UserAgent(),
 			Referer:           r.Referer(),
-			RemoteIP:          ipFromHostPort(r.RemoteAddr),
+			RemoteIP:          remoteIP(r),
 			TraceID:           sc.TraceID(),
 			SpanID:            sc.SpanID(),
 		}
@@ -59,6 +60,9 @@ func NewHandler(log Logger, h http.Handler) http.Handler {
 		// request_id 由中间件写入响应头（如 WithRequestID），这里在请求
 		// 完成后读取，保证 request log 与业务日志携带同一 request_id。
 		ent.RequestID = w2.Header().Get(RequestIDHeader)
+		ent.Route = route.route
+		ent.UserID = route.userID
+		ent.APIKeyID = route.apiKeyID
 		log.Log(ent)
 	})
 }
@@ -91,9 +95,27 @@ type Entry struct {
 	Referer           string
 	RequestHeaderSize int64
 	UserAgent         string
-	RemoteIP          string
+	// RemoteIP 是客户端 IP：服务器配置了 WithClientIP 时为其解析结果，
+	// 否则为直连对端地址。
+	RemoteIP string
 	// RequestID 是请求级关联 ID（WithRequestID 中间件生成/透传）。
 	RequestID string
+	// Route 是 Router 匹配的路由模板（如 "/api/users/{id}"），未经 Router
+	// 路由或未匹配时为空。
+	Route string
+	// UserID 是认证中间件（如 JWTAuth）识别的用户，未认证时为空。
+	UserID string
+	// APIKeyID 是 APIKeyAuth 认证所用 API key 的 ID（从不记录密钥本身），
+	// 未经 API key 认证时为空。
+	APIKeyID string
+}
+
+// remoteIP 返回 WithClientIP 解析的客户端 IP，未配置时为直连对端地址。
+func remoteIP(r *http.Request) string {
+	if ip, ok := ClientIPFrom(r.Context()); ok {
+		return ip.String()
+	}
+	return ipFromHostPort(r.RemoteAddr)
 }
 
 func ipFromHostPort(hp string) string {
@@ -248,6 +270,9 @@ func (l *RequestLogger) log(ent *Entry) error {
 		TraceID   string `json:"logging.googleapis.com/trace"`
 		SpanID    string `json:"logging.googleapis.com/spanId"`
 		RequestID string `json:"requestId"`
+		Route     string `json:"route,omitempty"`
+		UserID    string `json:"userId,omitempty"`
+		APIKeyID  string `json:"apiKeyId,omitempty"`
 	}
 	r.HTTPRequest.RequestMethod = ent.Request.Method
 	r.HTTPRequest.RequestURL = ent.Request.URL.String()
@@ -267,6 +292,9 @@ func (l *RequestLogger) log(ent *Entry) error {
 	r.TraceID = ent.TraceID.String()
 	r.SpanID = ent.SpanID.String()
 	r.RequestID = ent.RequestID
+	r.Route = ent.Route
+	r.UserID = ent.UserID
+	r.APIKeyID = ent.APIKeyID
 	l.logger.Debug("requestlog", "request", r)
 	return nil
 }
@@ -0,0 +1,214 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Router 是基于 Go 1.22 http.ServeMux 模式（"[METHOD ]/path/{name}"）的
+// 路由器，支持路由分组、分组级与路由级中间件。Router 实现 http.Handler，
+// 直接交给 NewServer 使用。
+//
+// 每个路由在其中间件之外包装一层：把路由模板（含分组前缀的路径部分，如
+// "/api/users/{id}"）写入请求 Context（RouteFrom），并把 otel span 重命名
+// 为 "METHOD 模板"、为 span 与 HTTP 指标附加 http.route 属性，request log
+// 同样记录该模板——span 名与指标标签因而是低基数的。未匹配的请求
+// （404/405）没有模板，span 名只含方法。
+//
+// 注册路由不是并发安全的，应在 Server 启动前完成；与 ServeMux 一样，
+// 模式冲突或非法时 panic。
+type Router struct {
+	mux         *http.ServeMux
+	prefix      string
+	middlewares []Middleware
+	// routes 是全部分组共享的路由表，按注册顺序记录。
+	routes *[]RouteInfo
+}
+
+// RouteInfo 描述一个已注册的路由，用于生成 OpenAPI 文档等检查。
+type RouteInfo struct {
+	// Method 是请求方法，模式未指定方法时为空。
+	Method string
+	// Path 是含分组前缀的路径模板，如 "/api/users/{id}"。
+	Path string
+	// Handler 是注册的 handler（不含中间件），JSON 创建的类型化 handler
+	// 可据此取得请求与响应类型。
+	Handler http.Handler
+}
+
+// NewRouter 创建根路由器，middlewares 作用于其后注册的全部路由。
+func NewRouter(middlewares ...Middleware) *Router {
+	return &Router{mux: http.NewServeMux(), middlewares: middlewares, routes: new([]RouteInfo)}
+}
+
+// Use 追加本分组的中间件，只作用于此后注册的路由与创建的子分组。
+func (r *Router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// Group 创建路径前缀为 prefix（相对本分组）的子分组：继承本分组当前的
+// 中间件，再追加 middlewares。子分组与父分组共享同一 ServeMux。prefix
+// 非空时须以 "/" 开头，否则 panic。
+func (r *Router) Group(prefix string, middlewares ...Middleware) *Router {
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		panic("http: group prefix " + prefix + " must start with /")
+	}
+	mws := make([]Middleware, 0, len(r.middlewares)+len(middlewares))
+	mws = append(mws, r.middlewares...)
+	mws = append(mws, middlewares...)
+	return &Router{
+		mux:         r.mux,
+		prefix:      r.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: mws,
+		routes:      r.routes,
+	}
+}
+
+// Route 创建子分组并以之调用 fn，便于以代码块组织分组内的路由。
+func (r *Router) Route(prefix string, fn func(g *Router), middlewares ...Middleware) {
+	fn(r.Group(prefix, middlewares...))
+}
+
+// Handle 注册路由。pattern 为 ServeMux 模式 "[METHOD ]/path"，path 相对
+// 本分组前缀；middlewares 只作用于该路由，位于分组中间件之内。分组中
+// 不支持带主机名的模式。
+func (r *Router) Handle(pattern string, h http.Handler, middlewares ...Middleware) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		method, path = "", pattern
+	}
+	path = strings.TrimLeft(path, " \t")
+	if r.prefix != "" && !strings.HasPrefix(path, "/") {
+		panic("http: route pattern " + pattern + " must start with / inside a group")
+	}
+	route := r.prefix + path
+	full := route
+	if method != "" {
+		full = method + " " + route
+	}
+	wrapped := chain(chain(h, middlewares), r.middlewares)
+	r.mux.Handle(full, withRoute(templatePath(route), wrapped))
+	*r.routes = append(*r.routes, RouteInfo{Method: method, Path: templatePath(route), Handler: h})
+}
+
+// HandleFunc 以函数注册路由，语义同 Handle。
+func (r *Router) HandleFunc(pattern string, fn http.HandlerFunc, middlewares ...Middleware) {
+	r.Handle(pattern, fn, middlewares...)
+}
+
+// Get 注册 GET 路由（ServeMux 同时匹配 HEAD）。
+func (r *Router) Get(path string, fn http.HandlerFunc, middlewares ...Middleware) {
+	r.Handle(http.MethodGet+" "+path, fn, middlewares...)
+}
+
+// Post 注册 POST 路由。
+func (r *Router) Post(path string, fn http.HandlerFunc, middlewares ...Middleware) {
+	r.Handle(http.MethodPost+" "+path, fn, middlewares...)
+}
+
+// Put 注册 PUT 路由。
+func (r *Router) Put(path string, fn http.HandlerFunc, middlewares ...Middleware) {
+	r.Handle(http.MethodPut+" "+path, fn, middlewares...)
+}
+
+// Patch 注册 PATCH 路由。
+func (r *Router) Patch(path string, fn http.HandlerFunc, middlewares ...Middleware) {
+	r.Handle(http.MethodPatch+" "+path, fn, middlewares...)
+}
+
+// Delete 注册 DELETE 路由。
+func (r *Router) Delete(path string, fn http.HandlerFunc, middlewares ...Middleware) {
+	r.Handle(http.MethodDelete+" "+path, fn, middlewares...)
+}
+
+// Routes 返回全部分组已注册的路由，顺序同注册顺序。
+func (r *Router) Routes() []RouteInfo {
+	return append([]RouteInfo(nil), *r.routes...)
+}
+
+// ServeHTTP 实现 http.Handler，交由底层 ServeMux 分发。
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mux.ServeHTTP(w, req)
+}
+
+// templatePath 去掉模式中的主机名部分，只保留路径模板。
+func templatePath(route string) string {
+	if i := strings.IndexByte(route, '/'); i > 0 {
+		return route[i:]
+	}
+	return route
+}
+
+// routeHolder 由外层处理链（request log、Router）放入请求 Context，
+// 路由匹配后写入模板，外层在 handler 返回后读取。
+type routeHolder struct {
+	route string
+	// userID 与 apiKeyID 由认证中间件写入，供 request log 记录。
+	userID   string
+	apiKeyID string
+}
+
+type routeCtx struct{}
+
+var keyRoute = routeCtx{}
+
+// withRouteHolder 确保请求 Context 携带 routeHolder，返回（可能替换的）
+// 请求与 holder。
+func withRouteHolder(r *http.Request) (*http.Request, *routeHolder) {
+	if h, ok := r.Context().Value(keyRoute).(*routeHolder); ok {
+		return r, h
+	}
+	h := &routeHolder{}
+	return r.WithContext(context.WithValue(r.Context(), keyRoute, h)), h
+}
+
+// RouteFrom 返回请求匹配的路由模板（如 "/api/users/{id}"）；请求未经
+// Router 路由或未匹配时返回空串。
+func RouteFrom(ctx context.Context) string {
+	if h, ok := ctx.Value(keyRoute).(*routeHolder); ok {
+		return h.route
+	}
+	return ""
+}
+
+// routeScope 在请求进入处理链时放入 routeHolder。
+func routeScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, _ = withRouteHolder(r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withRoute 记录路由模板并更新当前 otel span 与指标标签。
+func withRoute(route string, next http.Handler) http.Handler {
+	attr := attribute.String("http.route", route)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, holder := withRouteHolder(r)
+		holder.route = route
+		ctx := r.Context()
+		if span := trace.SpanFromContext(ctx); span.IsRecording() {
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(attr)
+		}
+		if labeler, ok := otelhttp.LabelerFromContext(ctx); ok {
+			labeler.Add(attr)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// spanName 是 otel handler 的 span 名格式化函数：路由匹配前只含方法
+// （低基数），匹配后为 "METHOD 模板"。otelhttp 在 handler 返回后若请求
+// 带 Pattern 会再次调用它，因此从 routeHolder 读取模板，避免把 withRoute
+// 设置的名字覆盖回方法。
+func spanName(_ string, r *http.Request) string {
+	if route := RouteFrom(r.Context()); route != "" {
+		return r.Method + " " + route
+	}
+	return r.Method
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// tag 返回在响应头 X-Trace 末尾追加 name 的中间件，用于断言执行顺序。
+func tag(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Trace", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func serve(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestRouterGroupsAndMiddleware(t *testing.T) {
+	r := NewRouter(tag("root"))
+	echoRoute := func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(RouteFrom(r.Context()) + " id=" + r.PathValue("id")))
+	}
+	api := r.Group("/api/")
+	api.Get("/public/{id}", echoRoute)
+	admin := api.Group("/admin", tag("auth"))
+	admin.Use(tag("audit"))
+	admin.Delete("/users/{id}", echoRoute, tag("route"))
+	r.Route("/v2", func(g *Router) {
+		g.HandleFunc("POST /items", echoRoute)
+	}, tag("v2"))
+
+	tests := []struct {
+		method, target string
+		status         int
+		body           string
+		trace          string
+	}{
+		{http.MethodGet, "/api/public/7", http.StatusOK, "/api/public/{id} id=7", "root"},
+		{http.MethodDelete, "/api/admin/users/9", http.StatusOK, "/api/admin/users/{id} id=9", "root,auth,audit,route"},
+		{http.MethodPost, "/v2/items", http.StatusOK, "/v2/items id=", "root,v2"},
+		{http.MethodGet, "/api/admin/users/9", http.StatusMethodNotAllowed, "", ""},
+		{http.MethodGet, "/nope", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		rec := serve(r, tt.method, tt.target)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.target, rec.Code, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		if got := rec.Body.String(); got != tt.body {
+			t.Errorf("%s %s: body = %q, want %q", tt.method, tt.target, got, tt.body)
+		}
+		if got := strings.Join(rec.Header().Values("X-Trace"), ","); got != tt.trace {
+			t.Errorf("%s %s: middleware order = %s, want %s", tt.method, tt.target, got, tt.trace)
+		}
+	}
+	if got := serve(r, http.MethodDelete, "/api/admin/users/9").Header().Get("Allow"); got != "" {
+		t.Errorf("Allow = %q on a matched route", got)
+	}
+	if got := serve(r, http.MethodGet, "/api/admin/users/9").Header().Get("Allow"); got != http.MethodDelete {
+		t.Errorf("405 Allow = %q, want DELETE", got)
+	}
+}
+
+func TestRouterUseAfterRegistration(t *testing.T) {
+	r := NewRouter()
+	r.Get("/before", func(http.ResponseWriter, *http.Request) {})
+	r.Use(tag("late"))
+	r.Get("/after", func(http.ResponseWriter, *http.Request) {})
+	if got := serve(r, http.MethodGet, "/before").Header().Get("X-Trace"); got != "" {
+		t.Errorf("/before trace = %q, Use must not affect earlier routes", got)
+	}
+	if got := serve(r, http.MethodGet, "/after").Header().Get("X-Trace"); got != "late" {
+		t.Errorf("/after trace = %q, want late", got)
+	}
+}
+
+// TestRouterGroupInvalidPrefix：分组前缀不以 "/" 开头时 panic。
+func TestRouterGroupInvalidPrefix(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Group(\"api\") did not panic")
+		}
+	}()
+	NewRouter().Group("api")
+}
+
+// TestRouteTemplateInTelemetry 验证经 Server 处理链时 span 名、span 与
+// 指标的 http.route 属性以及外层中间件均使用路由模板。
+func TestRouteTemplateInTelemetry(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	var outerRoute string
+	outer := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			outerRoute = RouteFrom(r.Context())
+		})
+	}
+	r := NewRouter()
+	r.Group("/users").Get("/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv := NewServer(r, WithTracerProvider(tp), WithMeterProvider(mp), WithMiddleware(outer), WithRequestLog(true))
+	h := srv.buildHandler(context.Background())
+
+	serve(h, http.MethodGet, "/users/42")
+	serve(h, http.MethodGet, "/missing")
+
+	if outerRoute != "" {
+		t.Errorf("outer route for unmatched request = %q, want empty", outerRoute)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(spans))
+	}
+	if got := spans[0].Name(); got != "GET /users/{id}" {
+		t.Errorf("span name = %q, want GET /users/{id}", got)
+	}
+	if got := spans[1].Name(); got != "GET" {
+		t.Errorf("unmatched span name = %q, want GET", got)
+	}
+	var routeAttr string
+	for _, kv := range spans[0].Attributes() {
+		if kv.Key == "http.route" {
+			routeAttr = kv.Value.AsString()
+		}
+	}
+	if routeAttr != "/users/{id}" {
+		t.Errorf("span http.route = %q", routeAttr)
+	}
+
+	var md metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &md); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	routes := map[string]bool{}
+	for _, sm := range md.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			hist, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok {
+				continue
+			}
+			for _, dp := range hist.DataPoints {
+				v, _ := dp.Attributes.Value(attribute.Key("http.route"))
+				routes[v.AsString()] = true
+			}
+		}
+	}
+	if !routes["/users/{id}"] {
+		t.Errorf("metric http.route values = %v, want /users/{id}", routes)
+	}
+}
+
+func TestRequestLogRoute(t *testing.T) {
+	var got *Entry
+	r := NewRouter()
+	r.Get("/orders/{id}", func(http.ResponseWriter, *http.Request) {})
+	h := NewHandler(loggerFunc(func(e *Entry) { got = e }), r)
+	serve(h, http.MethodGet, "/orders/1")
+	if got == nil || got.Route != "/orders/{id}" {
+		t.Fatalf("Entry.Route = %+v, want /orders/{id}", got)
+	}
+}
+
+type loggerFunc func(*Entry)
+
+func (f loggerFunc) Log(e *Entry) { f(e) }
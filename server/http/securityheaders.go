@@ -0,0 +1,110 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// SecurityHeaders 的缺省值。
+const (
+	DefaultHSTSMaxAge     = 180 * 24 * time.Hour
+	DefaultReferrerPolicy = "strict-origin-when-cross-origin"
+)
+
+// SecurityHeadersOptions 配置 SecurityHeaders 中间件，字段带 mapstructure
+// 标签，可直接作为 "server.http.security_headers" 配置段（见 Config）。
+// 字符串头部取值 "-" 表示不发送该头。
+type SecurityHeadersOptions struct {
+	// HSTSMaxAge 是 Strict-Transport-Security 的 max-age，0 时为
+	// DefaultHSTSMaxAge，负值不发送。
+	HSTSMaxAge time.Duration `mapstructure:"hsts_max_age"`
+	// HSTSIncludeSubdomains 追加 includeSubDomains。
+	HSTSIncludeSubdomains bool `mapstructure:"hsts_include_subdomains"`
+	// HSTSPreload 追加 preload。
+	HSTSPreload bool `mapstructure:"hsts_preload"`
+	// ForceHSTS 对非 TLS 请求同样发送 HSTS，用于 TLS 在前置代理终止的
+	// 部署；缺省只对 r.TLS != nil 的请求发送。
+	ForceHSTS bool `mapstructure:"force_hsts"`
+	// ContentSecurityPolicy 是 Content-Security-Policy，空时不发送。
+	ContentSecurityPolicy string `mapstructure:"content_security_policy"`
+	// ReferrerPolicy 是 Referrer-Policy，空时为 DefaultReferrerPolicy。
+	ReferrerPolicy string `mapstructure:"referrer_policy"`
+	// PermissionsPolicy 是 Permissions-Policy，空时不发送。
+	PermissionsPolicy string `mapstructure:"permissions_policy"`
+	// DisableContentTypeNosniff 关闭 X-Content-Type-Options: nosniff。
+	DisableContentTypeNosniff bool `mapstructure:"disable_content_type_nosniff"`
+}
+
+// SecurityHeaders 返回写安全响应头的中间件：在调用 handler 之前写入
+// Strict-Transport-Security、Content-Security-Policy、
+// X-Content-Type-Options、Referrer-Policy 与 Permissions-Policy（按 o），
+// handler 与内层中间件可以覆盖。单个路由的差异用 OverrideSecurityHeaders
+// 作为路由级中间件声明。
+func SecurityHeaders(o SecurityHeadersOptions) Middleware {
+	var hsts string
+	if o.HSTSMaxAge >= 0 {
+		maxAge := o.HSTSMaxAge
+		if maxAge == 0 {
+			maxAge = DefaultHSTSMaxAge
+		}
+		hsts = "max-age=" + strconv.FormatInt(int64(maxAge/time.Second), 10)
+		if o.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if o.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	headers := map[string]string{
+		"Content-Security-Policy": o.ContentSecurityPolicy,
+		"Referrer-Policy":         o.ReferrerPolicy,
+		"Permissions-Policy":      o.PermissionsPolicy,
+	}
+	if headers["Referrer-Policy"] == "" {
+		headers["Referrer-Policy"] = DefaultReferrerPolicy
+	}
+	if !o.DisableContentTypeNosniff {
+		headers["X-Content-Type-Options"] = "nosniff"
+	}
+	for k, v := range headers {
+		if v == "" || v == "-" {
+			delete(headers, k)
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			for k, v := range headers {
+				h.Set(k, v)
+			}
+			if hsts != "" && (r.TLS != nil || o.ForceHSTS) {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// OverrideSecurityHeaders 返回覆盖安全响应头的路由级中间件：headers 的
+// 值非空时替换外层 SecurityHeaders 写入的值，空串时删除该头。例如为文档
+// 页面放宽 CSP：
+//
+//	r.Get("/docs", docs, http.OverrideSecurityHeaders(map[string]string{
+//		"Content-Security-Policy": "default-src 'self' https://cdn.jsdelivr.net",
+//	}))
+func OverrideSecurityHeaders(headers map[string]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			for k, v := range headers {
+				if v == "" {
+					h.Del(k)
+				} else {
+					h.Set(k, v)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	r := NewRouter(SecurityHeaders(SecurityHeadersOptions{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		ContentSecurityPolicy: "default-src 'none'",
+		PermissionsPolicy:     "camera=()",
+	}))
+	r.Get("/api", func(http.ResponseWriter, *http.Request) {})
+	r.Get("/docs", func(http.ResponseWriter, *http.Request) {}, OverrideSecurityHeaders(map[string]string{
+		"Content-Security-Policy": "default-src 'self'",
+		"Permissions-Policy":      "",
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	want := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"Content-Security-Policy":   "default-src 'none'",
+		"X-Content-Type-Options":    "nosniff",
+		"Referrer-Policy":           DefaultReferrerPolicy,
+		"Permissions-Policy":        "camera=()",
+	}
+	for k, v := range want {
+		if got := rec.Header().Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+
+	// 非 TLS 请求不发送 HSTS；路由级覆盖替换或删除头部。
+	rec = serve(r, http.MethodGet, "/docs")
+	if rec.Header().Get("Strict-Transport-Security") != "" {
+		t.Error("HSTS sent over plain HTTP")
+	}
+	if got := rec.Header().Get("Content-Security-Policy"); got != "default-src 'self'" {
+		t.Errorf("overridden CSP = %q", got)
+	}
+	if _, ok := rec.Header()["Permissions-Policy"]; ok {
+		t.Error("Permissions-Policy not removed by override")
+	}
+}
+
+func TestSecurityHeadersDisabled(t *testing.T) {
+	h := SecurityHeaders(SecurityHeadersOptions{
+		HSTSMaxAge:                -1,
+		ForceHSTS:                 true,
+		ReferrerPolicy:            "-",
+		DisableContentTypeNosniff: true,
+	})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	rec := serve(h, http.MethodGet, "/")
+	for _, k := range []string{"Strict-Transport-Security", "Referrer-Policy", "X-Content-Type-Options", "Content-Security-Policy"} {
+		if v := rec.Header().Get(k); v != "" {
+			t.Errorf("%s = %q, want unset", k, v)
+		}
+	}
+}
@@ -7,13 +7,18 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/server/certs"
+	"github.com/lynx-go/lynx/server/listener"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
@@ -25,44 +30,144 @@ const (
 	DefaultHTTPAddr        = ":8080"
 	DefaultTimeout         = 60 * time.Second
 	DefaultShutdownTimeout = 10 * time.Second
+	// DefaultProdTimeout 是 prod 运行环境下未显式 WithTimeout 时的读写超时。
+	DefaultProdTimeout = 30 * time.Second
 )
 
 // Options 是 HTTP 服务服务的配置项。
 type Options struct {
-	Addr            string
-	Timeout         time.Duration
-	IdleTimeout     time.Duration
-	ShutdownTimeout time.Duration
-	HealthCheckers  lynx.HealthCheckersFunc
-	Logger          *slog.Logger
-	RequestLog      bool
-	TracerProvider  trace.TracerProvider
-	MeterProvider   metric.MeterProvider
-	Propagator      propagation.TextMapPropagator
-	Middlewares     []Middleware
+	// Addr 是监听地址："host:port"，或 "unix:/path/to.sock" 监听 Unix
+	// domain socket（见 listener.Listen）。
+	Addr string
+	// ListenAddrs 是 Addr 之外的监听地址（见 WithListenAddrs）。
+	ListenAddrs []string
+	// Listeners 是调用方提供的监听器（见 WithListener）。
+	Listeners []net.Listener
+	// UnixSocketMode 是 Unix domain socket 文件的权限，0 时为
+	// listener.DefaultUnixSocketMode。
+	UnixSocketMode os.FileMode
+	Timeout        time.Duration
+	// ReadHeaderTimeout、ReadTimeout 与 WriteTimeout 大于 0 时分别取代
+	// Timeout 设置底层 http.Server 的同名超时（见 WithReadHeaderTimeout）。
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	HealthCheckers    lynx.HealthCheckersFunc
+	Logger            *slog.Logger
+	RequestLog        bool
+	TracerProvider    trace.TracerProvider
+	MeterProvider     metric.MeterProvider
+	Propagator        propagation.TextMapPropagator
+	Middlewares       []Middleware
 	// TLSConfig 非 nil 时以 TLS 提供服务（需包含 Certificates 或由
 	// ServerOptions 填充）。
 	TLSConfig *tls.Config
+	// TLSFiles 非 nil 时以文件中的证书提供 TLS 并热加载（见 WithTLSFiles）。
+	TLSFiles *certs.Options
+	// ClientIP 非 nil 时按受信任代理解析客户端 IP（见 WithClientIP）。
+	ClientIP *ClientIPOptions
+	// ProxyProtocol 非 nil 时在全部监听器上解析 PROXY protocol 头（见
+	// WithProxyProtocol）。
+	ProxyProtocol *listener.ProxyProtocolOptions
+	// ConnLimits 非 nil 时限制连接总数与单 IP 连接数（见 WithConnLimits）。
+	ConnLimits *listener.LimitOptions
 	// ServerOptions 透传配置底层 *http.Server（如 MaxHeaderBytes、
 	// BaseContext），在内部超时配置之后应用。
 	ServerOptions func(*http.Server)
+	// Maintenance 配置维护模式下的请求拦截（见 WithMaintenance）。
+	Maintenance MaintenanceOptions
+	// ErrorHandler 是服务器级 ErrorHandler（见 WithErrorHandler），nil 时
+	// 为 DefaultErrorHandler。
+	ErrorHandler ErrorHandler
+	// OpenAPI 非 nil 时挂载 OpenAPI 文档（见 WithOpenAPI）。
+	OpenAPI *OpenAPIOptions
+	// H2C 为 true 时在明文连接上同时接受 HTTP/2（见 WithH2C）。
+	H2C bool
+	// GRPCHandler 非 nil 时处理 gRPC 请求（见 WithGRPCHandler）。
+	GRPCHandler http.Handler
+	// timeoutSet 标记 Timeout 是否由 WithTimeout 显式设置：显式设置时
+	// Init 不再按运行环境调整。
+	timeoutSet bool
+	// addrSet 标记 Addr 是否由 WithAddr 显式设置：使用 WithListener 且
+	// 未显式设置时不监听缺省地址。
+	addrSet bool
 }
 
 // Option 用于配置 HTTP 服务 Options 的选项函数。
 type Option func(*Options)
 
-// WithAddr 设置 HTTP 服务监听地址。
+// WithAddr 设置 HTTP 服务监听地址："host:port"，或 "unix:/path/to.sock"
+// 监听 Unix domain socket。
 func WithAddr(addr string) Option {
 	return func(o *Options) {
 		o.Addr = addr
+		o.addrSet = true
+	}
+}
+
+// WithListenAddrs 追加 Addr 之外的监听地址，同一服务同时监听多个地址，
+// 如公网 TCP 加供 sidecar 访问的本地 socket：
+//
+//	http.NewServer(router, http.WithAddr(":8080"), http.WithListenAddrs("unix:/run/orders/http.sock"))
+func WithListenAddrs(addrs ...string) Option {
+	return func(o *Options) {
+		o.ListenAddrs = append(o.ListenAddrs, addrs...)
+	}
+}
+
+// WithListener 在调用方提供的监听器上提供服务（如 systemd socket
+// activation、测试中预先绑定的端口），可多次使用。使用后若未经 WithAddr
+// 显式设置地址，不再监听缺省地址。监听器由 Server 接管，Stop 时关闭。
+func WithListener(ln net.Listener) Option {
+	return func(o *Options) {
+		o.Listeners = append(o.Listeners, ln)
+	}
+}
+
+// WithUnixSocketMode 设置 Unix domain socket 文件的权限，缺省
+// listener.DefaultUnixSocketMode（0660）。
+func WithUnixSocketMode(mode os.FileMode) Option {
+	return func(o *Options) {
+		o.UnixSocketMode = mode
 	}
 }
 
 // WithTimeout 设置 HTTP 服务的读写超时时间（同时作用于
-// ReadHeaderTimeout/ReadTimeout/WriteTimeout）。
+// ReadHeaderTimeout/ReadTimeout/WriteTimeout，可分别经
+// WithReadHeaderTimeout、WithReadTimeout、WithWriteTimeout 覆盖）。缺省
+// DefaultTimeout，prod 运行环境下缺省 DefaultProdTimeout。
 func WithTimeout(timeout time.Duration) Option {
 	return func(o *Options) {
 		o.Timeout = timeout
+		o.timeoutSet = true
+	}
+}
+
+// WithReadHeaderTimeout 设置读取请求头的超时时间，取代 WithTimeout 对
+// ReadHeaderTimeout 的设置。收紧它可防御只发送部分请求头占用连接的
+// slowloris 攻击，且不影响上传等需要较长 ReadTimeout 的请求。
+func WithReadHeaderTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.ReadHeaderTimeout = timeout
+	}
+}
+
+// WithReadTimeout 设置读取整个请求（含请求体）的截止时间，取代 WithTimeout
+// 对 ReadTimeout 的设置。单个路由可经 BodyLimit 另行设置请求体的截止时间
+// 与最低吞吐。
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.ReadTimeout = timeout
+	}
+}
+
+// WithWriteTimeout 设置写响应的截止时间，取代 WithTimeout 对 WriteTimeout
+// 的设置。
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.WriteTimeout = timeout
 	}
 }
 
@@ -102,6 +207,17 @@ func WithRequestLog(requestLog bool) Option {
 	}
 }
 
+// WithErrorHandler 设置服务器级 ErrorHandler：经请求 Context 下发（见
+// ErrorHandlerFrom），NewErrorHandler 传 nil 时、以及 Recovery、RateLimit、
+// KeyedRateLimit、ConcurrencyLimit、维护拦截与 Timeout 的缺省错误响应都
+// 使用它，使整个服务的错误响应格式一致。如 WithErrorHandler(ProblemErrorHandler)
+// 改为 RFC 7807 problem+json。
+func WithErrorHandler(h ErrorHandler) Option {
+	return func(o *Options) {
+		o.ErrorHandler = h
+	}
+}
+
 // WithTLSConfig 以 TLS 提供服务，cfg 需包含 Certificates 或由
 // WithServerOptions 填充。
 func WithTLSConfig(cfg *tls.Config) Option {
@@ -110,6 +226,65 @@ func WithTLSConfig(cfg *tls.Config) Option {
 	}
 }
 
+// WithTLSFiles 以文件中的证书与私钥提供 TLS，文件变化后热加载，
+// ClientCAFile 非空时启用 mTLS（客户端 CA 同样热加载），见 certs.Reloader。
+// 证书在 Start 时加载，失败时 Start 返回错误；同时设置 WithTLSConfig 时
+// 其作为模板（MinVersion、CipherSuites 等）。证书过期时 CheckHealth
+// 返回错误，到期时间以 lynx.tls.certificate.expiry 指标暴露。
+func WithTLSFiles(o certs.Options) Option {
+	return func(o2 *Options) {
+		o2.TLSFiles = &o
+	}
+}
+
+// WithClientIP 从受信任代理设置的转发头部解析客户端 IP（见
+// ClientIPOptions），结果经 ClientIPFrom/ClientIP 提供，request log 的
+// RemoteIP、KeyByIP 限流与认证日志均使用它。解析在处理链最外层进行。
+// 配置非法时 NewServer panic。
+func WithClientIP(o ClientIPOptions) Option {
+	return func(opts *Options) {
+		opts.ClientIP = &o
+	}
+}
+
+// WithProxyProtocol 在全部监听器上解析 PROXY protocol（v1/v2）头，连接的
+// RemoteAddr 为负载均衡器之前的客户端地址，适用于 TCP 层（L4）负载
+// 均衡器之后的部署。配置非法时 NewServer panic。
+func WithProxyProtocol(o listener.ProxyProtocolOptions) Option {
+	return func(opts *Options) {
+		opts.ProxyProtocol = &o
+	}
+}
+
+// WithConnLimits 限制同时打开的连接总数与单个客户端 IP 的连接数，超出
+// 上限的连接在 Accept 后立即关闭；与 WithProxyProtocol 同时使用时按 PROXY
+// 头中的客户端地址计数。当前连接数与拒绝次数以指标
+// lynx.server.connections.active、lynx.server.connections.rejected 暴露
+// （见 listener.ConnLimiter）。配置非法时 NewServer panic。
+func WithConnLimits(o listener.LimitOptions) Option {
+	return func(opts *Options) {
+		opts.ConnLimits = &o
+	}
+}
+
+// WithH2C 在明文连接上同时接受 HTTP/2（h2c，prior knowledge），gRPC
+// 客户端不经 TLS 连接时需要。TLS 连接经 ALPN 协商 HTTP/2，无需此选项。
+func WithH2C(enabled bool) Option {
+	return func(o *Options) {
+		o.H2C = enabled
+	}
+}
+
+// WithGRPCHandler 把 gRPC 请求（HTTP/2 且 Content-Type 为
+// application/grpc）交给 h，通常是 *grpc.Server（其 ServeHTTP），实现
+// gRPC 与 HTTP 共用端口。gRPC 请求不经过健康端点、中间件链与读写超时，
+// 由 gRPC 服务器自己的拦截器处理。一般经 server/mux 使用。
+func WithGRPCHandler(h http.Handler) Option {
+	return func(o *Options) {
+		o.GRPCHandler = h
+	}
+}
+
 // WithServerOptions 透传配置底层 *http.Server，在内部超时之后应用。
 func WithServerOptions(fn func(*http.Server)) Option {
 	return func(o *Options) {
@@ -158,11 +333,55 @@ func NewServer(handler http.Handler, opts ...Option) *Server {
 		options.Logger = slog.Default()
 	}
 
-	return &Server{
+	s := &Server{
 		logger:  options.Logger,
 		o:       options,
 		handler: handler,
+		conns:   newConnTracker(),
+		stdout:  os.Stdout,
 	}
+	if options.TLSFiles != nil {
+		co := *options.TLSFiles
+		if co.Name == "" {
+			co.Name = s.Name()
+		}
+		if co.Logger == nil {
+			co.Logger = options.Logger
+		}
+		if co.MeterProvider == nil {
+			co.MeterProvider = options.MeterProvider
+		}
+		s.certs = certs.New(co)
+	}
+	if options.ClientIP != nil {
+		res, err := newClientIPResolver(*options.ClientIP)
+		if err != nil {
+			panic("http: WithClientIP " + err.Error())
+		}
+		s.clientIP = res
+	}
+	if options.ProxyProtocol != nil {
+		p, err := listener.NewProxyProtocol(*options.ProxyProtocol)
+		if err != nil {
+			panic(err)
+		}
+		s.proxy = p
+	}
+	if options.ConnLimits != nil {
+		lo := *options.ConnLimits
+		if lo.Name == "" {
+			lo.Name = s.Name()
+		}
+		if lo.MeterProvider == nil {
+			lo.MeterProvider = options.MeterProvider
+		}
+		l, err := listener.NewConnLimiter(lo)
+		if err != nil {
+			panic(err)
+		}
+		s.limiter = l
+	}
+	return s
 }
 
 // Server 是 HTTP 服务，实现 lynx.Service 接口。
@@ -171,9 +390,25 @@ type Server struct {
 	// the two may run on different goroutines during shutdown.
 	mu         sync.RWMutex
 	httpServer *http.Server
-	logger     *slog.Logger
-	o          Options
-	handler    http.Handler
+	// listeners 是 Start 绑定的全部监听器（mu 保护），供 Addr/Addrs 读取。
+	listeners []net.Listener
+	logger    *slog.Logger
+	o         Options
+	handler   http.Handler
+	// conns 记录 SSE/WebSocket 等长连接，关停时通知并等待它们断开。
+	conns *connTracker
+	// certs 在 WithTLSFiles 时加载并热加载证书。
+	certs *certs.Reloader
+	// clientIP 在 WithClientIP 时解析客户端 IP。
+	clientIP *clientIPResolver
+	// proxy 在 WithProxyProtocol 时包装监听器。
+	proxy *listener.ProxyProtocol
+	// limiter 在 WithConnLimits 时包装监听器。
+	limiter *listener.ConnLimiter
+	// stdout 是 --openapi 输出文档的目标；dump 在 Init 读到 OpenAPIFlag
+	// 时记录文档选项，由 PreRun 输出。
+	stdout io.Writer
+	dump   *OpenAPIOptions
 }
 
 // Name 返回服务名称 "http"。
@@ -181,59 +416,267 @@ func (s *Server) Name() string {
 	return "http"
 }
 
-// Init 初始化服务，HTTP 服务无需在初始化阶段做额外工作。
+// Init 按运行环境调整缺省值：prod 下未显式 WithTimeout 时读写超时收紧为
+// DefaultProdTimeout；未在 WithMaintenance 中指定 State 时取应用 Context
+// 携带的维护状态；OpenAPI 文档的标题与版本缺省取应用名与版本。配置键
+// OpenAPIFlag 为 true 时（见 SetOpenAPIFlags）由 PreRun 输出文档。ctx 为
+// nil（脱离框架单用）时保持 NewServer 的配置。
 func (s *Server) Init(ctx lynx.AppContext) error {
+	if ctx == nil {
+		return nil
+	}
+	if !s.o.timeoutSet && lynx.EnvFromContext(ctx.Context()).IsProd() {
+		s.o.Timeout = DefaultProdTimeout
+	}
+	if s.o.Maintenance.State == nil {
+		s.o.Maintenance.State = lynx.MaintenanceFromContext(ctx.Context())
+	}
+	if s.o.OpenAPI != nil {
+		o := openAPIAppInfo(ctx.Context(), *s.o.OpenAPI)
+		s.o.OpenAPI = &o
+	}
+	if ctx.Config() != nil && ctx.Config().GetBool(OpenAPIFlag) {
+		o := openAPIAppInfo(ctx.Context(), s.openAPIOptions())
+		s.dump = &o
+	}
 	return nil
 }
 
+// PreRun 实现 lynx.PreRunner：Init 读到 OpenAPIFlag 时把文档写到标准输出
+// 并返回 lynx.ErrSkipRun，应用不执行钩子、不启动任何服务即退出。PreRun
+// 在全部注册完成后调用，Init 之后注册到 Router 的路由同样在文档中。
+func (s *Server) PreRun(context.Context) error {
+	if s.dump == nil {
+		return nil
+	}
+	doc, err := s.buildOpenAPI(*s.dump)
+	if err != nil {
+		return fmt.Errorf("generate openapi document: %w", err)
+	}
+	if _, err := fmt.Fprintln(s.stdout, string(doc)); err != nil {
+		return err
+	}
+	return lynx.ErrSkipRun
+}
+
+// openAPIAppInfo 以应用名与版本补全文档的标题与版本。
+func openAPIAppInfo(ctx context.Context, o OpenAPIOptions) OpenAPIOptions {
+	if o.Title == "" {
+		o.Title = lynx.NameFromContext(ctx)
+	}
+	if o.Version == "" {
+		o.Version = lynx.VersionFromContext(ctx)
+	}
+	return o
+}
+
+func (s *Server) openAPIOptions() OpenAPIOptions {
+	if s.o.OpenAPI != nil {
+		return *s.o.OpenAPI
+	}
+	return OpenAPIOptions{}
+}
+
+// OpenAPI 由 handler 的路由表生成 OpenAPI 文档（见 NewOpenAPI），按
+// WithOpenAPI 的配置；handler 不是 *Router 时文档不含路径。
+func (s *Server) OpenAPI() ([]byte, error) {
+	return s.buildOpenAPI(s.openAPIOptions())
+}
+
+func (s *Server) buildOpenAPI(o OpenAPIOptions) ([]byte, error) {
+	if o.ErrorHandler == nil {
+		o.ErrorHandler = s.o.ErrorHandler
+	}
+	var routes []RouteInfo
+	if r, ok := s.handler.(interface{ Routes() []RouteInfo }); ok {
+		routes = r.Routes()
+	}
+	return NewOpenAPI(routes, o)
+}
+
 // Start 启动 HTTP 服务并开始监听，阻塞至服务退出。
 // 显式注入 otel provider（WithPublicEndpoint 保持与旧实现一致的
 // traceparent-as-link 语义），不修改进程全局 otel provider。
+//
+// 同时在 Addr、WithListenAddrs 的地址与 WithListener 的监听器上提供服务；
+// 任一地址监听失败时不启动并返回错误，任一监听器异常退出时关闭整个服务
+// 并返回该错误。
 func (s *Server) Start(ctx context.Context) error {
-	s.logger.InfoContext(ctx, "starting HTTP server, listening on "+s.o.Addr)
-
 	srv := &http.Server{
 		Addr:              s.o.Addr,
 		Handler:           s.buildHandler(ctx),
-		ReadHeaderTimeout: s.o.Timeout,
-		ReadTimeout:       s.o.Timeout,
-		WriteTimeout:      s.o.Timeout,
+		ReadHeaderTimeout: orTimeout(s.o.ReadHeaderTimeout, s.o.Timeout),
+		ReadTimeout:       orTimeout(s.o.ReadTimeout, s.o.Timeout),
+		WriteTimeout:      orTimeout(s.o.WriteTimeout, s.o.Timeout),
 		IdleTimeout:       s.o.IdleTimeout,
 	}
+	if s.o.H2C {
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
 	if s.o.ServerOptions != nil {
 		s.o.ServerOptions(srv)
 	}
+	if s.o.TLSConfig != nil {
+		srv.TLSConfig = s.o.TLSConfig
+	}
+	if s.certs != nil {
+		if err := s.certs.Load(); err != nil {
+			return err
+		}
+		// mTLS 时握手配置由 GetConfigForClient 返回，ServeTLS 对外层配置
+		// 追加的 "h2" 不会生效，ALPN 须预先写入模板。
+		base := &tls.Config{}
+		if s.o.TLSConfig != nil {
+			base = s.o.TLSConfig.Clone()
+		}
+		if len(base.NextProtos) == 0 {
+			base.NextProtos = []string{"h2", "http/1.1"}
+		}
+		srv.TLSConfig = s.certs.TLSConfig(base)
+	}
 	s.mu.Lock()
 	s.httpServer = srv
 	s.mu.Unlock()
 
-	ln, err := net.Listen("tcp", s.o.Addr)
+	lns, err := s.listen()
 	if err != nil {
 		return err
 	}
-	if s.o.TLSConfig != nil {
-		srv.TLSConfig = s.o.TLSConfig
-		return srv.ServeTLS(ln, "", "")
+	s.mu.Lock()
+	s.listeners = lns
+	s.mu.Unlock()
+	s.logger.InfoContext(ctx, "starting HTTP server, listening on "+joinAddrs(lns))
+
+	errc := make(chan error, len(lns))
+	for _, ln := range lns {
+		go func() {
+			if s.o.TLSConfig != nil || s.certs != nil {
+				errc <- srv.ServeTLS(ln, "", "")
+				return
+			}
+			errc <- srv.Serve(ln)
+		}()
+	}
+	var first error
+	for range lns {
+		err := <-errc
+		if first == nil {
+			first = err
+			if !errors.Is(err, http.ErrServerClosed) {
+				// 一个监听器异常退出：关闭整个服务，不以部分地址继续运行。
+				_ = srv.Close()
+			}
+		}
+	}
+	return first
+}
+
+// listen 绑定全部监听地址并与 WithListener 的监听器合并；失败时关闭
+// 全部监听器（包括接管的 WithListener 监听器）。
+func (s *Server) listen() ([]net.Listener, error) {
+	var addrs []string
+	if s.o.addrSet || len(s.o.Listeners) == 0 {
+		addrs = append(addrs, s.o.Addr)
+	}
+	addrs = append(addrs, s.o.ListenAddrs...)
+	lns, err := listener.ListenAll(addrs, s.o.UnixSocketMode)
+	if err != nil {
+		listener.CloseAll(s.o.Listeners)
+		return nil, err
+	}
+	lns = append(lns, s.o.Listeners...)
+	for i, ln := range lns {
+		if s.proxy != nil {
+			ln = s.proxy.Listener(ln)
+		}
+		if s.limiter != nil {
+			// 位于 PROXY protocol 之外，单 IP 上限按客户端地址计数。
+			ln = s.limiter.Listener(ln)
+		}
+		lns[i] = ln
+	}
+	return lns, nil
+}
+
+// orTimeout 返回 d，d 未设置（≤ 0）时返回 fallback。
+func orTimeout(d, fallback time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// Addr 返回第一个监听器实际绑定的地址（以端口 0 监听时为系统分配的
+// 端口）；Start 尚未完成监听时返回 nil。
+func (s *Server) Addr() net.Addr {
+	if addrs := s.Addrs(); len(addrs) > 0 {
+		return addrs[0]
+	}
+	return nil
+}
+
+// Addrs 返回全部监听器实际绑定的地址，顺序为 Addr、WithListenAddrs、
+// WithListener；Start 尚未完成监听时返回 nil。
+func (s *Server) Addrs() []net.Addr {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.listeners == nil {
+		return nil
+	}
+	return listener.Addrs(s.listeners)
+}
+
+// CheckHealth 实现 lynx.Checker：WithTLSFiles 的证书尚未加载或已过期时
+// 返回错误，其余情况返回 nil。
+func (s *Server) CheckHealth() error {
+	if s.certs == nil {
+		return nil
 	}
-	return srv.Serve(ln)
+	return s.certs.CheckHealth()
+}
+
+// joinAddrs 把监听地址拼为日志文本。
+func joinAddrs(lns []net.Listener) string {
+	parts := make([]string, len(lns))
+	for i, ln := range lns {
+		parts[i] = ln.Addr().Network() + "://" + ln.Addr().String()
+	}
+	return strings.Join(parts, ", ")
 }
 
 // buildHandler 组装完整请求处理链：健康端点独立挂载（不经过
-// otel/requestlog），业务 handler 按 request log → 中间件 → otel 顺序包装。
+// otel/requestlog/维护拦截），业务 handler 按客户端 IP 解析 → request
+// log → 维护拦截 → 中间件 → otel 顺序包装；最外层放入路由模板 holder
+// （使各层在 handler 返回后都能经 RouteFrom 读到 Router 匹配的模板）与
+// 服务器级 ErrorHandler。
 func (s *Server) buildHandler(ctx context.Context) http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz/liveness", handleLiveness)
 	mux.Handle("/healthz/readiness", handleReadiness(s.o.HealthCheckers))
+	if s.o.OpenAPI != nil {
+		s.mountOpenAPI(ctx, mux)
+	}
 
 	user := chain(s.handler, s.o.Middlewares)
+	if s.o.Maintenance.State != nil {
+		user = Maintenance(s.o.Maintenance.State, s.o.Maintenance)(user)
+	}
 	if s.o.RequestLog {
 		user = NewHandler(NewRequestLogger(s.logger, func(err error) {
 			s.logger.ErrorContext(ctx, "failed to log HTTP request", "error", err)
 		}), user)
 	}
+	if s.clientIP != nil {
+		user = s.clientIP.middleware(user)
+	}
 	otelOpts := []otelhttp.Option{
 		// 入站 traceparent 提取为 span link 而非父 span。
 		otelhttp.WithPublicEndpointFn(func(*http.Request) bool { return true }),
+		// span 名先取方法，经 Router 匹配后为 "METHOD 路由模板"。
+		otelhttp.WithSpanNameFormatter(spanName),
 	}
 	if s.o.TracerProvider != nil {
 		otelOpts = append(otelOpts, otelhttp.WithTracerProvider(s.o.TracerProvider))
@@ -245,10 +688,69 @@ func (s *Server) buildHandler(ctx context.Context) http.Handler {
 		otelOpts = append(otelOpts, otelhttp.WithPropagators(s.o.Propagator))
 	}
 	user = otelhttp.NewHandler(user, "", otelOpts...)
-	mux.Handle("/", user)
+	if s.o.ErrorHandler != nil {
+		user = withErrorHandler(s.o.ErrorHandler)(user)
+	}
+	mux.Handle("/", routeScope(withConnTracker(s.conns)(user)))
+	if s.o.GRPCHandler != nil {
+		return dispatchGRPC(s.o.GRPCHandler, mux)
+	}
 	return mux
 }
 
+// dispatchGRPC 把 gRPC 请求交给 grpcHandler，其余交给 next。gRPC 流可能
+// 长时间存在，清除服务器的读写超时（HTTP/2 下按流生效）。
+func dispatchGRPC(grpcHandler, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isGRPCRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rc := http.NewResponseController(w)
+		_ = rc.SetReadDeadline(time.Time{})
+		_ = rc.SetWriteDeadline(time.Time{})
+		grpcHandler.ServeHTTP(w, r)
+	})
+}
+
+// isGRPCRequest 判断 r 是否为 gRPC 请求：HTTP/2，Content-Type 为
+// application/grpc 或 application/grpc+<codec>。
+func isGRPCRequest(r *http.Request) bool {
+	if r.ProtoMajor != 2 {
+		return false
+	}
+	ct := r.Header.Get("Content-Type")
+	return ct == "application/grpc" || strings.HasPrefix(ct, "application/grpc+") || strings.HasPrefix(ct, "application/grpc;")
+}
+
+// mountOpenAPI 挂载 OpenAPI 文档与可选的 UI 页面。文档在启动时生成一次；
+// 生成失败只记日志，不影响服务启动。
+func (s *Server) mountOpenAPI(ctx context.Context, mux *http.ServeMux) {
+	doc, err := s.OpenAPI()
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to generate OpenAPI document", "error", err)
+		return
+	}
+	o := s.o.OpenAPI
+	specPath := o.Path
+	if specPath == "" {
+		specPath = DefaultOpenAPIPath
+	}
+	mux.Handle("GET "+specPath, serveOpenAPI(doc))
+	if o.UI == "" {
+		return
+	}
+	uiPath := o.UIPath
+	if uiPath == "" {
+		uiPath = DefaultOpenAPIUIPath
+	}
+	if h := serveOpenAPIUI(o.UI, o.Title, specPath, o.UIAssetsURL); h != nil {
+		mux.Handle("GET "+uiPath, h)
+	} else {
+		s.logger.ErrorContext(ctx, "unknown OpenAPI UI, not mounted", "ui", o.UI)
+	}
+}
+
 // handleLiveness 存活检查：进程存活即返回 200。
 func handleLiveness(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
@@ -273,9 +775,20 @@ func handleReadiness(checkers lynx.HealthCheckersFunc) http.Handler {
 	})
 }
 
+// Drain 实现 lynx.Drainer：关停进入排水阶段时通知已登记的长连接
+// （WebSocket close 帧、SSE retry 事件）重连到其他实例，此后登记的连接
+// 立即收到通知。
+func (s *Server) Drain(ctx context.Context) {
+	s.logger.InfoContext(ctx, "draining HTTP live connections", "count", s.conns.count())
+	s.conns.goAway()
+}
+
 // Stop 优雅关停 HTTP 服务；服务尚未启动时直接返回 nil。
 // 为保证不无限挂起：调用方 context 无 deadline 时使用配置的 ShutdownTimeout，
 // 超时后强制关闭活动连接（长轮询/流式 handler），并以错误返回。
+//
+// 已登记的长连接（见 TrackConn）先收到 GoAway，Stop 在同一预算内等待它们
+// 断开；预算耗尽时强制关闭，返回的错误包含 *LiveConnsError（剩余数量）。
 func (s *Server) Stop(ctx context.Context) error {
 	s.logger.InfoContext(ctx, "stopping HTTP server")
 	s.mu.RLock()
@@ -289,6 +802,7 @@ func (s *Server) Stop(ctx context.Context) error {
 		ctx, cancel = context.WithTimeout(ctx, s.o.ShutdownTimeout)
 		defer cancel()
 	}
+	s.conns.goAway()
 	done := make(chan struct{})
 	var shutdownErr error
 	go func() {
@@ -299,16 +813,16 @@ func (s *Server) Stop(ctx context.Context) error {
 	case <-done:
 		switch {
 		case shutdownErr == nil, errors.Is(shutdownErr, http.ErrServerClosed):
-			// 正常优雅关停。
+			// 正常优雅关停；Shutdown 不等待 Hijack 的连接（WebSocket），
+			// 在剩余预算内等待其断开。
+			if n := s.conns.wait(ctx); n > 0 {
+				return s.forceClose(ctx, hs, ctx.Err())
+			}
 			return nil
 		case errors.Is(shutdownErr, context.Canceled), errors.Is(shutdownErr, context.DeadlineExceeded):
 			// Shutdown 因超时/取消返回：与 ctx.Done() 分支同样归入超时
 			// 路径，强制关闭活动连接并以错误返回——不得放行未完成的连接。
-			s.logger.ErrorContext(ctx, "graceful HTTP shutdown timed out, forcing close", "error", shutdownErr)
-			if err := hs.Close(); err != nil {
-				s.logger.ErrorContext(ctx, "failed to force-close http server after shutdown timeout", "error", err)
-			}
-			return fmt.Errorf("http server graceful shutdown timed out: %w", shutdownErr)
+			return s.forceClose(ctx, hs, shutdownErr)
 		default:
 			s.logger.ErrorContext(ctx, "failed to shutdown http server", "error", shutdownErr)
 			return shutdownErr
@@ -317,12 +831,26 @@ func (s *Server) Stop(ctx context.Context) error {
 		// ctx 已结束，Shutdown 必然很快返回；先等它退出再强制关闭，
 		// 避免与 Shutdown 内部的连接清理交错。
 		<-done
-		s.logger.ErrorContext(ctx, "graceful HTTP shutdown timed out, forcing close", "error", ctx.Err())
-		if err := hs.Close(); err != nil {
-			s.logger.ErrorContext(ctx, "failed to force-close http server after shutdown timeout", "error", err)
-		}
-		return fmt.Errorf("http server graceful shutdown timed out: %w", ctx.Err())
+		return s.forceClose(ctx, hs, ctx.Err())
 	}
 }
 
-var _ lynx.Service = (*Server)(nil)
+// forceClose 在关停超时后强制关闭服务器与剩余长连接，返回超时错误
+// （有剩余长连接时附带 *LiveConnsError）。
+func (s *Server) forceClose(ctx context.Context, hs *http.Server, cause error) error {
+	s.logger.ErrorContext(ctx, "graceful HTTP shutdown timed out, forcing close", "error", cause)
+	if err := hs.Close(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to force-close http server after shutdown timeout", "error", err)
+	}
+	err := fmt.Errorf("http server graceful shutdown timed out: %w", cause)
+	if n := s.conns.closeAll(); n > 0 {
+		s.logger.ErrorContext(ctx, "closed live connections at shutdown deadline", "count", n)
+		return errors.Join(err, &LiveConnsError{Remaining: n})
+	}
+	return err
+}
+
+var (
+	_ lynx.Service = (*Server)(nil)
+	_ lynx.Drainer = (*Server)(nil)
+)
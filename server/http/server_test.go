@@ -2,16 +2,29 @@ package http
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"math/big"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/lynx-go/lynx"
+	"github.com/lynx-go/lynx/server/certs"
+	"github.com/lynx-go/lynx/server/listener"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/propagation"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
@@ -81,6 +94,43 @@ func TestServerInit(t *testing.T) {
 	}
 }
 
+// envAppContext 是携带运行环境的最小 lynx.AppContext 实现。
+type envAppContext struct{ env lynx.Env }
+
+func (c envAppContext) Context() context.Context {
+	return lynx.ContextWithEnv(context.Background(), c.env)
+}
+func (envAppContext) Config() lynx.Config            { return nil }
+func (envAppContext) Logger(...any) *slog.Logger     { return slog.Default() }
+func (envAppContext) HealthCheckers() []lynx.Checker { return nil }
+func (envAppContext) Close()                         {}
+
+// TestServerInitEnvTimeout：prod 下未显式 WithTimeout 时收紧为
+// DefaultProdTimeout；dev 与显式设置保持原值。
+func TestServerInitEnvTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		env  lynx.Env
+		opts []Option
+		want time.Duration
+	}{
+		{name: "dev default", env: lynx.EnvDev, want: DefaultTimeout},
+		{name: "prod default", env: lynx.EnvProd, want: DefaultProdTimeout},
+		{name: "prod explicit", env: lynx.EnvProd, opts: []Option{WithTimeout(90 * time.Second)}, want: 90 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(http.NewServeMux(), tt.opts...)
+			if err := s.Init(envAppContext{env: tt.env}); err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			if s.o.Timeout != tt.want {
+				t.Errorf("Timeout = %v, want %v", s.o.Timeout, tt.want)
+			}
+		})
+	}
+}
+
 func TestAppendLatency(t *testing.T) {
 	tests := []struct {
 		d    time.Duration
@@ -354,7 +404,10 @@ func waitForDial(t *testing.T, addr string) {
 	t.Fatalf("server at %s did not start accepting connections", addr)
 }
 
-var _ lynx.Service = (*Server)(nil)
+var (
+	_ lynx.Service   = (*Server)(nil)
+	_ lynx.PreRunner = (*Server)(nil)
+)
 
 // TestStopBeforeStartIsNoop ensures Stop is safe before Start has assigned
 // the underlying server (e.g. shutdown during startup), and does not panic
@@ -560,3 +613,320 @@ func TestProvidersDoNotMutateGlobals(t *testing.T) {
 		t.Fatal("explicit tracer provider was not used (no spans recorded)")
 	}
 }
+
+// waitAddrs 等待 Start 完成监听并返回实际地址。
+func waitAddrs(t *testing.T, s *Server) []net.Addr {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		if addrs := s.Addrs(); addrs != nil {
+			return addrs
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("server did not start listening")
+	return nil
+}
+
+func getVia(t *testing.T, network, addr string) int {
+	t.Helper()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+	resp, err := client.Get("http://lynx/healthz/liveness")
+	if err != nil {
+		t.Fatalf("GET via %s %s: %v", network, addr, err)
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode
+}
+
+// TestMultipleListeners 验证同时监听 TCP（端口 0）、Unix domain socket
+// 与调用方提供的监听器，Addr 报告实际端口，Stop 清理 socket 文件。
+func TestMultipleListeners(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "http.sock")
+	extra, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(http.NotFoundHandler(),
+		WithAddr("127.0.0.1:0"),
+		WithListenAddrs("unix:"+sock),
+		WithListener(extra),
+	)
+	startErr := make(chan error, 1)
+	go func() { startErr <- srv.Start(context.Background()) }()
+
+	addrs := waitAddrs(t, srv)
+	if len(addrs) != 3 {
+		t.Fatalf("Addrs() = %v, want 3", addrs)
+	}
+	if tcp := srv.Addr().(*net.TCPAddr); tcp.Port == 0 {
+		t.Errorf("Addr() = %v, want bound port", tcp)
+	}
+	if fi, err := os.Stat(sock); err != nil || fi.Mode().Perm() != 0o660 {
+		t.Errorf("socket stat = %v, %v, want mode 0660", fi, err)
+	}
+	for _, a := range addrs {
+		if code := getVia(t, a.Network(), a.String()); code != http.StatusOK {
+			t.Errorf("GET via %v = %d, want 200", a, code)
+		}
+	}
+
+	if err := srv.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	select {
+	case err := <-startErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("Start() error = %v, want http.ErrServerClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() did not return after Stop()")
+	}
+	if _, err := os.Stat(sock); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("socket file left after Stop: %v", err)
+	}
+}
+
+// TestWithListenerSkipsDefaultAddr 验证只提供监听器时不再监听缺省地址。
+func TestWithListenerSkipsDefaultAddr(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(http.NotFoundHandler(), WithListener(ln))
+	go func() { _ = srv.Start(context.Background()) }()
+	defer srv.Stop(context.Background())
+	if addrs := waitAddrs(t, srv); len(addrs) != 1 || addrs[0].String() != ln.Addr().String() {
+		t.Fatalf("Addrs() = %v, want [%v]", addrs, ln.Addr())
+	}
+}
+
+// TestStartListenError 验证任一地址监听失败时 Start 返回错误并释放已
+// 绑定的地址。
+func TestStartListenError(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "http.sock")
+	srv := NewServer(http.NotFoundHandler(), WithAddr("unix:"+sock), WithListenAddrs("256.0.0.1:0"))
+	if err := srv.Start(context.Background()); err == nil {
+		t.Fatal("Start() error = nil, want listen error")
+	}
+	if _, err := os.Stat(sock); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("socket file left after failed Start: %v", err)
+	}
+	if srv.Addr() != nil {
+		t.Errorf("Addr() = %v, want nil", srv.Addr())
+	}
+}
+
+func TestDispatchGRPC(t *testing.T) {
+	grpcHit := false
+	h := dispatchGRPC(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { grpcHit = true }),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	)
+	tests := []struct {
+		proto int
+		ct    string
+		want  bool
+	}{
+		{2, "application/grpc", true},
+		{2, "application/grpc+proto", true},
+		{2, "application/grpc-web", false},
+		{2, "application/json", false},
+		{1, "application/grpc", false},
+	}
+	for _, tt := range tests {
+		grpcHit = false
+		r := httptest.NewRequest(http.MethodPost, "/pkg.Svc/Method", nil)
+		r.ProtoMajor = tt.proto
+		r.Header.Set("Content-Type", tt.ct)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		if grpcHit != tt.want {
+			t.Errorf("HTTP/%d %q dispatched to gRPC = %v, want %v", tt.proto, tt.ct, grpcHit, tt.want)
+		}
+	}
+}
+
+// writeTestCert 生成仅用于测试的自签证书（同时作为服务端证书、客户端
+// 证书与 CA）并写入临时目录，返回文件路径与客户端所需的证书与 CA 池。
+func writeTestCert(t *testing.T) (certFile, keyFile string, cert tls.Certificate, pool *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if cert, err = tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		t.Fatal(err)
+	}
+	pool = x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	return certFile, keyFile, cert, pool
+}
+
+// TestTLSFiles 验证 WithTLSFiles 以文件证书提供 mTLS，经 ALPN 协商
+// HTTP/2，CheckHealth 反映证书状态。
+func TestTLSFiles(t *testing.T) {
+	certFile, keyFile, cert, pool := writeTestCert(t)
+	srv := NewServer(http.NotFoundHandler(),
+		WithAddr("127.0.0.1:0"),
+		WithTLSFiles(certs.Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile}),
+	)
+	if err := srv.CheckHealth(); !errors.Is(err, certs.ErrNotLoaded) {
+		t.Errorf("CheckHealth before Start = %v, want certs.ErrNotLoaded", err)
+	}
+	go func() { _ = srv.Start(context.Background()) }()
+	defer srv.Stop(context.Background())
+	addr := waitAddrs(t, srv)[0].String()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: pool, ServerName: "localhost", Certificates: []tls.Certificate{cert}},
+		ForceAttemptHTTP2: true,
+	}}
+	resp, err := client.Get("https://" + addr + "/healthz/liveness")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ProtoMajor != 2 {
+		t.Errorf("GET = %d %s, want 200 over HTTP/2", resp.StatusCode, resp.Proto)
+	}
+	if err := srv.CheckHealth(); err != nil {
+		t.Errorf("CheckHealth() = %v", err)
+	}
+
+	client.Transport.(*http.Transport).TLSClientConfig.Certificates = nil
+	client.CloseIdleConnections()
+	if resp, err := client.Get("https://" + addr + "/healthz/liveness"); err == nil {
+		_ = resp.Body.Close()
+		t.Error("GET without client certificate succeeded")
+	}
+}
+
+func TestTLSFilesStartError(t *testing.T) {
+	srv := NewServer(http.NotFoundHandler(),
+		WithAddr("127.0.0.1:0"),
+		WithTLSFiles(certs.Options{CertFile: "missing.crt", KeyFile: "missing.key"}),
+	)
+	if err := srv.Start(context.Background()); err == nil {
+		t.Fatal("Start() error = nil with missing certificate files")
+	}
+	if NewServer(http.NotFoundHandler()).CheckHealth() != nil {
+		t.Error("CheckHealth() without TLS files != nil")
+	}
+}
+
+// TestSplitTimeouts 验证 WithReadHeaderTimeout 等覆盖 WithTimeout 的对应
+// 超时，未覆盖的仍取 Timeout。
+func TestSplitTimeouts(t *testing.T) {
+	got := make(chan *http.Server, 1)
+	srv := NewServer(http.NotFoundHandler(),
+		WithAddr("127.0.0.1:0"),
+		WithTimeout(time.Minute),
+		WithReadHeaderTimeout(2*time.Second),
+		WithWriteTimeout(5*time.Minute),
+		WithServerOptions(func(hs *http.Server) { got <- hs }),
+	)
+	go func() { _ = srv.Start(context.Background()) }()
+	defer srv.Stop(context.Background())
+	hs := <-got
+	if hs.ReadHeaderTimeout != 2*time.Second || hs.ReadTimeout != time.Minute || hs.WriteTimeout != 5*time.Minute {
+		t.Errorf("timeouts = %v/%v/%v, want 2s/1m/5m", hs.ReadHeaderTimeout, hs.ReadTimeout, hs.WriteTimeout)
+	}
+}
+
+// TestConnLimits 验证 WithConnLimits 关闭超出上限的连接并暴露指标。
+func TestConnLimits(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(http.NotFoundHandler(),
+		WithListener(ln),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+		WithConnLimits(listener.LimitOptions{MaxConnsPerIP: 1}),
+	)
+	go func() { _ = srv.Start(context.Background()) }()
+	defer srv.Stop(context.Background())
+	waitAddrs(t, srv)
+
+	// 第一个 keep-alive 连接占用名额。
+	c1, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	_, _ = io.WriteString(c1, "GET /healthz/liveness HTTP/1.1\r\nHost: lynx\r\n\r\n")
+	_ = c1.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c1.Read(make([]byte, 1)); err != nil {
+		t.Fatalf("first connection Read() error = %v", err)
+	}
+
+	c2, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	_ = c2.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if n, err := c2.Read(make([]byte, 1)); n != 0 || err == nil {
+		t.Fatalf("second connection Read() = %d, %v, want closed", n, err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var rejected, active int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				if m.Name == "lynx.server.connections.rejected" {
+					for _, dp := range data.DataPoints {
+						if v, _ := dp.Attributes.Value("server"); v.AsString() == "http" {
+							rejected += dp.Value
+						}
+					}
+				}
+			case metricdata.Gauge[int64]:
+				if m.Name == "lynx.server.connections.active" {
+					active = data.DataPoints[0].Value
+				}
+			}
+		}
+	}
+	if rejected != 1 || active != 1 {
+		t.Errorf("rejected = %d, active = %d, want 1 and 1", rejected, active)
+	}
+}
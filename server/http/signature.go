@@ -0,0 +1,213 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureOptions.Scheme 的取值。
+const (
+	// SignatureTimestamped（缺省，Stripe 风格）：请求头为
+	// "t=<unix 秒>,v1=<hex>[,v1=<hex>...]"，签名内容为 "<t>.<body>"；
+	// 时间戳超出 Tolerance 的请求视为重放并拒绝。
+	SignatureTimestamped = "timestamped"
+	// SignatureBody（GitHub 风格）：请求头为 "sha256=<hex>"，签名内容只有
+	// body。该格式没有时间戳，无法防重放，仅用于对接已有的发送方。
+	SignatureBody = "body"
+)
+
+// 请求签名校验的缺省值。
+const (
+	// DefaultSignatureHeader 是 SignatureTimestamped 的缺省请求头。
+	DefaultSignatureHeader = "X-Signature"
+	// DefaultBodySignatureHeader 是 SignatureBody 的缺省请求头（同 GitHub）。
+	DefaultBodySignatureHeader = "X-Hub-Signature-256"
+	// DefaultSignatureTolerance 是缺省重放窗口。
+	DefaultSignatureTolerance = 5 * time.Minute
+	// DefaultSignatureMaxBodyBytes 是参与签名的请求体上限。
+	DefaultSignatureMaxBodyBytes = 1 << 20
+)
+
+// ErrSignatureInvalid 由 VerifySignature 在签名缺失、格式错误、不匹配或
+// 时间戳超出重放窗口时使用（401）。
+var ErrSignatureInvalid = &APIError{Status: http.StatusUnauthorized, Message: "invalid signature", quiet: true}
+
+// SignatureOptions 配置 VerifySignature，字段带 mapstructure 标签，可从
+// 配置解码（如 Webhook 路由的配置段）。
+type SignatureOptions struct {
+	// Scheme 是 SignatureTimestamped（缺省）或 SignatureBody。
+	Scheme string `mapstructure:"scheme"`
+	// Secrets 是当前有效的 HMAC-SHA256 密钥，任一匹配即通过；轮换时先
+	// 加入新密钥，发送方切换后再移除旧密钥。
+	Secrets []string `mapstructure:"secrets"`
+	// Header 是签名请求头，缺省按 Scheme 取 DefaultSignatureHeader 或
+	// DefaultBodySignatureHeader。
+	Header string `mapstructure:"header"`
+	// Tolerance 是重放窗口：时间戳与当前时间之差（双向）超过它时拒绝，
+	// 缺省 DefaultSignatureTolerance。仅用于 SignatureTimestamped。
+	Tolerance time.Duration `mapstructure:"tolerance"`
+	// MaxBodyBytes 是请求体上限，超出时响应 413，缺省
+	// DefaultSignatureMaxBodyBytes。
+	MaxBodyBytes int64 `mapstructure:"max_body_bytes"`
+}
+
+type signatureVerifier struct {
+	o       SignatureOptions
+	secrets [][]byte
+	now     func() time.Time
+}
+
+// VerifySignature 返回 HMAC 请求签名校验中间件，用于接收 Webhook 等
+// 服务间回调：读取请求体（之后重新提供给 handler），按 Scheme 校验签名
+// 与时间戳，失败时经服务器级 ErrorHandler 写 ErrSignatureInvalid（401）。
+// 签名比较为恒定时间。
+//
+//	hooks := r.Group("/webhooks", http.VerifySignature(http.SignatureOptions{
+//		Secrets: []string{newSecret, oldSecret},
+//	}))
+//
+// 发送方可用 SignRequest 生成 SignatureTimestamped 格式的请求头。配置
+// 非法（无密钥、未知 Scheme）时 panic。
+func VerifySignature(o SignatureOptions) Middleware {
+	mw, err := newVerifySignature(o)
+	if err != nil {
+		panic(err)
+	}
+	return mw
+}
+
+func newVerifySignature(o SignatureOptions) (Middleware, error) {
+	if o.Scheme == "" {
+		o.Scheme = SignatureTimestamped
+	}
+	switch o.Scheme {
+	case SignatureTimestamped:
+		if o.Header == "" {
+			o.Header = DefaultSignatureHeader
+		}
+	case SignatureBody:
+		if o.Header == "" {
+			o.Header = DefaultBodySignatureHeader
+		}
+	default:
+		return nil, fmt.Errorf("http: unknown signature scheme %q", o.Scheme)
+	}
+	if o.Tolerance <= 0 {
+		o.Tolerance = DefaultSignatureTolerance
+	}
+	if o.MaxBodyBytes <= 0 {
+		o.MaxBodyBytes = DefaultSignatureMaxBodyBytes
+	}
+	v := &signatureVerifier{o: o, now: time.Now}
+	for _, s := range o.Secrets {
+		if s == "" {
+			return nil, errors.New("http: empty signature secret")
+		}
+		v.secrets = append(v.secrets, []byte(s))
+	}
+	if len(v.secrets) == 0 {
+		return nil, errors.New("http: VerifySignature requires at least one secret")
+	}
+	return v.middleware, nil
+}
+
+func (v *signatureVerifier) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(v.o.Header)
+		if header == "" {
+			writeError(w, r, ErrSignatureInvalid)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, v.o.MaxBodyBytes+1))
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			writeError(w, r, ErrBodyTooLarge)
+			return
+		}
+		if err != nil {
+			writeError(w, r, NewAPIError(http.StatusBadRequest, "failed to read request body").WithCause(err))
+			return
+		}
+		if int64(len(body)) > v.o.MaxBodyBytes {
+			writeError(w, r, NewAPIError(http.StatusRequestEntityTooLarge, "request body too large"))
+			return
+		}
+		if err := v.verify(header, body); err != nil {
+			slog.DebugContext(r.Context(), "http: signature rejected", "path", r.URL.Path, "client_ip", ClientIP(r).String(), "error", err)
+			writeError(w, r, ErrSignatureInvalid)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// verify 校验签名请求头，返回的错误只用于调试日志。
+func (v *signatureVerifier) verify(header string, body []byte) error {
+	if v.o.Scheme == SignatureBody {
+		sig, ok := strings.CutPrefix(header, "sha256=")
+		if !ok {
+			return errors.New("missing sha256= prefix")
+		}
+		return v.match(body, []string{sig})
+	}
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = val
+		case "v1":
+			sigs = append(sigs, val)
+		}
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("missing or malformed timestamp")
+	}
+	if d := v.now().Sub(time.Unix(sec, 0)); d > v.o.Tolerance || d < -v.o.Tolerance {
+		return fmt.Errorf("timestamp outside tolerance (%s)", d.Round(time.Second))
+	}
+	return v.match(signedPayload(ts, body), sigs)
+}
+
+// match 报告 sigs 中是否有以任一密钥对 payload 签名的结果。
+func (v *signatureVerifier) match(payload []byte, sigs []string) error {
+	for _, secret := range v.secrets {
+		m := hmac.New(sha256.New, secret)
+		m.Write(payload)
+		want := m.Sum(nil)
+		for _, sig := range sigs {
+			got, err := hex.DecodeString(sig)
+			if err == nil && hmac.Equal(got, want) {
+				return nil
+			}
+		}
+	}
+	return errors.New("signature mismatch")
+}
+
+func signedPayload(ts string, body []byte) []byte {
+	return append([]byte(ts+"."), body...)
+}
+
+// SignRequest 返回以 secret 对 body 签名的 SignatureTimestamped 请求头值
+// （"t=<unix 秒>,v1=<hex>"），供发送方与测试使用。
+func SignRequest(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write(signedPayload(ts, body))
+	return "t=" + ts + ",v1=" + hex.EncodeToString(m.Sum(nil))
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signedRequest(h http.Handler, header, value, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(body))
+	if value != "" {
+		req.Header.Set(header, value)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func echoBody() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(w, r.Body)
+	})
+}
+
+func TestVerifySignature(t *testing.T) {
+	h := VerifySignature(SignatureOptions{Secrets: []string{"new", "old"}, MaxBodyBytes: 64})(echoBody())
+	now := time.Now()
+	body := `{"event":"paid"}`
+
+	rec := signedRequest(h, DefaultSignatureHeader, SignRequest("old", now, []byte(body)), body)
+	if rec.Code != http.StatusOK || rec.Body.String() != body {
+		t.Fatalf("status = %d body = %q", rec.Code, rec.Body)
+	}
+	// 发送方轮换期间可同时携带新旧签名。
+	rotated := SignRequest("retired", now, []byte(body)) + ",v1=" + strings.Split(SignRequest("new", now, []byte(body)), "v1=")[1]
+	if rec := signedRequest(h, DefaultSignatureHeader, rotated, body); rec.Code != http.StatusOK {
+		t.Fatalf("multiple signatures status = %d", rec.Code)
+	}
+
+	tests := []struct {
+		name, value, body string
+		status            int
+	}{
+		{"missing", "", body, http.StatusUnauthorized},
+		{"unknown secret", SignRequest("other", now, []byte(body)), body, http.StatusUnauthorized},
+		{"tampered body", SignRequest("new", now, []byte(body)), `{"event":"refunded"}`, http.StatusUnauthorized},
+		{"replayed", SignRequest("new", now.Add(-10*time.Minute), []byte(body)), body, http.StatusUnauthorized},
+		{"future", SignRequest("new", now.Add(10*time.Minute), []byte(body)), body, http.StatusUnauthorized},
+		{"no timestamp", "v1=abcd", body, http.StatusUnauthorized},
+		{"too large", SignRequest("new", now, []byte(strings.Repeat("x", 65))), strings.Repeat("x", 65), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := signedRequest(h, DefaultSignatureHeader, tt.value, tt.body); rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
+
+// TestVerifySignatureOuterBodyLimit：外层请求体上限（http.MaxBytesReader）
+// 触发时响应 413 而不是 400。
+func TestVerifySignatureOuterBodyLimit(t *testing.T) {
+	verify := VerifySignature(SignatureOptions{Secrets: []string{"s"}})(echoBody())
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, 8)
+		verify.ServeHTTP(w, r)
+	})
+	body := strings.Repeat("x", 16)
+	if rec := signedRequest(h, DefaultSignatureHeader, SignRequest("s", time.Now(), []byte(body)), body); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", rec.Code)
+	}
+}
+
+func TestVerifySignatureBodyScheme(t *testing.T) {
+	h := VerifySignature(SignatureOptions{Scheme: SignatureBody, Secrets: []string{"gh"}})(echoBody())
+	body := `{"action":"opened"}`
+	m := hmac.New(sha256.New, []byte("gh"))
+	m.Write([]byte(body))
+	sig := "sha256=" + hex.EncodeToString(m.Sum(nil))
+	if rec := signedRequest(h, DefaultBodySignatureHeader, sig, body); rec.Code != http.StatusOK || rec.Body.String() != body {
+		t.Fatalf("status = %d body = %q", rec.Code, rec.Body)
+	}
+	if rec := signedRequest(h, DefaultBodySignatureHeader, strings.TrimPrefix(sig, "sha256="), body); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("missing prefix status = %d", rec.Code)
+	}
+}
+
+func TestVerifySignatureInvalidConfig(t *testing.T) {
+	for _, o := range []SignatureOptions{
+		{},
+		{Secrets: []string{""}},
+		{Secrets: []string{"s"}, Scheme: "md5"},
+	} {
+		if _, err := newVerifySignature(o); err == nil {
+			t.Errorf("%+v accepted", o)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSSEShutdownRetry 是服务器关停时通知 SSE 客户端的重连延迟。
+const DefaultSSEShutdownRetry = time.Second
+
+// sseGoAwayTimeout 是关停时写 retry 事件的写超时。
+const sseGoAwayTimeout = 5 * time.Second
+
+// ErrStreamClosed 由 SSEStream.Send 在流已关闭（客户端断开、服务器关停
+// 或已调用 Close）后返回。
+var ErrStreamClosed = errors.New("http: stream closed")
+
+// SSEEvent 是一条 Server-Sent Events 事件。
+type SSEEvent struct {
+	// ID 设置客户端的 Last-Event-ID，重连时由浏览器回传。
+	ID string
+	// Event 是事件类型，为空时客户端按 "message" 处理。
+	Event string
+	// Data 是事件数据，多行时按行拆分为多个 data 字段。
+	Data string
+	// Retry 非 0 时设置客户端的重连延迟。
+	Retry time.Duration
+}
+
+// SSEOption 用于配置 NewSSEStream 的选项函数。
+type SSEOption func(*sseOptions)
+
+type sseOptions struct {
+	shutdownRetry time.Duration
+	heartbeat     time.Duration
+}
+
+// WithSSEShutdownRetry 设置服务器关停时发给客户端的重连延迟，缺省
+// DefaultSSEShutdownRetry。
+func WithSSEShutdownRetry(d time.Duration) SSEOption {
+	return func(o *sseOptions) {
+		o.shutdownRetry = d
+	}
+}
+
+// WithSSEHeartbeat 设置心跳间隔：空闲时定期发送注释行，避免代理与负载
+// 均衡器因空闲断开连接。缺省不发送。
+func WithSSEHeartbeat(d time.Duration) SSEOption {
+	return func(o *sseOptions) {
+		o.heartbeat = d
+	}
+}
+
+// SSEStream 是一条 Server-Sent Events 响应流，并发安全。
+type SSEStream struct {
+	w      http.ResponseWriter
+	rc     *http.ResponseController
+	o      sseOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	closed  bool
+	untrack func()
+}
+
+// NewSSEStream 开始 SSE 响应：写出 text/event-stream 响应头，取消服务器的
+// 写超时（WithTimeout 对长连接不适用），并把流登记为 Server 的长连接。
+// 服务器排水或关停时向客户端发送 retry 事件并结束流（Context 被取消），
+// 客户端随即重连到其他实例。handler 应在 Context 结束后返回：
+//
+//	stream, err := http.NewSSEStream(w, r)
+//	if err != nil {
+//		return err
+//	}
+//	defer stream.Close()
+//	for {
+//		select {
+//		case <-stream.Context().Done():
+//			return nil
+//		case ev := <-events:
+//			if err := stream.Send(http.SSEEvent{Event: "order", Data: ev}); err != nil {
+//				return nil
+//			}
+//		}
+//	}
+//
+// ResponseWriter 不支持 Flush 时返回错误。
+func NewSSEStream(w http.ResponseWriter, r *http.Request, opts ...SSEOption) (*SSEStream, error) {
+	o := sseOptions{shutdownRetry: DefaultSSEShutdownRetry}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	rc := http.NewResponseController(w)
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return nil, err
+	}
+	_ = rc.SetWriteDeadline(time.Time{})
+	ctx, cancel := context.WithCancel(r.Context())
+	s := &SSEStream{w: w, rc: rc, o: o, ctx: ctx, cancel: cancel}
+	s.untrack = TrackConn(r.Context(), s)
+	if o.heartbeat > 0 {
+		go s.heartbeat()
+	}
+	return s, nil
+}
+
+// Context 在客户端断开、服务器关停或 Close 后结束。
+func (s *SSEStream) Context() context.Context { return s.ctx }
+
+// Send 写出一条事件并立即 Flush。
+func (s *SSEStream) Send(ev SSEEvent) error {
+	var b strings.Builder
+	if ev.ID != "" {
+		b.WriteString("id: " + sseField(ev.ID) + "\n")
+	}
+	if ev.Event != "" {
+		b.WriteString("event: " + sseField(ev.Event) + "\n")
+	}
+	if ev.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(ev.Retry.Milliseconds(), 10) + "\n")
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(ev.Data, "\r\n", "\n"), "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+func (s *SSEStream) write(p string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.ctx.Err() != nil {
+		return ErrStreamClosed
+	}
+	if _, err := s.w.Write([]byte(p)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// GoAway 实现 LiveConn：发送重连延迟后结束流。写入在独立 goroutine 中
+// 进行并带写超时，客户端不读取时不会阻塞关停。
+func (s *SSEStream) GoAway() {
+	go func() {
+		defer s.cancel()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.closed || s.ctx.Err() != nil {
+			return
+		}
+		_ = s.rc.SetWriteDeadline(time.Now().Add(sseGoAwayTimeout))
+		if _, err := s.w.Write([]byte("retry: " + strconv.FormatInt(s.o.shutdownRetry.Milliseconds(), 10) + "\n\n")); err == nil {
+			_ = s.rc.Flush()
+		}
+	}()
+}
+
+// Close 结束流并注销登记，可重复调用；handler 返回前应调用。
+func (s *SSEStream) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.cancel()
+	s.untrack()
+	return nil
+}
+
+func (s *SSEStream) heartbeat() {
+	t := time.NewTicker(s.o.heartbeat)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-t.C:
+			if s.write(":\n\n") != nil {
+				return
+			}
+		}
+	}
+}
+
+// sseField 去掉单行字段中的换行，避免注入额外字段。
+func sseField(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEStreamSend(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	s, err := NewSSEStream(rec, r)
+	if err != nil {
+		t.Fatalf("NewSSEStream() error = %v", err)
+	}
+	if err := s.Send(SSEEvent{ID: "7", Event: "order\nx", Data: "a\nb", Retry: 2 * time.Second}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	want := "id: 7\nevent: orderx\nretry: 2000\ndata: a\ndata: b\n\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+
+	_ = s.Close()
+	if err := s.Send(SSEEvent{Data: "late"}); !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("Send() after Close error = %v, want ErrStreamClosed", err)
+	}
+	if s.Context().Err() == nil {
+		t.Error("Context not cancelled after Close")
+	}
+}
+
+func TestSSEStreamGoAwayOnStop(t *testing.T) {
+	sent := make(chan struct{})
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := NewSSEStream(w, r, WithSSEShutdownRetry(250*time.Millisecond))
+		if err != nil {
+			t.Errorf("NewSSEStream() error = %v", err)
+			return
+		}
+		defer s.Close()
+		_ = s.Send(SSEEvent{Data: "hello"})
+		close(sent)
+		<-s.Context().Done()
+	})
+	srv, addr := startLiveServer(t, h, WithShutdownTimeout(5*time.Second))
+
+	resp, err := http.Get("http://" + addr + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	<-sent
+
+	stopErr := make(chan error, 1)
+	go func() { stopErr <- srv.Stop(context.Background()) }()
+
+	body := bufio.NewReader(resp.Body)
+	var lines []string
+	for {
+		line, err := body.ReadString('\n')
+		lines = append(lines, strings.TrimRight(line, "\n"))
+		if err != nil {
+			break
+		}
+	}
+	if !strings.Contains(strings.Join(lines, "|"), "data: hello||retry: 250|") {
+		t.Errorf("stream = %q, want hello event followed by retry", lines)
+	}
+	select {
+	case err := <-stopErr:
+		if err != nil {
+			t.Errorf("Stop() error = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() did not return")
+	}
+}
+
+func TestSSEStreamHeartbeat(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+	s, err := NewSSEStream(rec, r, WithSSEHeartbeat(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	_ = s.Close() // Close 之后不再写入，读取 rec 无竞争
+	if !strings.HasPrefix(rec.Body.String(), ":\n\n") {
+		t.Errorf("body = %q, want heartbeat comments", rec.Body.String())
+	}
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Timeout 返回请求超时中间件：为请求 Context 设置 d 的 deadline；handler
+// 返回时若 deadline 已到且尚未开始写响应，以服务器级 ErrorHandler 写
+// ErrRequestTimeout（缺省 503 + {"error":{"message":"request timeout"}}）。
+//
+// 超时是协作式的：handler 需要经 r.Context() 感知取消（数据库、下游调用
+// 等）才能提前返回；与 http.TimeoutHandler 不同，不缓冲响应，也不另起
+// goroutine。经 NewErrorHandler 包装的 handler 返回包装
+// context.DeadlineExceeded 的错误时同样按 ErrRequestTimeout 响应。
+// d ≤ 0 时构造期 panic。
+func Timeout(d time.Duration) Middleware {
+	if d <= 0 {
+		panic(fmt.Sprintf("http: Timeout duration must be > 0, got %v", d))
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			r = r.WithContext(ctx)
+			tw := &trackedWriter{ResponseWriter: w}
+			next.ServeHTTP(tw, r)
+			if !tw.headerWritten() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				writeError(tw, r, ErrRequestTimeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// DefaultMaxBodyBytes 是类型化 handler 缺省的请求体大小上限（1 MiB）。
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+// JSONOption 用于配置 JSON 类型化 handler 的选项函数。
+type JSONOption func(*jsonOptions)
+
+type jsonOptions struct {
+	maxBodyBytes int64
+	validator    func(any) error
+	codecs       *Codecs
+	status       int
+	op           operationDoc
+}
+
+// WithMaxBodyBytes 设置请求体大小上限，缺省 DefaultMaxBodyBytes；超过时
+// 响应 413。n <= 0 表示不限制。
+func WithMaxBodyBytes(n int64) JSONOption {
+	return func(o *jsonOptions) {
+		o.maxBodyBytes = n
+	}
+}
+
+// WithValidator 设置额外的校验函数（如 go-playground/validator 的
+// Struct），在 validate 标签与 Validate 方法之后调用，参数为 *Req。返回
+// 的 DetailedError 字段详情与其余校验结果合并。
+func WithValidator(fn func(v any) error) JSONOption {
+	return func(o *jsonOptions) {
+		o.validator = fn
+	}
+}
+
+// WithCodecs 设置编解码器注册表，缺省 DefaultCodecs。
+func WithCodecs(c *Codecs) JSONOption {
+	return func(o *jsonOptions) {
+		o.codecs = c
+	}
+}
+
+// WithSuccessStatus 设置成功响应的状态码，缺省 200（如创建资源时用
+// 201）。handler 返回 nil 响应时总是 204。
+func WithSuccessStatus(status int) JSONOption {
+	return func(o *jsonOptions) {
+		o.status = status
+	}
+}
+
+// Validator 由需要自定义校验的请求类型实现，在 validate 标签校验之后
+// 调用。返回 DetailedError 时其字段详情与标签校验结果合并为一个 400；
+// 返回其他错误时（没有字段错误的情况下）APIError 原样响应，普通错误
+// 以其消息作为 400。
+type Validator interface {
+	Validate() error
+}
+
+// typedRoute 由 JSON 返回的 handler 实现，暴露请求与响应类型等元数据，
+// 供 OpenAPI 文档生成按路由检查。
+type typedRoute interface {
+	routeDoc() routeDoc
+}
+
+// routeDoc 是类型化路由的文档元数据。
+type routeDoc struct {
+	req, resp reflect.Type
+	status    int
+	codecs    *Codecs
+	op        operationDoc
+}
+
+type typedHandler[Req, Resp any] struct {
+	fn   func(ctx context.Context, req *Req) (*Resp, error)
+	opts jsonOptions
+	info *structInfo
+}
+
+// JSON 把类型化业务函数适配为 http.Handler：
+//   - 请求：按 Content-Type 选择编解码器解码请求体（空请求体跳过，不支持
+//     的类型 415，超过大小上限 413，无法解析 400）；随后按结构体标签绑定
+//     路径参数（`path:"id"`，即 r.PathValue）、查询参数（`query:"limit"`）
+//     与请求头（`header:"X-Tenant"`），覆盖请求体中的同名字段；
+//   - 校验：validate 标签（见下）、Validator 接口与 WithValidator 依次执行，
+//     全部字段错误合并为一个 400 APIError（Message "validation failed"，
+//     Fields 为字段详情），经服务器级 ErrorHandler 写出；
+//   - 响应：按 Accept 协商编解码器（无可接受的类型时 406，在调用 fn 之前
+//     判定）；fn 返回错误时交给 ErrorHandler，返回 nil 响应时为 204。
+//
+// validate 标签支持 required、min=N、max=N、len=N、oneof=a b c 与
+// pattern=RE，逗号分隔；嵌套结构体与其切片递归校验，字段名形如
+// "items[0].qty"。标签非法时 JSON 在构造时 panic。
+//
+//	r.Handle("POST /orders/{shop}", http.JSON(createOrder), auth)
+func JSON[Req, Resp any](fn func(ctx context.Context, req *Req) (*Resp, error), opts ...JSONOption) http.Handler {
+	o := jsonOptions{maxBodyBytes: DefaultMaxBodyBytes, codecs: DefaultCodecs, status: http.StatusOK}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &typedHandler[Req, Resp]{fn: fn, opts: o, info: inspectStruct(reflect.TypeFor[Req]())}
+}
+
+func (h *typedHandler[Req, Resp]) routeDoc() routeDoc {
+	return routeDoc{
+		req:    reflect.TypeFor[Req](),
+		resp:   reflect.TypeFor[Resp](),
+		status: h.opts.status,
+		codecs: h.opts.codecs,
+		op:     h.opts.op,
+	}
+}
+
+func (h *typedHandler[Req, Resp]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	codec, ok := h.opts.codecs.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		writeError(w, r, NewAPIError(http.StatusNotAcceptable, "not acceptable"))
+		return
+	}
+	req := new(Req)
+	if err := h.decode(r, req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	resp, err := h.fn(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	body, err := codec.Marshal(resp)
+	if err != nil {
+		writeError(w, r, fmt.Errorf("encode response: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", codec.ContentType())
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(h.opts.status)
+	_, _ = w.Write(body)
+}
+
+// decode 解码请求体、绑定参数并校验，失败时返回 APIError。
+func (h *typedHandler[Req, Resp]) decode(r *http.Request, req *Req) error {
+	if err := h.decodeBody(r, req); err != nil {
+		return err
+	}
+	v := reflect.ValueOf(req).Elem()
+	fields := bindParams(r, v, h.info)
+	if v.Kind() == reflect.Struct {
+		fields = append(fields, validateStruct(v, h.info, "")...)
+	}
+	var other error
+	collect := func(err error) {
+		if err == nil {
+			return
+		}
+		var de DetailedError
+		if errors.As(err, &de) && len(de.FieldErrors()) > 0 {
+			fields = append(fields, de.FieldErrors()...)
+		} else if other == nil {
+			other = err
+		}
+	}
+	if vr, ok := any(req).(Validator); ok {
+		collect(vr.Validate())
+	}
+	if h.opts.validator != nil {
+		collect(h.opts.validator(req))
+	}
+	switch {
+	case len(fields) > 0:
+		return &APIError{Status: http.StatusBadRequest, Message: "validation failed", Fields: fields}
+	case other != nil:
+		var ae *APIError
+		if errors.As(other, &ae) {
+			return other
+		}
+		return NewAPIError(http.StatusBadRequest, other.Error())
+	}
+	return nil
+}
+
+func (h *typedHandler[Req, Resp]) decodeBody(r *http.Request, req *Req) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	body := io.Reader(r.Body)
+	if h.opts.maxBodyBytes > 0 {
+		// 多读 1 字节以区分“恰好等于上限”与“超过上限”。
+		body = io.LimitReader(r.Body, h.opts.maxBodyBytes+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return NewAPIError(http.StatusRequestEntityTooLarge, "request body too large")
+		}
+		if errors.Is(err, ErrBodyTimeout) {
+			return ErrBodyTimeout
+		}
+		return NewAPIError(http.StatusBadRequest, "failed to read request body").WithCause(err)
+	}
+	if h.opts.maxBodyBytes > 0 && int64(len(data)) > h.opts.maxBodyBytes {
+		return NewAPIError(http.StatusRequestEntityTooLarge, "request body too large")
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	codec, ok := h.opts.codecs.Lookup(r.Header.Get("Content-Type"))
+	if !ok {
+		return NewAPIError(http.StatusUnsupportedMediaType, "unsupported content type")
+	}
+	if err := codec.Unmarshal(data, req); err != nil {
+		return NewAPIError(http.StatusBadRequest, "invalid request body").WithCause(err)
+	}
+	return nil
+}
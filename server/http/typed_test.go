@@ -0,0 +1,338 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type orderItem struct {
+	SKU string `json:"sku" validate:"required"`
+	Qty int    `json:"qty" validate:"min=1,max=99"`
+}
+
+type createOrderReq struct {
+	Shop    string        `path:"shop"`
+	Tenant  string        `header:"X-Tenant" validate:"required"`
+	DryRun  bool          `query:"dry_run"`
+	Tags    []string      `query:"tag"`
+	Wait    time.Duration `query:"wait"`
+	Channel string        `json:"channel" validate:"oneof=web app"`
+	Items   []orderItem   `json:"items" validate:"min=1"`
+}
+
+type createOrderResp struct {
+	ID     string   `json:"id"`
+	Shop   string   `json:"shop"`
+	Tenant string   `json:"tenant"`
+	DryRun bool     `json:"dry_run"`
+	Tags   []string `json:"tags"`
+	Wait   string   `json:"wait"`
+	Items  int      `json:"items"`
+}
+
+func createOrder(_ context.Context, req *createOrderReq) (*createOrderResp, error) {
+	return &createOrderResp{
+		ID: "o-1", Shop: req.Shop, Tenant: req.Tenant, DryRun: req.DryRun,
+		Tags: req.Tags, Wait: req.Wait.String(), Items: len(req.Items),
+	}, nil
+}
+
+func typedRequest(h http.Handler, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.Handle(method+" /shops/{shop}/orders", h)
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body == "" {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestJSONBinding：请求体、路径、查询参数与请求头一并绑定，成功状态码
+// 可配置。
+func TestJSONBinding(t *testing.T) {
+	h := JSON(createOrder, WithSuccessStatus(http.StatusCreated))
+	rec := typedRequest(h, http.MethodPost, "/shops/s1/orders?dry_run=true&tag=a&tag=b&wait=2s",
+		`{"channel":"web","items":[{"sku":"x","qty":2}]}`,
+		http.Header{"X-Tenant": {"t1"}, "Content-Type": {"application/json; charset=utf-8"}})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201; body=%s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	var got createOrderResp
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := createOrderResp{ID: "o-1", Shop: "s1", Tenant: "t1", DryRun: true, Tags: []string{"a", "b"}, Wait: "2s", Items: 1}
+	if got.Shop != want.Shop || got.Tenant != want.Tenant || !got.DryRun || strings.Join(got.Tags, ",") != "a,b" ||
+		got.Wait != want.Wait || got.Items != want.Items {
+		t.Fatalf("resp = %+v, want %+v", got, want)
+	}
+}
+
+// TestJSONValidation：绑定与校验错误合并为一个 400，字段名使用标签名
+// 与嵌套路径。
+func TestJSONValidation(t *testing.T) {
+	called := false
+	h := JSON(func(ctx context.Context, req *createOrderReq) (*createOrderResp, error) {
+		called = true
+		return createOrder(ctx, req)
+	})
+	rec := typedRequest(h, http.MethodPost, "/shops/s1/orders?dry_run=maybe",
+		`{"channel":"fax","items":[{"sku":"x","qty":2},{"qty":0}]}`, nil)
+	if called {
+		t.Fatal("handler called for invalid request")
+	}
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+	var body errorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.Message != "validation failed" {
+		t.Fatalf("message = %q", body.Error.Message)
+	}
+	got := map[string]string{}
+	for _, f := range body.Error.Fields {
+		got[f.Field] = f.Code
+	}
+	want := map[string]string{
+		"dry_run":      "invalid",
+		"X-Tenant":     "required",
+		"channel":      "oneof",
+		"items[1].sku": "required",
+		"items[1].qty": "min",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("fields = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("field %s code = %q, want %q (all: %v)", k, got[k], v, got)
+		}
+	}
+}
+
+type signupReq struct {
+	Email    string `json:"email" validate:"required,pattern=^[^@]+@[^@]+$"`
+	Password string `json:"password" validate:"min=8"`
+	Confirm  string `json:"confirm"`
+}
+
+func (r *signupReq) Validate() error {
+	if r.Password != r.Confirm {
+		return NewAPIError(http.StatusBadRequest, "").WithField("confirm", "does not match password")
+	}
+	return nil
+}
+
+type empty struct{}
+
+// TestJSONValidatorInterface：Validate 方法与 WithValidator 的字段详情
+// 与标签校验合并。
+func TestJSONValidatorInterface(t *testing.T) {
+	h := JSON(func(context.Context, *signupReq) (*empty, error) { return nil, nil },
+		WithValidator(func(v any) error {
+			if v.(*signupReq).Email == "root@example.com" {
+				return NewAPIError(http.StatusBadRequest, "").WithField("email", "reserved")
+			}
+			return nil
+		}))
+	rec := typedRequest(h, http.MethodPost, "/shops/s1/orders",
+		`{"email":"root@example.com","password":"short","confirm":"other"}`, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+	for _, want := range []string{`"field":"password"`, `"field":"confirm"`, `"message":"reserved"`} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("body %s missing %s", rec.Body, want)
+		}
+	}
+
+	rec = typedRequest(h, http.MethodPost, "/shops/s1/orders",
+		`{"email":"a@b.c","password":"longenough","confirm":"longenough"}`, nil)
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Fatalf("status = %d body=%q, want 204", rec.Code, rec.Body)
+	}
+}
+
+// TestJSONRequestErrors：请求体超限、类型不支持、无法解析与 Accept
+// 不可接受。
+func TestJSONRequestErrors(t *testing.T) {
+	h := JSON(func(context.Context, *signupReq) (*empty, error) { return &empty{}, nil }, WithMaxBodyBytes(16))
+	tests := []struct {
+		name   string
+		body   string
+		header http.Header
+		status int
+	}{
+		{"too large", `{"email":"someone@example.com"}`, nil, http.StatusRequestEntityTooLarge},
+		{"unsupported", `<a/>`, http.Header{"Content-Type": {"application/xml"}}, http.StatusUnsupportedMediaType},
+		{"malformed", `{"email":`, nil, http.StatusBadRequest},
+		{"not acceptable", ``, http.Header{"Accept": {"application/xml"}}, http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := typedRequest(h, http.MethodPost, "/shops/s1/orders", tt.body, tt.header)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d; body=%s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+// TestJSONHandlerError：业务错误经服务器级 ErrorHandler 写出。
+func TestJSONHandlerError(t *testing.T) {
+	h := JSON(func(context.Context, *empty) (*empty, error) {
+		return nil, NewAPIError(http.StatusConflict, "order exists")
+	})
+	rec := typedRequest(withErrorHandler(ProblemErrorHandler)(h), http.MethodGet, "/shops/s1/orders", "", nil)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+}
+
+type textCodec struct{}
+
+func (textCodec) ContentType() string { return "text/plain" }
+
+func (textCodec) Marshal(v any) ([]byte, error) {
+	if s, ok := v.(interface{ String() string }); ok {
+		return []byte(s.String()), nil
+	}
+	return nil, errors.New("not a Stringer")
+}
+
+func (textCodec) Unmarshal([]byte, any) error { return errors.New("unsupported") }
+
+type greeting struct{ Name string }
+
+func (g *greeting) String() string { return "hello " + g.Name }
+
+// TestJSONNegotiation：按 Accept 从注册表中选择响应编解码器。
+func TestJSONNegotiation(t *testing.T) {
+	codecs := NewCodecs(JSONCodec{}, textCodec{})
+	h := JSON(func(context.Context, *empty) (*greeting, error) { return &greeting{Name: "lynx"}, nil }, WithCodecs(codecs))
+	rec := typedRequest(h, http.MethodGet, "/shops/s1/orders", "", http.Header{"Accept": {"text/*;q=0.9, application/json;q=0.5"}})
+	if rec.Body.String() != "hello lynx" || rec.Header().Get("Content-Type") != "text/plain" {
+		t.Fatalf("got %q (%s)", rec.Body, rec.Header().Get("Content-Type"))
+	}
+	rec = typedRequest(h, http.MethodGet, "/shops/s1/orders", "", nil)
+	if rec.Body.String() != `{"Name":"lynx"}` {
+		t.Fatalf("default codec body = %q", rec.Body)
+	}
+}
+
+// TestJSONEncodeError：响应编码失败 → 500，响应体不含内部错误信息。
+func TestJSONEncodeError(t *testing.T) {
+	h := JSON(func(context.Context, *empty) (*empty, error) { return &empty{}, nil }, WithCodecs(NewCodecs(textCodec{})))
+	rec := typedRequest(h, http.MethodGet, "/shops/s1/orders", "", nil)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", rec.Code)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"error":{"message":"Internal Server Error"}}` {
+		t.Fatalf("body = %q", got)
+	}
+}
+
+// TestJSONInvalidTag：非法 validate 标签在构造时 panic。
+func TestJSONInvalidTag(t *testing.T) {
+	type bad struct {
+		N int `validate:"min=abc"`
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	JSON(func(context.Context, *bad) (*empty, error) { return nil, nil })
+}
+
+type TenantScope struct {
+	Tenant string `header:"X-Tenant" validate:"required"`
+	Region string `query:"region"`
+}
+
+type listOrdersReq struct {
+	*TenantScope
+	Shop string `path:"shop"`
+}
+
+// TestJSONEmbeddedPointer：嵌入指针的字段绑定时分配，未绑定任何字段时
+// 跳过其校验而不是 panic。
+func TestJSONEmbeddedPointer(t *testing.T) {
+	h := JSON(func(_ context.Context, req *listOrdersReq) (*createOrderResp, error) {
+		resp := &createOrderResp{Shop: req.Shop}
+		if req.TenantScope != nil {
+			resp.Tenant = req.Tenant + "/" + req.Region
+		}
+		return resp, nil
+	})
+	rec := typedRequest(h, http.MethodGet, "/shops/s1/orders?region=eu", "", http.Header{"X-Tenant": {"t1"}})
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"tenant":"t1/eu"`) {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	rec = typedRequest(h, http.MethodGet, "/shops/s1/orders", "", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"shop":"s1"`) {
+		t.Fatalf("without tenant: status = %d, body = %s", rec.Code, rec.Body)
+	}
+}
+
+// TestInspectStructConcurrent：并发的首次解析都得到完整的元数据；解析
+// panic 后不缓存残缺的结果。
+func TestInspectStructConcurrent(t *testing.T) {
+	type node struct {
+		Children []node `json:"children"`
+		Name     string `json:"name" validate:"required"`
+	}
+	typ := reflect.TypeFor[node]()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			info := inspectStruct(typ)
+			if len(info.rules) != 2 || info.rules[0].nested != info.rules[0].nested.rules[0].nested {
+				t.Errorf("rules = %+v, want children (self-referencing) and name", info.rules)
+			}
+		}()
+	}
+	wg.Wait()
+	errs := validateStruct(reflect.ValueOf(node{Name: "a", Children: []node{{}}}), inspectStruct(typ), "")
+	if len(errs) != 1 || errs[0].Field != "children[0].name" {
+		t.Errorf("errs = %+v, want children[0].name", errs)
+	}
+
+	type bad struct {
+		N int `validate:"min=abc"`
+	}
+	for i := 0; i < 2; i++ {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("inspectStruct call %d did not panic", i+1)
+				}
+			}()
+			inspectStruct(reflect.TypeFor[bad]())
+		}()
+	}
+}
@@ -0,0 +1,441 @@
+package http
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// WebSocket 消息类型（RFC 6455 opcode）。
+const (
+	WebSocketText   = 1
+	WebSocketBinary = 2
+
+	wsContinuation = 0
+	wsClose        = 8
+	wsPing         = 9
+	wsPong         = 10
+)
+
+// WebSocket 关闭码（RFC 6455 7.4.1）。
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseNoStatus        = 1005
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// DefaultWebSocketReadLimit 是单条消息的缺省最大字节数。
+const DefaultWebSocketReadLimit = 1 << 20
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsCloseTimeout 是发送 close 帧等控制帧的写超时。
+const wsCloseTimeout = 5 * time.Second
+
+var (
+	// ErrWebSocketHandshake 表示请求不是合法的 WebSocket 握手（400）。
+	ErrWebSocketHandshake = &APIError{Status: http.StatusBadRequest, Message: "invalid websocket handshake", quiet: true}
+	// ErrWebSocketOrigin 表示 Origin 校验未通过（403）。
+	ErrWebSocketOrigin = &APIError{Status: http.StatusForbidden, Message: "websocket origin not allowed", quiet: true}
+)
+
+// WebSocketCloseError 是 ReadMessage 在收到对端 close 帧（或因协议错误
+// 关闭连接）时返回的错误。
+type WebSocketCloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *WebSocketCloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("websocket: close %d", e.Code)
+	}
+	return fmt.Sprintf("websocket: close %d: %s", e.Code, e.Reason)
+}
+
+// WebSocketOptions 配置 UpgradeWebSocket。
+type WebSocketOptions struct {
+	// Subprotocols 是服务端支持的子协议，按优先级排列；取客户端
+	// Sec-WebSocket-Protocol 中第一个受支持者。
+	Subprotocols []string
+	// CheckOrigin 校验握手请求的 Origin；为 nil 时要求 Origin 缺失或与
+	// Host 相同，防止跨站 WebSocket 劫持。
+	CheckOrigin func(r *http.Request) bool
+	// ReadLimit 是单条消息的最大字节数，超出时以 1009 关闭连接；≤ 0 时取
+	// DefaultWebSocketReadLimit。
+	ReadLimit int64
+}
+
+// WebSocket 是服务端 WebSocket 连接（RFC 6455，不含扩展）。ReadMessage
+// 须在单个 goroutine 中调用；WriteMessage 与 Close 并发安全。
+type WebSocket struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	subprotocol string
+	readLimit   int64
+
+	wmu     sync.Mutex
+	closing bool
+
+	untrack func()
+	once    sync.Once
+}
+
+// UpgradeWebSocket 完成 WebSocket 握手并接管连接，连接登记为 Server 的
+// 长连接：服务器排水或关停时发送 close 帧 1001，客户端应断开并重连到其他
+// 实例；关停预算耗尽时连接被强制关闭。handler 读到错误后应调用 Close 并
+// 返回：
+//
+//	ws, err := http.UpgradeWebSocket(w, r, http.WebSocketOptions{})
+//	if err != nil {
+//		return err
+//	}
+//	defer ws.Close(http.CloseNormal, "")
+//	for {
+//		typ, msg, err := ws.ReadMessage()
+//		if err != nil {
+//			return nil
+//		}
+//		if err := ws.WriteMessage(typ, msg); err != nil {
+//			return nil
+//		}
+//	}
+//
+// 握手非法时返回 ErrWebSocketHandshake，Origin 不被允许时返回
+// ErrWebSocketOrigin，此时尚未写响应，handler 直接返回即可。
+func UpgradeWebSocket(w http.ResponseWriter, r *http.Request, o WebSocketOptions) (*WebSocket, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet ||
+		!headerContainsToken(r.Header, "Connection", "upgrade") ||
+		!headerContainsToken(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" ||
+		!validWebSocketKey(key) {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, ErrWebSocketHandshake
+	}
+	check := o.CheckOrigin
+	if check == nil {
+		check = sameOrigin
+	}
+	if !check(r) {
+		return nil, ErrWebSocketOrigin
+	}
+	subprotocol := selectSubprotocol(r, o.Subprotocols)
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket: hijack: %w", err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	if brw.Reader.Buffered() > 0 {
+		// 客户端不应在握手完成前发送帧。
+		_ = conn.Close()
+		return nil, errors.New("websocket: client sent data before handshake")
+	}
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + webSocketAccept(key) + "\r\n")
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	b.WriteString("\r\n")
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("websocket: handshake: %w", err)
+	}
+
+	limit := o.ReadLimit
+	if limit <= 0 {
+		limit = DefaultWebSocketReadLimit
+	}
+	ws := &WebSocket{conn: conn, br: brw.Reader, subprotocol: subprotocol, readLimit: limit}
+	ws.untrack = TrackConn(r.Context(), wsLiveConn{ws})
+	return ws, nil
+}
+
+// Subprotocol 返回协商的子协议，未协商时为空。
+func (ws *WebSocket) Subprotocol() string { return ws.subprotocol }
+
+// ReadMessage 读取下一条完整消息，返回类型（WebSocketText 或
+// WebSocketBinary）与内容。ping 自动回复 pong；收到 close 帧时回复 close
+// 并返回 *WebSocketCloseError。协议错误、消息超过 ReadLimit 或文本不是
+// 合法 UTF-8 时以相应关闭码关闭连接并返回错误。
+func (ws *WebSocket) ReadMessage() (int, []byte, error) {
+	var (
+		typ int
+		msg []byte
+	)
+	for {
+		fin, op, payload, err := ws.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case wsPing:
+			if err := ws.writeControl(wsPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			ce := parseClosePayload(payload)
+			if ce.Code == CloseProtocolError {
+				return 0, nil, ws.fail(CloseProtocolError, "invalid close frame")
+			}
+			code := ce.Code
+			if code == CloseNoStatus {
+				code = CloseNormal
+			}
+			_ = ws.Close(code, "")
+			return 0, nil, ce
+		case WebSocketText, WebSocketBinary:
+			if typ != 0 {
+				return 0, nil, ws.fail(CloseProtocolError, "expected continuation frame")
+			}
+			typ = op
+		case wsContinuation:
+			if typ == 0 {
+				return 0, nil, ws.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		default:
+			return 0, nil, ws.fail(CloseProtocolError, "unknown opcode")
+		}
+		if int64(len(msg))+int64(len(payload)) > ws.readLimit {
+			return 0, nil, ws.fail(CloseMessageTooBig, "message too big")
+		}
+		msg = append(msg, payload...)
+		if fin {
+			if typ == WebSocketText && !utf8.Valid(msg) {
+				return 0, nil, ws.fail(CloseInvalidPayload, "invalid utf-8")
+			}
+			return typ, msg, nil
+		}
+	}
+}
+
+// readFrame 读取一帧并去掉掩码。
+func (ws *WebSocket) readFrame() (fin bool, op int, payload []byte, err error) {
+	var h [2]byte
+	if _, err = io.ReadFull(ws.br, h[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = h[0]&0x80 != 0
+	op = int(h[0] & 0x0f)
+	if h[0]&0x70 != 0 {
+		return false, 0, nil, ws.fail(CloseProtocolError, "reserved bits set")
+	}
+	if h[1]&0x80 == 0 {
+		return false, 0, nil, ws.fail(CloseProtocolError, "client frame not masked")
+	}
+	n := uint64(h[1] & 0x7f)
+	control := op >= wsClose
+	if control && (!fin || n > 125) {
+		return false, 0, nil, ws.fail(CloseProtocolError, "invalid control frame")
+	}
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(ws.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(ws.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > uint64(ws.readLimit) {
+		return false, 0, nil, ws.fail(CloseMessageTooBig, "message too big")
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(ws.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(ws.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// WriteMessage 发送一条 WebSocketText 或 WebSocketBinary 消息。连接已
+// 开始关闭时返回 ErrStreamClosed。
+func (ws *WebSocket) WriteMessage(typ int, data []byte) error {
+	if typ != WebSocketText && typ != WebSocketBinary {
+		return fmt.Errorf("websocket: invalid message type %d", typ)
+	}
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+	if ws.closing {
+		return ErrStreamClosed
+	}
+	return ws.writeFrame(typ, data)
+}
+
+// writeFrame 写出一帧（服务端帧不加掩码），调用方持有 wmu。
+func (ws *WebSocket) writeFrame(op int, data []byte) error {
+	buf := make([]byte, 0, 10+len(data))
+	buf = append(buf, 0x80|byte(op))
+	switch n := len(data); {
+	case n <= 125:
+		buf = append(buf, byte(n))
+	case n <= 0xffff:
+		buf = append(buf, 126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, 127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	buf = append(buf, data...)
+	_, err := ws.conn.Write(buf)
+	return err
+}
+
+func (ws *WebSocket) writeControl(op int, data []byte) error {
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+	if ws.closing {
+		return ErrStreamClosed
+	}
+	_ = ws.conn.SetWriteDeadline(time.Now().Add(wsCloseTimeout))
+	defer ws.conn.SetWriteDeadline(time.Time{})
+	return ws.writeFrame(op, data)
+}
+
+// sendClose 发送 close 帧（只发送一次），之后不再允许写入。
+func (ws *WebSocket) sendClose(code int, reason string) error {
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+	if ws.closing {
+		return nil
+	}
+	ws.closing = true
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload = append(payload, reason...)
+	_ = ws.conn.SetWriteDeadline(time.Now().Add(wsCloseTimeout))
+	return ws.writeFrame(wsClose, payload)
+}
+
+// fail 以 code 关闭连接并返回对应的 *WebSocketCloseError。
+func (ws *WebSocket) fail(code int, reason string) error {
+	_ = ws.Close(code, reason)
+	return &WebSocketCloseError{Code: code, Reason: reason}
+}
+
+// wsLiveConn 把 WebSocket 登记为 LiveConn：GoAway 发送 close 帧 1001 后
+// 等待客户端断开，Close 在关停预算耗尽时直接关闭底层连接。
+type wsLiveConn struct{ ws *WebSocket }
+
+func (c wsLiveConn) GoAway() {
+	// 并发的 WriteMessage 可能阻塞在慢客户端上，close 帧异步发送。
+	go func() { _ = c.ws.sendClose(CloseGoingAway, "server shutting down") }()
+}
+
+func (c wsLiveConn) Close() error { return c.ws.conn.Close() }
+
+// Close 发送 close 帧（尚未发送时）、关闭底层连接并注销登记，可重复
+// 调用；handler 返回前应调用。
+func (ws *WebSocket) Close(code int, reason string) error {
+	var err error
+	ws.once.Do(func() {
+		_ = ws.sendClose(code, reason)
+		err = ws.conn.Close()
+		ws.untrack()
+	})
+	return err
+}
+
+// parseClosePayload 解析 close 帧负载；格式错误时返回 CloseProtocolError。
+func parseClosePayload(p []byte) *WebSocketCloseError {
+	switch {
+	case len(p) == 0:
+		return &WebSocketCloseError{Code: CloseNoStatus}
+	case len(p) == 1:
+		return &WebSocketCloseError{Code: CloseProtocolError}
+	}
+	code := int(binary.BigEndian.Uint16(p))
+	reason := p[2:]
+	if code < 1000 || code == CloseNoStatus || code == 1006 || code == 1015 || !utf8.Valid(reason) {
+		return &WebSocketCloseError{Code: CloseProtocolError}
+	}
+	return &WebSocketCloseError{Code: code, Reason: string(reason)}
+}
+
+func webSocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func validWebSocketKey(key string) bool {
+	b, err := base64.StdEncoding.DecodeString(key)
+	return err == nil && len(b) == 16
+}
+
+// headerContainsToken 判断逗号分隔的头 name 是否包含 token（不区分大小写）。
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func selectSubprotocol(r *http.Request, supported []string) string {
+	if len(supported) == 0 {
+		return ""
+	}
+	offered := map[string]bool{}
+	for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, p := range strings.Split(v, ",") {
+			offered[strings.TrimSpace(p)] = true
+		}
+	}
+	for _, p := range supported {
+		if offered[p] {
+			return p
+		}
+	}
+	return ""
+}
+
+// sameOrigin 允许无 Origin（非浏览器客户端）或 Origin 与 Host 相同的请求。
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,263 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsClient 是测试用的最小 WebSocket 客户端。
+type wsClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, addr, path string, header map[string]string) (*wsClient, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	req := "GET " + path + " HTTP/1.1\r\nHost: " + addr + "\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"
+	for k, v := range header {
+		req += k + ": " + v + "\r\n"
+	}
+	if _, err := conn.Write([]byte(req + "\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &wsClient{conn: conn, br: br}, resp
+}
+
+func (c *wsClient) write(t *testing.T, fin bool, op int, payload []byte) {
+	t.Helper()
+	b0 := byte(op)
+	if fin {
+		b0 |= 0x80
+	}
+	buf := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, 0x80|byte(n))
+	default:
+		buf = append(buf, 0x80|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	}
+	mask := [4]byte{1, 2, 3, 4}
+	buf = append(buf, mask[:]...)
+	for i, b := range payload {
+		buf = append(buf, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (c *wsClient) read(t *testing.T) (int, []byte) {
+	t.Helper()
+	_ = c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var h [2]byte
+	if _, err := io.ReadFull(c.br, h[:]); err != nil {
+		t.Fatalf("read frame: %v", err)
+	}
+	n := int(h[1] & 0x7f)
+	if n == 126 {
+		var ext [2]byte
+		_, _ = io.ReadFull(c.br, ext[:])
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	p := make([]byte, n)
+	if _, err := io.ReadFull(c.br, p); err != nil {
+		t.Fatalf("read payload: %v", err)
+	}
+	return int(h[0] & 0x0f), p
+}
+
+func closeCode(p []byte) int {
+	if len(p) < 2 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(p))
+}
+
+func echoWebSocket(t *testing.T, o WebSocketOptions, done chan<- error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := UpgradeWebSocket(w, r, o)
+		if err != nil {
+			w.WriteHeader(err.(*APIError).Status)
+			return
+		}
+		defer ws.Close(CloseNormal, "")
+		for {
+			typ, msg, err := ws.ReadMessage()
+			if err != nil {
+				done <- err
+				return
+			}
+			if err := ws.WriteMessage(typ, msg); err != nil {
+				done <- err
+				return
+			}
+		}
+	})
+}
+
+func TestWebSocketEcho(t *testing.T) {
+	done := make(chan error, 1)
+	_, addr := startLiveServer(t, echoWebSocket(t, WebSocketOptions{Subprotocols: []string{"v2", "v1"}}, done))
+
+	c, resp := dialWebSocket(t, addr, "/ws", map[string]string{"Sec-WebSocket-Protocol": "v1, v2"})
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %d, want 101", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Sec-WebSocket-Accept = %q", got)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Protocol"); got != "v2" {
+		t.Errorf("Sec-WebSocket-Protocol = %q, want v2", got)
+	}
+
+	c.write(t, true, WebSocketText, []byte("hello"))
+	if op, p := c.read(t); op != WebSocketText || string(p) != "hello" {
+		t.Errorf("echo = %d %q", op, p)
+	}
+
+	// 分片消息，中间插入 ping。
+	c.write(t, false, WebSocketBinary, []byte("ab"))
+	c.write(t, true, wsPing, []byte("p"))
+	c.write(t, true, wsContinuation, []byte(strings.Repeat("c", 200)))
+	if op, p := c.read(t); op != wsPong || string(p) != "p" {
+		t.Errorf("pong = %d %q", op, p)
+	}
+	if op, p := c.read(t); op != WebSocketBinary || string(p) != "ab"+strings.Repeat("c", 200) {
+		t.Errorf("echo = %d %q", op, p)
+	}
+
+	c.write(t, true, wsClose, append(binary.BigEndian.AppendUint16(nil, CloseNormal), "bye"...))
+	if op, p := c.read(t); op != wsClose || closeCode(p) != CloseNormal {
+		t.Errorf("close reply = %d %v", op, p)
+	}
+	var ce *WebSocketCloseError
+	if err := <-done; !errors.As(err, &ce) || ce.Code != CloseNormal || ce.Reason != "bye" {
+		t.Errorf("ReadMessage() error = %v, want close 1000 bye", err)
+	}
+}
+
+func TestWebSocketProtocolErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(t *testing.T, c *wsClient)
+		code int
+	}{
+		{"too big", func(t *testing.T, c *wsClient) { c.write(t, true, WebSocketBinary, make([]byte, 200)) }, CloseMessageTooBig},
+		{"invalid utf-8", func(t *testing.T, c *wsClient) { c.write(t, true, WebSocketText, []byte{0xff}) }, CloseInvalidPayload},
+		{"unexpected continuation", func(t *testing.T, c *wsClient) { c.write(t, true, wsContinuation, []byte("x")) }, CloseProtocolError},
+		{"unmasked", func(t *testing.T, c *wsClient) { _, _ = c.conn.Write([]byte{0x81, 0x01, 'x'}) }, CloseProtocolError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := make(chan error, 1)
+			_, addr := startLiveServer(t, echoWebSocket(t, WebSocketOptions{ReadLimit: 100}, done))
+			c, _ := dialWebSocket(t, addr, "/ws", nil)
+			tt.send(t, c)
+			if op, p := c.read(t); op != wsClose || closeCode(p) != tt.code {
+				t.Errorf("close frame = %d %v, want code %d", op, p, tt.code)
+			}
+			var ce *WebSocketCloseError
+			if err := <-done; !errors.As(err, &ce) || ce.Code != tt.code {
+				t.Errorf("ReadMessage() error = %v, want code %d", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestUpgradeWebSocketRejects(t *testing.T) {
+	valid := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "http://example.com/ws", nil)
+		r.Header.Set("Connection", "keep-alive, Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		r.Header.Set("Sec-WebSocket-Version", "13")
+		r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		return r
+	}
+	tests := []struct {
+		name   string
+		mutate func(r *http.Request)
+		want   error
+	}{
+		{"post", func(r *http.Request) { r.Method = http.MethodPost }, ErrWebSocketHandshake},
+		{"no upgrade", func(r *http.Request) { r.Header.Del("Upgrade") }, ErrWebSocketHandshake},
+		{"bad version", func(r *http.Request) { r.Header.Set("Sec-WebSocket-Version", "8") }, ErrWebSocketHandshake},
+		{"bad key", func(r *http.Request) { r.Header.Set("Sec-WebSocket-Key", "short") }, ErrWebSocketHandshake},
+		{"cross origin", func(r *http.Request) { r.Header.Set("Origin", "https://evil.example") }, ErrWebSocketOrigin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			tt.mutate(r)
+			if _, err := UpgradeWebSocket(httptest.NewRecorder(), r, WebSocketOptions{}); err != tt.want {
+				t.Errorf("UpgradeWebSocket() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	r := valid()
+	r.Header.Set("Origin", "https://example.com")
+	if !sameOrigin(r) {
+		t.Error("same-origin request rejected")
+	}
+}
+
+func TestWebSocketGoAwayOnStop(t *testing.T) {
+	done := make(chan error, 1)
+	srv, addr := startLiveServer(t, echoWebSocket(t, WebSocketOptions{}, done), WithShutdownTimeout(5*time.Second))
+	c, _ := dialWebSocket(t, addr, "/ws", nil)
+	c.write(t, true, WebSocketText, []byte("hi"))
+	c.read(t)
+
+	stopErr := make(chan error, 1)
+	go func() { stopErr <- srv.Stop(context.Background()) }()
+	if op, p := c.read(t); op != wsClose || closeCode(p) != CloseGoingAway {
+		t.Fatalf("frame = %d %v, want close 1001", op, p)
+	}
+	c.write(t, true, wsClose, binary.BigEndian.AppendUint16(nil, CloseGoingAway))
+	select {
+	case err := <-stopErr:
+		if err != nil {
+			t.Errorf("Stop() error = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() did not return after client closed")
+	}
+}
+
+func TestWebSocketStopReportsUnclosedClients(t *testing.T) {
+	done := make(chan error, 1)
+	srv, addr := startLiveServer(t, echoWebSocket(t, WebSocketOptions{}, done), WithShutdownTimeout(100*time.Millisecond))
+	c, _ := dialWebSocket(t, addr, "/ws", nil)
+	c.write(t, true, WebSocketText, []byte("hi"))
+	c.read(t)
+
+	// 客户端忽略 close 帧，预算耗尽后被强制关闭。
+	err := srv.Stop(context.Background())
+	var lce *LiveConnsError
+	if !errors.As(err, &lce) || lce.Remaining != 1 {
+		t.Fatalf("Stop() error = %v, want *LiveConnsError{Remaining: 1}", err)
+	}
+	if err := <-done; err == nil {
+		t.Error("ReadMessage() returned nil after force close")
+	}
+}
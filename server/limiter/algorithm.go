@@ -0,0 +1,114 @@
+package limiter
+
+import (
+	"math"
+	"time"
+)
+
+// Sample 是一次请求完成时的观测值，交给 Algorithm 调整并发上限。
+type Sample struct {
+	// RTT 是请求从获得名额到释放的耗时。
+	RTT time.Duration
+	// InFlight 是请求获得名额时（含自身）的在途请求数。
+	InFlight int
+	// Dropped 表示请求因过载失败（超时、下游不可用等）。
+	Dropped bool
+}
+
+// Algorithm 根据观测值计算新的并发上限。Limiter 在持锁状态下调用
+// Update，实现无需自行加锁，但同一实例不应在多个 Limiter 间共享。
+type Algorithm interface {
+	Update(limit float64, s Sample) float64
+}
+
+// AIMD 是加性增、乘性减算法：请求成功且延迟不超过 LatencyThreshold 时
+// 上限加 Increase（仅在在途数接近上限、上限确实成为瓶颈时），请求被丢弃
+// 或延迟超标时上限乘以 Backoff。适合有明确延迟目标（SLO）的服务。
+type AIMD struct {
+	// LatencyThreshold 是视为过载的延迟，0 表示只按 Dropped 判断。
+	LatencyThreshold time.Duration
+	// Increase 是每次成功的增量，缺省 1。
+	Increase float64
+	// Backoff 是过载时的乘数，取值 (0,1)，缺省 0.9。
+	Backoff float64
+}
+
+// Update 实现 Algorithm。
+func (a *AIMD) Update(limit float64, s Sample) float64 {
+	backoff := a.Backoff
+	if backoff <= 0 || backoff >= 1 {
+		backoff = 0.9
+	}
+	if s.Dropped || (a.LatencyThreshold > 0 && s.RTT > a.LatencyThreshold) {
+		return limit * backoff
+	}
+	// 在途数远低于上限时上限不是瓶颈，增大它没有依据。
+	if float64(s.InFlight)*2 < limit {
+		return limit
+	}
+	inc := a.Increase
+	if inc <= 0 {
+		inc = 1
+	}
+	return limit + inc
+}
+
+// Gradient 是基于延迟梯度的算法（参考 Netflix concurrency-limits 的
+// Gradient2）：以长期平均 RTT 为基线，短期 RTT 相对基线升高时按比例
+// 收缩上限，持平时以 sqrt(limit) 的排队余量缓慢增长。无需预设延迟目标，
+// 适合延迟分布未知或随负载漂移的服务。零值可用。
+type Gradient struct {
+	// Tolerance 是可容忍的短期/长期 RTT 比值，超过后开始收缩，缺省 1.5。
+	Tolerance float64
+	// Smoothing 是新上限的平滑系数，取值 (0,1]，缺省 0.2。
+	Smoothing float64
+	// LongWindow 是长期 RTT 指数平均的样本窗口，缺省 600。
+	LongWindow int
+
+	longRTT float64
+	samples int
+}
+
+// NewGradient 返回缺省参数的 Gradient。
+func NewGradient() *Gradient {
+	return &Gradient{}
+}
+
+// Update 实现 Algorithm。
+func (g *Gradient) Update(limit float64, s Sample) float64 {
+	tolerance, smoothing, window := g.Tolerance, g.Smoothing, g.LongWindow
+	if tolerance < 1 {
+		tolerance = 1.5
+	}
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = 0.2
+	}
+	if window <= 0 {
+		window = 600
+	}
+	if s.Dropped {
+		return limit * (1 - smoothing/2)
+	}
+	rtt := float64(s.RTT)
+	if rtt <= 0 {
+		return limit
+	}
+	// 长期 RTT：预热阶段取算术平均，之后为指数平均。
+	g.samples++
+	if g.samples <= window {
+		g.longRTT += (rtt - g.longRTT) / float64(g.samples)
+	} else {
+		g.longRTT += (rtt - g.longRTT) * 2 / float64(window+1)
+	}
+	// 短期 RTT 低于基线较多时基线随之回落，避免负载下降后上限长期偏低。
+	if rtt < g.longRTT/2 {
+		g.longRTT = rtt * 2
+	}
+	gradient := math.Max(0.5, math.Min(1, tolerance*g.longRTT/rtt))
+	// 在途数远低于上限时不增长（与 AIMD 同理），但延迟升高时照常收缩。
+	if gradient >= 1 && float64(s.InFlight)*2 < limit {
+		return limit
+	}
+	next := limit*gradient + math.Sqrt(limit)
+	return limit*(1-smoothing) + next*smoothing
+}
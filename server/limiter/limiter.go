@@ -0,0 +1,304 @@
+// Package limiter 提供自适应并发限制（load shedding）：按观测到的请求
+// 延迟动态调整在途请求上限（AIMD 或延迟梯度算法），超出上限的请求按
+// 优先级被拒绝。server/http 的 ConcurrencyLimit 中间件与 server/grpc 的
+// 同名拦截器基于本包实现。
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Default values for Limiter configuration.
+const (
+	DefaultInitialLimit = 20
+	DefaultMinLimit     = 1
+	DefaultMaxLimit     = 1000
+	// DefaultLowPriorityRatio 是低优先级请求可使用的上限比例。
+	DefaultLowPriorityRatio = 0.8
+)
+
+// Priority 是请求的优先级类别。
+type Priority int
+
+const (
+	// PriorityNormal 是缺省优先级：在途数达到上限时拒绝。
+	PriorityNormal Priority = iota
+	// PriorityLow 的请求只能使用上限的一部分（WithLowPriorityRatio），
+	// 过载时最先被拒绝。
+	PriorityLow
+	// PriorityCritical 的请求（健康检查、运维流量）从不被拒绝，也不参与
+	// 上限调整，但计入在途数。
+	PriorityCritical
+)
+
+// String 返回优先级名：low、normal、critical。
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityCritical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
+
+// ParsePriority 解析优先级名（不区分大小写），无法识别时返回
+// PriorityNormal 与 false。
+func ParsePriority(s string) (Priority, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low":
+		return PriorityLow, true
+	case "normal":
+		return PriorityNormal, true
+	case "critical":
+		return PriorityCritical, true
+	}
+	return PriorityNormal, false
+}
+
+// Outcome 是请求完成后的结果分类，决定是否以及如何更新上限。
+type Outcome int
+
+const (
+	// Success 表示请求正常完成，RTT 作为延迟样本。
+	Success Outcome = iota
+	// Dropped 表示请求因过载失败（超时、下游不可用），上限收缩。
+	Dropped
+	// Ignore 表示结果不代表服务端负载（客户端取消、流式请求等），只释放
+	// 名额，不更新上限。
+	Ignore
+)
+
+// Option 用于配置 Limiter 的选项函数。
+type Option func(*Limiter)
+
+// WithName 设置限制器名，作为指标属性 limiter 的值，缺省 "default"。
+func WithName(name string) Option {
+	return func(l *Limiter) {
+		l.name = name
+	}
+}
+
+// WithAlgorithm 设置上限调整算法，缺省为 NewGradient()。
+func WithAlgorithm(a Algorithm) Option {
+	return func(l *Limiter) {
+		l.algorithm = a
+	}
+}
+
+// WithInitialLimit 设置初始并发上限，缺省 DefaultInitialLimit。
+func WithInitialLimit(n int) Option {
+	return func(l *Limiter) {
+		l.limit = float64(n)
+	}
+}
+
+// WithMinLimit 设置上限的下界，缺省 DefaultMinLimit。
+func WithMinLimit(n int) Option {
+	return func(l *Limiter) {
+		l.minLimit = n
+	}
+}
+
+// WithMaxLimit 设置上限的上界，即非 critical 请求的最大在途数，缺省
+// DefaultMaxLimit。
+func WithMaxLimit(n int) Option {
+	return func(l *Limiter) {
+		l.maxLimit = n
+	}
+}
+
+// WithLowPriorityRatio 设置低优先级请求可使用的上限比例，取值 (0,1]，缺省
+// DefaultLowPriorityRatio：在途数达到 ratio*limit 后拒绝低优先级请求，为
+// 普通请求保留余量。
+func WithLowPriorityRatio(ratio float64) Option {
+	return func(l *Limiter) {
+		l.lowRatio = ratio
+	}
+}
+
+// WithMeterProvider 设置导出指标的 MeterProvider，缺省取
+// otel.GetMeterProvider()。
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(l *Limiter) {
+		l.meterProvider = mp
+	}
+}
+
+// Limiter 是自适应并发限制器，并发安全。每个请求先 Acquire，完成后以
+// 结果调用 Token.Release；算法按释放时的延迟样本调整上限。
+//
+// 导出的 otel 指标（属性 limiter=<name>）：
+//   - lynx.concurrency.limit：当前上限（gauge）；
+//   - lynx.concurrency.inflight：当前在途请求数（gauge）；
+//   - lynx.concurrency.shed：被拒绝的请求数（counter，附加 priority 属性）。
+type Limiter struct {
+	name          string
+	algorithm     Algorithm
+	minLimit      int
+	maxLimit      int
+	lowRatio      float64
+	meterProvider metric.MeterProvider
+	now           func() time.Time
+
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+
+	shed metric.Int64Counter
+	attr metric.MeasurementOption
+}
+
+// New 创建 Limiter。上下界非法（min < 1 或 max < min）时返回错误。
+func New(opts ...Option) (*Limiter, error) {
+	l := &Limiter{
+		name:     "default",
+		limit:    DefaultInitialLimit,
+		minLimit: DefaultMinLimit,
+		maxLimit: DefaultMaxLimit,
+		lowRatio: DefaultLowPriorityRatio,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.minLimit < 1 || l.maxLimit < l.minLimit {
+		return nil, fmt.Errorf("limiter: invalid limit bounds [%d, %d]", l.minLimit, l.maxLimit)
+	}
+	if l.lowRatio <= 0 || l.lowRatio > 1 {
+		return nil, fmt.Errorf("limiter: low priority ratio must be in (0, 1], got %v", l.lowRatio)
+	}
+	if l.algorithm == nil {
+		l.algorithm = NewGradient()
+	}
+	l.limit = l.clamp(l.limit)
+	if l.meterProvider == nil {
+		l.meterProvider = otel.GetMeterProvider()
+	}
+	if err := l.initMetrics(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Limiter) initMetrics() error {
+	meter := l.meterProvider.Meter("github.com/lynx-go/lynx/server/limiter")
+	attrs := attribute.NewSet(attribute.String("limiter", l.name))
+	l.attr = metric.WithAttributeSet(attrs)
+	var err error
+	if l.shed, err = meter.Int64Counter("lynx.concurrency.shed",
+		metric.WithDescription("Requests rejected by the adaptive concurrency limiter."),
+		metric.WithUnit("{request}"),
+	); err != nil {
+		return err
+	}
+	limitGauge, err := meter.Int64ObservableGauge("lynx.concurrency.limit",
+		metric.WithDescription("Current adaptive concurrency limit."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return err
+	}
+	inflightGauge, err := meter.Int64ObservableGauge("lynx.concurrency.inflight",
+		metric.WithDescription("Requests currently in flight."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(limitGauge, int64(l.Limit()), metric.WithAttributeSet(attrs))
+		o.ObserveInt64(inflightGauge, int64(l.InFlight()), metric.WithAttributeSet(attrs))
+		return nil
+	}, limitGauge, inflightGauge)
+	return err
+}
+
+// Token 代表一个已获得的名额，必须 Release；重复 Release 不生效。
+type Token struct {
+	l        *Limiter
+	start    time.Time
+	inflight int
+	critical bool
+	// released 由 l.mu 保护。
+	released bool
+}
+
+// Acquire 按优先级尝试获得名额：critical 总是成功；normal 在途数小于
+// 上限时成功；low 在途数小于 ratio*上限时成功。失败时计入 shed 指标。
+func (l *Limiter) Acquire(ctx context.Context, p Priority) (*Token, bool) {
+	l.mu.Lock()
+	limit := math.Floor(l.limit)
+	allowed := true
+	switch p {
+	case PriorityCritical:
+	case PriorityLow:
+		allowed = float64(l.inflight) < math.Max(1, math.Floor(limit*l.lowRatio))
+	default:
+		allowed = float64(l.inflight) < limit
+	}
+	if !allowed {
+		l.mu.Unlock()
+		l.shed.Add(ctx, 1, l.attr, metric.WithAttributes(attribute.String("priority", p.String())))
+		return nil, false
+	}
+	l.inflight++
+	t := &Token{l: l, start: l.now(), inflight: l.inflight, critical: p == PriorityCritical}
+	l.mu.Unlock()
+	return t, true
+}
+
+// Release 归还名额，并按 outcome 以本次 RTT 更新上限（critical 请求与
+// Ignore 不更新）。只有首次调用生效，重复调用既不重复归还名额，也不
+// 再次更新上限。
+func (t *Token) Release(outcome Outcome) {
+	l := t.l
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if t.released {
+		return
+	}
+	t.released = true
+	l.inflight--
+	if t.critical || outcome == Ignore {
+		return
+	}
+	s := Sample{RTT: l.now().Sub(t.start), InFlight: t.inflight, Dropped: outcome == Dropped}
+	l.limit = l.clamp(l.algorithm.Update(l.limit, s))
+}
+
+func (l *Limiter) clamp(v float64) float64 {
+	if math.IsNaN(v) {
+		return float64(l.minLimit)
+	}
+	return math.Min(float64(l.maxLimit), math.Max(float64(l.minLimit), v))
+}
+
+// Limit 返回当前并发上限（取整）。
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// InFlight 返回当前在途请求数（含 critical 请求）。
+func (l *Limiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inflight
+}
+
+// Name 返回限制器名。
+func (l *Limiter) Name() string {
+	return l.name
+}
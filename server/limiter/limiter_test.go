@@ -0,0 +1,197 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func newTestLimiter(t *testing.T, opts ...Option) *Limiter {
+	t.Helper()
+	l, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return l
+}
+
+func TestAcquirePriorities(t *testing.T) {
+	l := newTestLimiter(t, WithAlgorithm(&AIMD{}), WithInitialLimit(5), WithLowPriorityRatio(0.6))
+	ctx := context.Background()
+	var tokens []*Token
+	for i := 0; i < 3; i++ {
+		tok, ok := l.Acquire(ctx, PriorityLow)
+		if !ok {
+			t.Fatalf("low %d rejected below 0.6*limit", i)
+		}
+		tokens = append(tokens, tok)
+	}
+	if _, ok := l.Acquire(ctx, PriorityLow); ok {
+		t.Error("low admitted at 0.6*limit")
+	}
+	for i := 0; i < 2; i++ {
+		tok, ok := l.Acquire(ctx, PriorityNormal)
+		if !ok {
+			t.Fatalf("normal %d rejected below limit", i)
+		}
+		tokens = append(tokens, tok)
+	}
+	if _, ok := l.Acquire(ctx, PriorityNormal); ok {
+		t.Error("normal admitted at limit")
+	}
+	crit, ok := l.Acquire(ctx, PriorityCritical)
+	if !ok {
+		t.Fatal("critical rejected")
+	}
+	if got := l.InFlight(); got != 6 {
+		t.Errorf("InFlight() = %d, want 6 (critical counted)", got)
+	}
+	crit.Release(Success)
+	for _, tok := range tokens {
+		tok.Release(Ignore)
+	}
+	if l.InFlight() != 0 || l.Limit() != 5 {
+		t.Errorf("after release: inflight %d limit %d, want 0 and unchanged 5", l.InFlight(), l.Limit())
+	}
+}
+
+func TestAIMD(t *testing.T) {
+	a := &AIMD{LatencyThreshold: 100 * time.Millisecond}
+	if got := a.Update(10, Sample{RTT: 10 * time.Millisecond, InFlight: 10}); got != 11 {
+		t.Errorf("success at limit = %v, want 11", got)
+	}
+	if got := a.Update(10, Sample{RTT: 10 * time.Millisecond, InFlight: 2}); got != 10 {
+		t.Errorf("success with idle capacity = %v, want unchanged 10", got)
+	}
+	if got := a.Update(10, Sample{RTT: time.Second, InFlight: 10}); got != 9 {
+		t.Errorf("slow request = %v, want 9", got)
+	}
+	if got := a.Update(10, Sample{Dropped: true}); got != 9 {
+		t.Errorf("dropped = %v, want 9", got)
+	}
+}
+
+func TestGradientShrinksOnLatencySpike(t *testing.T) {
+	g := NewGradient()
+	limit := 20.0
+	for i := 0; i < 100; i++ {
+		limit = g.Update(limit, Sample{RTT: 10 * time.Millisecond, InFlight: int(limit)})
+	}
+	if limit <= 20 {
+		t.Fatalf("limit = %v, want growth under steady latency", limit)
+	}
+	grown := limit
+	for i := 0; i < 20; i++ {
+		limit = g.Update(limit, Sample{RTT: 200 * time.Millisecond, InFlight: int(limit)})
+	}
+	if limit >= grown/2 {
+		t.Errorf("limit = %v after latency spike, want well below %v", limit, grown)
+	}
+}
+
+func TestLimiterAdaptsAndClamps(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newTestLimiter(t, WithAlgorithm(&AIMD{LatencyThreshold: 50 * time.Millisecond}),
+		WithInitialLimit(2), WithMinLimit(1), WithMaxLimit(3))
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		a, _ := l.Acquire(ctx, PriorityNormal)
+		b, _ := l.Acquire(ctx, PriorityNormal)
+		now = now.Add(time.Millisecond)
+		a.Release(Success)
+		b.Release(Success)
+	}
+	if got := l.Limit(); got != 3 {
+		t.Errorf("Limit() = %d, want clamped to max 3", got)
+	}
+	for i := 0; i < 30; i++ {
+		tok, _ := l.Acquire(ctx, PriorityNormal)
+		tok.Release(Dropped)
+	}
+	if got := l.Limit(); got != 1 {
+		t.Errorf("Limit() = %d, want clamped to min 1", got)
+	}
+}
+
+// TestReleaseTwice：重复 Release 不重复归还名额，也不再次调整上限。
+func TestReleaseTwice(t *testing.T) {
+	l := newTestLimiter(t, WithAlgorithm(&AIMD{Backoff: 0.5}), WithInitialLimit(8))
+	ctx := context.Background()
+	other, _ := l.Acquire(ctx, PriorityNormal)
+	tok, _ := l.Acquire(ctx, PriorityNormal)
+	tok.Release(Dropped)
+	tok.Release(Dropped)
+	if got := l.InFlight(); got != 1 {
+		t.Errorf("InFlight() = %d, want 1", got)
+	}
+	if got := l.Limit(); got != 4 {
+		t.Errorf("Limit() = %d, want 4 after a single drop", got)
+	}
+	other.Release(Ignore)
+}
+
+func TestNewInvalidBounds(t *testing.T) {
+	if _, err := New(WithMinLimit(10), WithMaxLimit(5)); err == nil {
+		t.Error("New() with max < min succeeded")
+	}
+	if _, err := New(WithLowPriorityRatio(0)); err == nil {
+		t.Error("New() with ratio 0 succeeded")
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	l := newTestLimiter(t, WithName("api"), WithInitialLimit(1), WithMeterProvider(mp))
+	ctx := context.Background()
+	tok, _ := l.Acquire(ctx, PriorityNormal)
+	_, _ = l.Acquire(ctx, PriorityLow)
+	defer tok.Release(Ignore)
+
+	var md metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &md); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	got := map[string]int64{}
+	for _, sm := range md.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				for _, dp := range data.DataPoints {
+					if v, _ := dp.Attributes.Value("limiter"); v.AsString() == "api" {
+						got[m.Name] = dp.Value
+					}
+				}
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					name, _ := dp.Attributes.Value("limiter")
+					if v, _ := dp.Attributes.Value(attribute.Key("priority")); v.AsString() == "low" && name.AsString() == "api" {
+						got[m.Name] = dp.Value
+					}
+				}
+			}
+		}
+	}
+	want := map[string]int64{"lynx.concurrency.limit": 1, "lynx.concurrency.inflight": 1, "lynx.concurrency.shed": 1}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %d, want %d (all: %v)", k, got[k], v, got)
+		}
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	for in, want := range map[string]Priority{"LOW": PriorityLow, " critical ": PriorityCritical, "normal": PriorityNormal} {
+		if got, ok := ParsePriority(in); !ok || got != want {
+			t.Errorf("ParsePriority(%q) = %v, %v", in, got, ok)
+		}
+	}
+	if _, ok := ParsePriority("urgent"); ok {
+		t.Error("ParsePriority(urgent) ok")
+	}
+}
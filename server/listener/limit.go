@@ -0,0 +1,246 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrConnLimit 表示连接因超过单 IP 连接数上限被拒绝：PROXY protocol
+// 连接的客户端地址在首次读写时才可知，此时读写返回此错误，服务器随即
+// 关闭连接。
+var ErrConnLimit = errors.New("listener: connection limit exceeded")
+
+// LimitOptions 配置连接数限制（见 NewConnLimiter），可作为配置段解码。
+type LimitOptions struct {
+	// MaxConns 是同时打开的连接总数上限，0 表示不限制。
+	MaxConns int `mapstructure:"max_conns"`
+	// MaxConnsPerIP 是单个客户端 IP 同时打开的连接数上限，0 表示不限制。
+	// 与 ProxyProtocol 同时使用时按 PROXY 头中的客户端地址计数；Unix
+	// domain socket 连接不计入。
+	MaxConnsPerIP int `mapstructure:"max_conns_per_ip"`
+	// Name 标识所属的服务器，用于指标属性 server。
+	Name string `mapstructure:"-"`
+	// MeterProvider 缺省取 otel.GetMeterProvider()。
+	MeterProvider metric.MeterProvider `mapstructure:"-"`
+}
+
+// ConnLimiter 限制监听器上同时打开的连接数，超出上限的连接在 Accept 后
+// 立即关闭（不阻塞 Accept，也不向对端写出数据）。
+//
+// 指标（meter "github.com/lynx-go/lynx/server/listener"，属性 server）：
+//   - lynx.server.connections.active：当前打开的连接数（gauge）。
+//   - lynx.server.connections.rejected：被拒绝的连接数，属性 reason 为
+//     max_conns 或 max_conns_per_ip。
+type ConnLimiter struct {
+	o LimitOptions
+
+	mu     sync.Mutex
+	active int
+	perIP  map[netip.Addr]int
+
+	rejected   metric.Int64Counter
+	maxConns   metric.MeasurementOption
+	maxPerIP   metric.MeasurementOption
+	activeAttr metric.ObserveOption
+}
+
+// NewConnLimiter 校验配置并创建 ConnLimiter，上限为负数或指标注册失败时
+// 返回错误。
+func NewConnLimiter(o LimitOptions) (*ConnLimiter, error) {
+	if o.MaxConns < 0 || o.MaxConnsPerIP < 0 {
+		return nil, fmt.Errorf("listener: connection limits must be >= 0, got max_conns=%d max_conns_per_ip=%d", o.MaxConns, o.MaxConnsPerIP)
+	}
+	if o.MeterProvider == nil {
+		o.MeterProvider = otel.GetMeterProvider()
+	}
+	l := &ConnLimiter{o: o, perIP: map[netip.Addr]int{}}
+	if err := l.initMetrics(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *ConnLimiter) initMetrics() error {
+	meter := l.o.MeterProvider.Meter("github.com/lynx-go/lynx/server/listener")
+	server := attribute.String("server", l.o.Name)
+	l.maxConns = metric.WithAttributeSet(attribute.NewSet(server, attribute.String("reason", "max_conns")))
+	l.maxPerIP = metric.WithAttributeSet(attribute.NewSet(server, attribute.String("reason", "max_conns_per_ip")))
+	l.activeAttr = metric.WithAttributeSet(attribute.NewSet(server))
+	var err error
+	if l.rejected, err = meter.Int64Counter("lynx.server.connections.rejected",
+		metric.WithDescription("Connections closed because a connection limit was reached, by reason."),
+		metric.WithUnit("{connection}"),
+	); err != nil {
+		return err
+	}
+	active, err := meter.Int64ObservableGauge("lynx.server.connections.active",
+		metric.WithDescription("Connections currently open."),
+		metric.WithUnit("{connection}"),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(active, int64(l.Active()), l.activeAttr)
+		return nil
+	}, active)
+	return err
+}
+
+// Active 返回当前打开的连接数。
+func (l *ConnLimiter) Active() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active
+}
+
+// Listener 返回限制连接数的 ln。与 ProxyProtocol 同时使用时应包装在
+// ProxyProtocol.Listener 之外，单 IP 上限才能按客户端地址计数。
+func (l *ConnLimiter) Listener(ln net.Listener) net.Listener {
+	return &limitListener{Listener: ln, l: l}
+}
+
+type limitListener struct {
+	net.Listener
+	l *ConnLimiter
+}
+
+func (ln *limitListener) Accept() (net.Conn, error) {
+	for {
+		c, err := ln.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if !ln.l.acquire() {
+			_ = c.Close()
+			continue
+		}
+		lc := &limitConn{Conn: c, l: ln.l}
+		if _, lazy := c.(*proxyConn); lazy {
+			// 读取 PROXY 头之前客户端地址未知：在连接 goroutine 中首次读写时计数。
+			return lc, nil
+		}
+		if lc.acquireIP(); lc.err != nil {
+			_ = lc.Close()
+			continue
+		}
+		return lc, nil
+	}
+}
+
+// acquire 占用一个连接名额，超出 MaxConns 时返回 false。
+func (l *ConnLimiter) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.o.MaxConns > 0 && l.active >= l.o.MaxConns {
+		l.rejected.Add(context.Background(), 1, l.maxConns)
+		return false
+	}
+	l.active++
+	return true
+}
+
+// acquireIP 为 ip 占用一个名额，超出 MaxConnsPerIP 时返回 false。
+func (l *ConnLimiter) acquireIP(ip netip.Addr) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.perIP[ip] >= l.o.MaxConnsPerIP {
+		l.rejected.Add(context.Background(), 1, l.maxPerIP)
+		return false
+	}
+	l.perIP[ip]++
+	return true
+}
+
+func (l *ConnLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+}
+
+func (l *ConnLimiter) releaseIP(ip netip.Addr) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.perIP[ip]--; l.perIP[ip] <= 0 {
+		delete(l.perIP, ip)
+	}
+}
+
+// limitConn 在关闭时归还名额。
+type limitConn struct {
+	net.Conn
+	l    *ConnLimiter
+	once sync.Once
+	err  error
+
+	mu     sync.Mutex
+	closed bool
+	ip     netip.Addr
+	hasIP  bool
+}
+
+// acquireIP 按对端 IP 计数（只执行一次）；超出上限时记录 ErrConnLimit。
+func (c *limitConn) acquireIP() {
+	c.once.Do(func() {
+		if c.l.o.MaxConnsPerIP == 0 {
+			return
+		}
+		tcp, ok := c.Conn.RemoteAddr().(*net.TCPAddr)
+		if !ok {
+			return
+		}
+		ip, ok := netip.AddrFromSlice(tcp.IP)
+		if !ok {
+			return
+		}
+		ip = ip.Unmap()
+		if !c.l.acquireIP(ip) {
+			c.err = ErrConnLimit
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.closed {
+			// 计数期间连接已关闭：Close 未能看到该名额，在此归还。
+			c.l.releaseIP(ip)
+			return
+		}
+		c.ip, c.hasIP = ip, true
+	})
+}
+
+func (c *limitConn) Read(b []byte) (int, error) {
+	if c.acquireIP(); c.err != nil {
+		return 0, c.err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *limitConn) Write(b []byte) (int, error) {
+	if c.acquireIP(); c.err != nil {
+		return 0, c.err
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		c.l.release()
+		if c.hasIP {
+			c.l.releaseIP(c.ip)
+		}
+	}
+	return err
+}
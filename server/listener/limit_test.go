@@ -0,0 +1,215 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// acceptLoop accepts connections from ln until it is closed.
+func acceptLoop(t *testing.T, ln net.Listener) <-chan net.Conn {
+	t.Helper()
+	conns := make(chan net.Conn, 8)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				close(conns)
+				return
+			}
+			conns <- c
+		}
+	}()
+	t.Cleanup(func() {
+		_ = ln.Close()
+		for c := range conns {
+			_ = c.Close()
+		}
+	})
+	return conns
+}
+
+func accepted(t *testing.T, conns <-chan net.Conn) net.Conn {
+	t.Helper()
+	select {
+	case c := <-conns:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not accepted")
+		return nil
+	}
+}
+
+// expectClosed asserts that the server closed c without writing data.
+func expectClosed(t *testing.T, c net.Conn) {
+	t.Helper()
+	_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if n, err := c.Read(make([]byte, 1)); n != 0 || err == nil {
+		t.Fatalf("Read() = %d, %v, want closed connection", n, err)
+	}
+}
+
+func rejectedCounts(t *testing.T, reader *sdkmetric.ManualReader) map[string]int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					reason, _ := dp.Attributes.Value("reason")
+					counts[m.Name+"/"+reason.AsString()] += dp.Value
+				}
+			case metricdata.Gauge[int64]:
+				for _, dp := range data.DataPoints {
+					counts[m.Name] = dp.Value
+				}
+			}
+		}
+	}
+	return counts
+}
+
+func TestConnLimiterMaxConns(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	l, err := NewConnLimiter(LimitOptions{MaxConns: 1, Name: "http", MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := acceptLoop(t, l.Listener(raw))
+
+	c1, err := net.Dial("tcp", raw.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	s1 := accepted(t, conns)
+
+	c2, err := net.Dial("tcp", raw.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	expectClosed(t, c2)
+	if got := l.Active(); got != 1 {
+		t.Errorf("Active() = %d, want 1", got)
+	}
+
+	_ = s1.Close()
+	_ = s1.Close() // 重复关闭不重复归还
+	if got := l.Active(); got != 0 {
+		t.Errorf("Active() after Close = %d, want 0", got)
+	}
+	c3, err := net.Dial("tcp", raw.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c3.Close()
+	accepted(t, conns)
+
+	counts := rejectedCounts(t, reader)
+	if got := counts["lynx.server.connections.rejected/max_conns"]; got != 1 {
+		t.Errorf("rejected{max_conns} = %d, want 1", got)
+	}
+	if got := counts["lynx.server.connections.active"]; got != 1 {
+		t.Errorf("active = %d, want 1", got)
+	}
+}
+
+func TestConnLimiterMaxConnsPerIP(t *testing.T) {
+	l, err := NewConnLimiter(LimitOptions{MaxConnsPerIP: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := acceptLoop(t, l.Listener(raw))
+
+	c1, err := net.Dial("tcp", raw.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	accepted(t, conns)
+
+	c2, err := net.Dial("tcp", raw.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	expectClosed(t, c2)
+	if got := l.Active(); got != 1 {
+		t.Errorf("Active() = %d, want 1", got)
+	}
+}
+
+// TestConnLimiterProxyProtocol 验证与 PROXY protocol 同时使用时按 PROXY
+// 头中的客户端地址计数。
+func TestConnLimiterProxyProtocol(t *testing.T) {
+	p, err := NewProxyProtocol(ProxyProtocolOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewConnLimiter(LimitOptions{MaxConnsPerIP: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := acceptLoop(t, l.Listener(p.Listener(raw)))
+
+	dial := func(src string) net.Conn {
+		c, err := net.Dial("tcp", raw.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		if _, err := io.WriteString(c, "PROXY TCP4 "+src+" 10.0.0.1 4000 443\r\nping"); err != nil {
+			t.Fatal(err)
+		}
+		return accepted(t, conns)
+	}
+	read := func(c net.Conn) error {
+		_ = c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, err := io.ReadFull(c, make([]byte, 4))
+		return err
+	}
+
+	if err := read(dial("203.0.113.7")); err != nil {
+		t.Fatalf("first connection Read() error = %v", err)
+	}
+	second := dial("203.0.113.7")
+	if err := read(second); !errors.Is(err, ErrConnLimit) {
+		t.Fatalf("second connection Read() error = %v, want ErrConnLimit", err)
+	}
+	if _, err := second.Write([]byte("x")); !errors.Is(err, ErrConnLimit) {
+		t.Errorf("second connection Write() error = %v, want ErrConnLimit", err)
+	}
+	if err := read(dial("198.51.100.9")); err != nil {
+		t.Fatalf("other client Read() error = %v", err)
+	}
+}
+
+func TestNewConnLimiterInvalid(t *testing.T) {
+	if _, err := NewConnLimiter(LimitOptions{MaxConns: -1}); err == nil {
+		t.Error("NewConnLimiter(MaxConns: -1) error = nil")
+	}
+}
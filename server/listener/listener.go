@@ -0,0 +1,131 @@
+// Package listener 按地址创建服务器监听器：TCP 地址（"host:port"）与
+// Unix domain socket（"unix:" 前缀），处理 socket 文件权限与残留文件
+// 清理；可选的 PROXY protocol（v1/v2）解析使连接的对端地址为负载
+// 均衡器之前的客户端地址（见 ProxyProtocol）；ConnLimiter 限制连接总数
+// 与单 IP 连接数。server/http 与 server/grpc 的 Start 基于本包监听。
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// UnixPrefix 是 Unix domain socket 地址的前缀，如 "unix:/run/orders.sock"；
+// 路径以 "@" 开头时为 Linux 抽象命名空间 socket（无文件）。
+const UnixPrefix = "unix:"
+
+// DefaultUnixSocketMode 是 socket 文件的缺省权限：属主与同组用户可连接，
+// 便于 sidecar 以同组身份访问。
+const DefaultUnixSocketMode os.FileMode = 0o660
+
+// staleDialTimeout 是探测残留 socket 文件是否仍有进程监听的超时。
+const staleDialTimeout = time.Second
+
+// ErrSocketInUse 表示 socket 文件仍有进程在监听（如同一服务的另一个
+// 实例），Listen 不会删除它。
+var ErrSocketInUse = errors.New("listener: unix socket is in use")
+
+// Split 把 addr 解析为 network 与 address：带 UnixPrefix 的为 "unix"，
+// 其余为 "tcp"。"unix:///run/x.sock" 形式同样接受。
+func Split(addr string) (network, address string) {
+	if path, ok := strings.CutPrefix(addr, UnixPrefix); ok {
+		if p, ok := strings.CutPrefix(path, "//"); ok {
+			path = p
+		}
+		return "unix", path
+	}
+	return "tcp", addr
+}
+
+// Listen 在 addr 上监听。Unix domain socket 的处理：
+//
+//   - 路径上残留的 socket 文件（进程崩溃未清理）在无进程监听时删除；仍有
+//     进程监听时返回 ErrSocketInUse，路径是普通文件时返回错误，都不删除。
+//   - 监听后把文件权限设为 mode（0 时为 DefaultUnixSocketMode）。
+//   - 监听器 Close 时删除 socket 文件。
+func Listen(addr string, mode os.FileMode) (net.Listener, error) {
+	network, address := Split(addr)
+	if network == "tcp" {
+		return net.Listen(network, address)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("listener: empty unix socket path in %q", addr)
+	}
+	if strings.HasPrefix(address, "@") {
+		return net.Listen(network, address)
+	}
+	if err := removeStale(address); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	if mode == 0 {
+		mode = DefaultUnixSocketMode
+	}
+	if err := os.Chmod(address, mode); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("listener: chmod %s: %w", address, err)
+	}
+	return ln, nil
+}
+
+// ListenAll 依次在 addrs 上监听；任一失败时关闭已创建的监听器并返回
+// 错误。
+func ListenAll(addrs []string, mode os.FileMode) ([]net.Listener, error) {
+	lns := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		ln, err := Listen(addr, mode)
+		if err != nil {
+			CloseAll(lns)
+			return nil, fmt.Errorf("listen on %s: %w", addr, err)
+		}
+		lns = append(lns, ln)
+	}
+	return lns, nil
+}
+
+// CloseAll 关闭 lns，忽略错误（已由 Serve 关闭的监听器再次关闭会报错）。
+func CloseAll(lns []net.Listener) {
+	for _, ln := range lns {
+		_ = ln.Close()
+	}
+}
+
+// Addrs 返回 lns 实际绑定的地址（端口 0 时为系统分配的端口）。
+func Addrs(lns []net.Listener) []net.Addr {
+	addrs := make([]net.Addr, len(lns))
+	for i, ln := range lns {
+		addrs[i] = ln.Addr()
+	}
+	return addrs
+}
+
+// removeStale 删除 path 上无进程监听的残留 socket 文件。
+func removeStale(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("listener: %w", err)
+	}
+	if fi.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("listener: %s exists and is not a socket", path)
+	}
+	conn, err := net.DialTimeout("unix", path, staleDialTimeout)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%w: %s", ErrSocketInUse, path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("listener: remove stale socket: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package listener
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct{ addr, network, address string }{
+		{":8080", "tcp", ":8080"},
+		{"127.0.0.1:0", "tcp", "127.0.0.1:0"},
+		{"unix:/run/x.sock", "unix", "/run/x.sock"},
+		{"unix:///run/x.sock", "unix", "/run/x.sock"},
+		{"unix:rel.sock", "unix", "rel.sock"},
+	}
+	for _, tt := range tests {
+		network, address := Split(tt.addr)
+		if network != tt.network || address != tt.address {
+			t.Errorf("Split(%q) = %q, %q, want %q, %q", tt.addr, network, address, tt.network, tt.address)
+		}
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.sock")
+	ln, err := Listen(UnixPrefix+path, 0o600)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want 0600", fi.Mode().Perm())
+	}
+
+	// 仍在监听的 socket 不被删除。
+	if _, err := Listen(UnixPrefix+path, 0); !errors.Is(err, ErrSocketInUse) {
+		t.Errorf("second Listen() error = %v, want ErrSocketInUse", err)
+	}
+
+	_ = ln.Close()
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("socket file not removed on Close: %v", err)
+	}
+}
+
+func TestListenRemovesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.sock")
+	// 模拟崩溃残留：监听后不删除文件即关闭。
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	ln, err := Listen(UnixPrefix+path, 0)
+	if err != nil {
+		t.Fatalf("Listen() error = %v, want stale socket replaced", err)
+	}
+	defer ln.Close()
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != DefaultUnixSocketMode {
+		t.Errorf("mode = %v, want %v", fi.Mode().Perm(), DefaultUnixSocketMode)
+	}
+}
+
+func TestListenRefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(UnixPrefix+path, 0); err == nil {
+		t.Fatal("Listen() on a regular file succeeded")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("regular file removed: %v", err)
+	}
+}
+
+func TestListenAllClosesOnError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.sock")
+	_, err := ListenAll([]string{UnixPrefix + path, UnixPrefix + filepath.Join(dir, "missing", "b.sock")}, 0)
+	if err == nil {
+		t.Fatal("ListenAll() error = nil")
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("first listener not closed: %v", err)
+	}
+
+	lns, err := ListenAll([]string{"127.0.0.1:0", UnixPrefix + path}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseAll(lns)
+	addrs := Addrs(lns)
+	if a := addrs[0].(*net.TCPAddr); a.Port == 0 {
+		t.Error("TCP addr has port 0, want bound port")
+	}
+	if addrs[1].Network() != "unix" || addrs[1].String() != path {
+		t.Errorf("unix addr = %v", addrs[1])
+	}
+}
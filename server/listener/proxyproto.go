@@ -0,0 +1,282 @@
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultProxyHeaderTimeout 是读取 PROXY protocol 头的缺省超时。
+const DefaultProxyHeaderTimeout = 5 * time.Second
+
+// ErrProxyHeader 表示连接的 PROXY protocol 头缺失或非法，连接的读取
+// 返回此错误，服务器随即关闭连接。
+var ErrProxyHeader = errors.New("listener: invalid PROXY protocol header")
+
+// proxyV2Signature 是 PROXY protocol v2 头的 12 字节签名。
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// proxyV1MaxLen 是 v1 头的最大长度（含 CRLF）。
+const proxyV1MaxLen = 107
+
+// ProxyProtocolOptions 配置 PROXY protocol（v1 文本与 v2 二进制）解析，
+// 可作为配置段解码。
+type ProxyProtocolOptions struct {
+	// TrustedSources 是允许发送 PROXY 头的来源（负载均衡器）IP 或 CIDR；
+	// 其他来源的连接不解析 PROXY 头，保留对端地址。为空时信任全部来源，
+	// 仅适用于只有负载均衡器可达的端口。Unix domain socket 连接视为受信任。
+	TrustedSources []string `mapstructure:"trusted_sources"`
+	// Optional 为 true 时受信任来源也可以不发送 PROXY 头；缺省要求发送，
+	// 缺失时关闭连接。
+	Optional bool `mapstructure:"optional"`
+	// HeaderTimeout 是读取 PROXY 头的超时，缺省 DefaultProxyHeaderTimeout。
+	HeaderTimeout time.Duration `mapstructure:"header_timeout"`
+}
+
+// ProxyProtocol 包装监听器，使其连接的 RemoteAddr 为 PROXY 头中的客户端
+// 地址。
+type ProxyProtocol struct {
+	trusted  []netip.Prefix
+	optional bool
+	timeout  time.Duration
+}
+
+// NewProxyProtocol 校验配置并创建 ProxyProtocol，TrustedSources 非法时
+// 返回错误。
+func NewProxyProtocol(o ProxyProtocolOptions) (*ProxyProtocol, error) {
+	p := &ProxyProtocol{optional: o.Optional, timeout: o.HeaderTimeout}
+	if p.timeout <= 0 {
+		p.timeout = DefaultProxyHeaderTimeout
+	}
+	for _, s := range o.TrustedSources {
+		var (
+			prefix netip.Prefix
+			err    error
+		)
+		if strings.Contains(s, "/") {
+			prefix, err = netip.ParsePrefix(s)
+		} else {
+			var a netip.Addr
+			if a, err = netip.ParseAddr(s); err == nil {
+				prefix = netip.PrefixFrom(a.Unmap(), a.Unmap().BitLen())
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("listener: invalid PROXY protocol trusted source %q: %w", s, err)
+		}
+		p.trusted = append(p.trusted, prefix.Masked())
+	}
+	return p, nil
+}
+
+// Listener 返回解析 PROXY 头的 ln。头部在连接的首次 Read 或 RemoteAddr
+// 时读取（位于服务器的连接 goroutine 中，慢连接不阻塞 Accept），读取期间
+// 设置 HeaderTimeout 读截止时间，完成后清除。
+func (p *ProxyProtocol) Listener(ln net.Listener) net.Listener {
+	return &proxyListener{Listener: ln, p: p}
+}
+
+type proxyListener struct {
+	net.Listener
+	p *ProxyProtocol
+}
+
+func (l *proxyListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !l.p.trustedSource(c.RemoteAddr()) {
+		return c, nil
+	}
+	return &proxyConn{Conn: c, p: l.p}, nil
+}
+
+func (p *ProxyProtocol) trustedSource(addr net.Addr) bool {
+	if len(p.trusted) == 0 {
+		return true
+	}
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return true
+	}
+	ip, ok := netip.AddrFromSlice(tcp.IP)
+	if !ok {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range p.trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// proxyConn 在首次使用时读取 PROXY 头。
+type proxyConn struct {
+	net.Conn
+	p    *ProxyProtocol
+	once sync.Once
+	r    *bufio.Reader
+	src  net.Addr
+	dst  net.Addr
+	err  error
+}
+
+func (c *proxyConn) init() {
+	c.once.Do(func() {
+		_ = c.Conn.SetReadDeadline(time.Now().Add(c.p.timeout))
+		c.r = bufio.NewReader(c.Conn)
+		c.src, c.dst, c.err = readProxyHeader(c.r, c.p.optional)
+		_ = c.Conn.SetReadDeadline(time.Time{})
+	})
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	c.init()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.r.Read(b)
+}
+
+// Write 在 PROXY 头非法时返回错误，不向未通过校验的连接写出任何数据
+// （如 net/http 对读取错误回写的 400 响应）。
+func (c *proxyConn) Write(b []byte) (int, error) {
+	c.init()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.Conn.Write(b)
+}
+
+// RemoteAddr 返回 PROXY 头中的源地址；头部缺失（Optional）、LOCAL 命令
+// 或 UNKNOWN 协议时返回直连对端地址。
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.init()
+	if c.src != nil {
+		return c.src
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// LocalAddr 返回 PROXY 头中的目的地址，规则同 RemoteAddr。
+func (c *proxyConn) LocalAddr() net.Addr {
+	c.init()
+	if c.dst != nil {
+		return c.dst
+	}
+	return c.Conn.LocalAddr()
+}
+
+// readProxyHeader 读取并解析 PROXY 头，返回源与目的地址（不携带地址时
+// 为 nil）。
+func readProxyHeader(r *bufio.Reader, optional bool) (src, dst net.Addr, err error) {
+	sig, peekErr := r.Peek(len(proxyV2Signature))
+	switch {
+	case bytes.Equal(sig, proxyV2Signature):
+		return readProxyV2(r)
+	case bytes.HasPrefix(sig, []byte("PROXY ")):
+		return readProxyV1(r)
+	case optional:
+		return nil, nil, nil
+	case peekErr != nil && !errors.Is(peekErr, io.EOF):
+		return nil, nil, fmt.Errorf("%w: %w", ErrProxyHeader, peekErr)
+	}
+	return nil, nil, fmt.Errorf("%w: missing", ErrProxyHeader)
+}
+
+// readProxyV1 解析 "PROXY TCP4 <src> <dst> <sport> <dport>\r\n"。
+func readProxyV1(r *bufio.Reader) (src, dst net.Addr, err error) {
+	var line []byte
+	for len(line) < proxyV1MaxLen {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrProxyHeader, err)
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	s, ok := strings.CutSuffix(string(line), "\r\n")
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: v1 header too long or not CRLF-terminated", ErrProxyHeader)
+	}
+	fields := strings.Split(s, " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, nil, fmt.Errorf("%w: malformed v1 header %q", ErrProxyHeader, s)
+	}
+	if src, err = parseV1Addr(fields[2], fields[4], fields[1] == "TCP4"); err != nil {
+		return nil, nil, err
+	}
+	if dst, err = parseV1Addr(fields[3], fields[5], fields[1] == "TCP4"); err != nil {
+		return nil, nil, err
+	}
+	return src, dst, nil
+}
+
+func parseV1Addr(ip, port string, v4 bool) (net.Addr, error) {
+	a, err := netip.ParseAddr(ip)
+	if err != nil || a.Is4() != v4 {
+		return nil, fmt.Errorf("%w: invalid v1 address %q", ErrProxyHeader, ip)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid v1 port %q", ErrProxyHeader, port)
+	}
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(a, uint16(p))), nil
+}
+
+// readProxyV2 解析 v2 二进制头，跳过 TLV 扩展。
+func readProxyV2(r *bufio.Reader) (src, dst net.Addr, err error) {
+	var hdr [16]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrProxyHeader, err)
+	}
+	if hdr[12]>>4 != 2 {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", ErrProxyHeader, hdr[12]>>4)
+	}
+	body := make([]byte, binary.BigEndian.Uint16(hdr[14:16]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrProxyHeader, err)
+	}
+	switch cmd := hdr[12] & 0x0f; cmd {
+	case 0x0: // LOCAL：负载均衡器自身的连接（如健康检查），保留对端地址。
+		return nil, nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, nil, fmt.Errorf("%w: unsupported command %d", ErrProxyHeader, cmd)
+	}
+	var n int
+	switch hdr[13] >> 4 {
+	case 0x1: // AF_INET
+		n = 4
+	case 0x2: // AF_INET6
+		n = 16
+	default: // AF_UNSPEC、AF_UNIX：不携带可用的 IP 地址。
+		return nil, nil, nil
+	}
+	if len(body) < 2*n+4 {
+		return nil, nil, fmt.Errorf("%w: v2 address block too short", ErrProxyHeader)
+	}
+	srcIP, _ := netip.AddrFromSlice(body[:n])
+	dstIP, _ := netip.AddrFromSlice(body[n : 2*n])
+	sport := binary.BigEndian.Uint16(body[2*n:])
+	dport := binary.BigEndian.Uint16(body[2*n+2:])
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(srcIP, sport)),
+		net.TCPAddrFromAddrPort(netip.AddrPortFrom(dstIP, dport)), nil
+}
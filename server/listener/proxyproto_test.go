@@ -0,0 +1,126 @@
+package listener
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// proxyV2 builds a v2 header for a TCP connection.
+func proxyV2(cmd byte, src, dst net.IP, sport, dport uint16) []byte {
+	var b bytes.Buffer
+	b.Write(proxyV2Signature)
+	b.WriteByte(0x20 | cmd)
+	addrs := new(bytes.Buffer)
+	if v4 := src.To4(); v4 != nil {
+		b.WriteByte(0x11)
+		addrs.Write(v4)
+		addrs.Write(dst.To4())
+	} else {
+		b.WriteByte(0x21)
+		addrs.Write(src.To16())
+		addrs.Write(dst.To16())
+	}
+	_ = binary.Write(addrs, binary.BigEndian, sport)
+	_ = binary.Write(addrs, binary.BigEndian, dport)
+	addrs.Write([]byte{0x04, 0x00, 0x01, 0xff}) // TLV: PP2_TYPE_NOOP
+	_ = binary.Write(&b, binary.BigEndian, uint16(addrs.Len()))
+	b.Write(addrs.Bytes())
+	return b.Bytes()
+}
+
+// proxyRoundTrip sends header+"ping" through a ProxyProtocol listener and
+// returns the remote address and payload seen by the server.
+func proxyRoundTrip(t *testing.T, o ProxyProtocolOptions, header []byte) (string, string, error) {
+	t.Helper()
+	p, err := NewProxyProtocol(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := p.Listener(raw)
+	defer ln.Close()
+
+	go func() {
+		c, err := net.Dial("tcp", raw.Addr().String())
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		_, _ = c.Write(append(header, "ping"...))
+		_, _ = io.Copy(io.Discard, c)
+	}()
+	c, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	_ = c.SetDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(c, buf)
+	return c.RemoteAddr().String(), string(buf), err
+}
+
+func TestProxyProtocol(t *testing.T) {
+	tests := []struct {
+		name, header, remote string
+	}{
+		{"v1 tcp4", "PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\r\n", "203.0.113.7:51234"},
+		{"v1 tcp6", "PROXY TCP6 2001:db8::7 2001:db8::1 51234 443\r\n", "[2001:db8::7]:51234"},
+		{"v2 tcp4", string(proxyV2(1, net.ParseIP("198.51.100.9"), net.ParseIP("10.0.0.1"), 4000, 443)), "198.51.100.9:4000"},
+		{"v2 tcp6", string(proxyV2(1, net.ParseIP("2001:db8::9"), net.ParseIP("2001:db8::1"), 4000, 443)), "[2001:db8::9]:4000"},
+	}
+	for _, tt := range tests {
+		remote, payload, err := proxyRoundTrip(t, ProxyProtocolOptions{}, []byte(tt.header))
+		if err != nil || remote != tt.remote || payload != "ping" {
+			t.Errorf("%s: remote = %q payload = %q err = %v, want %q", tt.name, remote, payload, err, tt.remote)
+		}
+	}
+
+	// LOCAL 与 UNKNOWN 保留直连对端地址。
+	for _, header := range []string{
+		"PROXY UNKNOWN\r\n",
+		string(proxyV2(0, net.ParseIP("198.51.100.9"), net.ParseIP("10.0.0.1"), 4000, 443)),
+	} {
+		remote, payload, err := proxyRoundTrip(t, ProxyProtocolOptions{}, []byte(header))
+		if host, _, _ := net.SplitHostPort(remote); err != nil || host != "127.0.0.1" || payload != "ping" {
+			t.Errorf("header %q: remote = %q payload = %q err = %v", header, remote, payload, err)
+		}
+	}
+}
+
+func TestProxyProtocolRequiredAndOptional(t *testing.T) {
+	for _, header := range []string{"", "PROXY TCP4 bogus\r\n", "PROXY TCP4 203.0.113.7 10.0.0.1 99999 443\r\n"} {
+		if _, _, err := proxyRoundTrip(t, ProxyProtocolOptions{HeaderTimeout: 100 * time.Millisecond}, []byte(header)); !errors.Is(err, ErrProxyHeader) {
+			t.Errorf("header %q: err = %v, want ErrProxyHeader", header, err)
+		}
+	}
+	remote, payload, err := proxyRoundTrip(t, ProxyProtocolOptions{Optional: true}, []byte("without header\r\n"))
+	if host, _, _ := net.SplitHostPort(remote); err != nil || host != "127.0.0.1" || payload != "with" {
+		t.Errorf("optional: remote = %q payload = %q err = %v", remote, payload, err)
+	}
+}
+
+func TestProxyProtocolUntrustedSource(t *testing.T) {
+	// 来源不受信任：不解析 PROXY 头，头部原样交给应用。
+	remote, payload, err := proxyRoundTrip(t, ProxyProtocolOptions{TrustedSources: []string{"10.0.0.0/8"}},
+		[]byte("PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\r\n"))
+	if host, _, _ := net.SplitHostPort(remote); err != nil || host != "127.0.0.1" || payload != "PROX" {
+		t.Errorf("remote = %q payload = %q err = %v", remote, payload, err)
+	}
+	remote, _, err = proxyRoundTrip(t, ProxyProtocolOptions{TrustedSources: []string{"127.0.0.1"}},
+		[]byte("PROXY TCP4 203.0.113.7 10.0.0.1 51234 443\r\n"))
+	if err != nil || remote != "203.0.113.7:51234" {
+		t.Errorf("trusted source: remote = %q err = %v", remote, err)
+	}
+	if _, err := NewProxyProtocol(ProxyProtocolOptions{TrustedSources: []string{"not-an-ip"}}); err == nil {
+		t.Error("NewProxyProtocol accepted an invalid trusted source")
+	}
+}
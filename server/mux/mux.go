@@ -0,0 +1,132 @@
+// Package mux 在同一端口上同时提供 gRPC 与 HTTP 服务：HTTP 服务器接受
+// 全部连接，HTTP/2 且 Content-Type 为 application/grpc 的请求交给 gRPC
+// 服务器（grpc.Server.ServeHTTP），其余请求走 HTTP 处理链。明文连接经
+// h2c 支持 HTTP/2，TLS 连接经 ALPN 协商。适用于每个 Pod 只允许一个入口
+// 端口的环境；有独立端口时分别注册 server/http 与 server/grpc 即可，
+// gRPC 走原生传输性能更好。
+package mux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/lynx-go/lynx"
+	lynxgrpc "github.com/lynx-go/lynx/server/grpc"
+	lynxhttp "github.com/lynx-go/lynx/server/http"
+)
+
+// Options 是单端口复用服务的配置项。
+type Options struct {
+	// HTTP 是 HTTP 服务器的选项：监听地址、TLS、超时、中间件等均在此
+	// 配置，作用于整个端口。
+	HTTP []lynxhttp.Option
+	// GRPC 是 gRPC 服务器的选项（拦截器、ServerOptions 等）。其监听地址
+	// 被忽略：gRPC 经 HTTP 服务器的端口提供；TLS（包括 WithTLSFiles）
+	// 同样由 HTTP 侧配置。
+	GRPC []lynxgrpc.Option
+}
+
+// Option 用于配置 Options 的选项函数。
+type Option func(*Options)
+
+// WithHTTPOptions 追加 HTTP 服务器的选项。
+func WithHTTPOptions(opts ...lynxhttp.Option) Option {
+	return func(o *Options) {
+		o.HTTP = append(o.HTTP, opts...)
+	}
+}
+
+// WithGRPCOptions 追加 gRPC 服务器的选项。
+func WithGRPCOptions(opts ...lynxgrpc.Option) Option {
+	return func(o *Options) {
+		o.GRPC = append(o.GRPC, opts...)
+	}
+}
+
+// Server 是单端口复用 gRPC 与 HTTP 的服务，实现 lynx.Service。
+type Server struct {
+	http *lynxhttp.Server
+	grpc *lynxgrpc.Server
+}
+
+// NewServer 创建单端口复用服务，handler 处理非 gRPC 请求。gRPC 业务服务
+// 注册到 GRPC().GetServer()：
+//
+//	srv := mux.NewServer(router,
+//		mux.WithHTTPOptions(http.WithAddr(":8080"), http.WithHealthCheckers(app.HealthCheckers)),
+//		mux.WithGRPCOptions(grpc.WithInterceptors(interceptor.JWT(verifier))),
+//	)
+//	pb.RegisterOrdersServer(srv.GRPC().GetServer(), orders)
+//	app.Register(srv)
+func NewServer(handler http.Handler, opts ...Option) *Server {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// gRPC 服务器不自行监听缺省地址。
+	grpcSrv := lynxgrpc.NewServer(append(o.GRPC, lynxgrpc.WithAddr(""))...)
+	httpSrv := lynxhttp.NewServer(handler, append(o.HTTP,
+		lynxhttp.WithH2C(true),
+		lynxhttp.WithGRPCHandler(grpcSrv.GetServer()),
+	)...)
+	return &Server{http: httpSrv, grpc: grpcSrv}
+}
+
+// HTTP 返回内部的 HTTP 服务器（Addr、OpenAPI 等）。
+func (s *Server) HTTP() *lynxhttp.Server { return s.http }
+
+// GRPC 返回内部的 gRPC 服务器，用于注册业务服务。
+func (s *Server) GRPC() *lynxgrpc.Server { return s.grpc }
+
+// Name 返回服务名称 "mux"。
+func (s *Server) Name() string { return "mux" }
+
+// Init 依次初始化两个服务器（运行环境缺省值、维护状态等）。
+func (s *Server) Init(ctx lynx.AppContext) error {
+	if err := s.http.Init(ctx); err != nil {
+		return err
+	}
+	return s.grpc.Init(ctx)
+}
+
+// Start 启动两个服务器并阻塞至退出。HTTP 服务器监听失败或异常退出时
+// 同时停止 gRPC 服务器。
+func (s *Server) Start(ctx context.Context) error {
+	grpcDone := make(chan error, 1)
+	go func() { grpcDone <- s.grpc.Start(ctx) }()
+	err := s.http.Start(ctx)
+	if !errors.Is(err, http.ErrServerClosed) {
+		_ = s.grpc.Stop(ctx)
+	}
+	return errors.Join(err, <-grpcDone)
+}
+
+// Drain 实现 lynx.Drainer：gRPC health 置为 NOT_SERVING，HTTP 长连接
+// 收到 GoAway，两者同时开始排水。
+func (s *Server) Drain(ctx context.Context) {
+	s.grpc.Drain(ctx)
+	s.http.Drain(ctx)
+}
+
+// Stop 先关停 HTTP 服务器：停止接受连接、向 HTTP/2 客户端发送 GOAWAY，
+// 并在 HTTP 的关停预算内等待在途请求（包括 gRPC 调用）完成；再停止 gRPC
+// 服务器。顺序不可颠倒：grpc.Server.GracefulStop 会立即关闭经 ServeHTTP
+// 处理的流。
+func (s *Server) Stop(ctx context.Context) error {
+	httpErr := s.http.Stop(ctx)
+	grpcErr := s.grpc.Stop(ctx)
+	return errors.Join(httpErr, grpcErr)
+}
+
+// CheckHealth 报告 gRPC 服务器的运行状态与 HTTP 侧证书（WithTLSFiles）
+// 的有效性。
+func (s *Server) CheckHealth() error {
+	return errors.Join(s.grpc.CheckHealth(), s.http.CheckHealth())
+}
+
+var (
+	_ lynx.Service = (*Server)(nil)
+	_ lynx.Drainer = (*Server)(nil)
+	_ lynx.Checker = (*Server)(nil)
+)
@@ -0,0 +1,204 @@
+package mux
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	lynxgrpc "github.com/lynx-go/lynx/server/grpc"
+	lynxhttp "github.com/lynx-go/lynx/server/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func testHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Proto)
+	})
+}
+
+// startMux 启动 s 并返回实际监听地址与 Start 的结果通道。
+func startMux(t *testing.T, s *Server) (string, <-chan error) {
+	t.Helper()
+	startErr := make(chan error, 1)
+	go func() { startErr <- s.Start(context.Background()) }()
+	for i := 0; i < 500; i++ {
+		if a := s.HTTP().Addr(); a != nil {
+			return a.String(), startErr
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("server did not start listening")
+	return "", nil
+}
+
+func healthCheck(t *testing.T, addr string, creds credentials.TransportCredentials) {
+	t.Helper()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("status = %v, want SERVING", resp.Status)
+	}
+}
+
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestMuxCleartext(t *testing.T) {
+	s := NewServer(testHandler(), WithHTTPOptions(lynxhttp.WithAddr("127.0.0.1:0")))
+	addr, startErr := startMux(t, s)
+
+	healthCheck(t, addr, insecure.NewCredentials())
+	if got := get(t, http.DefaultClient, "http://"+addr+"/"); got != "HTTP/1.1" {
+		t.Errorf("HTTP/1.1 request served as %q", got)
+	}
+	// h2c 的非 gRPC 请求仍由 HTTP handler 处理。
+	h2c := &http.Client{Transport: &http.Transport{Protocols: new(http.Protocols)}}
+	h2c.Transport.(*http.Transport).Protocols.SetUnencryptedHTTP2(true)
+	if got := get(t, h2c, "http://"+addr+"/"); got != "HTTP/2.0" {
+		t.Errorf("h2c request served as %q", got)
+	}
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	select {
+	case err := <-startErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("Start() error = %v, want http.ErrServerClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() did not return after Stop()")
+	}
+	if err := s.CheckHealth(); err == nil {
+		t.Error("CheckHealth() = nil after Stop")
+	}
+}
+
+func TestMuxTLS(t *testing.T) {
+	s := NewServer(testHandler(), WithHTTPOptions(
+		lynxhttp.WithAddr("127.0.0.1:0"),
+		lynxhttp.WithTLSConfig(testTLSConfig(t)),
+	))
+	addr, _ := startMux(t, s)
+	defer s.Stop(context.Background())
+
+	healthCheck(t, addr, credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}))
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+	}}
+	if got := get(t, client, "https://"+addr+"/"); got != "HTTP/2.0" {
+		t.Errorf("HTTPS request served as %q, want HTTP/2.0 via ALPN", got)
+	}
+}
+
+// TestMuxStopWaitsForRPC 验证 Stop 等待在途 gRPC 调用完成而不是中断它。
+func TestMuxStopWaitsForRPC(t *testing.T) {
+	entered := make(chan struct{})
+	slow := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		close(entered)
+		time.Sleep(200 * time.Millisecond)
+		return handler(ctx, req)
+	}
+	s := NewServer(testHandler(),
+		WithHTTPOptions(lynxhttp.WithAddr("127.0.0.1:0")),
+		WithGRPCOptions(lynxgrpc.WithInterceptors(slow)),
+	)
+	addr, startErr := startMux(t, s)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rpcErr := make(chan error, 1)
+	go func() {
+		_, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		rpcErr <- err
+	}()
+	<-entered
+
+	s.Drain(context.Background())
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if err := <-rpcErr; err != nil {
+		t.Errorf("in-flight RPC error = %v, want nil", err)
+	}
+	select {
+	case <-startErr:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() did not return after Stop()")
+	}
+}
+
+func TestMuxListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	s := NewServer(testHandler(), WithHTTPOptions(lynxhttp.WithAddr(ln.Addr().String())))
+	done := make(chan error, 1)
+	go func() { done <- s.Start(context.Background()) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Start() = nil, want listen error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() did not return after listen error (gRPC side not stopped)")
+	}
+}
+
+// testTLSConfig 生成仅用于测试的自签证书。
+func testTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &tmpl, &tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
@@ -2,6 +2,7 @@ package lynx
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 )
 
@@ -32,6 +33,26 @@ type Lifecycle interface {
 	Stop(ctx context.Context) error
 }
 
+// Drainer 由持有长连接（SSE、WebSocket 等）的服务可选实现：关停进入排水
+// 阶段时（早于 OnStop 钩子与 Stop），App 调用 Drain 通知服务请对端重连到
+// 其他实例。Drain 只发出通知，不得阻塞；等待连接断开由 Stop 在关停预算
+// 内完成。
+type Drainer interface {
+	Drain(ctx context.Context)
+}
+
+// PreRunner 由需要在应用真正运行前介入的服务可选实现：Run 在全部注册
+// 完成之后、执行 OnStart 钩子与启动任何服务之前，按注册顺序调用 PreRun。
+// 返回 ErrSkipRun 表示应用无需继续运行（如输出 OpenAPI 文档后退出），
+// 返回其他错误表示运行失败；两种情况下 Run 都不执行钩子、不启动服务，
+// 逆序 Stop 已注册的服务后返回（ErrSkipRun 时返回 nil）。
+type PreRunner interface {
+	PreRun(ctx context.Context) error
+}
+
+// ErrSkipRun 由 PreRunner.PreRun 返回，让 Run 在启动任何服务前正常结束。
+var ErrSkipRun = errors.New("lynx: skip run")
+
 // Service 是可由 App 托管生命周期的命名服务。
 type Service interface {
 	Name() string
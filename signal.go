@@ -0,0 +1,283 @@
+package lynx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+// SignalFunc 是用户注册的信号处理函数（见 App.OnSignal），返回的错误仅
+// 记录日志，不影响应用运行。
+type SignalFunc func(ctx context.Context, sig os.Signal) error
+
+// SignalAction 是信号动作表（Options.SignalActions）中的框架内置动作。
+type SignalAction string
+
+// 内置信号动作。
+const (
+	// SignalActionNone 不执行内置动作：用于在动作表中关闭某个缺省条目，
+	// 通过 OnSignal 注册的处理函数仍会执行。
+	SignalActionNone SignalAction = ""
+	// SignalActionReload 重新读取配置文件并重新应用日志级别。
+	SignalActionReload SignalAction = "reload"
+	// SignalActionDump 转储 goroutine 栈与服务状态到日志或文件（见 DumpDir）。
+	SignalActionDump SignalAction = "dump"
+	// SignalActionToggleDebug 在 debug 与原日志级别之间切换。
+	SignalActionToggleDebug SignalAction = "toggle-debug"
+	// SignalActionToggleMaintenance 切换应用维护状态（见 Maintenance），
+	// 不在缺省动作表中。
+	SignalActionToggleMaintenance SignalAction = "toggle-maintenance"
+)
+
+// ErrSignalActionInvalid 表示动作表中存在无法识别的内置动作。
+var ErrSignalActionInvalid = errors.New("signal action must be one of reload, dump, toggle-debug or toggle-maintenance")
+
+func (a SignalAction) valid() bool {
+	switch a {
+	case SignalActionNone, SignalActionReload, SignalActionDump, SignalActionToggleDebug,
+		SignalActionToggleMaintenance:
+		return true
+	}
+	return false
+}
+
+// OnSignal 为 sig 注册处理函数：Run 开始时订阅动作表与 OnSignal 涉及的
+// 全部信号，收到后先执行内置动作（若有），再按注册顺序执行处理函数。
+// 必须先于 Run 调用；sig 属于退出信号时处理函数不会被执行。
+func (app *lynx) OnSignal(sig os.Signal, fns ...SignalFunc) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if app.onSignals == nil {
+		app.onSignals = map[os.Signal][]SignalFunc{}
+	}
+	app.onSignals[sig] = append(app.onSignals[sig], fns...)
+}
+
+// signalSet 返回需要订阅的非退出信号：动作表中动作非空的信号与 OnSignal
+// 注册过的信号。退出信号优先：重叠的动作条目直接跳过（如 WithExitSignals
+// 包含 SIGHUP），重叠的 OnSignal 条目记录告警后忽略。
+func (app *lynx) signalSet() []os.Signal {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	seen := map[os.Signal]bool{}
+	var sigs []os.Signal
+	add := func(sig os.Signal) {
+		if seen[sig] {
+			return
+		}
+		seen[sig] = true
+		sigs = append(sigs, sig)
+	}
+	for sig, action := range app.o.SignalActions {
+		if action != SignalActionNone && !isExitSignal(app.o.ExitSignals, sig) {
+			add(sig)
+		}
+	}
+	for sig, fns := range app.onSignals {
+		if len(fns) == 0 {
+			continue
+		}
+		if isExitSignal(app.o.ExitSignals, sig) {
+			app.logger.Warn("signal handler registered for an exit signal, ignored", "signal", sig.String())
+			continue
+		}
+		add(sig)
+	}
+	return sigs
+}
+
+func isExitSignal(exits []os.Signal, sig os.Signal) bool {
+	for _, s := range exits {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
+
+// notifySignals 订阅动作信号并返回缓冲 chan：与退出信号一样在 OnStart
+// hooks 之前注册，hook 阻塞期间到达的信号在 hooks 结束后依次处理。
+// 无需订阅时返回 nil。
+func (app *lynx) notifySignals() chan os.Signal {
+	sigs := app.signalSet()
+	if len(sigs) == 0 {
+		return nil
+	}
+	ch := make(chan os.Signal, len(sigs))
+	signal.Notify(ch, sigs...)
+	return ch
+}
+
+// serveSignals 在应用 Context 取消前顺序处理动作信号；返回前退订。
+func (app *lynx) serveSignals(ch chan os.Signal) {
+	defer signal.Stop(ch)
+	for {
+		select {
+		case <-app.ctx.Done():
+			return
+		case sig := <-ch:
+			app.handleSignal(app.ctx, sig)
+		}
+	}
+}
+
+// handleSignal 执行 sig 的内置动作与用户处理函数，错误逐个记录日志。
+func (app *lynx) handleSignal(ctx context.Context, sig os.Signal) {
+	app.mu.Lock()
+	action := app.o.SignalActions[sig]
+	fns := append([]SignalFunc(nil), app.onSignals[sig]...)
+	app.mu.Unlock()
+
+	app.Logger().Info("signal received", "signal", sig.String(), "action", string(action))
+	var err error
+	switch action {
+	case SignalActionReload:
+		err = app.reloadConfig()
+	case SignalActionDump:
+		err = app.dump(ctx)
+	case SignalActionToggleDebug:
+		err = app.toggleDebug()
+	case SignalActionToggleMaintenance:
+		enabled := app.maintenance.Toggle()
+		app.Logger().Info("maintenance toggled", "maintenance", enabled)
+	}
+	if err != nil {
+		app.Logger().ErrorContext(ctx, "signal action failed", "signal", sig.String(), "action", string(action), "error", err)
+	}
+	for _, fn := range fns {
+		if err := fn(ctx, sig); err != nil {
+			app.Logger().ErrorContext(ctx, "signal handler failed", "signal", sig.String(), "error", err)
+		}
+	}
+}
+
+// reloadConfig 重新读取配置文件，重新应用 maintenance.enabled，并在框架
+// 托管 logger 时重新应用日志级别。未使用配置文件时只重新应用已有配置。
+// 注意：与 viper 的 WatchConfig 相同，重新读取期间的并发配置读取不做额外同步。
+func (app *lynx) reloadConfig() error {
+	if app.c.ConfigFileUsed() != "" {
+		if err := app.c.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to reload config: %w", err)
+		}
+		app.Logger().Info("config reloaded", "file", app.c.ConfigFileUsed())
+	}
+	app.applyMaintenanceConfig()
+	if !app.managedLogger.Load() {
+		return nil
+	}
+	levelStr := LogLevelFromConfig(app.Config())
+	level := slog.LevelInfo
+	if levelStr != "" {
+		var err error
+		if level, err = ParseLogLevel(levelStr); err != nil {
+			return fmt.Errorf("invalid log level %q: %w", levelStr, err)
+		}
+	}
+	app.levelMu.Lock()
+	app.baseLevel = level
+	app.level.Set(level)
+	app.levelMu.Unlock()
+	return nil
+}
+
+// applyMaintenanceConfig 在配置键 maintenance.enabled 已设置时按其设置
+// 维护状态；未设置时保持现状（不覆盖经 debug 服务或信号切换的状态）。
+func (app *lynx) applyMaintenanceConfig() {
+	if !app.c.IsSet("maintenance.enabled") {
+		return
+	}
+	enabled := app.c.GetBool("maintenance.enabled")
+	if app.maintenance.Set(enabled) {
+		app.Logger().Info("maintenance set from config", "maintenance", enabled)
+	}
+}
+
+// toggleDebug 在 debug 与基础日志级别之间切换：框架托管的 logger 通过
+// 内部 LevelVar 切换；沿用标准库缺省 logger 时通过 slog.SetLogLoggerLevel
+// 切换；SetLogger 设置的自定义 logger 无法由框架调整，返回错误（可改用
+// OnSignal 自行处理）。
+func (app *lynx) toggleDebug() error {
+	if app.customLogger.Load() {
+		return errors.New("log level of a logger set via SetLogger cannot be toggled; handle the signal with OnSignal")
+	}
+	app.levelMu.Lock()
+	defer app.levelMu.Unlock()
+	next := slog.LevelDebug
+	if app.level.Level() <= slog.LevelDebug {
+		next = app.baseLevel
+		if next <= slog.LevelDebug {
+			next = slog.LevelInfo
+		}
+	}
+	app.level.Set(next)
+	if !app.managedLogger.Load() {
+		slog.SetLogLoggerLevel(next)
+	}
+	app.Logger().Info("log level toggled", "level", next.String())
+	return nil
+}
+
+// dump 转储 goroutine 栈与服务状态：设置了 DumpDir 时写入该目录下的
+// 文件并记录路径，否则直接记录到日志。
+func (app *lynx) dump(ctx context.Context) error {
+	var stacks bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&stacks, 2); err != nil {
+		return fmt.Errorf("failed to dump goroutines: %w", err)
+	}
+	status := app.serviceStatus()
+
+	if app.o.DumpDir == "" {
+		app.Logger().InfoContext(ctx, "dump", "services", status, "goroutines", stacks.String())
+		return nil
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "app: %s\nid: %s\nversion: %s\nenv: %s\ntime: %s\n\n",
+		NameFromContext(app.ctx), IDFromContext(app.ctx), VersionFromContext(app.ctx),
+		EnvFromContext(app.ctx), time.Now().Format(time.RFC3339))
+	buf.WriteString("services:\n")
+	for _, s := range status {
+		fmt.Fprintf(&buf, "  %s\n", s)
+	}
+	buf.WriteString("\ngoroutines:\n")
+	buf.Write(stacks.Bytes())
+
+	if err := os.MkdirAll(app.o.DumpDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create dump dir: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.dump", NameFromContext(app.ctx), time.Now().Format("20060102T150405.000000000"))
+	path := filepath.Join(app.o.DumpDir, name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write dump: %w", err)
+	}
+	app.Logger().InfoContext(ctx, "dump written", "path", path, "services", status)
+	return nil
+}
+
+// serviceStatus 按注册顺序返回 "名称: 健康状态" 形式的服务状态；
+// 未实现 Checker 的服务标记为 running。
+func (app *lynx) serviceStatus() []string {
+	app.mu.Lock()
+	svcs := append([]Service(nil), app.services...)
+	app.mu.Unlock()
+	out := make([]string, 0, len(svcs))
+	for _, svc := range svcs {
+		state := "running"
+		if c, ok := svc.(Checker); ok {
+			if err := c.CheckHealth(); err != nil {
+				state = "unhealthy: " + strings.TrimSpace(err.Error())
+			} else {
+				state = "healthy"
+			}
+		}
+		out = append(out, svc.Name()+": "+state)
+	}
+	return out
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package lynx
+
+import "os"
+
+// defaultSignalActions 返回缺省信号动作表：非 unix 平台没有 SIGHUP/SIGUSR1/
+// SIGUSR2，缺省为空表，可通过 WithSignalAction 显式配置。
+func defaultSignalActions() map[os.Signal]SignalAction {
+	return map[os.Signal]SignalAction{}
+}
@@ -0,0 +1,279 @@
+//go:build unix
+
+package lynx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalActionDefaults(t *testing.T) {
+	o := NewOptions()
+	want := map[os.Signal]SignalAction{
+		syscall.SIGHUP:  SignalActionReload,
+		syscall.SIGUSR1: SignalActionDump,
+		syscall.SIGUSR2: SignalActionToggleDebug,
+	}
+	for sig, action := range want {
+		if got := o.SignalActions[sig]; got != action {
+			t.Errorf("SignalActions[%v] = %q, want %q", sig, got, action)
+		}
+	}
+
+	// 显式条目（含 SignalActionNone）在再次 EnsureDefaults 后保持不变。
+	o = &Options{}
+	WithSignalAction(syscall.SIGHUP, SignalActionNone)(o)
+	WithSignalAction(syscall.SIGUSR1, SignalActionToggleDebug)(o)
+	o.EnsureDefaults()
+	if got, ok := o.SignalActions[syscall.SIGHUP]; !ok || got != SignalActionNone {
+		t.Errorf("SIGHUP = %q (present %v), want explicit none", got, ok)
+	}
+	if got := o.SignalActions[syscall.SIGUSR1]; got != SignalActionToggleDebug {
+		t.Errorf("SIGUSR1 = %q, want toggle-debug", got)
+	}
+	if got := o.SignalActions[syscall.SIGUSR2]; got != SignalActionToggleDebug {
+		t.Errorf("SIGUSR2 = %q, want default toggle-debug", got)
+	}
+}
+
+func TestSignalActionInvalid(t *testing.T) {
+	o := NewOptions(WithSignalAction(syscall.SIGHUP, "restart"))
+	if err := o.Validate(); !errors.Is(err, ErrSignalActionInvalid) {
+		t.Fatalf("Validate() = %v, want ErrSignalActionInvalid", err)
+	}
+}
+
+// TestSignalSetExitSignalsWin 验证退出信号优先：与退出信号重叠的动作
+// 条目与 OnSignal 处理函数都不会被订阅为动作信号。
+func TestSignalSetExitSignalsWin(t *testing.T) {
+	app, err := newLynx(NewOptions(
+		WithExitSignals(syscall.SIGTERM, syscall.SIGHUP),
+		WithSignalAction(syscall.SIGUSR1, SignalActionNone),
+	))
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	app.OnSignal(syscall.SIGTERM, func(context.Context, os.Signal) error { return nil })
+	app.OnSignal(syscall.SIGUSR1, func(context.Context, os.Signal) error { return nil })
+
+	got := map[os.Signal]bool{}
+	for _, sig := range app.(*lynx).signalSet() {
+		got[sig] = true
+	}
+	if got[syscall.SIGHUP] || got[syscall.SIGTERM] {
+		t.Errorf("signalSet() = %v, must not contain exit signals", got)
+	}
+	if !got[syscall.SIGUSR1] || !got[syscall.SIGUSR2] {
+		t.Errorf("signalSet() = %v, want SIGUSR1 (OnSignal) and SIGUSR2 (default action)", got)
+	}
+}
+
+// TestOnSignalHandlerRuns 验证运行期间收到的动作信号触发 OnSignal 处理函数，
+// 且不会使应用退出。
+func TestOnSignalHandlerRuns(t *testing.T) {
+	app, err := newLynx(NewOptions(WithSignalAction(syscall.SIGUSR2, SignalActionNone)))
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	svc := &blockingService{name: "svc"}
+	app.Register(svc)
+	got := make(chan os.Signal, 1)
+	app.OnSignal(syscall.SIGUSR2, func(_ context.Context, sig os.Signal) error {
+		got <- sig
+		return errors.New("handler errors are only logged")
+	})
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- app.Run() }()
+	waitFor(t, 2*time.Second, svc.started.Load, "service to start")
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatalf("kill: %v", err)
+	}
+	select {
+	case sig := <-got:
+		if sig != syscall.SIGUSR2 {
+			t.Errorf("handler got %v, want SIGUSR2", sig)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnSignal handler was not called")
+	}
+
+	app.Close()
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Fatalf("Run() error = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after Close()")
+	}
+}
+
+// TestSignalBufferedDuringOnStart 验证 OnStart hook 执行期间到达的动作信号
+// 被缓冲，在 hooks 结束后处理，而不是丢失或按缺省行为处理。
+func TestSignalBufferedDuringOnStart(t *testing.T) {
+	app, err := newLynx(NewOptions(WithSignalAction(syscall.SIGUSR1, SignalActionNone)))
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	record := func(e string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}
+	app.OnStart(func(context.Context) error {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+		record("hook done")
+		return nil
+	})
+	handled := make(chan struct{})
+	app.OnSignal(syscall.SIGUSR1, func(context.Context, os.Signal) error {
+		record("signal")
+		close(handled)
+		return nil
+	})
+	svc := &blockingService{name: "svc"}
+	app.Register(svc)
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- app.Run() }()
+	select {
+	case <-handled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("signal received during OnStart was not handled")
+	}
+	app.Close()
+	if err := <-runErr; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(events, ",") != "hook done,signal" {
+		t.Errorf("events = %v, want [hook done signal]", events)
+	}
+}
+
+func TestToggleDebugManagedLogger(t *testing.T) {
+	origArgs, origLogger := os.Args, slog.Default()
+	defer func() { os.Args = origArgs; slog.SetDefault(origLogger) }()
+	os.Args = []string{"lynx", "--log-level", "warn"}
+
+	a, err := newLynx(NewOptions())
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	app := a.(*lynx)
+	ctx := context.Background()
+
+	app.handleSignal(ctx, syscall.SIGUSR2)
+	if got := app.level.Level(); got != slog.LevelDebug {
+		t.Fatalf("level after toggle = %v, want DEBUG", got)
+	}
+	if !app.Logger().Enabled(ctx, slog.LevelDebug) {
+		t.Error("logger should be enabled at DEBUG after toggle")
+	}
+	app.handleSignal(ctx, syscall.SIGUSR2)
+	if got := app.level.Level(); got != slog.LevelWarn {
+		t.Fatalf("level after second toggle = %v, want WARN", got)
+	}
+}
+
+func TestToggleDebugCustomLogger(t *testing.T) {
+	origLogger := slog.Default()
+	defer slog.SetDefault(origLogger)
+
+	a, err := newLynx(NewOptions())
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	app := a.(*lynx)
+	app.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	if err := app.toggleDebug(); err == nil {
+		t.Fatal("toggleDebug() = nil, want error for a logger set via SetLogger")
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	origArgs, origLogger := os.Args, slog.Default()
+	defer func() { os.Args = origArgs; slog.SetDefault(origLogger) }()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("logging:\n  level: info\nfoo: a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Args = []string{"lynx", "-c", path}
+
+	a, err := newLynx(NewOptions())
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	app := a.(*lynx)
+	if err := os.WriteFile(path, []byte("logging:\n  level: debug\nfoo: b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	app.handleSignal(context.Background(), syscall.SIGHUP)
+
+	if got := app.Config().GetString("foo"); got != "b" {
+		t.Errorf("foo = %q after reload, want b", got)
+	}
+	if got := app.level.Level(); got != slog.LevelDebug {
+		t.Errorf("level = %v after reload, want DEBUG", got)
+	}
+}
+
+func TestDumpToDir(t *testing.T) {
+	dir := t.TempDir()
+	a, err := newLynx(NewOptions(WithName("dumper"), WithDumpDir(dir)))
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	app := a.(*lynx)
+	app.Register(&blockingService{name: "svc-a"})
+	app.handleSignal(context.Background(), syscall.SIGUSR1)
+
+	files, err := filepath.Glob(filepath.Join(dir, "dumper-*.dump"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("dump files = %v (err %v), want exactly one", files, err)
+	}
+	bs, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"app: dumper", "svc-a: running", "goroutine "} {
+		if !strings.Contains(string(bs), want) {
+			t.Errorf("dump missing %q", want)
+		}
+	}
+}
+
+func TestToggleMaintenanceSignalAction(t *testing.T) {
+	a, err := newLynx(NewOptions(WithSignalAction(syscall.SIGUSR2, SignalActionToggleMaintenance)))
+	if err != nil {
+		t.Fatalf("newLynx() error = %v", err)
+	}
+	app := a.(*lynx)
+	app.handleSignal(context.Background(), syscall.SIGUSR2)
+	if !MaintenanceFromContext(app.Context()).Enabled() {
+		t.Fatal("toggle-maintenance should enable maintenance")
+	}
+	app.handleSignal(context.Background(), syscall.SIGUSR2)
+	if MaintenanceFromContext(app.Context()).Enabled() {
+		t.Fatal("second toggle-maintenance should leave maintenance")
+	}
+}
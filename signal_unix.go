@@ -0,0 +1,18 @@
+//go:build unix
+
+package lynx
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultSignalActions 返回缺省信号动作表：SIGHUP 重载配置、SIGUSR1 转储、
+// SIGUSR2 切换 debug 日志级别。
+func defaultSignalActions() map[os.Signal]SignalAction {
+	return map[os.Signal]SignalAction{
+		syscall.SIGHUP:  SignalActionReload,
+		syscall.SIGUSR1: SignalActionDump,
+		syscall.SIGUSR2: SignalActionToggleDebug,
+	}
+}